@@ -24,6 +24,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -43,6 +44,7 @@ import (
 	"ssw-logs-capture/pkg/profiling"
 	"ssw-logs-capture/pkg/hotreload"
 	"ssw-logs-capture/pkg/leakdetection"
+	"ssw-logs-capture/pkg/linelimit"
 	"ssw-logs-capture/pkg/positions"
 	"ssw-logs-capture/pkg/security"
 	"ssw-logs-capture/pkg/slo"
@@ -50,6 +52,7 @@ import (
 	"ssw-logs-capture/pkg/types"
 
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 // App represents the main application instance that coordinates all log capture,
@@ -66,6 +69,13 @@ import (
 //   - processor: Applies transformations and filtering to log entries
 //   - fileMonitor: Monitors filesystem changes and reads log files
 //   - containerMonitor: Monitors Docker container logs via Docker API
+//   - dockerJSONFileMonitor: Reads Docker json-file logs when the socket is unavailable
+//   - syslogMonitor: Receives syslog messages over UDP, TCP and TLS
+//   - kafkaMonitor: Consumes log messages from Kafka topics
+//   - fluentForwardMonitor: Receives Fluent Forward protocol records over TCP and unix sockets
+//   - execMonitor: Captures the output of supervised commands and standard input
+//   - kmsgMonitor: Reads kernel ring buffer records from /dev/kmsg
+//   - kubernetesPodMonitor: Reads pod logs from /var/log/pods (CRI format)
 //
 // Enterprise Components (when enabled):
 //   - securityManager: Handles authentication, authorization, and audit logging
@@ -83,10 +93,18 @@ type App struct {
 	// Core components - fundamental services for log capture and processing
 	taskManager      types.TaskManager                  // Manages background tasks and provides heartbeat monitoring
 	dispatcher       types.Dispatcher                   // Orchestrates log entry processing and delivery to sinks
+	ingestLineLimit  *linelimit.Limiter                 // Applies server.max_line_bytes to HTTP ingest (nil = unbounded)
 	positionManager  *positions.PositionBufferManager  // Tracks file reading positions for resumable operations
 	processor        *processing.LogProcessor           // Applies transformations and filtering to log entries
 	fileMonitor      *monitors.FileMonitor              // Monitors filesystem changes and reads log files
 	containerMonitor *monitors.ContainerMonitor         // Monitors Docker container logs via Docker API
+	dockerJSONFileMonitor *monitors.DockerJSONFileMonitor // Reads Docker json-file logs when the socket is unavailable
+	syslogMonitor    *monitors.SyslogMonitor            // Receives syslog messages over UDP, TCP and TLS
+	kafkaMonitor     *monitors.KafkaMonitor             // Consumes log messages from Kafka topics
+	fluentForwardMonitor *monitors.FluentForwardMonitor // Receives Fluent Forward protocol records
+	execMonitor          *monitors.ExecMonitor          // Captures the output of supervised commands and stdin
+	kmsgMonitor          *monitors.KmsgMonitor          // Reads kernel ring buffer records from /dev/kmsg
+	kubernetesPodMonitor *monitors.KubernetesPodMonitor // Reads pod logs from /var/log/pods (CRI format)
 	diskManager         *cleanup.DiskSpaceManager          // Manages disk space and performs cleanup operations
 	resourceMonitor     *leakdetection.ResourceMonitor     // Monitors system resources and detects potential leaks (legacy)
 	resourceMonitorNew  *monitoring.ResourceMonitor        // New resource monitoring system with alerts and metrics
@@ -105,6 +123,7 @@ type App struct {
 
 	// HTTP and metrics infrastructure
 	httpServer      *http.Server              // Main HTTP server for API endpoints
+	otlpGRPCServer  *grpc.Server              // OTLP/gRPC LogsService receiver
 	metricsServer   *metrics.MetricsServer    // Prometheus metrics server
 	enhancedMetrics *metrics.EnhancedMetrics  // Advanced metrics collection and reporting
 
@@ -229,6 +248,7 @@ func (app *App) initializeComponents() error {
 		return err
 	}
 	app.initHTTPServer()
+	app.initOTLPGRPCServer()
 	app.initMetricsServer()
 	return nil
 }
@@ -289,6 +309,41 @@ func (app *App) Start() error {
 			return fmt.Errorf("failed to start container monitor: %w", err)
 		}
 	}
+	if app.dockerJSONFileMonitor != nil {
+		if err := app.dockerJSONFileMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start docker json-file monitor: %w", err)
+		}
+	}
+	if app.syslogMonitor != nil {
+		if err := app.syslogMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start syslog monitor: %w", err)
+		}
+	}
+	if app.kafkaMonitor != nil {
+		if err := app.kafkaMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start kafka monitor: %w", err)
+		}
+	}
+	if app.fluentForwardMonitor != nil {
+		if err := app.fluentForwardMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start fluent forward monitor: %w", err)
+		}
+	}
+	if app.execMonitor != nil {
+		if err := app.execMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start exec monitor: %w", err)
+		}
+	}
+	if app.kmsgMonitor != nil {
+		if err := app.kmsgMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start kmsg monitor: %w", err)
+		}
+	}
+	if app.kubernetesPodMonitor != nil {
+		if err := app.kubernetesPodMonitor.Start(app.ctx); err != nil {
+			return fmt.Errorf("failed to start kubernetes pod monitor: %w", err)
+		}
+	}
 	if app.diskManager != nil {
 		if err := app.diskManager.Start(); err != nil {
 			return fmt.Errorf("failed to start disk manager: %w", err)
@@ -343,6 +398,20 @@ func (app *App) Start() error {
 			}
 		}()
 	}
+	if app.otlpGRPCServer != nil {
+		listener, err := net.Listen("tcp", app.config.Ingest.OTLP.GRPCAddress)
+		if err != nil {
+			return fmt.Errorf("failed to start OTLP gRPC receiver: %w", err)
+		}
+		app.wg.Add(1)
+		go func() {
+			defer app.wg.Done()
+			app.logger.WithField("addr", listener.Addr().String()).Info("Starting OTLP gRPC receiver")
+			if err := app.otlpGRPCServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+				app.logger.WithError(err).Error("OTLP gRPC receiver error")
+			}
+		}()
+	}
 
 	app.logger.Info("SSW Logs Capture Go started successfully")
 	return nil
@@ -386,6 +455,18 @@ func (app *App) Stop() error {
 			defer cancel()
 			app.httpServer.Shutdown(ctx)
 		}
+		if app.otlpGRPCServer != nil {
+			stopped := make(chan struct{})
+			go func() {
+				app.otlpGRPCServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				app.otlpGRPCServer.Stop()
+			}
+		}
 
 		if app.fileMonitor != nil {
 			app.fileMonitor.Stop()
@@ -393,6 +474,27 @@ func (app *App) Stop() error {
 		if app.containerMonitor != nil {
 			app.containerMonitor.Stop()
 		}
+		if app.dockerJSONFileMonitor != nil {
+			app.dockerJSONFileMonitor.Stop()
+		}
+		if app.syslogMonitor != nil {
+			app.syslogMonitor.Stop()
+		}
+		if app.kafkaMonitor != nil {
+			app.kafkaMonitor.Stop()
+		}
+		if app.fluentForwardMonitor != nil {
+			app.fluentForwardMonitor.Stop()
+		}
+		if app.execMonitor != nil {
+			app.execMonitor.Stop()
+		}
+		if app.kmsgMonitor != nil {
+			app.kmsgMonitor.Stop()
+		}
+		if app.kubernetesPodMonitor != nil {
+			app.kubernetesPodMonitor.Stop()
+		}
 		if app.diskManager != nil {
 			if err := app.diskManager.Stop(); err != nil {
 				app.logger.WithError(err).Error("Failed to stop disk manager")
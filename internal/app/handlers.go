@@ -3,6 +3,7 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,8 +13,11 @@ import (
 	"time"
 
 	"ssw-logs-capture/internal/dispatcher"
+	"ssw-logs-capture/internal/ingest"
 	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/compression"
 	"ssw-logs-capture/pkg/tracing"
+	"ssw-logs-capture/pkg/types"
 
 	"github.com/gorilla/mux"
 )
@@ -127,6 +131,10 @@ func (app *App) registerHandlers(router *mux.Router) {
 		}
 	}
 
+	// Splunk HEC routes authenticate with HEC tokens instead of the security middleware
+	var tokenMiddleware func(http.Handler) http.Handler
+	tokenMiddleware = metricsMiddleware
+
 	// Apply tracing middleware if tracing is enabled (outermost)
 	if app.tracingManager != nil {
 		tracer := app.tracingManager.GetTracer()
@@ -135,6 +143,9 @@ func (app *App) registerHandlers(router *mux.Router) {
 		middleware = func(h http.Handler) http.Handler {
 			return traceMiddleware(prevMiddleware(h))
 		}
+		tokenMiddleware = func(h http.Handler) http.Handler {
+			return traceMiddleware(metricsMiddleware(h))
+		}
 	}
 
 	// Register endpoints with middleware
@@ -149,6 +160,12 @@ func (app *App) registerHandlers(router *mux.Router) {
 	// Log ingest endpoint for load testing and API access
 	router.Handle("/api/v1/logs", middleware(http.HandlerFunc(app.logsIngestHandler))).Methods("POST")
 
+	// Loki-compatible push endpoint (Promtail, Grafana Alloy, Docker Loki driver)
+	router.Handle("/loki/api/v1/push", middleware(http.HandlerFunc(app.lokiPushHandler))).Methods("POST")
+
+	// Elasticsearch _bulk and Splunk HEC compatible endpoints (when enabled)
+	app.registerIngestHandlers(router, middleware, tokenMiddleware)
+
 	// Metrics endpoint (proxy to metrics server)
 	router.Handle("/metrics", middleware(http.HandlerFunc(app.metricsHandler))).Methods("GET")
 
@@ -156,6 +173,7 @@ func (app *App) registerHandlers(router *mux.Router) {
 	router.Handle("/debug/goroutines", middleware(http.HandlerFunc(app.debugGoroutinesHandler))).Methods("GET")
 	router.Handle("/debug/memory", middleware(http.HandlerFunc(app.debugMemoryHandler))).Methods("GET")
 	router.Handle("/debug/positions/validate", middleware(http.HandlerFunc(app.debugPositionsValidateHandler))).Methods("GET")
+	router.Handle("/pipelines/match", middleware(http.HandlerFunc(app.pipelineMatchHandler))).Methods("POST")
 
 	// Resource monitoring endpoint
 	router.Handle("/api/resources/metrics", middleware(http.HandlerFunc(app.handleResourceMetrics))).Methods("GET")
@@ -328,6 +346,90 @@ func (app *App) healthHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if app.dockerJSONFileMonitor != nil {
+		status := "healthy"
+		if !app.dockerJSONFileMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["docker_json_file_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
+	if app.syslogMonitor != nil {
+		status := "healthy"
+		if !app.syslogMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["syslog_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
+	if app.kafkaMonitor != nil {
+		status := "healthy"
+		if !app.kafkaMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["kafka_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
+	if app.fluentForwardMonitor != nil {
+		status := "healthy"
+		if !app.fluentForwardMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["fluent_forward_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
+	if app.execMonitor != nil {
+		status := "healthy"
+		if !app.execMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["exec_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
+	if app.kmsgMonitor != nil {
+		status := "healthy"
+		if !app.kmsgMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["kmsg_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
+	if app.kubernetesPodMonitor != nil {
+		status := "healthy"
+		if !app.kubernetesPodMonitor.IsHealthy() {
+			status = "unhealthy"
+			allHealthy = false
+		}
+		services["kubernetes_pod_monitor"] = map[string]interface{}{
+			"status":  status,
+			"enabled": true,
+		}
+	}
+
 	// Check enterprise services
 	if app.goroutineTracker != nil {
 		goroutineStats := app.goroutineTracker.GetStats()
@@ -426,6 +528,34 @@ func (app *App) statsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if app.dockerJSONFileMonitor != nil {
+		stats["docker_json_file_monitor"] = app.dockerJSONFileMonitor.GetStatus()
+	}
+
+	if app.syslogMonitor != nil {
+		stats["syslog_monitor"] = app.syslogMonitor.GetStatus()
+	}
+
+	if app.kafkaMonitor != nil {
+		stats["kafka_monitor"] = app.kafkaMonitor.GetStatus()
+	}
+
+	if app.fluentForwardMonitor != nil {
+		stats["fluent_forward_monitor"] = app.fluentForwardMonitor.GetStatus()
+	}
+
+	if app.execMonitor != nil {
+		stats["exec_monitor"] = app.execMonitor.GetStatus()
+	}
+
+	if app.kmsgMonitor != nil {
+		stats["kmsg_monitor"] = app.kmsgMonitor.GetStatus()
+	}
+
+	if app.kubernetesPodMonitor != nil {
+		stats["kubernetes_pod_monitor"] = app.kubernetesPodMonitor.GetStatus()
+	}
+
 	// Cleanup and disk management
 	if app.diskManager != nil {
 		stats["cleanup"] = app.diskManager.GetStatus()
@@ -691,21 +821,38 @@ func (app *App) dlqStatsHandler(w http.ResponseWriter, r *http.Request) {
 // log entries directly to the log capturer for processing. Each log entry
 // is validated and then sent to the dispatcher for processing and delivery.
 //
-// Request Body (JSON):
+// The body is a single JSON object, a JSON array of objects or an NDJSON
+// stream (one object per line), optionally compressed with gzip, zstd or
+// deflate (Content-Encoding). server.max_body_size limits the body both
+// before and after decompression.
+//
+// Entry format (JSON):
 //   {
 //     "message": "Log message content",          // Required
 //     "level": "info",                           // Optional, defaults to "info"
 //     "source_type": "api",                      // Optional, defaults to "api"
 //     "source_id": "external-system-1",          // Optional
 //     "labels": {"key": "value"},                // Optional
-//     "timestamp": "2025-11-02T12:00:00Z"        // Optional, defaults to now
+//     "fields": {"user_id": 42},                 // Optional structured fields
+//     "trace_id": "4bf92f3577b34da6",            // Optional tracing identifiers
+//     "span_id": "00f067aa0ba902b7",             // Optional
+//     "timestamp": "2025-11-02T12:00:00Z"        // Optional RFC3339 or epoch seconds, defaults to now
 //   }
 //
+// The timestamp, level, fields and tracing identifiers are preserved through
+// processing and delivered to the sinks as sent.
+//
+// Response Body (JSON): overall status ("accepted", "partial" or "rejected"),
+// accepted/rejected counts and one result per entry, in request order:
+//   {"index": 0, "status": "accepted"}
+//   {"index": 1, "status": "rejected", "reason": "missing required field: message"}
+//
 // Response Codes:
-//   - 200 OK: Log entry accepted and queued
-//   - 400 Bad Request: Invalid JSON or missing required fields
-//   - 500 Internal Server Error: Failed to process log entry
-//   - 503 Service Unavailable: Dispatcher not available
+//   - 200 OK: At least one entry accepted and queued
+//   - 400 Bad Request: Malformed body or every entry invalid
+//   - 413 Request Entity Too Large: Body exceeds max_body_size
+//   - 415 Unsupported Media Type: Unknown Content-Encoding
+//   - 503 Service Unavailable: Dispatcher not available or no entry could be queued
 //
 // This endpoint is primarily used for:
 //   - Load testing and performance validation
@@ -718,65 +865,83 @@ func (app *App) logsIngestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request body
-	var entry struct {
-		Message    string            `json:"message"`
-		Level      string            `json:"level"`
-		SourceType string            `json:"source_type"`
-		SourceID   string            `json:"source_id"`
-		Labels     map[string]string `json:"labels"`
-		Timestamp  time.Time         `json:"timestamp"`
+	limit := app.config.Server.MaxBodySize
+	if limit <= 0 {
+		limit = maxIngestBodySize
 	}
 
-	body, err := io.ReadAll(r.Body)
+	body, status, err := readIngestBody(w, r, limit)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
+		http.Error(w, err.Error(), status)
 		return
 	}
-	defer r.Body.Close()
 
-	if err := json.Unmarshal(body, &entry); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	body, err = ingest.DecodeContentEncoding(body, r.Header.Get("Content-Encoding"), limit)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, compression.ErrUnsupportedContentEncoding):
+			status = http.StatusUnsupportedMediaType
+		case errors.Is(err, compression.ErrDecompressedTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
-	// Validate required fields
-	if entry.Message == "" {
-		http.Error(w, "Missing required field: message", http.StatusBadRequest)
+	items, err := ingest.DecodeAPILogs(body)
+	if err != nil {
+		metrics.RecordError("http_ingest", "decode_error")
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Set defaults
-	if entry.Level == "" {
-		entry.Level = "info"
-	}
-	if entry.SourceType == "" {
-		entry.SourceType = "api"
-	}
-	if entry.SourceID == "" {
-		entry.SourceID = "http-ingest"
-	}
-	if entry.Labels == nil {
-		entry.Labels = make(map[string]string)
+	// Only valid entries are dispatched; keep their position in the request
+	var entries []*types.LogEntry
+	var positions []int
+	for i, item := range items {
+		if item.Entry == nil {
+			continue
+		}
+		// Add API-specific labels
+		item.Entry.Labels["ingested_via"] = "http_api"
+		item.Entry.Labels["client_ip"] = r.RemoteAddr
+		entries = append(entries, item.Entry)
+		positions = append(positions, i)
+	}
+	if len(entries) < len(items) {
+		metrics.RecordError("http_ingest", "invalid_entry")
+	}
+	entries, positions = app.limitIngestLines(entries, positions)
+
+	dispatchFailed := false
+	if len(entries) > 0 {
+		if err := app.dispatcher.HandleBatch(r.Context(), entries); err != nil {
+			metrics.RecordError("http_ingest", "dispatch_error")
+			dispatchFailed = true
+			var batchErr *types.BatchError
+			if errors.As(err, &batchErr) {
+				for index, entryErr := range batchErr.Errors {
+					items[positions[index]].Reject(fmt.Sprintf("failed to process log entry: %v", entryErr))
+				}
+			} else {
+				for _, position := range positions {
+					items[position].Reject(fmt.Sprintf("failed to process log entry: %v", err))
+				}
+			}
+		}
 	}
-	// Add API-specific labels
-	entry.Labels["ingested_via"] = "http_api"
-	entry.Labels["client_ip"] = r.RemoteAddr
 
-	// Send to dispatcher
-	ctx := r.Context()
-	if err := app.dispatcher.Handle(ctx, entry.SourceType, entry.SourceID, entry.Message, entry.Labels); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to process log entry: %v", err), http.StatusInternalServerError)
-		return
+	response := ingest.APIResults(items)
+	status = http.StatusOK
+	if response.Accepted == 0 {
+		// Dispatcher rejections are retryable; invalid entries are not
+		status = http.StatusBadRequest
+		if dispatchFailed {
+			status = http.StatusServiceUnavailable
+		}
 	}
-
-	// Success response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "accepted",
-		"message": "Log entry queued for processing",
-	})
+	writeJSON(w, status, response)
 }
 
 // Enterprise handlers - Advanced monitoring and security endpoints
@@ -915,6 +1080,57 @@ func (app *App) dlqReprocessHandler(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "DLQ not available", http.StatusServiceUnavailable)
 }
 
+// pipelineMatchHandler explains which processing pipeline a log entry would use.
+//
+// The request body is a JSON entry (message, level, source_type, source_id,
+// labels, fields). The response lists every selection rule in evaluation
+// order, whether it matched and the first criterion that failed, along with
+// the winning pipeline (or the default fallback).
+//
+// Response Codes:
+//   - 200 OK: Explanation returned
+//   - 400 Bad Request: Invalid JSON body
+//   - 503 Service Unavailable: Log processor not initialized
+func (app *App) pipelineMatchHandler(w http.ResponseWriter, r *http.Request) {
+	if app.processor == nil {
+		http.Error(w, "Log processor not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var request struct {
+		Message    string                 `json:"message"`
+		Level      string                 `json:"level"`
+		SourceType string                 `json:"source_type"`
+		SourceID   string                 `json:"source_id"`
+		TraceID    string                 `json:"trace_id"`
+		Labels     map[string]string      `json:"labels"`
+		Fields     map[string]interface{} `json:"fields"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	entry := &types.LogEntry{
+		Message:    request.Message,
+		Level:      request.Level,
+		SourceType: request.SourceType,
+		SourceID:   request.SourceID,
+		TraceID:    request.TraceID,
+		Labels:     request.Labels,
+		Fields:     request.Fields,
+	}
+	if entry.Labels == nil {
+		entry.Labels = make(map[string]string)
+	}
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]interface{})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(app.processor.MatchPipeline(entry))
+}
+
 // metricsHandler proxies requests to the metrics server for Prometheus metrics.
 //
 // This endpoint provides access to Prometheus metrics on the main API port:
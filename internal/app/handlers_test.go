@@ -0,0 +1,525 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/internal/ingest"
+	"ssw-logs-capture/internal/processing"
+	"ssw-logs-capture/pkg/linelimit"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordingDispatcher armazena as entradas recebidas pelos handlers de ingestão
+type recordingDispatcher struct {
+	mu      sync.Mutex
+	entries []*types.LogEntry
+	reject  bool
+	// rejectMessage recusa apenas as entradas com esta mensagem
+	rejectMessage string
+}
+
+func (d *recordingDispatcher) Handle(ctx context.Context, sourceType, sourceID, message string, labels map[string]string) error {
+	return d.HandleEntry(ctx, &types.LogEntry{SourceType: sourceType, SourceID: sourceID, Message: message, Labels: labels})
+}
+
+func (d *recordingDispatcher) HandleEntry(ctx context.Context, entry *types.LogEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.reject || (d.rejectMessage != "" && entry.Message == d.rejectMessage) {
+		return errors.New("dispatcher queue full")
+	}
+	d.entries = append(d.entries, entry)
+	return nil
+}
+
+func (d *recordingDispatcher) HandleBatch(ctx context.Context, entries []*types.LogEntry) error {
+	failed := make(map[int]error)
+	for i, entry := range entries {
+		if err := d.HandleEntry(ctx, entry); err != nil {
+			failed[i] = err
+		}
+	}
+	if len(failed) > 0 {
+		return &types.BatchError{Total: len(entries), Errors: failed}
+	}
+	return nil
+}
+
+func (d *recordingDispatcher) AddSink(sink types.Sink)         {}
+func (d *recordingDispatcher) Start(ctx context.Context) error { return nil }
+func (d *recordingDispatcher) Stop() error                     { return nil }
+func (d *recordingDispatcher) GetStats() types.DispatcherStats { return types.DispatcherStats{} }
+
+func (d *recordingDispatcher) Entries() []*types.LogEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*types.LogEntry(nil), d.entries...)
+}
+
+func newIngestTestApp(dispatcher types.Dispatcher) *App {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return &App{config: &types.Config{}, dispatcher: dispatcher, logger: logger}
+}
+
+func TestLokiPushHandler(t *testing.T) {
+	dispatcher := &recordingDispatcher{}
+	app := newIngestTestApp(dispatcher)
+
+	body := `{"streams":[{"stream":{"job":"promtail"},"values":[["1714557600123456789","hello"]]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Scope-OrgID", "team-a")
+	rr := httptest.NewRecorder()
+	app.lokiPushHandler(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	entries := dispatcher.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "hello", entries[0].Message)
+	assert.Equal(t, time.Unix(0, 1714557600123456789), entries[0].Timestamp)
+	assert.Equal(t, "team-a", entries[0].Labels["tenant"])
+	assert.Equal(t, "promtail", entries[0].Labels["job"])
+
+	// Corpo inválido
+	req = httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(`{"streams":[{"stream":{},"values":[]}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	app.lokiPushHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Corpo acima do limite
+	req = httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", bytes.NewReader(make([]byte, maxIngestBodySize+1)))
+	rr = httptest.NewRecorder()
+	app.lokiPushHandler(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	// Dispatcher recusando parte das entradas: 200 com o resultado por entrada,
+	// para o cliente não reenviar as já aceitas
+	dispatcher.rejectMessage = "refused"
+	partial := `{"streams":[{"stream":{"job":"promtail"},"values":[["1714557600000000000","kept"],["1714557600000000001","refused"]]}]}`
+	req = httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(partial))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	app.lokiPushHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response ingest.APIResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, ingest.APIStatusPartial, response.Status)
+	assert.Equal(t, 1, response.Accepted)
+	assert.Equal(t, ingest.APIStatusRejected, response.Results[1].Status)
+	assert.Len(t, dispatcher.Entries(), 2)
+
+	// Dispatcher recusando tudo: 429 para o cliente tentar de novo
+	dispatcher.reject = true
+	req = httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	app.lokiPushHandler(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
+
+func TestLogsIngestHandler(t *testing.T) {
+	dispatcher := &recordingDispatcher{rejectMessage: "refused"}
+	app := newIngestTestApp(dispatcher)
+
+	post := func(body []byte, contentEncoding string) (*httptest.ResponseRecorder, ingest.APIResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/logs", bytes.NewReader(body))
+		if contentEncoding != "" {
+			req.Header.Set("Content-Encoding", contentEncoding)
+		}
+		rr := httptest.NewRecorder()
+		app.logsIngestHandler(rr, req)
+		var response ingest.APIResponse
+		if rr.Header().Get("Content-Type") == "application/json" {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		}
+		return rr, response
+	}
+
+	// Objeto único, compatível com o formato anterior
+	rr, response := post([]byte(`{"message":"single","level":"WARN","timestamp":"2024-05-01T10:00:00Z"}`), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ingest.APIStatusAccepted, response.Status)
+	entries := dispatcher.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "warn", entries[0].Level)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), entries[0].Timestamp)
+	assert.Equal(t, "http_api", entries[0].Labels["ingested_via"])
+
+	// Array com entradas inválidas e recusadas pelo dispatcher
+	rr, response = post([]byte(`[{"message":"one"},{"level":"info"},"text",{"message":"refused"},{"message":"two","timestamp":"soon"}]`), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ingest.APIStatusPartial, response.Status)
+	assert.Equal(t, 1, response.Accepted)
+	assert.Equal(t, 4, response.Rejected)
+	require.Len(t, response.Results, 5)
+	assert.Equal(t, ingest.APIEntryResult{Index: 0, Status: ingest.APIStatusAccepted}, response.Results[0])
+	assert.Equal(t, "missing required field: message", response.Results[1].Reason)
+	assert.Equal(t, "entry must be a JSON object", response.Results[2].Reason)
+	assert.Contains(t, response.Results[3].Reason, "dispatcher queue full")
+	assert.Contains(t, response.Results[4].Reason, "invalid timestamp")
+
+	// NDJSON comprimido com gzip e zstd
+	ndjson := []byte("{\"message\":\"a\",\"timestamp\":1714557600}\n\n{\"message\":\"b\"}\n")
+	var gz bytes.Buffer
+	writer := gzip.NewWriter(&gz)
+	writer.Write(ndjson)
+	writer.Close()
+	rr, response = post(gz.Bytes(), "gzip")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2, response.Accepted)
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	rr, response = post(encoder.EncodeAll(ndjson, nil), "zstd")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2, response.Accepted)
+
+	entries = dispatcher.Entries()
+	require.Len(t, entries, 6)
+	assert.Equal(t, time.Unix(1714557600, 0), entries[2].Timestamp)
+	assert.Equal(t, []string{"a", "b"}, []string{entries[4].Message, entries[5].Message})
+
+	// Todas inválidas: 400; todas recusadas pelo dispatcher: 503
+	rr, response = post([]byte(`[{"level":"info"}]`), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ingest.APIStatusRejected, response.Status)
+	rr, _ = post([]byte(`{"message":"refused"}`), "")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	// Corpo malformado e Content-Encoding desconhecido
+	rr, _ = post([]byte(`[{"message":"a"}`), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr, _ = post(ndjson, "br")
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	// Limite configurável, também aplicado após descomprimir
+	app.config.Server.MaxBodySize = 128
+	rr, _ = post(bytes.Repeat([]byte(" "), 129), "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	gz.Reset()
+	writer = gzip.NewWriter(&gz)
+	writer.Write(bytes.Repeat(ndjson, 10))
+	writer.Close()
+	require.Less(t, gz.Len(), 128)
+	rr, _ = post(gz.Bytes(), "gzip")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestLogsIngestHandler_LineLimit(t *testing.T) {
+	dispatcher := &recordingDispatcher{rejectMessage: "refused"}
+	app := newIngestTestApp(dispatcher)
+	app.config.Server.LineLimit = types.LineLimitConfig{MaxLineBytes: 10, Policy: linelimit.PolicySplit}
+	require.NoError(t, app.newIngestLineLimit())
+
+	body := `[{"message":"0123456789abcdefghijKLMNO"},{"message":"refused"},{"message":"short"}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logs", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	app.logsIngestHandler(rr, req)
+
+	var response ingest.APIResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Accepted)
+	require.Len(t, response.Results, 3)
+	assert.Equal(t, ingest.APIStatusAccepted, response.Results[0].Status)
+	assert.Contains(t, response.Results[1].Reason, "dispatcher queue full", "rejections map back to the original item")
+
+	entries := dispatcher.Entries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, []string{"0123456789", "abcdefghij", "KLMNO", "short"}, []string{entries[0].Message, entries[1].Message, entries[2].Message, entries[3].Message})
+	assert.Equal(t, entries[0].Fields[linelimit.FieldSplitGroup], entries[2].Fields[linelimit.FieldSplitGroup])
+	assert.Equal(t, true, entries[2].Fields[linelimit.FieldSplitFinal])
+}
+
+func TestPipelineMatchHandler(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+pipelines:
+  - name: default
+    steps: []
+  - name: web
+    selectors:
+      - container_name: "web-*"
+    steps: []
+`), 0644))
+	processor, err := processing.NewLogProcessor(types.PipelineConfig{Enabled: true, File: file}, logrus.New())
+	require.NoError(t, err)
+
+	app := newIngestTestApp(&recordingDispatcher{})
+	app.processor = processor
+
+	body := `{"source_type":"docker","labels":{"container_name":"web-1"}}`
+	rr := httptest.NewRecorder()
+	app.pipelineMatchHandler(rr, httptest.NewRequest(http.MethodPost, "/pipelines/match", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var match processing.PipelineMatch
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &match))
+	assert.Equal(t, "web", match.Pipeline)
+	assert.Equal(t, processing.MatchReasonRule, match.Reason)
+	require.Len(t, match.Evaluated, 1)
+	assert.True(t, match.Evaluated[0].Matched)
+
+	rr = httptest.NewRecorder()
+	app.pipelineMatchHandler(rr, httptest.NewRequest(http.MethodPost, "/pipelines/match", strings.NewReader(`{"labels":{"container_name":"db"}}`)))
+	match = processing.PipelineMatch{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &match))
+	assert.Equal(t, "default", match.Pipeline)
+	assert.Equal(t, processing.MatchReasonDefault, match.Reason)
+	assert.Nil(t, match.Rule)
+
+	rr = httptest.NewRecorder()
+	app.pipelineMatchHandler(rr, httptest.NewRequest(http.MethodPost, "/pipelines/match", strings.NewReader(`{`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestElasticsearchBulkHandler(t *testing.T) {
+	dispatcher := &recordingDispatcher{}
+	app := newIngestTestApp(dispatcher)
+	app.config.Ingest.Elasticsearch = types.ElasticsearchIngestConfig{Enabled: true, Version: "8.11.0"}
+	router := mux.NewRouter()
+	app.registerIngestHandlers(router, metricsMiddleware, metricsMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Elasticsearch", rr.Header().Get("X-Elastic-Product"))
+	assert.Contains(t, rr.Body.String(), `"number":"8.11.0"`)
+
+	body := "{\"index\":{\"_id\":\"1\"}}\n{\"message\":\"hello\",\"log.level\":\"error\"}\n{\"delete\":{\"_id\":\"2\"}}\n"
+	req = httptest.NewRequest(http.MethodPost, "/app-logs/_bulk", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response ingest.BulkResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, response.Errors)
+	require.Len(t, response.Items, 2)
+	assert.Equal(t, http.StatusCreated, response.Items[0]["index"].Status)
+	assert.Equal(t, "app-logs", response.Items[0]["index"].Index)
+	assert.Equal(t, http.StatusBadRequest, response.Items[1]["delete"].Status)
+
+	entries := dispatcher.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "hello", entries[0].Message)
+	assert.Equal(t, "error", entries[0].Level)
+	assert.Equal(t, "app-logs", entries[0].Labels["index"])
+
+	// Itens recusados pelo dispatcher voltam com 429
+	dispatcher.reject = true
+	req = httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader("{\"index\":{\"_index\":\"logs\"}}\n{\"message\":\"x\"}\n"))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	response = ingest.BulkResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusTooManyRequests, response.Items[0]["index"].Status)
+	assert.Equal(t, "es_rejected_execution_exception", response.Items[0]["index"].Error.Type)
+
+	// Linha de ação inválida falha o pedido inteiro
+	req = httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader("not json\n"))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSplunkHECHandlers(t *testing.T) {
+	dispatcher := &recordingDispatcher{}
+	app := newIngestTestApp(dispatcher)
+	app.config.Ingest.SplunkHEC = types.SplunkHECIngestConfig{Enabled: true, Tokens: []string{"secret-token"}}
+	router := mux.NewRouter()
+	app.registerIngestHandlers(router, metricsMiddleware, metricsMiddleware)
+
+	send := func(path, authorization, body string) (int, ingest.HECResponse) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response ingest.HECResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return rr.Code, response
+	}
+
+	status, response := send("/services/collector/event", "", `{"event":"x"}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, ingest.HECCodeTokenMissing, response.Code)
+
+	status, response = send("/services/collector/event", "Splunk wrong", `{"event":"x"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, ingest.HECCodeInvalidToken, response.Code)
+
+	status, response = send("/services/collector/event", "Splunk secret-token", `{"event":"payment failed","host":"api-1"}{"event":{"msg":"retry"}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ingest.HECCodeSuccess, response.Code)
+
+	status, _ = send("/services/collector/raw?sourcetype=nginx&host=edge-1", "Splunk secret-token", "line one\nline two\n")
+	assert.Equal(t, http.StatusOK, status)
+
+	entries := dispatcher.Entries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, "payment failed", entries[0].Message)
+	assert.Equal(t, "api-1", entries[0].Labels["host"])
+	assert.Equal(t, "retry", entries[1].Message)
+	assert.Equal(t, "line two", entries[3].Message)
+	assert.Equal(t, "nginx", entries[3].Labels["sourcetype"])
+	assert.Equal(t, "edge-1", entries[3].Labels["host"])
+
+	status, response = send("/services/collector/event", "Splunk secret-token", `{"event":"ok"}{"host":"x"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, ingest.HECCodeEventMissing, response.Code)
+
+	// Recusa parcial: 200 para o cliente não reenviar os eventos aceitos
+	dispatcher.rejectMessage = "dropped"
+	status, response = send("/services/collector/event", "Splunk secret-token", `{"event":"kept"}{"event":"dropped"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ingest.HECCodeSuccess, response.Code)
+	entries = dispatcher.Entries()
+	require.Len(t, entries, 5)
+	assert.Equal(t, "kept", entries[4].Message)
+
+	status, response = send("/services/collector/event", "Splunk secret-token", `{"event":"dropped"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, ingest.HECCodeServerBusy, response.Code)
+
+	dispatcher.reject = true
+	status, response = send("/services/collector/event", "Splunk secret-token", `{"event":"x"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, ingest.HECCodeServerBusy, response.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/services/collector/health", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":17`)
+}
+
+// otlpTestRequest monta um export OTLP com uma entrada por mensagem
+func otlpTestRequest(messages ...string) *collogspb.ExportLogsServiceRequest {
+	records := make([]*logspb.LogRecord, 0, len(messages))
+	for _, message := range messages {
+		records = append(records, &logspb.LogRecord{
+			TimeUnixNano: uint64(time.Now().UnixNano()),
+			SeverityText: "INFO",
+			Body:         &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: message}},
+		})
+	}
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{ScopeLogs: []*logspb.ScopeLogs{{LogRecords: records}}}},
+	}
+}
+
+func TestOTLPLogsHandler(t *testing.T) {
+	dispatcher := &recordingDispatcher{rejectMessage: "dropped"}
+	app := newIngestTestApp(dispatcher)
+	app.config.Ingest.OTLP.Enabled = true
+	router := mux.NewRouter()
+	app.registerIngestHandlers(router, metricsMiddleware, metricsMiddleware)
+
+	send := func(body []byte, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/logs", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body, err := proto.Marshal(otlpTestRequest("kept", "dropped"))
+	require.NoError(t, err)
+	rr := send(body, "application/x-protobuf")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-protobuf", rr.Header().Get("Content-Type"))
+
+	var response collogspb.ExportLogsServiceResponse
+	require.NoError(t, proto.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.GetPartialSuccess().GetRejectedLogRecords())
+	require.Len(t, dispatcher.Entries(), 1)
+	assert.Equal(t, "kept", dispatcher.Entries()[0].Message)
+
+	rr = send([]byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"body":{"stringValue":"from json"}}]}]}]}`), "application/json")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Len(t, dispatcher.Entries(), 2)
+
+	// Todas recusadas: 503 para o exporter tentar de novo
+	body, err = proto.Marshal(otlpTestRequest("dropped"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, send(body, "application/x-protobuf").Code)
+
+	assert.Equal(t, http.StatusBadRequest, send([]byte("{"), "application/json").Code)
+}
+
+func TestExportOTLPLogs_SplitRecordsCountedOnce(t *testing.T) {
+	dispatcher := &recordingDispatcher{rejectMessage: "xxxxx"}
+	app := newIngestTestApp(dispatcher)
+	app.config.Server.LineLimit = types.LineLimitConfig{MaxLineBytes: 5, Policy: linelimit.PolicySplit}
+	require.NoError(t, app.newIngestLineLimit())
+
+	// O segundo record vira três partes recusadas: conta como um único record
+	response, err := app.exportOTLPLogs(context.Background(), otlpTestRequest("kept", "xxxxxxxxxxxxxxx"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.GetPartialSuccess().GetRejectedLogRecords())
+	require.Len(t, dispatcher.Entries(), 1)
+	assert.Equal(t, "kept", dispatcher.Entries()[0].Message)
+}
+
+func TestOTLPLogsService_GRPC(t *testing.T) {
+	dispatcher := &recordingDispatcher{rejectMessage: "dropped"}
+	app := newIngestTestApp(dispatcher)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, &otlpLogsService{app: app})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := collogspb.NewLogsServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := client.Export(ctx, otlpTestRequest("one", "two", "dropped"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.GetPartialSuccess().GetRejectedLogRecords())
+	assert.Len(t, dispatcher.Entries(), 2)
+
+	_, err = client.Export(ctx, otlpTestRequest("dropped"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,530 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"ssw-logs-capture/internal/ingest"
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/linelimit"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxIngestBodySize limits the (possibly compressed) body accepted by the push endpoints
+const maxIngestBodySize = 16 << 20
+
+// limitIngestLines applies server.max_line_bytes to decoded push entries.
+// positions maps each entry to its request item and may be nil; split entries
+// keep the position of their item, and entries diverted to the dead letter
+// queue are removed together with their position.
+func (app *App) limitIngestLines(entries []*types.LogEntry, positions []int) ([]*types.LogEntry, []int) {
+	if app.ingestLineLimit == nil {
+		return entries, positions
+	}
+
+	var limited []*types.LogEntry
+	var limitedPositions []int
+	for i, entry := range entries {
+		for _, piece := range app.ingestLineLimit.Apply(entry) {
+			limited = append(limited, piece)
+			if positions != nil {
+				limitedPositions = append(limitedPositions, positions[i])
+			}
+		}
+	}
+	return limited, limitedPositions
+}
+
+// newIngestLineLimit creates the limiter for server.max_line_bytes.
+func (app *App) newIngestLineLimit() error {
+	limiter, err := linelimit.New(app.config.Server.LineLimit, "http_ingest", linelimit.DeadLetterQueueOf(app.dispatcher))
+	if err != nil {
+		return fmt.Errorf("invalid server line limit: %w", err)
+	}
+	app.ingestLineLimit = limiter
+	return nil
+}
+
+// readIngestBody reads a push request body up to limit bytes. The returned
+// status is 413 when the limit is exceeded and 400 for other read failures.
+func readIngestBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, int, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	defer r.Body.Close()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body too large: limit is %d bytes", limit)
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return body, http.StatusOK, nil
+}
+
+// lokiPushHandler receives Loki push requests so other agents can use this
+// capturer as a relay.
+//
+// Bodies follow Loki's rules: application/json is the JSON PushRequest and any
+// other content type is a snappy-compressed protobuf PushRequest. Each stream's
+// labels become entry labels, structured metadata becomes fields and the
+// original nanosecond timestamps are preserved. The X-Scope-OrgID header, when
+// present, is added as the "tenant" label.
+//
+// Response Codes (as returned by Loki):
+//   - 204 No Content: All entries accepted
+//   - 200 OK: Some entries were queued and others rejected by the dispatcher.
+//     The body uses the /api/v1/logs format (status "partial", one result per
+//     entry in stream order). The rejected entries are not retried by Loki
+//     clients, which only resend on 429/5xx; answering 429 here would
+//     duplicate the entries already queued.
+//   - 400 Bad Request: Malformed body, labels or timestamps
+//   - 413 Request Entity Too Large: Body exceeds the size limit
+//   - 429 Too Many Requests: The dispatcher rejected every entry (clients retry)
+//   - 503 Service Unavailable: Dispatcher not available
+func (app *App) lokiPushHandler(w http.ResponseWriter, r *http.Request) {
+	if app.dispatcher == nil {
+		http.Error(w, "Dispatcher not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, status, err := readIngestBody(w, r, maxIngestBodySize)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	streams, err := ingest.DecodeLokiPush(body, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"))
+	if err != nil {
+		metrics.RecordError("loki_push", "decode_error")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, _ := app.limitIngestLines(ingest.LokiEntries(streams, r.Header.Get("X-Scope-OrgID")), nil)
+	if len(entries) > 0 {
+		if err := app.dispatcher.HandleBatch(r.Context(), entries); err != nil {
+			metrics.RecordError("loki_push", "dispatch_error")
+
+			// Partial rejection: 200 so the client does not resend accepted entries
+			var batchErr *types.BatchError
+			if errors.As(err, &batchErr) && len(batchErr.Errors) < len(entries) {
+				items := make([]*ingest.APIItem, len(entries))
+				for i, entry := range entries {
+					items[i] = &ingest.APIItem{Entry: entry}
+				}
+				for index, entryErr := range batchErr.Errors {
+					items[index].Reject(fmt.Sprintf("failed to process log entry: %v", entryErr))
+				}
+				writeJSON(w, http.StatusOK, ingest.APIResults(items))
+				return
+			}
+
+			http.Error(w, fmt.Sprintf("ingestion rejected: %v", err), http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// registerIngestHandlers registers the Elasticsearch, Splunk HEC and OTLP/HTTP
+// endpoints enabled in the ingest configuration. HEC routes use tokenMiddleware,
+// which skips the security middleware because HEC clients authenticate with
+// their own tokens.
+func (app *App) registerIngestHandlers(router *mux.Router, middleware, tokenMiddleware func(http.Handler) http.Handler) {
+	if app.config.Ingest.Elasticsearch.Enabled {
+		router.Handle("/", middleware(http.HandlerFunc(app.elasticsearchInfoHandler))).Methods("GET", "HEAD")
+		router.Handle("/_license", middleware(http.HandlerFunc(app.elasticsearchLicenseHandler))).Methods("GET")
+		router.Handle("/_bulk", middleware(http.HandlerFunc(app.elasticsearchBulkHandler))).Methods("POST", "PUT")
+		router.Handle("/{index}/_bulk", middleware(http.HandlerFunc(app.elasticsearchBulkHandler))).Methods("POST", "PUT")
+	}
+
+	if app.config.Ingest.SplunkHEC.Enabled {
+		for _, path := range []string{"/services/collector", "/services/collector/event", "/services/collector/event/1.0"} {
+			router.Handle(path, tokenMiddleware(http.HandlerFunc(app.splunkHECEventHandler))).Methods("POST")
+		}
+		for _, path := range []string{"/services/collector/raw", "/services/collector/raw/1.0"} {
+			router.Handle(path, tokenMiddleware(http.HandlerFunc(app.splunkHECRawHandler))).Methods("POST")
+		}
+		router.Handle("/services/collector/health", tokenMiddleware(http.HandlerFunc(app.splunkHECHealthHandler))).Methods("GET")
+	}
+
+	if app.config.Ingest.OTLP.Enabled {
+		router.Handle("/v1/logs", middleware(http.HandlerFunc(app.otlpLogsHandler))).Methods("POST")
+	}
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeElasticsearchError writes an error in the format returned by Elasticsearch.
+func writeElasticsearchError(w http.ResponseWriter, status int, errorType, reason string) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":   errorType,
+			"reason": reason,
+		},
+		"status": status,
+	})
+}
+
+// elasticsearchInfoHandler answers the cluster info request that Beats, Logstash
+// and the official clients send before bulk indexing. The reported version is
+// taken from ingest.elasticsearch.version so clients accept the connection.
+func (app *App) elasticsearchInfoHandler(w http.ResponseWriter, r *http.Request) {
+	hostname, _ := os.Hostname()
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":         hostname,
+		"cluster_name": "ssw-logs-capture",
+		"cluster_uuid": "ssw-logs-capture",
+		"version": map[string]interface{}{
+			"number":       app.config.Ingest.Elasticsearch.Version,
+			"build_flavor": "default",
+		},
+		"tagline": "You Know, for Search",
+	})
+}
+
+// elasticsearchLicenseHandler reports an active basic license, which Beats
+// check before sending data.
+func (app *App) elasticsearchLicenseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"license": map[string]interface{}{
+			"status": "active",
+			"type":   "basic",
+			"mode":   "basic",
+		},
+	})
+}
+
+// elasticsearchBulkHandler receives Elasticsearch _bulk requests.
+//
+// The body is NDJSON with action/document pairs. index and create documents
+// become log entries (see ingest.DecodeElasticsearchBulk for the field mapping);
+// update and delete are answered with a per-item error. The index from the URL
+// is used for actions without _index.
+//
+// Response Codes (as returned by Elasticsearch):
+//   - 200 OK: Request processed; per-item status in "items", "errors" is true
+//     when any item failed. Items rejected by the dispatcher get status 429 so
+//     clients retry only those documents.
+//   - 400 Bad Request: Malformed action line or compressed body
+//   - 413 Request Entity Too Large: Body exceeds the size limit
+//   - 503 Service Unavailable: Dispatcher not available
+func (app *App) elasticsearchBulkHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	if app.dispatcher == nil {
+		writeElasticsearchError(w, http.StatusServiceUnavailable, "unavailable_shards_exception", "dispatcher not available")
+		return
+	}
+
+	body, status, err := readIngestBody(w, r, maxIngestBodySize)
+	if err != nil {
+		writeElasticsearchError(w, status, "parse_exception", err.Error())
+		return
+	}
+	body, err = ingest.DecodeContentEncoding(body, r.Header.Get("Content-Encoding"), ingest.LokiMaxDecodedSize)
+	if err != nil {
+		writeElasticsearchError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+
+	items, err := ingest.DecodeElasticsearchBulk(body, mux.Vars(r)["index"])
+	if err != nil {
+		metrics.RecordError("elasticsearch_bulk", "decode_error")
+		writeElasticsearchError(w, http.StatusBadRequest, "illegal_argument_exception", err.Error())
+		return
+	}
+
+	// Only items with an entry are dispatched; keep their position in the request
+	var entries []*types.LogEntry
+	var positions []int
+	for i, item := range items {
+		if item.Entry != nil {
+			entries = append(entries, item.Entry)
+			positions = append(positions, i)
+		}
+	}
+	entries, positions = app.limitIngestLines(entries, positions)
+
+	if len(entries) > 0 {
+		if err := app.dispatcher.HandleBatch(r.Context(), entries); err != nil {
+			metrics.RecordError("elasticsearch_bulk", "dispatch_error")
+			var batchErr *types.BatchError
+			if errors.As(err, &batchErr) {
+				for index, entryErr := range batchErr.Errors {
+					items[positions[index]].Fail(http.StatusTooManyRequests, "es_rejected_execution_exception", entryErr.Error())
+				}
+			} else {
+				for _, position := range positions {
+					items[position].Fail(http.StatusTooManyRequests, "es_rejected_execution_exception", err.Error())
+				}
+			}
+		}
+	}
+
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	writeJSON(w, http.StatusOK, ingest.BulkResults(items, time.Since(start)))
+}
+
+// splunkHECAuthorize validates the HEC token against ingest.splunk_hec.tokens,
+// writing the Splunk error response when the request is not authorized.
+func (app *App) splunkHECAuthorize(w http.ResponseWriter, r *http.Request) bool {
+	token, err := ingest.HECToken(r.Header.Get("Authorization"))
+	if err != nil {
+		status, response := ingest.HECErrorResponse(err)
+		writeJSON(w, status, response)
+		return false
+	}
+
+	for _, allowed := range app.config.Ingest.SplunkHEC.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+
+	metrics.RecordError("splunk_hec", "invalid_token")
+	writeJSON(w, http.StatusForbidden, ingest.HECResponse{Text: "Invalid token", Code: ingest.HECCodeInvalidToken})
+	return false
+}
+
+// splunkHECEventHandler receives Splunk HEC events (/services/collector/event).
+//
+// The body holds one or more concatenated JSON events; see
+// ingest.DecodeHECEvents for the field mapping. Requests authenticate with
+// "Authorization: Splunk <token>" using one of the configured HEC tokens.
+//
+// Response Codes (as returned by Splunk):
+//   - 200 OK: {"text":"Success","code":0}
+//   - 400 Bad Request: Invalid or missing event data (with invalid-event-number)
+//   - 401/403: Missing, malformed or unknown token
+//   - 413 Request Entity Too Large: Body exceeds the size limit
+//   - 503 Service Unavailable: {"text":"Server is busy","code":9} when every
+//     event is rejected. HEC clients resend the whole request after a 503, so
+//     a partial rejection is answered with 200 and the rejected events go to
+//     the dead letter queue.
+func (app *App) splunkHECEventHandler(w http.ResponseWriter, r *http.Request) {
+	app.handleSplunkHEC(w, r, ingest.DecodeHECEvents)
+}
+
+// splunkHECRawHandler receives raw Splunk HEC data (/services/collector/raw).
+// Each non-empty line is an event; host, source, sourcetype and index are
+// taken from the query string.
+func (app *App) splunkHECRawHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	metadata := ingest.HECMetadata{
+		Host:       query.Get("host"),
+		Source:     query.Get("source"),
+		SourceType: query.Get("sourcetype"),
+		Index:      query.Get("index"),
+	}
+	app.handleSplunkHEC(w, r, func(body []byte) ([]*types.LogEntry, error) {
+		return ingest.DecodeHECRaw(body, metadata)
+	})
+}
+
+// handleSplunkHEC authenticates, decodes and dispatches a HEC request.
+func (app *App) handleSplunkHEC(w http.ResponseWriter, r *http.Request, decode func([]byte) ([]*types.LogEntry, error)) {
+	if !app.splunkHECAuthorize(w, r) {
+		return
+	}
+	if app.dispatcher == nil {
+		writeJSON(w, http.StatusServiceUnavailable, ingest.HECResponse{Text: "Server is busy", Code: ingest.HECCodeServerBusy})
+		return
+	}
+
+	body, status, err := readIngestBody(w, r, maxIngestBodySize)
+	if err != nil {
+		writeJSON(w, status, ingest.HECResponse{Text: err.Error(), Code: ingest.HECCodeInvalidData})
+		return
+	}
+	body, err = ingest.DecodeContentEncoding(body, r.Header.Get("Content-Encoding"), ingest.LokiMaxDecodedSize)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ingest.HECResponse{Text: err.Error(), Code: ingest.HECCodeInvalidData})
+		return
+	}
+
+	entries, err := decode(body)
+	if err != nil {
+		metrics.RecordError("splunk_hec", "decode_error")
+		status, response := ingest.HECErrorResponse(err)
+		writeJSON(w, status, response)
+		return
+	}
+
+	entries, _ = app.limitIngestLines(entries, nil)
+	if err := app.dispatcher.HandleBatch(r.Context(), entries); err != nil {
+		metrics.RecordError("splunk_hec", "dispatch_error")
+		var batchErr *types.BatchError
+		if !errors.As(err, &batchErr) || len(batchErr.Errors) == batchErr.Total {
+			writeJSON(w, http.StatusServiceUnavailable, ingest.HECResponse{Text: "Server is busy", Code: ingest.HECCodeServerBusy})
+			return
+		}
+		app.deadLetterHECEvents(entries, batchErr)
+	}
+
+	writeJSON(w, http.StatusOK, ingest.HECResponse{Text: "Success", Code: ingest.HECCodeSuccess})
+}
+
+// deadLetterHECEvents sends the events rejected in a partially accepted HEC
+// request to the dead letter queue, since the client will not resend them.
+func (app *App) deadLetterHECEvents(entries []*types.LogEntry, batchErr *types.BatchError) {
+	app.logger.WithFields(logrus.Fields{
+		"rejected": len(batchErr.Errors),
+		"total":    batchErr.Total,
+	}).Warn("Splunk HEC request partially rejected by the dispatcher")
+
+	queue := linelimit.DeadLetterQueueOf(app.dispatcher)
+	if queue == nil {
+		return
+	}
+	for index, entryErr := range batchErr.Errors {
+		if index >= len(entries) {
+			continue
+		}
+		if err := queue.AddEntry(*entries[index].DeepCopy(), entryErr.Error(), "dispatch_rejected", "splunk_hec", 0, nil); err != nil {
+			app.logger.WithError(err).Warn("Failed to send rejected HEC event to the dead letter queue")
+		}
+	}
+}
+
+// splunkHECHealthHandler answers the HEC health check used by load balancers
+// and forwarders.
+func (app *App) splunkHECHealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ingest.HECResponse{Text: "HEC is healthy", Code: ingest.HECCodeHealthy})
+}
+
+// otlpLogsHandler receives OTLP/HTTP log exports (/v1/logs).
+//
+// Requests are ExportLogsServiceRequest messages encoded as protobuf
+// (application/x-protobuf) or JSON (application/json), optionally gzip
+// compressed. The response uses the request's encoding; see
+// ingest.OTLPEntries for how records map to log entries.
+//
+// Response Codes (as defined by OTLP/HTTP):
+//   - 200 OK: Export accepted; partial_success reports records rejected by
+//     the dispatcher when only part of the request was accepted
+//   - 400 Bad Request: Malformed body or unsupported content type
+//   - 413 Request Entity Too Large: Body exceeds the size limit
+//   - 503 Service Unavailable: Dispatcher unavailable or rejecting every
+//     record (clients retry)
+func (app *App) otlpLogsHandler(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if app.dispatcher == nil {
+		writeOTLPStatus(w, contentType, http.StatusServiceUnavailable, status.New(codes.Unavailable, "dispatcher not available"))
+		return
+	}
+
+	body, httpStatus, err := readIngestBody(w, r, maxIngestBodySize)
+	if err != nil {
+		writeOTLPStatus(w, contentType, httpStatus, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	request, err := ingest.DecodeOTLPLogs(body, contentType, r.Header.Get("Content-Encoding"))
+	if err != nil {
+		metrics.RecordError("otlp_logs", "decode_error")
+		writeOTLPStatus(w, contentType, http.StatusBadRequest, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	response, err := app.exportOTLPLogs(r.Context(), request)
+	if err != nil {
+		writeOTLPStatus(w, contentType, http.StatusServiceUnavailable, status.New(codes.Unavailable, err.Error()))
+		return
+	}
+
+	data, responseType, err := ingest.MarshalOTLP(response, contentType)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", responseType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// writeOTLPStatus writes a google.rpc.Status error body in the request's encoding.
+func writeOTLPStatus(w http.ResponseWriter, contentType string, httpStatus int, st *status.Status) {
+	data, responseType, err := ingest.MarshalOTLP(st.Proto(), contentType)
+	if err != nil {
+		http.Error(w, st.Message(), httpStatus)
+		return
+	}
+	w.Header().Set("Content-Type", responseType)
+	w.WriteHeader(httpStatus)
+	w.Write(data)
+}
+
+// exportOTLPLogs dispatches the records of an OTLP export. When the dispatcher
+// rejects only some entries the response carries partial_success; when it
+// rejects all of them an error is returned so the client retries the export.
+func (app *App) exportOTLPLogs(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	records := ingest.OTLPEntries(request)
+	positions := make([]int, len(records))
+	for i := range positions {
+		positions[i] = i
+	}
+	entries, positions := app.limitIngestLines(records, positions)
+	if len(entries) == 0 {
+		return &collogspb.ExportLogsServiceResponse{}, nil
+	}
+
+	err := app.dispatcher.HandleBatch(ctx, entries)
+	if err == nil {
+		return &collogspb.ExportLogsServiceResponse{}, nil
+	}
+
+	metrics.RecordError("otlp_logs", "dispatch_error")
+	var batchErr *types.BatchError
+	if errors.As(err, &batchErr) && len(batchErr.Errors) < len(entries) {
+		// Split entries share the position of their log record, which is
+		// counted once however many of its parts were rejected
+		rejected := make(map[int]bool, len(batchErr.Errors))
+		for index := range batchErr.Errors {
+			if index < len(positions) {
+				rejected[positions[index]] = true
+			}
+		}
+		return ingest.OTLPPartialSuccess(len(rejected), batchErr), nil
+	}
+	return nil, fmt.Errorf("ingestion rejected: %w", err)
+}
+
+// otlpLogsService implements the OTLP/gRPC LogsService on top of exportOTLPLogs.
+type otlpLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	app *App
+}
+
+// Export receives an OTLP/gRPC log export. Rejections of the whole export are
+// returned as Unavailable, which OTLP exporters retry.
+func (s *otlpLogsService) Export(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	if s.app.dispatcher == nil {
+		return nil, status.Error(codes.Unavailable, "dispatcher not available")
+	}
+	response, err := s.app.exportOTLPLogs(ctx, request)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return response, nil
+}
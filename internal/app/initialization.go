@@ -30,6 +30,8 @@ import (
 	"ssw-logs-capture/pkg/types"
 
 	"github.com/gorilla/mux"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
 )
 
 // initCoreServices initializes the fundamental services required for log processing.
@@ -75,7 +77,7 @@ func (app *App) initCoreServices() error {
 	}
 	app.dispatcher = dispatcher.NewDispatcher(dispatcherConfig, processor, app.logger, app.enhancedMetrics)
 
-	return nil
+	return app.newIngestLineLimit()
 }
 
 // initSinks initializes and configures all output destinations for log entries.
@@ -176,8 +178,34 @@ func (app *App) initSinks() error {
 //   - Supports container filtering by labels and names
 //   - Implements connection pooling and automatic reconnection
 //   - Includes health checking and graceful error handling
+//   - With source "json-file", reads /var/lib/docker/containers/<id>/<id>-json.log
+//     directly (no Docker socket), applying the same filters and multiline rules
+//
+// Syslog Monitor:
+//   - Receives RFC 5424 and RFC 3164 messages over UDP, TCP and TLS
+//   - Supports octet-counting and newline framing on stream transports
+//
+// Kafka Monitor:
+//   - Consumes topics through a sarama consumer group (SASL/SCRAM and TLS)
+//   - Decodes JSON or raw messages; offsets are committed only after dispatch
+//
+// Fluent Forward Monitor:
+//   - Speaks the Forward protocol over TCP and unix sockets (Fluent Bit, Fluentd, Docker fluentd driver)
+//   - Acks chunks only after dispatch; optional shared-key handshake
+//
+// Exec Monitor:
+//   - Runs configured commands and captures stdout/stderr line by line
+//   - Restarts commands per restart policy with exponential backoff; optional stdin mode
 //
-// Both monitors integrate with:
+// Kmsg Monitor:
+//   - Reads kernel ring buffer records from /dev/kmsg (Linux)
+//   - Checkpoints the last sequence number in the positions directory
+//
+// Kubernetes Pod Monitor:
+//   - Discovers /var/log/pods/<namespace>_<pod>_<uid>/<container>/N.log on the node
+//   - Parses the CRI log format and reassembles partial lines
+//
+// All monitors integrate with:
 //   - Timestamp validation for log entry enrichment
 //   - Dispatcher for log entry delivery
 //   - Task manager for lifecycle coordination
@@ -202,6 +230,7 @@ func (app *App) initMonitors() error {
 			Recursive:          app.config.FileMonitorService.Recursive,
 			FollowSymlinks:     app.config.FileMonitorService.FollowSymlinks,
 			PipelineConfig:     app.config.FileMonitorService.PipelineConfig, // Add PipelineConfig from loaded file_pipeline.yml
+			LineLimit:          app.config.FileMonitorService.LineLimit,
 		}
 		fileMonitor, err := monitors.NewFileMonitor(fileConfig, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.positionManager, app.logger)
 		if err != nil {
@@ -220,17 +249,97 @@ func (app *App) initMonitors() error {
 			MaxConcurrent:     app.config.ContainerMonitor.MaxConcurrent,
 			ReconnectInterval: parseDurationSafe(app.config.ContainerMonitor.ReconnectInterval, 30*time.Second),
 			HealthCheckDelay:  parseDurationSafe(app.config.ContainerMonitor.HealthCheckDelay, 30*time.Second),
+			ContainersPath:    app.config.ContainerMonitor.ContainersPath,
+			ScanInterval:      parseDurationSafe(app.config.ContainerMonitor.ScanInterval, 10*time.Second),
 			IncludeLabels:     app.config.ContainerMonitor.IncludeLabels,
 			ExcludeLabels:     app.config.ContainerMonitor.ExcludeLabels,
 			IncludeNames:      app.config.ContainerMonitor.IncludeNames,
 			ExcludeNames:      app.config.ContainerMonitor.ExcludeNames,
+			IncludeStdout:     app.config.ContainerMonitor.IncludeStdout,
+			IncludeStderr:     app.config.ContainerMonitor.IncludeStderr,
+			Multiline:         app.config.ContainerMonitor.Multiline,
+			MultilineRules:    app.config.ContainerMonitor.MultilineRules,
+			LineLimit:         app.config.ContainerMonitor.LineLimit,
+		}
+		if app.config.ContainerMonitor.Source == "json-file" {
+			dockerJSONFileMonitor, err := monitors.NewDockerJSONFileMonitor(dockerConfig, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.positionManager, app.logger)
+			if err != nil {
+				return fmt.Errorf("failed to create docker json-file monitor: %w", err)
+			}
+			app.dockerJSONFileMonitor = dockerJSONFileMonitor
+			app.logger.WithField("containers_path", dockerConfig.ContainersPath).Info("Container monitor initialized (json-file source)")
+		} else {
+			containerMonitor, err := monitors.NewContainerMonitor(dockerConfig, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.positionManager, app.logger)
+			if err != nil {
+				return fmt.Errorf("failed to create container monitor: %w", err)
+			}
+			app.containerMonitor = containerMonitor
+			app.logger.Info("Container monitor initialized")
+		}
+	}
+
+	// Syslog Monitor
+	if app.config.SyslogMonitor.Enabled {
+		syslogMonitor, err := monitors.NewSyslogMonitor(app.config.SyslogMonitor, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create syslog monitor: %w", err)
+		}
+		app.syslogMonitor = syslogMonitor
+		app.logger.Info("Syslog monitor initialized")
+	}
+
+	// Kafka Monitor
+	if app.config.KafkaMonitor.Enabled {
+		kafkaMonitor, err := monitors.NewKafkaMonitor(app.config.KafkaMonitor, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create kafka monitor: %w", err)
 		}
-		containerMonitor, err := monitors.NewContainerMonitor(dockerConfig, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.positionManager, app.logger)
+		app.kafkaMonitor = kafkaMonitor
+		app.logger.Info("Kafka monitor initialized")
+	}
+
+	// Fluent Forward Monitor
+	if app.config.FluentForward.Enabled {
+		fluentForwardMonitor, err := monitors.NewFluentForwardMonitor(app.config.FluentForward, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.logger)
 		if err != nil {
-			return fmt.Errorf("failed to create container monitor: %w", err)
+			return fmt.Errorf("failed to create fluent forward monitor: %w", err)
 		}
-		app.containerMonitor = containerMonitor
-		app.logger.Info("Container monitor initialized")
+		app.fluentForwardMonitor = fluentForwardMonitor
+		app.logger.Info("Fluent forward monitor initialized")
+	}
+
+	// Exec Monitor
+	if app.config.ExecMonitor.Enabled {
+		execMonitor, err := monitors.NewExecMonitor(app.config.ExecMonitor, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create exec monitor: %w", err)
+		}
+		app.execMonitor = execMonitor
+		app.logger.Info("Exec monitor initialized")
+	}
+
+	// Kmsg Monitor
+	if app.config.KmsgMonitor.Enabled {
+		positionsDir := ""
+		if app.config.Positions.Enabled {
+			positionsDir = app.config.Positions.Directory
+		}
+		kmsgMonitor, err := monitors.NewKmsgMonitor(app.config.KmsgMonitor, app.config.TimestampValidation, app.dispatcher, app.taskManager, positionsDir, app.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create kmsg monitor: %w", err)
+		}
+		app.kmsgMonitor = kmsgMonitor
+		app.logger.Info("Kmsg monitor initialized")
+	}
+
+	// Kubernetes Pod Monitor
+	if app.config.KubernetesPods.Enabled {
+		kubernetesPodMonitor, err := monitors.NewKubernetesPodMonitor(app.config.KubernetesPods, app.config.TimestampValidation, app.dispatcher, app.taskManager, app.positionManager, app.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create kubernetes pod monitor: %w", err)
+		}
+		app.kubernetesPodMonitor = kubernetesPodMonitor
+		app.logger.WithField("logs_path", app.config.KubernetesPods.LogsPath).Info("Kubernetes pod monitor initialized")
 	}
 
 	return nil
@@ -762,7 +871,9 @@ func (app *App) initReloader() error {
 // initServiceDiscovery initializes the service discovery functionality.
 //
 // This function sets up automatic service discovery for monitoring sources
-// including Docker containers, file-based services, and future Kubernetes integration.
+// including Docker containers, file-based services, and Kubernetes pods. When
+// Kubernetes discovery is enabled, the pod watch cache also enriches the labels
+// of logs collected by the Kubernetes pod monitor.
 func (app *App) initServiceDiscovery() error {
 	if !app.config.ServiceDiscovery.Enabled {
 		app.logger.Info("Service discovery disabled")
@@ -789,6 +900,21 @@ func (app *App) initServiceDiscovery() error {
 			RequiredLabels: app.config.ServiceDiscovery.File.RequiredLabels,
 			AutoDetectLogs: app.config.ServiceDiscovery.File.AutoDetectLogs,
 		},
+		KubernetesEnabled: app.config.ServiceDiscovery.KubernetesEnabled,
+		KubernetesConfig: discovery.KubernetesDiscoveryConfig{
+			Namespace:           app.config.ServiceDiscovery.Kubernetes.Namespace,
+			RequiredAnnotations: app.config.ServiceDiscovery.Kubernetes.RequiredAnnotations,
+			RequiredLabels:      app.config.ServiceDiscovery.Kubernetes.RequiredLabels,
+			ServiceAccount:      app.config.ServiceDiscovery.Kubernetes.ServiceAccount,
+			APIServer:           app.config.ServiceDiscovery.Kubernetes.APIServer,
+			TokenFile:           app.config.ServiceDiscovery.Kubernetes.TokenFile,
+			CAFile:              app.config.ServiceDiscovery.Kubernetes.CAFile,
+			InsecureSkipVerify:  app.config.ServiceDiscovery.Kubernetes.InsecureSkipVerify,
+			NodeName:            app.config.ServiceDiscovery.Kubernetes.NodeName,
+			PodLabels:           app.config.ServiceDiscovery.Kubernetes.PodLabels,
+			PodAnnotations:      app.config.ServiceDiscovery.Kubernetes.PodAnnotations,
+			NamespaceLabels:     app.config.ServiceDiscovery.Kubernetes.NamespaceLabels,
+		},
 	}
 
 	serviceDiscovery, err := discovery.NewServiceDiscovery(discoveryConfig, app.logger)
@@ -796,6 +922,11 @@ func (app *App) initServiceDiscovery() error {
 		return fmt.Errorf("failed to create service discovery: %w", err)
 	}
 
+	// Pod logs are enriched with metadata from the Kubernetes watch cache
+	if watcher := serviceDiscovery.KubernetesWatcher(); watcher != nil && app.kubernetesPodMonitor != nil {
+		app.kubernetesPodMonitor.SetEnricher(watcher)
+	}
+
 	app.serviceDiscovery = serviceDiscovery
 	app.logger.Info("Service discovery initialized")
 	return nil
@@ -869,6 +1000,22 @@ func (app *App) initHTTPServer() {
 	app.logger.WithField("addr", addr).Info("HTTP server initialized")
 }
 
+// initOTLPGRPCServer configures the OTLP/gRPC LogsService receiver.
+//
+// The receiver shares the dispatch path of the OTLP/HTTP /v1/logs endpoint
+// and listens on ingest.otlp.grpc_address. It is only created when both
+// ingest.otlp.enabled and ingest.otlp.grpc_enabled are set; the listener is
+// opened in Start.
+func (app *App) initOTLPGRPCServer() {
+	otlpConfig := app.config.Ingest.OTLP
+	if !otlpConfig.Enabled || !otlpConfig.GRPCEnabled {
+		return
+	}
+	app.otlpGRPCServer = grpc.NewServer(grpc.MaxRecvMsgSize(otlpConfig.MaxRecvMessageMiB << 20))
+	collogspb.RegisterLogsServiceServer(app.otlpGRPCServer, &otlpLogsService{app: app})
+	app.logger.WithField("addr", otlpConfig.GRPCAddress).Info("OTLP gRPC receiver initialized")
+}
+
 // initMetricsServer configures the Prometheus metrics server.
 //
 // This method sets up a dedicated HTTP server for Prometheus metrics
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"ssw-logs-capture/pkg/errors"
+	"ssw-logs-capture/pkg/linelimit"
 	"ssw-logs-capture/pkg/types"
 
 	"gopkg.in/yaml.v2"
@@ -146,6 +147,9 @@ func applyDefaults(config *types.Config) {
 	if config.Server.Host == "" {
 		config.Server.Host = "0.0.0.0"
 	}
+	if config.Server.MaxBodySize == 0 {
+		config.Server.MaxBodySize = 16 << 20
+	}
 
 	// Metrics defaults
 	if config.Metrics.Port == 0 {
@@ -212,11 +216,106 @@ func applyDefaults(config *types.Config) {
 	if config.ContainerMonitor.MaxConcurrent == 0 {
 		config.ContainerMonitor.MaxConcurrent = 50
 	}
+	if config.ContainerMonitor.Source == "" {
+		config.ContainerMonitor.Source = "api"
+	}
+	if config.ContainerMonitor.ContainersPath == "" {
+		config.ContainerMonitor.ContainersPath = "/var/lib/docker/containers"
+	}
+	if config.ContainerMonitor.ScanInterval == "" {
+		config.ContainerMonitor.ScanInterval = "10s"
+	}
 	config.ContainerMonitor.Enabled = true
-	config.ContainerMonitor.IncludeStdout = true
-	config.ContainerMonitor.IncludeStderr = true
+	// Coletar ambos os streams quando nenhum foi selecionado explicitamente
+	if !config.ContainerMonitor.IncludeStdout && !config.ContainerMonitor.IncludeStderr {
+		config.ContainerMonitor.IncludeStdout = true
+		config.ContainerMonitor.IncludeStderr = true
+	}
 	config.ContainerMonitor.Follow = true
 
+	// Syslog Monitor defaults
+	if config.SyslogMonitor.MaxMessageSize == 0 {
+		config.SyslogMonitor.MaxMessageSize = 64 * 1024
+	}
+	if config.SyslogMonitor.MaxConnections == 0 {
+		config.SyslogMonitor.MaxConnections = 1000
+	}
+	if config.SyslogMonitor.ReadTimeout == "" {
+		config.SyslogMonitor.ReadTimeout = "5m"
+	}
+
+	// Fluent Forward defaults
+	if config.FluentForward.MaxMessageSize == 0 {
+		config.FluentForward.MaxMessageSize = 16 * 1024 * 1024
+	}
+	if config.FluentForward.MaxConnections == 0 {
+		config.FluentForward.MaxConnections = 1000
+	}
+	if config.FluentForward.ReadTimeout == "" {
+		config.FluentForward.ReadTimeout = "5m"
+	}
+
+	// Kmsg Monitor defaults
+	if config.KmsgMonitor.DevicePath == "" {
+		config.KmsgMonitor.DevicePath = "/dev/kmsg"
+	}
+	if config.KmsgMonitor.FlushInterval == "" {
+		config.KmsgMonitor.FlushInterval = "5s"
+	}
+
+	// Exec Monitor defaults
+	if config.ExecMonitor.MaxLineSize == 0 {
+		config.ExecMonitor.MaxLineSize = 1024 * 1024
+	}
+	for i := range config.ExecMonitor.Commands {
+		command := &config.ExecMonitor.Commands[i]
+		if command.RestartPolicy == "" {
+			command.RestartPolicy = "on-failure"
+		}
+		if command.RestartDelay == "" {
+			command.RestartDelay = "1s"
+		}
+		if command.MaxRestartDelay == "" {
+			command.MaxRestartDelay = "1m"
+		}
+	}
+
+	// Kafka Monitor defaults
+	if config.KafkaMonitor.GroupID == "" {
+		config.KafkaMonitor.GroupID = "ssw-logs-capture"
+	}
+	if config.KafkaMonitor.Version == "" {
+		config.KafkaMonitor.Version = "2.8.0"
+	}
+	if config.KafkaMonitor.InitialOffset == "" {
+		config.KafkaMonitor.InitialOffset = "newest"
+	}
+	if config.KafkaMonitor.Format == "" {
+		config.KafkaMonitor.Format = "json"
+	}
+	if config.KafkaMonitor.CommitInterval == "" {
+		config.KafkaMonitor.CommitInterval = "1s"
+	}
+
+	// Ingest endpoints defaults
+	if config.Ingest.Elasticsearch.Version == "" {
+		config.Ingest.Elasticsearch.Version = "8.11.0"
+	}
+	if config.Ingest.OTLP.GRPCAddress == "" {
+		config.Ingest.OTLP.GRPCAddress = ":4317"
+	}
+	if config.Ingest.OTLP.MaxRecvMessageMiB == 0 {
+		config.Ingest.OTLP.MaxRecvMessageMiB = 4
+	}
+
+	// Kubernetes pod logs defaults
+	if config.KubernetesPods.LogsPath == "" {
+		config.KubernetesPods.LogsPath = "/var/log/pods"
+	}
+	if config.KubernetesPods.ScanInterval == "" {
+		config.KubernetesPods.ScanInterval = "10s"
+	}
+
 	// Dispatcher defaults
 	if config.Dispatcher.QueueSize == 0 {
 		config.Dispatcher.QueueSize = 10000
@@ -554,6 +653,15 @@ func (v *ConfigValidator) validateServer() {
 				v.addError("server", "validate_write_timeout", fmt.Sprintf("invalid write timeout: %s", v.config.Server.WriteTimeout))
 			}
 		}
+
+		if v.config.Server.MaxBodySize < 0 {
+			v.addError("server", "validate_max_body_size", fmt.Sprintf("max_body_size cannot be negative: %d", v.config.Server.MaxBodySize))
+		}
+	}
+
+	// The ingest limiter is created with the dispatcher, even when the server is disabled
+	if err := linelimit.Validate(v.config.Server.LineLimit); err != nil {
+		v.addError("server", "validate_line_limit", err.Error())
 	}
 }
 
@@ -585,10 +693,17 @@ func (v *ConfigValidator) validateMonitoring() {
 			v.addError("container_monitor", "validate_max_concurrent", "max concurrent must be positive")
 		}
 
+		switch v.config.ContainerMonitor.Source {
+		case "", "api", "json-file":
+		default:
+			v.addError("container_monitor", "validate_source", fmt.Sprintf("invalid source %q (expected api or json-file)", v.config.ContainerMonitor.Source))
+		}
+
 		// Validate duration strings
 		durations := map[string]string{
 			"health_check_delay":  v.config.ContainerMonitor.HealthCheckDelay,
 			"reconnect_interval":  v.config.ContainerMonitor.ReconnectInterval,
+			"scan_interval":       v.config.ContainerMonitor.ScanInterval,
 		}
 
 		for field, duration := range durations {
@@ -598,6 +713,91 @@ func (v *ConfigValidator) validateMonitoring() {
 				}
 			}
 		}
+
+		if err := linelimit.Validate(v.config.ContainerMonitor.LineLimit); err != nil {
+			v.addError("container_monitor", "validate_line_limit", err.Error())
+		}
+	}
+
+	// Fluent Forward validation
+	if v.config.FluentForward.Enabled {
+		if v.config.FluentForward.Address == "" && v.config.FluentForward.SocketPath == "" {
+			v.addError("fluent_forward", "validate_address", "address or socket_path is required when enabled")
+		}
+		if v.config.FluentForward.ReadTimeout != "" {
+			if _, err := time.ParseDuration(v.config.FluentForward.ReadTimeout); err != nil {
+				v.addError("fluent_forward", "validate_duration", fmt.Sprintf("invalid read_timeout: %s", v.config.FluentForward.ReadTimeout))
+			}
+		}
+	}
+
+	// Kmsg monitor validation
+	if v.config.KmsgMonitor.Enabled && v.config.KmsgMonitor.FlushInterval != "" {
+		if _, err := time.ParseDuration(v.config.KmsgMonitor.FlushInterval); err != nil {
+			v.addError("kmsg_monitor", "validate_duration", fmt.Sprintf("invalid flush_interval: %s", v.config.KmsgMonitor.FlushInterval))
+		}
+	}
+
+	// Exec monitor validation
+	if v.config.ExecMonitor.Enabled {
+		if len(v.config.ExecMonitor.Commands) == 0 && !v.config.ExecMonitor.Stdin {
+			v.addError("exec_monitor", "validate_commands", "at least one command or stdin is required when enabled")
+		}
+		for i, command := range v.config.ExecMonitor.Commands {
+			if command.Command == "" {
+				v.addError("exec_monitor", "validate_command", fmt.Sprintf("commands[%d]: command is required", i))
+			}
+			switch command.RestartPolicy {
+			case "", "always", "on-failure", "never":
+			default:
+				v.addError("exec_monitor", "validate_restart_policy", fmt.Sprintf("commands[%d]: invalid restart_policy: %s", i, command.RestartPolicy))
+			}
+			for _, delay := range []string{command.RestartDelay, command.MaxRestartDelay} {
+				if delay == "" {
+					continue
+				}
+				if _, err := time.ParseDuration(delay); err != nil {
+					v.addError("exec_monitor", "validate_duration", fmt.Sprintf("commands[%d]: invalid restart delay: %s", i, delay))
+				}
+			}
+		}
+	}
+
+	// Kafka monitoring validation
+	if v.config.KafkaMonitor.Enabled {
+		if len(v.config.KafkaMonitor.Brokers) == 0 {
+			v.addError("kafka_monitor", "validate_brokers", "at least one broker is required when enabled")
+		}
+
+		if len(v.config.KafkaMonitor.Topics) == 0 {
+			v.addError("kafka_monitor", "validate_topics", "at least one topic is required when enabled")
+		}
+
+		switch v.config.KafkaMonitor.Format {
+		case "", "json", "raw":
+		default:
+			v.addError("kafka_monitor", "validate_format", fmt.Sprintf("invalid format %q (expected json or raw)", v.config.KafkaMonitor.Format))
+		}
+
+		switch v.config.KafkaMonitor.InitialOffset {
+		case "", "newest", "oldest":
+		default:
+			v.addError("kafka_monitor", "validate_initial_offset", fmt.Sprintf("invalid initial offset %q (expected newest or oldest)", v.config.KafkaMonitor.InitialOffset))
+		}
+
+		if v.config.KafkaMonitor.CommitInterval != "" {
+			if _, err := time.ParseDuration(v.config.KafkaMonitor.CommitInterval); err != nil {
+				v.addError("kafka_monitor", "validate_duration", fmt.Sprintf("invalid commit_interval: %s", v.config.KafkaMonitor.CommitInterval))
+			}
+		}
+	}
+
+	// Ingest endpoints validation
+	if v.config.Ingest.SplunkHEC.Enabled && len(v.config.Ingest.SplunkHEC.Tokens) == 0 {
+		v.addError("ingest", "validate_hec_tokens", "splunk_hec requires at least one token when enabled")
+	}
+	if v.config.Ingest.OTLP.MaxRecvMessageMiB < 0 {
+		v.addError("ingest", "validate_otlp_max_recv", "otlp max_recv_message_mib must not be negative")
 	}
 
 	// File monitoring validation
@@ -606,6 +806,10 @@ func (v *ConfigValidator) validateMonitoring() {
 			v.addError("file_monitor", "validate_buffer_size", "read buffer size must be positive")
 		}
 
+		if err := linelimit.Validate(v.config.FileMonitorService.LineLimit); err != nil {
+			v.addError("file_monitor", "validate_line_limit", err.Error())
+		}
+
 		// C12: Validate directory paths are absolute (existence checked at runtime)
 		for _, dir := range v.config.FilesConfig.WatchDirectories {
 			if dir != "" && !filepath.IsAbs(dir) {
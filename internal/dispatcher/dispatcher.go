@@ -574,6 +574,31 @@ func (d *Dispatcher) Stop() error {
 // Returns:
 //   - error: Processing error including rate limiting, queue full, or validation errors
 func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message string, labels map[string]string) error {
+	now := time.Now()
+	return d.HandleEntry(ctx, &types.LogEntry{
+		Timestamp:   now,
+		Message:     message,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		Labels:      labels,
+		ProcessedAt: now,
+	})
+}
+
+// HandleEntry processes a fully-formed log entry through the dispatcher pipeline.
+//
+// Unlike Handle, the entry's Timestamp, Level, Fields and TraceID/SpanID are
+// preserved end-to-end through deduplication, processing and delivery to sinks.
+// A zero Timestamp is replaced by the current time. The entry is copied, so the
+// caller keeps ownership of the pointer and its maps.
+//
+// Returns:
+//   - error: Processing error including rate limiting, queue full, or validation errors
+func (d *Dispatcher) HandleEntry(ctx context.Context, entry *types.LogEntry) error {
+	if entry == nil {
+		return fmt.Errorf("nil log entry")
+	}
+
 	if !d.isRunning {
 		return fmt.Errorf("dispatcher not running")
 	}
@@ -597,6 +622,9 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 		return fmt.Errorf("rate limit exceeded")
 	}
 
+	// Criar cópia segura da entrada (labels e fields não são compartilhados com o chamador)
+	entryCopy := newDispatchEntry(entry)
+
 	// Verificar backpressure e aplicar controle de fluxo
 	if d.config.BackpressureEnabled && d.backpressureManager != nil {
 		// Atualizar métricas do sistema para backpressure
@@ -621,25 +649,12 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 
 				// Em vez de descartar, colocar numa fila de baixa prioridade
 				// Para manter integridade dos dados
-				return d.handleLowPriorityEntry(ctx, sourceType, sourceID, message, labels)
+				return d.handleLowPriorityEntry(ctx, entryCopy)
 			}
 		}
 	}
 
-	// Criar entrada de log com cópia segura dos labels
-	labelsCopy := make(map[string]string, len(labels))
-	for k, v := range labels {
-		labelsCopy[k] = v
-	}
-
-	entry := types.LogEntry{
-		Timestamp:   time.Now(),
-		Message:     message,
-		SourceType:  sourceType,
-		SourceID:    sourceID,
-		Labels:      labelsCopy,
-		ProcessedAt: time.Now(),
-	}
+	sourceType, sourceID := entryCopy.SourceType, entryCopy.SourceID
 
 	// Verificar duplicação se habilitado (respeitando degradação)
 	if d.config.DeduplicationEnabled && d.deduplicationManager != nil {
@@ -650,7 +665,7 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 		}
 
 		if !skipDeduplication {
-			if d.deduplicationManager.IsDuplicate(sourceID, message, entry.Timestamp) {
+			if d.deduplicationManager.IsDuplicate(sourceID, entryCopy.Message, entryCopy.Timestamp) {
 				// Log duplicado detectado - incrementar estatística e retornar
 				d.statsMutex.Lock()
 				d.stats.DuplicatesDetected++
@@ -664,16 +679,16 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 
 	// Validar timestamp (detectar timestamps muito antigos)
 	now := time.Now()
-	if entry.Timestamp.Before(now.Add(-d.config.TimestampTolerance)) {
+	if entryCopy.Timestamp.Before(now.Add(-d.config.TimestampTolerance)) {
 		d.logger.WithFields(logrus.Fields{
-			"trace_id":           entry.TraceID,
+			"trace_id":           entryCopy.TraceID,
 			"source_type":        sourceType,
 			"source_id":          sourceID,
-			"original_timestamp": entry.Timestamp,
-			"drift_seconds":      now.Sub(entry.Timestamp).Seconds(),
+			"original_timestamp": entryCopy.Timestamp,
+			"drift_seconds":      now.Sub(entryCopy.Timestamp).Seconds(),
 		}).Warn("Timestamp muito antigo; ajustando para agora")
 
-		entry.Timestamp = now
+		entryCopy.Timestamp = now
 		d.updateTimestampWarnings()
 	}
 
@@ -686,10 +701,10 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 		}
 
 		if !skipProcessing {
-			processedEntry, err := d.processor.Process(ctx, &entry)
+			processedEntry, err := d.processor.Process(ctx, entryCopy)
 			if err != nil {
 				d.logger.WithError(err).WithFields(logrus.Fields{
-					"trace_id":    entry.TraceID,
+					"trace_id":    entryCopy.TraceID,
 					"source_type": sourceType,
 					"source_id":   sourceID,
 				}).Error("Failed to process log entry")
@@ -697,7 +712,7 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 				return err
 			}
 			if processedEntry != nil {
-				entry = *processedEntry
+				entryCopy = processedEntry
 			}
 		}
 	}
@@ -705,7 +720,7 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 	// C5: Race Condition Fix - Use deep copy to avoid sharing mutex
 	// Deep copy ensures the queued item has independent maps and fresh mutex
 	item := dispatchItem{
-		Entry:     *entry.DeepCopy(),
+		Entry:     *entryCopy.DeepCopy(),
 		Timestamp: time.Now(),
 		Retries:   0,
 	}
@@ -729,6 +744,47 @@ func (d *Dispatcher) Handle(ctx context.Context, sourceType, sourceID, message s
 	}
 }
 
+// HandleBatch processes several fully-formed entries through HandleEntry.
+//
+// Every entry is attempted even if earlier ones fail. When some entries are
+// rejected the returned error is a *types.BatchError keyed by batch index, so
+// callers can report per-entry results or retry only the failed entries.
+func (d *Dispatcher) HandleBatch(ctx context.Context, entries []*types.LogEntry) error {
+	var failed map[int]error
+
+	for i, entry := range entries {
+		if err := d.HandleEntry(ctx, entry); err != nil {
+			if failed == nil {
+				failed = make(map[int]error)
+			}
+			failed[i] = err
+		}
+	}
+
+	if failed != nil {
+		return &types.BatchError{Total: len(entries), Errors: failed}
+	}
+	return nil
+}
+
+// newDispatchEntry copia a entrada recebida preenchendo os campos obrigatórios
+func newDispatchEntry(entry *types.LogEntry) *types.LogEntry {
+	entryCopy := entry.DeepCopy()
+	now := time.Now()
+
+	if entryCopy.Timestamp.IsZero() {
+		entryCopy.Timestamp = now
+	}
+	if entryCopy.ProcessedAt.IsZero() {
+		entryCopy.ProcessedAt = now
+	}
+	if entryCopy.Labels == nil {
+		entryCopy.Labels = make(map[string]string)
+	}
+
+	return entryCopy
+}
+
 // GetStats retorna estatísticas do dispatcher
 // PHASE 2 REFACTORING: Delegates to StatsCollector for thread-safe stats access
 func (d *Dispatcher) GetStats() types.DispatcherStats {
@@ -1332,28 +1388,13 @@ func (d *Dispatcher) updateBackpressureMetricsOriginal() {
 */
 
 // handleLowPriorityEntry processa uma entrada de baixa prioridade sem descartar
-func (d *Dispatcher) handleLowPriorityEntry(ctx context.Context, sourceType, sourceID, message string, labels map[string]string) error {
+func (d *Dispatcher) handleLowPriorityEntry(ctx context.Context, entry *types.LogEntry) error {
 	// Em vez de descartar, envia para Dead Letter Queue se disponível
 	if d.config.DLQEnabled && d.deadLetterQueue != nil {
-		// C1: Race Condition Fix - Create safe copy of labels map
-		labelsCopy := make(map[string]string, len(labels))
-		for k, v := range labels {
-			labelsCopy[k] = v
-		}
-
-		entry := types.LogEntry{
-			Timestamp:   time.Now(),
-			Message:     message,
-			SourceType:  sourceType,
-			SourceID:    sourceID,
-			Labels:      labelsCopy,
-			ProcessedAt: time.Now(),
-		}
-
 		// Adicionar tag indicando que foi throttled (thread-safe)
 		entry.SetLabel("throttle_reason", "backpressure_low_priority")
 
-		d.deadLetterQueue.AddEntry(entry, "throttled due to backpressure", "backpressure", "dispatcher", 0, map[string]string{
+		d.deadLetterQueue.AddEntry(*entry.DeepCopy(), "throttled due to backpressure", "backpressure", "dispatcher", 0, map[string]string{
 			"throttle_level": d.backpressureManager.GetLevel().String(),
 		})
 		return nil
@@ -1361,8 +1402,8 @@ func (d *Dispatcher) handleLowPriorityEntry(ctx context.Context, sourceType, sou
 
 	// Se não tiver DLQ, logar warning mas não descartar
 	d.logger.WithFields(logrus.Fields{
-		"source_type": sourceType,
-		"source_id":   sourceID,
+		"source_type": entry.SourceType,
+		"source_id":   entry.SourceID,
 		"level":       d.backpressureManager.GetLevel().String(),
 	}).Warn("Log entry throttled due to backpressure, but no DLQ available")
 
@@ -1370,46 +1411,31 @@ func (d *Dispatcher) handleLowPriorityEntry(ctx context.Context, sourceType, sou
 	select {
 	case <-time.After(10 * time.Millisecond):
 		// Retry apenas uma vez sem backpressure check para evitar recursão
-		return d.handleWithoutBackpressure(ctx, sourceType, sourceID, message, labels)
+		return d.handleWithoutBackpressure(ctx, entry)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 }
 
 // handleWithoutBackpressure processa uma entrada sem verificar backpressure (usado internamente)
-func (d *Dispatcher) handleWithoutBackpressure(ctx context.Context, sourceType, sourceID, message string, labels map[string]string) error {
+func (d *Dispatcher) handleWithoutBackpressure(ctx context.Context, entry *types.LogEntry) error {
 	if !d.isRunning {
 		return fmt.Errorf("dispatcher not running")
 	}
 
-	// Criar entrada de log com cópia segura dos labels
-	labelsCopy := make(map[string]string, len(labels))
-	for k, v := range labels {
-		labelsCopy[k] = v
-	}
-
-	entry := types.LogEntry{
-		Timestamp:   time.Now(),
-		Message:     message,
-		SourceType:  sourceType,
-		SourceID:    sourceID,
-		Labels:      labelsCopy,
-		ProcessedAt: time.Now(),
-	}
-
 	// Processar entrada
 	if d.processor != nil {
-		processedEntry, err := d.processor.Process(ctx, &entry)
+		processedEntry, err := d.processor.Process(ctx, entry)
 		if err != nil {
 			d.logger.WithError(err).WithFields(logrus.Fields{
 				"trace_id":    entry.TraceID,
-				"source_type": sourceType,
-				"source_id":   sourceID,
+				"source_type": entry.SourceType,
+				"source_id":   entry.SourceID,
 			}).Error("Failed to process log entry in low priority path")
 			return err
 		}
 		if processedEntry != nil {
-			entry = *processedEntry
+			entry = processedEntry
 		}
 	}
 
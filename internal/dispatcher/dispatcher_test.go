@@ -169,6 +169,112 @@ func TestDispatcherHandleLogEntry(t *testing.T) {
 	mockSink.AssertExpectations(t)
 }
 
+// TestDispatcherHandleEntryPreservesFields tests that HandleEntry keeps the entry metadata
+func TestDispatcherHandleEntryPreservesFields(t *testing.T) {
+	config := DispatcherConfig{
+		QueueSize:    100,
+		Workers:      1,
+		BatchSize:    1,
+		BatchTimeout: 50 * time.Millisecond,
+		MaxRetries:   1,
+		RetryDelay:   10 * time.Millisecond,
+	}
+
+	logger := logrus.New()
+	dispatcher := NewDispatcher(config, nil, logger, nil)
+
+	var mu sync.Mutex
+	var sent []*types.LogEntry
+	mockSink := &MockSink{}
+	mockSink.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries := args.Get(1).([]types.LogEntry)
+		for i := range entries {
+			sent = append(sent, entries[i].DeepCopy())
+		}
+	}).Return(nil)
+	mockSink.On("IsHealthy").Return(true)
+	dispatcher.AddSink(mockSink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, dispatcher.Start(ctx))
+
+	timestamp := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	entry := &types.LogEntry{
+		TraceID:    "trace-1",
+		SpanID:     "span-1",
+		Timestamp:  timestamp,
+		Message:    "payment failed",
+		Level:      "error",
+		SourceType: "api",
+		SourceID:   "checkout",
+		Labels:     map[string]string{"service": "checkout"},
+		Fields:     map[string]interface{}{"amount": 42.5},
+	}
+	require.NoError(t, dispatcher.HandleEntry(ctx, entry))
+
+	// O chamador mantém a posse da entrada
+	entry.Labels["service"] = "changed"
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, dispatcher.Stop())
+
+	got := sent[0]
+	assert.True(t, timestamp.Equal(got.Timestamp))
+	assert.Equal(t, "error", got.Level)
+	assert.Equal(t, "trace-1", got.TraceID)
+	assert.Equal(t, "span-1", got.SpanID)
+	assert.Equal(t, 42.5, got.Fields["amount"])
+	assert.Equal(t, "checkout", got.Labels["service"])
+	assert.False(t, got.ProcessedAt.IsZero())
+}
+
+// TestDispatcherHandleBatch tests per-entry errors of a batch
+func TestDispatcherHandleBatch(t *testing.T) {
+	config := DispatcherConfig{
+		QueueSize:    100,
+		Workers:      1,
+		BatchSize:    10,
+		BatchTimeout: 50 * time.Millisecond,
+		MaxRetries:   1,
+		RetryDelay:   10 * time.Millisecond,
+	}
+
+	logger := logrus.New()
+	dispatcher := NewDispatcher(config, nil, logger, nil)
+
+	mockSink := &MockSink{}
+	mockSink.On("Send", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("IsHealthy").Return(true)
+	dispatcher.AddSink(mockSink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, dispatcher.Start(ctx))
+	defer dispatcher.Stop()
+
+	err := dispatcher.HandleBatch(ctx, []*types.LogEntry{
+		{Message: "first", SourceType: "api", SourceID: "batch"},
+		nil,
+		{Message: "third", SourceType: "api", SourceID: "batch"},
+	})
+	require.Error(t, err)
+
+	batchErr, ok := err.(*types.BatchError)
+	require.True(t, ok)
+	assert.Equal(t, 3, batchErr.Total)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.Contains(t, batchErr.Errors, 1)
+
+	assert.NoError(t, dispatcher.HandleBatch(ctx, []*types.LogEntry{{Message: "ok"}}))
+}
+
 // TestDispatcherBatching tests batching functionality
 func TestDispatcherBatching(t *testing.T) {
 	config := DispatcherConfig{
@@ -0,0 +1,184 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// Estados de uma entrada e da resposta de /api/v1/logs
+const (
+	APIStatusAccepted = "accepted"
+	APIStatusPartial  = "partial"
+	APIStatusRejected = "rejected"
+)
+
+// APIItem é uma entrada de /api/v1/logs com a LogEntry decodificada ou o motivo da rejeição
+type APIItem struct {
+	Entry  *types.LogEntry
+	Reason string
+}
+
+// Reject marca o item como rejeitado
+func (item *APIItem) Reject(reason string) {
+	item.Entry = nil
+	item.Reason = reason
+}
+
+// APIResponse é a resposta de /api/v1/logs com um resultado por entrada, na ordem do pedido
+type APIResponse struct {
+	Status   string           `json:"status"`
+	Message  string           `json:"message"`
+	Accepted int              `json:"accepted"`
+	Rejected int              `json:"rejected"`
+	Results  []APIEntryResult `json:"results"`
+}
+
+// APIEntryResult é o resultado de uma entrada
+type APIEntryResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// apiLogEntry é o formato JSON de uma entrada de /api/v1/logs
+type apiLogEntry struct {
+	Message    string                 `json:"message"`
+	Level      string                 `json:"level"`
+	SourceType string                 `json:"source_type"`
+	SourceID   string                 `json:"source_id"`
+	Labels     map[string]string      `json:"labels"`
+	Fields     map[string]interface{} `json:"fields"`
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id"`
+	Timestamp  interface{}            `json:"timestamp"`
+}
+
+// DecodeAPILogs decodifica o corpo de /api/v1/logs: um array JSON de entradas,
+// um único objeto ou NDJSON (um objeto por linha, linhas vazias ignoradas).
+// Entradas inválidas viram itens rejeitados; só um corpo vazio ou um array
+// malformado fazem o pedido inteiro falhar.
+func DecodeAPILogs(body []byte) ([]*APIItem, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, errors.New("empty request body")
+	}
+
+	var raw [][]byte
+	switch {
+	case trimmed[0] == '[':
+		var array []json.RawMessage
+		if err := json.Unmarshal(trimmed, &array); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+		for _, element := range array {
+			raw = append(raw, element)
+		}
+	case json.Valid(trimmed):
+		// Um único objeto, possivelmente em várias linhas
+		raw = append(raw, trimmed)
+	default:
+		for _, line := range bytes.Split(trimmed, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				raw = append(raw, line)
+			}
+		}
+	}
+
+	if len(raw) == 0 {
+		return nil, errors.New("no log entries in request body")
+	}
+
+	now := time.Now()
+	items := make([]*APIItem, 0, len(raw))
+	for _, data := range raw {
+		entry, err := decodeAPIEntry(data, now)
+		if err != nil {
+			items = append(items, &APIItem{Reason: err.Error()})
+			continue
+		}
+		items = append(items, &APIItem{Entry: entry})
+	}
+	return items, nil
+}
+
+// decodeAPIEntry converte uma entrada aplicando os padrões: nível "info",
+// source_type "api", source_id "http-ingest" e timestamp atual quando ausente.
+// timestamp aceita RFC3339 ou segundos desde a época (fracionários).
+func decodeAPIEntry(data []byte, now time.Time) (*types.LogEntry, error) {
+	if len(data) == 0 || data[0] != '{' {
+		return nil, errors.New("entry must be a JSON object")
+	}
+
+	var decoded apiLogEntry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoded.Message == "" {
+		return nil, errors.New("missing required field: message")
+	}
+
+	timestamp := now
+	if decoded.Timestamp != nil {
+		parsed, ok := parseDocumentTime(decoded.Timestamp, time.Second)
+		if !ok {
+			return nil, fmt.Errorf("invalid timestamp: %v", decoded.Timestamp)
+		}
+		timestamp = parsed
+	}
+
+	entry := &types.LogEntry{
+		TraceID:     decoded.TraceID,
+		SpanID:      decoded.SpanID,
+		Timestamp:   timestamp,
+		Message:     decoded.Message,
+		Level:       normalizeLevel(decoded.Level),
+		SourceType:  decoded.SourceType,
+		SourceID:    decoded.SourceID,
+		Labels:      decoded.Labels,
+		Fields:      decoded.Fields,
+		ProcessedAt: now,
+	}
+	if entry.Level == "" {
+		entry.Level = "info"
+	}
+	if entry.SourceType == "" {
+		entry.SourceType = "api"
+	}
+	if entry.SourceID == "" {
+		entry.SourceID = "http-ingest"
+	}
+	if entry.Labels == nil {
+		entry.Labels = make(map[string]string)
+	}
+	return entry, nil
+}
+
+// APIResults monta a resposta a partir dos itens; itens com Entry foram aceitos
+func APIResults(items []*APIItem) APIResponse {
+	response := APIResponse{Results: make([]APIEntryResult, 0, len(items))}
+	for i, item := range items {
+		if item.Entry != nil {
+			response.Accepted++
+			response.Results = append(response.Results, APIEntryResult{Index: i, Status: APIStatusAccepted})
+			continue
+		}
+		response.Rejected++
+		response.Results = append(response.Results, APIEntryResult{Index: i, Status: APIStatusRejected, Reason: item.Reason})
+	}
+
+	switch {
+	case response.Rejected == 0:
+		response.Status = APIStatusAccepted
+	case response.Accepted == 0:
+		response.Status = APIStatusRejected
+	default:
+		response.Status = APIStatusPartial
+	}
+	response.Message = fmt.Sprintf("%d of %d log entries queued for processing", response.Accepted, len(items))
+	return response
+}
@@ -0,0 +1,46 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeAPILogs(t *testing.T) {
+	// Objeto único em várias linhas
+	items, err := DecodeAPILogs([]byte("{\n  \"message\": \"pretty\",\n  \"labels\": {\"app\": \"api\"},\n  \"timestamp\": 1714557600.5\n}"))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	entry := items[0].Entry
+	require.NotNil(t, entry)
+	assert.Equal(t, "pretty", entry.Message)
+	assert.Equal(t, "info", entry.Level)
+	assert.Equal(t, "api", entry.SourceType)
+	assert.Equal(t, "http-ingest", entry.SourceID)
+	assert.Equal(t, "api", entry.Labels["app"])
+	assert.Equal(t, time.Unix(1714557600, 500000000), entry.Timestamp)
+
+	// NDJSON com linha inválida no meio
+	items, err = DecodeAPILogs([]byte("{\"message\":\"a\",\"source_id\":\"svc\"}\r\n{broken\n\n{\"message\":\"b\",\"labels\":{\"n\":1}}\n"))
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, "svc", items[0].Entry.SourceID)
+	assert.Nil(t, items[1].Entry)
+	assert.Contains(t, items[1].Reason, "invalid JSON")
+	assert.Nil(t, items[2].Entry)
+
+	response := APIResults(items)
+	assert.Equal(t, APIStatusPartial, response.Status)
+	assert.Equal(t, 1, response.Accepted)
+	assert.Equal(t, 2, response.Rejected)
+	assert.Equal(t, "1 of 3 log entries queued for processing", response.Message)
+
+	_, err = DecodeAPILogs([]byte("  \n"))
+	assert.Error(t, err)
+	_, err = DecodeAPILogs([]byte("[]"))
+	assert.Error(t, err)
+	_, err = DecodeAPILogs([]byte(`[{"message":"a"},`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package ingest
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flattenDocument copia o documento para out usando chaves com ponto para objetos
+// aninhados ({"log":{"level":"info"}} vira "log.level"). Arrays são mantidos.
+func flattenDocument(prefix string, doc map[string]interface{}, out map[string]interface{}) {
+	for key, value := range doc {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenDocument(key, nested, out)
+			continue
+		}
+		out[key] = value
+	}
+}
+
+// popString remove e retorna o primeiro campo string não vazio entre as chaves
+func popString(fields map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			delete(fields, key)
+			return value
+		}
+	}
+	return ""
+}
+
+// parseDocumentTime aceita RFC 3339 (com ou sem fuso, assumindo UTC) ou número
+// de época na unidade informada
+func parseDocumentTime(value interface{}, unit time.Duration) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		if t, err := time.Parse("2006-01-02T15:04:05.999999999", v); err == nil {
+			return t, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return epochTime(f, unit), true
+		}
+	case float64:
+		return epochTime(v, unit), true
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return epochTime(f, unit), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// epochTime converte um valor de época (possivelmente fracionário) na unidade informada
+func epochTime(value float64, unit time.Duration) time.Time {
+	whole := int64(value)
+	frac := math.Round((value - float64(whole)) * float64(unit))
+	return time.Unix(0, whole*int64(unit)+int64(frac))
+}
+
+// normalizeLevel padroniza o nível em minúsculas
+func normalizeLevel(level string) string {
+	return strings.ToLower(strings.TrimSpace(level))
+}
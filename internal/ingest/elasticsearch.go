@@ -0,0 +1,201 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/google/uuid"
+)
+
+// BulkItem é uma operação do _bulk com a entrada decodificada ou o erro do item
+type BulkItem struct {
+	Action string
+	Index  string
+	ID     string
+	Entry  *types.LogEntry
+
+	Status    int
+	ErrorType string
+	Reason    string
+}
+
+// Fail marca o item como rejeitado com status e erro no formato do Elasticsearch
+func (item *BulkItem) Fail(status int, errorType, reason string) {
+	item.Entry = nil
+	item.Status = status
+	item.ErrorType = errorType
+	item.Reason = reason
+}
+
+// BulkResponse é a resposta do _bulk com um resultado por item, na ordem do pedido
+type BulkResponse struct {
+	Took   int64                       `json:"took"`
+	Errors bool                        `json:"errors"`
+	Items  []map[string]BulkItemResult `json:"items"`
+}
+
+// BulkItemResult é o resultado de um item do _bulk
+type BulkItemResult struct {
+	Index   string         `json:"_index"`
+	ID      string         `json:"_id"`
+	Version int            `json:"_version,omitempty"`
+	Result  string         `json:"result,omitempty"`
+	Status  int            `json:"status"`
+	Error   *BulkItemError `json:"error,omitempty"`
+}
+
+// BulkItemError descreve a falha de um item
+type BulkItemError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// DecodeElasticsearchBulk decodifica um corpo NDJSON do _bulk. index e create
+// viram LogEntry; update e delete são respondidos com erro por item. Só linhas de
+// ação inválidas fazem o pedido inteiro falhar, como no Elasticsearch.
+func DecodeElasticsearchBulk(body []byte, defaultIndex string) ([]*BulkItem, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+
+	now := time.Now()
+	var items []*BulkItem
+	line := 0
+	for scanner.Scan() {
+		line++
+		actionLine := bytes.TrimSpace(scanner.Bytes())
+		if len(actionLine) == 0 {
+			continue
+		}
+
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(actionLine, &action); err != nil || len(action) != 1 {
+			return nil, fmt.Errorf("malformed action/metadata line [%d], expected a single action object", line)
+		}
+
+		item := &BulkItem{Index: defaultIndex, Status: http.StatusCreated}
+		for name, meta := range action {
+			item.Action = name
+			if meta.Index != "" {
+				item.Index = meta.Index
+			}
+			item.ID = meta.ID
+		}
+
+		switch item.Action {
+		case "index", "create", "update":
+			// Ação seguida do documento
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("action/metadata line [%d] has no document line", line)
+			}
+			line++
+		case "delete":
+		default:
+			return nil, fmt.Errorf("malformed action/metadata line [%d], unknown action [%s]", line, item.Action)
+		}
+
+		if item.ID == "" {
+			item.ID = uuid.New().String()
+		}
+		items = append(items, item)
+
+		if item.Action == "update" || item.Action == "delete" {
+			item.Fail(http.StatusBadRequest, "illegal_argument_exception", fmt.Sprintf("%s is not supported by this endpoint", item.Action))
+			continue
+		}
+		if item.Index == "" {
+			item.Fail(http.StatusBadRequest, "action_request_validation_exception", "Validation Failed: 1: index is missing;")
+			continue
+		}
+
+		var doc map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil || doc == nil {
+			item.Fail(http.StatusBadRequest, "mapper_parsing_exception", "failed to parse document")
+			continue
+		}
+		item.Entry = elasticsearchEntry(doc, item.Index, now)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read bulk body: %w", err)
+	}
+
+	return items, nil
+}
+
+// elasticsearchEntry converte um documento (ECS ou livre) em LogEntry. @timestamp,
+// message, log.level/level, trace.id e span.id são promovidos; o restante vira
+// fields com chaves achatadas. O índice vira a label "index".
+func elasticsearchEntry(doc map[string]interface{}, index string, now time.Time) *types.LogEntry {
+	fields := make(map[string]interface{}, len(doc))
+	flattenDocument("", doc, fields)
+
+	entry := &types.LogEntry{
+		Timestamp:   now,
+		SourceType:  "elasticsearch",
+		SourceID:    index,
+		Labels:      map[string]string{"index": index},
+		ProcessedAt: now,
+	}
+
+	if timestamp, ok := parseDocumentTime(fields["@timestamp"], time.Millisecond); ok {
+		entry.Timestamp = timestamp
+		delete(fields, "@timestamp")
+	}
+	entry.Message = popString(fields, "message")
+	entry.Level = normalizeLevel(popString(fields, "log.level", "level"))
+	entry.TraceID = popString(fields, "trace.id")
+	entry.SpanID = popString(fields, "span.id")
+	if host := popString(fields, "host.name", "host.hostname"); host != "" {
+		entry.Labels["host"] = host
+	}
+
+	for key, value := range fields {
+		if number, ok := value.(json.Number); ok {
+			fields[key] = jsonNumberValue(number)
+		}
+	}
+	entry.Fields = fields
+
+	return entry
+}
+
+// jsonNumberValue converte json.Number em int64 quando inteiro, senão float64
+func jsonNumberValue(number json.Number) interface{} {
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	f, _ := number.Float64()
+	return f
+}
+
+// BulkResults monta a resposta do _bulk a partir dos itens processados
+func BulkResults(items []*BulkItem, took time.Duration) BulkResponse {
+	response := BulkResponse{
+		Took:  took.Milliseconds(),
+		Items: make([]map[string]BulkItemResult, 0, len(items)),
+	}
+
+	for _, item := range items {
+		result := BulkItemResult{Index: item.Index, ID: item.ID, Status: item.Status}
+		if item.ErrorType != "" {
+			response.Errors = true
+			result.Error = &BulkItemError{Type: item.ErrorType, Reason: item.Reason}
+		} else {
+			result.Version = 1
+			result.Result = "created"
+		}
+		response.Items = append(response.Items, map[string]BulkItemResult{item.Action: result})
+	}
+
+	return response
+}
@@ -0,0 +1,83 @@
+package ingest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeElasticsearchBulk(t *testing.T) {
+	body := `{"index":{"_index":"filebeat-8.11.0","_id":"1"}}
+{"@timestamp":"2024-05-01T10:00:00.123Z","message":"GET /health 200","log":{"level":"INFO"},"host":{"name":"web-1"},"http":{"response":{"status_code":200}},"trace":{"id":"abc"}}
+{"create":{}}
+{"message":"uses the url index","duration":1.5}
+
+{"delete":{"_index":"logs","_id":"2"}}
+{"update":{"_id":"3"}}
+{"doc":{"message":"x"}}
+{"index":{}}
+not json
+`
+
+	items, err := DecodeElasticsearchBulk([]byte(body), "logs-default")
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+
+	first := items[0]
+	assert.Equal(t, "index", first.Action)
+	assert.Equal(t, "1", first.ID)
+	require.NotNil(t, first.Entry)
+	assert.Equal(t, "GET /health 200", first.Entry.Message)
+	assert.Equal(t, "info", first.Entry.Level)
+	assert.Equal(t, "abc", first.Entry.TraceID)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC), first.Entry.Timestamp.UTC())
+	assert.Equal(t, map[string]string{"index": "filebeat-8.11.0", "host": "web-1"}, first.Entry.Labels)
+	assert.Equal(t, int64(200), first.Entry.Fields["http.response.status_code"])
+	assert.NotContains(t, first.Entry.Fields, "message")
+
+	second := items[1]
+	assert.Equal(t, "logs-default", second.Index)
+	assert.NotEmpty(t, second.ID)
+	require.NotNil(t, second.Entry)
+	assert.Equal(t, 1.5, second.Entry.Fields["duration"])
+
+	for _, item := range items[2:] {
+		assert.Nil(t, item.Entry)
+		assert.Equal(t, http.StatusBadRequest, item.Status)
+	}
+	assert.Equal(t, "illegal_argument_exception", items[2].ErrorType)
+	assert.Equal(t, "mapper_parsing_exception", items[4].ErrorType)
+
+	// Sem índice na URL nem na ação
+	items, err = DecodeElasticsearchBulk([]byte("{\"index\":{}}\n{\"message\":\"x\"}\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, "action_request_validation_exception", items[0].ErrorType)
+
+	for _, invalid := range []string{
+		"not json\n",
+		"{\"index\":{},\"create\":{}}\n{}\n",
+		"{\"upsert\":{}}\n{}\n",
+		"{\"index\":{\"_index\":\"logs\"}}\n",
+	} {
+		_, err := DecodeElasticsearchBulk([]byte(invalid), "logs")
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBulkResults(t *testing.T) {
+	items, err := DecodeElasticsearchBulk([]byte("{\"index\":{\"_id\":\"a\"}}\n{\"message\":\"ok\"}\n{\"index\":{\"_id\":\"b\"}}\n{\"message\":\"rejected\"}\n"), "logs")
+	require.NoError(t, err)
+	items[1].Fail(http.StatusTooManyRequests, "es_rejected_execution_exception", "queue full")
+
+	response := BulkResults(items, 5*time.Millisecond)
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"took":5,"errors":true,"items":[
+		{"index":{"_index":"logs","_id":"a","_version":1,"result":"created","status":201}},
+		{"index":{"_index":"logs","_id":"b","status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}
+	]}`, string(data))
+}
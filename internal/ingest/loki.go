@@ -0,0 +1,438 @@
+// Package ingest decodifica os formatos de push recebidos pela API HTTP
+// (Loki, Elasticsearch, Splunk, OTLP) em LogEntry.
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/pkg/compression"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// LokiMaxDecodedSize limita o tamanho do corpo descomprimido de um push
+const LokiMaxDecodedSize = 64 << 20
+
+// lokiPushJSON é o corpo JSON de /loki/api/v1/push
+type lokiPushJSON struct {
+	Streams []struct {
+		Stream map[string]string   `json:"stream"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"streams"`
+}
+
+// LokiStream é um stream decodificado: labels e entradas com timestamp em nanossegundos
+type LokiStream struct {
+	Labels  map[string]string
+	Entries []LokiEntry
+}
+
+// LokiEntry é uma linha do stream com o structured metadata opcional
+type LokiEntry struct {
+	Timestamp time.Time
+	Line      string
+	Metadata  map[string]string
+}
+
+// DecodeLokiPush decodifica um PushRequest do Loki. Como no Loki, corpos
+// application/json são JSON e qualquer outro content type é protobuf com snappy.
+// Content-Encoding gzip é aceito em ambos.
+func DecodeLokiPush(body []byte, contentType, contentEncoding string) ([]LokiStream, error) {
+	body, err := DecodeContentEncoding(body, contentEncoding, LokiMaxDecodedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" {
+		return decodeLokiJSON(body)
+	}
+
+	decodedLen, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy payload: %w", err)
+	}
+	if decodedLen > LokiMaxDecodedSize {
+		return nil, fmt.Errorf("decompressed push request exceeds %d bytes", LokiMaxDecodedSize)
+	}
+	decoded, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy payload: %w", err)
+	}
+	return decodeLokiProtobuf(decoded)
+}
+
+// DecodeContentEncoding descomprime o corpo conforme o Content-Encoding (gzip,
+// zstd, deflate ou identidade), recusando resultados maiores que limit
+func DecodeContentEncoding(body []byte, contentEncoding string, limit int64) ([]byte, error) {
+	algorithm, err := compression.AlgorithmFromContentEncoding(contentEncoding)
+	if err != nil {
+		return nil, err
+	}
+	if algorithm == compression.AlgorithmNone {
+		return body, nil
+	}
+
+	decoded, err := compression.DecompressLimited(body, algorithm, limit)
+	if errors.Is(err, compression.ErrDecompressedTooLarge) {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes: %w", limit, err)
+	}
+	return decoded, err
+}
+
+// decodeLokiJSON decodifica {"streams":[{"stream":{...},"values":[["<ns>","<linha>",{metadata}]]}]}
+func decodeLokiJSON(body []byte) ([]LokiStream, error) {
+	var req lokiPushJSON
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid push request JSON: %w", err)
+	}
+
+	streams := make([]LokiStream, 0, len(req.Streams))
+	for i, s := range req.Streams {
+		if len(s.Stream) == 0 {
+			return nil, fmt.Errorf("stream %d: at least one label pair is required per stream", i)
+		}
+		for name := range s.Stream {
+			if !validLabelName(name) {
+				return nil, fmt.Errorf("stream %d: invalid label name %q", i, name)
+			}
+		}
+
+		stream := LokiStream{Labels: s.Stream, Entries: make([]LokiEntry, 0, len(s.Values))}
+		for j, value := range s.Values {
+			if len(value) < 2 || len(value) > 3 {
+				return nil, fmt.Errorf("stream %d value %d: expected [timestamp, line] or [timestamp, line, metadata]", i, j)
+			}
+
+			var ts, line string
+			if err := json.Unmarshal(value[0], &ts); err != nil {
+				return nil, fmt.Errorf("stream %d value %d: timestamp must be a string of nanoseconds", i, j)
+			}
+			nanos, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("stream %d value %d: invalid timestamp %q", i, j, ts)
+			}
+			if err := json.Unmarshal(value[1], &line); err != nil {
+				return nil, fmt.Errorf("stream %d value %d: line must be a string", i, j)
+			}
+
+			entry := LokiEntry{Timestamp: time.Unix(0, nanos), Line: line}
+			if len(value) == 3 {
+				if err := json.Unmarshal(value[2], &entry.Metadata); err != nil {
+					return nil, fmt.Errorf("stream %d value %d: structured metadata must be an object of strings", i, j)
+				}
+			}
+			stream.Entries = append(stream.Entries, entry)
+		}
+		streams = append(streams, stream)
+	}
+
+	return streams, nil
+}
+
+// decodeLokiProtobuf decodifica o logproto.PushRequest:
+//
+//	PushRequest  { repeated Stream streams = 1; }
+//	Stream       { string labels = 1; repeated Entry entries = 2; }
+//	Entry        { Timestamp timestamp = 1; string line = 2; repeated LabelPair structuredMetadata = 3; }
+//	LabelPair    { string name = 1; string value = 2; }
+func decodeLokiProtobuf(data []byte) ([]LokiStream, error) {
+	var streams []LokiStream
+	err := walkProto(data, func(num protowire.Number, typ protowire.Type, _ uint64, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		stream, err := decodeLokiProtoStream(value)
+		if err != nil {
+			return fmt.Errorf("stream %d: %w", len(streams), err)
+		}
+		streams = append(streams, stream)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid push request protobuf: %w", err)
+	}
+	return streams, nil
+}
+
+// decodeLokiProtoStream decodifica um Stream, convertendo a string de labels
+func decodeLokiProtoStream(data []byte) (LokiStream, error) {
+	var stream LokiStream
+	var labels string
+	err := walkProto(data, func(num protowire.Number, typ protowire.Type, _ uint64, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			labels = string(value)
+		case 2:
+			entry, err := decodeLokiProtoEntry(value)
+			if err != nil {
+				return err
+			}
+			stream.Entries = append(stream.Entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return stream, err
+	}
+
+	stream.Labels, err = ParseLokiLabels(labels)
+	return stream, err
+}
+
+// decodeLokiProtoEntry decodifica um Entry com timestamp e structured metadata
+func decodeLokiProtoEntry(data []byte) (LokiEntry, error) {
+	var entry LokiEntry
+	err := walkProto(data, func(num protowire.Number, typ protowire.Type, _ uint64, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			timestamp, err := decodeProtoTimestamp(value)
+			if err != nil {
+				return err
+			}
+			entry.Timestamp = timestamp
+		case 2:
+			entry.Line = string(value)
+		case 3:
+			var name, labelValue string
+			err := walkProto(value, func(num protowire.Number, typ protowire.Type, _ uint64, value []byte) error {
+				if typ == protowire.BytesType && num == 1 {
+					name = string(value)
+				} else if typ == protowire.BytesType && num == 2 {
+					labelValue = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if entry.Metadata == nil {
+				entry.Metadata = make(map[string]string)
+			}
+			entry.Metadata[name] = labelValue
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// decodeProtoTimestamp decodifica google.protobuf.Timestamp { int64 seconds = 1; int32 nanos = 2; }
+func decodeProtoTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := walkProto(data, func(num protowire.Number, typ protowire.Type, v uint64, _ []byte) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case 1:
+			seconds = int64(v)
+		case 2:
+			nanos = int64(int32(v))
+		}
+		return nil
+	})
+	return time.Unix(seconds, nanos), err
+}
+
+// walkProto percorre os campos de uma mensagem protobuf, entregando o valor de
+// campos varint e o conteúdo de campos length-delimited; os demais são ignorados
+func walkProto(data []byte, fn func(num protowire.Number, typ protowire.Type, varint uint64, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var varint uint64
+		var value []byte
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := fn(num, typ, varint, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseLokiLabels converte a notação de labels do Loki ({app="api", env="prod"})
+// em mapa. Valores usam aspas duplas com escapes no estilo Go.
+func ParseLokiLabels(s string) (map[string]string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("invalid labels %q: expected {name=\"value\", ...}", s)
+	}
+
+	labels := make(map[string]string)
+	rest := s[1 : len(s)-1]
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			break
+		}
+
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid labels %q: missing '='", s)
+		}
+		name := strings.TrimSpace(rest[:eq])
+		if !validLabelName(name) {
+			return nil, fmt.Errorf("invalid labels %q: invalid label name %q", s, name)
+		}
+
+		rest = strings.TrimSpace(rest[eq+1:])
+		if rest == "" || rest[0] != '"' {
+			return nil, fmt.Errorf("invalid labels %q: value of %s must be quoted", s, name)
+		}
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("invalid labels %q: unterminated value of %s", s, name)
+		}
+		value, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels %q: value of %s: %w", s, name, err)
+		}
+		labels[name] = value
+
+		rest = strings.TrimSpace(rest[end+1:])
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("invalid labels %q: expected ',' after %s", s, name)
+		}
+		rest = rest[1:]
+	}
+
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("at least one label pair is required per stream")
+	}
+	return labels, nil
+}
+
+// FormatLokiLabels gera a notação de labels ordenada por nome
+func FormatLokiLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// validLabelName segue a regra do Prometheus: [a-zA-Z_][a-zA-Z0-9_]*
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// LokiEntries converte os streams em LogEntry preservando o timestamp original.
+// Labels do stream viram labels, structured metadata vira fields e o level vem
+// da label ou do metadata "level"/"detected_level".
+func LokiEntries(streams []LokiStream, tenant string) []*types.LogEntry {
+	now := time.Now()
+	var entries []*types.LogEntry
+	for _, stream := range streams {
+		sourceID := FormatLokiLabels(stream.Labels)
+		for _, e := range stream.Entries {
+			labels := make(map[string]string, len(stream.Labels)+1)
+			for k, v := range stream.Labels {
+				labels[k] = v
+			}
+			if tenant != "" {
+				labels["tenant"] = tenant
+			}
+
+			fields := make(map[string]interface{}, len(e.Metadata))
+			for k, v := range e.Metadata {
+				fields[k] = v
+			}
+
+			timestamp := e.Timestamp
+			if timestamp.IsZero() || timestamp.UnixNano() == 0 {
+				timestamp = now
+			}
+
+			entry := &types.LogEntry{
+				Timestamp:   timestamp,
+				Message:     e.Line,
+				Level:       lokiLevel(labels, e.Metadata),
+				SourceType:  "loki",
+				SourceID:    sourceID,
+				Labels:      labels,
+				Fields:      fields,
+				ProcessedAt: now,
+			}
+			if traceID := e.Metadata["trace_id"]; traceID != "" {
+				entry.TraceID = traceID
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// lokiLevel procura o nível nas labels e no structured metadata
+func lokiLevel(labels, metadata map[string]string) string {
+	for _, key := range []string{"level", "detected_level"} {
+		if level := labels[key]; level != "" {
+			return strings.ToLower(level)
+		}
+		if level := metadata[key]; level != "" {
+			return strings.ToLower(level)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// lokiProtoPush monta um PushRequest protobuf com snappy, como o Promtail envia
+func lokiProtoPush(labels string, lines map[int64]string, metadata map[string]string) []byte {
+	var stream []byte
+	stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+	stream = protowire.AppendString(stream, labels)
+	for ns, line := range lines {
+		var ts []byte
+		ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(ns/1e9))
+		ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(ns%1e9))
+
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendBytes(entry, ts)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, line)
+		for name, value := range metadata {
+			var pair []byte
+			pair = protowire.AppendTag(pair, 1, protowire.BytesType)
+			pair = protowire.AppendString(pair, name)
+			pair = protowire.AppendTag(pair, 2, protowire.BytesType)
+			pair = protowire.AppendString(pair, value)
+			entry = protowire.AppendTag(entry, 3, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, pair)
+		}
+
+		stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+		stream = protowire.AppendBytes(stream, entry)
+	}
+	// Campo hash (3) é ignorado
+	stream = protowire.AppendTag(stream, 3, protowire.VarintType)
+	stream = protowire.AppendVarint(stream, 12345)
+
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendBytes(req, stream)
+	return snappy.Encode(nil, req)
+}
+
+func TestDecodeLokiPush_Protobuf(t *testing.T) {
+	body := lokiProtoPush(`{job="varlogs", filename="/var/log/app.log", level="WARN"}`,
+		map[int64]string{1714557600123456789: "disk almost full"},
+		map[string]string{"trace_id": "abc123"})
+
+	streams, err := DecodeLokiPush(body, "application/x-protobuf", "")
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	assert.Equal(t, map[string]string{"job": "varlogs", "filename": "/var/log/app.log", "level": "WARN"}, streams[0].Labels)
+	require.Len(t, streams[0].Entries, 1)
+	assert.Equal(t, int64(1714557600123456789), streams[0].Entries[0].Timestamp.UnixNano())
+	assert.Equal(t, "disk almost full", streams[0].Entries[0].Line)
+
+	entries := LokiEntries(streams, "team-a")
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, int64(1714557600123456789), entry.Timestamp.UnixNano())
+	assert.Equal(t, "loki", entry.SourceType)
+	assert.Equal(t, `{filename="/var/log/app.log", job="varlogs", level="WARN"}`, entry.SourceID)
+	assert.Equal(t, "warn", entry.Level)
+	assert.Equal(t, "team-a", entry.Labels["tenant"])
+	assert.Equal(t, "abc123", entry.TraceID)
+	assert.Equal(t, "abc123", entry.Fields["trace_id"])
+
+	_, err = DecodeLokiPush([]byte("not snappy"), "", "")
+	assert.Error(t, err)
+}
+
+func TestDecodeLokiPush_JSON(t *testing.T) {
+	body := `{"streams":[{"stream":{"app":"api"},"values":[["1714557600000000001","first"],["1714557600000000002","second",{"user":"42"}]]}]}`
+
+	streams, err := DecodeLokiPush([]byte(body), "application/json; charset=utf-8", "")
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Len(t, streams[0].Entries, 2)
+	assert.Equal(t, time.Unix(0, 1714557600000000001), streams[0].Entries[0].Timestamp)
+	assert.Equal(t, map[string]string{"user": "42"}, streams[0].Entries[1].Metadata)
+
+	entries := LokiEntries(streams, "")
+	require.Len(t, entries, 2)
+	assert.NotContains(t, entries[0].Labels, "tenant")
+	assert.Equal(t, "42", entries[1].Fields["user"])
+
+	var gz bytes.Buffer
+	writer := gzip.NewWriter(&gz)
+	writer.Write([]byte(body))
+	writer.Close()
+	streams, err = DecodeLokiPush(gz.Bytes(), "application/json", "gzip")
+	require.NoError(t, err)
+	assert.Len(t, streams[0].Entries, 2)
+
+	for _, invalid := range []string{
+		`{"streams":[{"stream":{},"values":[["1","x"]]}]}`,
+		`{"streams":[{"stream":{"a":"b"},"values":[[1,"x"]]}]}`,
+		`{"streams":[{"stream":{"a":"b"},"values":[["abc","x"]]}]}`,
+		`{"streams":[{"stream":{"a":"b"},"values":[["1"]]}]}`,
+		`{"streams":[{"stream":{"bad-name":"b"},"values":[]}]}`,
+		`not json`,
+	} {
+		_, err := DecodeLokiPush([]byte(invalid), "application/json", "")
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseLokiLabels(t *testing.T) {
+	labels, err := ParseLokiLabels(`{app="api", msg="say \"hi\", then=go", path="C:\\logs"}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "api", "msg": `say "hi", then=go`, "path": `C:\logs`}, labels)
+
+	for _, invalid := range []string{``, `{}`, `app="api"`, `{app=api}`, `{app="api"`, `{app="api" env="x"}`, `{1app="x"}`, `{app="unterminated}`} {
+		_, err := ParseLokiLabels(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	assert.Equal(t, `{a="1", b="x\"y"}`, FormatLokiLabels(map[string]string{"b": `x"y`, "a": "1"}))
+}
@@ -0,0 +1,277 @@
+package ingest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// OTLPContentTypeJSON e OTLPContentTypeProtobuf são os content types do OTLP/HTTP
+const (
+	OTLPContentTypeJSON     = "application/json"
+	OTLPContentTypeProtobuf = "application/x-protobuf"
+)
+
+// OTLPMaxDecodedSize limita o tamanho do corpo descomprimido de um export OTLP/HTTP
+const OTLPMaxDecodedSize = 64 << 20
+
+// OTLPIsJSON indica se o content type é o encoding JSON do OTLP/HTTP
+func OTLPIsJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == OTLPContentTypeJSON
+}
+
+// DecodeOTLPLogs decodifica um ExportLogsServiceRequest do OTLP/HTTP em protobuf
+// ou JSON, conforme o content type
+func DecodeOTLPLogs(body []byte, contentType, contentEncoding string) (*collogspb.ExportLogsServiceRequest, error) {
+	body, err := DecodeContentEncoding(body, contentEncoding, OTLPMaxDecodedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &collogspb.ExportLogsServiceRequest{}
+	if OTLPIsJSON(contentType) {
+		body, err = otlpJSONHexIDs(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP JSON: %w", err)
+		}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, request); err != nil {
+			return nil, fmt.Errorf("invalid OTLP JSON: %w", err)
+		}
+		return request, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != OTLPContentTypeProtobuf {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	if err := proto.Unmarshal(body, request); err != nil {
+		return nil, fmt.Errorf("invalid OTLP protobuf: %w", err)
+	}
+	return request, nil
+}
+
+// otlpJSONHexIDs converte traceId e spanId de hex (formato do OTLP/JSON) para o
+// base64 esperado pelo protojson
+func otlpJSONHexIDs(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	resourceLogs, _ := request["resourceLogs"].([]interface{})
+	for _, rl := range resourceLogs {
+		rlMap, _ := rl.(map[string]interface{})
+		scopeLogs, _ := rlMap["scopeLogs"].([]interface{})
+		for _, sl := range scopeLogs {
+			slMap, _ := sl.(map[string]interface{})
+			records, _ := slMap["logRecords"].([]interface{})
+			for _, record := range records {
+				recordMap, _ := record.(map[string]interface{})
+				for _, key := range []string{"traceId", "spanId"} {
+					value, ok := recordMap[key].(string)
+					if !ok || value == "" {
+						continue
+					}
+					id, err := hex.DecodeString(value)
+					if err != nil {
+						return nil, fmt.Errorf("%s must be hex encoded: %w", key, err)
+					}
+					recordMap[key] = base64.StdEncoding.EncodeToString(id)
+				}
+			}
+		}
+	}
+
+	return json.Marshal(request)
+}
+
+// MarshalOTLP codifica uma resposta (ou status de erro) no mesmo encoding do pedido
+func MarshalOTLP(message proto.Message, contentType string) ([]byte, string, error) {
+	if OTLPIsJSON(contentType) {
+		data, err := protojson.Marshal(message)
+		return data, OTLPContentTypeJSON, err
+	}
+	data, err := proto.Marshal(message)
+	return data, OTLPContentTypeProtobuf, err
+}
+
+// OTLPEntries converte os LogRecords em LogEntry. Atributos do resource viram
+// labels (nomes com "." viram "_"), atributos do registro viram fields e o
+// service.name é o SourceID.
+func OTLPEntries(request *collogspb.ExportLogsServiceRequest) []*types.LogEntry {
+	now := time.Now()
+	var entries []*types.LogEntry
+
+	for _, resourceLogs := range request.GetResourceLogs() {
+		resourceLabels := make(map[string]string)
+		sourceID := "otlp"
+		for _, attribute := range resourceLogs.GetResource().GetAttributes() {
+			value := anyValueString(attribute.GetValue())
+			resourceLabels[otlpLabelName(attribute.GetKey())] = value
+			if attribute.GetKey() == "service.name" && value != "" {
+				sourceID = value
+			}
+		}
+
+		for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+			scope := scopeLogs.GetScope()
+			for _, record := range scopeLogs.GetLogRecords() {
+				entry := &types.LogEntry{
+					Timestamp:   otlpTimestamp(record, now),
+					Message:     anyValueString(record.GetBody()),
+					Level:       otlpLevel(record),
+					SourceType:  "otlp",
+					SourceID:    sourceID,
+					Labels:      make(map[string]string, len(resourceLabels)),
+					Fields:      make(map[string]interface{}, len(record.GetAttributes())+2),
+					ProcessedAt: now,
+				}
+				for key, value := range resourceLabels {
+					entry.Labels[key] = value
+				}
+
+				if traceID := record.GetTraceId(); len(traceID) > 0 {
+					entry.TraceID = hex.EncodeToString(traceID)
+				}
+				if spanID := record.GetSpanId(); len(spanID) > 0 {
+					entry.SpanID = hex.EncodeToString(spanID)
+				}
+
+				attributes := make(map[string]interface{}, len(record.GetAttributes()))
+				for _, attribute := range record.GetAttributes() {
+					attributes[attribute.GetKey()] = anyValueInterface(attribute.GetValue())
+				}
+				flattenDocument("", attributes, entry.Fields)
+
+				if record.GetSeverityNumber() != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+					entry.Fields["severity_number"] = int64(record.GetSeverityNumber())
+				}
+				if name := record.GetEventName(); name != "" {
+					entry.Fields["event_name"] = name
+				}
+				if scope.GetName() != "" {
+					entry.Fields["otel_scope_name"] = scope.GetName()
+				}
+				if scope.GetVersion() != "" {
+					entry.Fields["otel_scope_version"] = scope.GetVersion()
+				}
+
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries
+}
+
+// otlpTimestamp usa time_unix_nano, observed_time_unix_nano ou o horário atual
+func otlpTimestamp(record *logspb.LogRecord, now time.Time) time.Time {
+	if ns := record.GetTimeUnixNano(); ns > 0 {
+		return time.Unix(0, int64(ns))
+	}
+	if ns := record.GetObservedTimeUnixNano(); ns > 0 {
+		return time.Unix(0, int64(ns))
+	}
+	return now
+}
+
+// otlpLevel usa severity_text ou, quando ausente, a faixa do severity_number
+func otlpLevel(record *logspb.LogRecord) string {
+	if text := normalizeLevel(record.GetSeverityText()); text != "" {
+		return text
+	}
+
+	switch number := record.GetSeverityNumber(); {
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_FATAL:
+		return "fatal"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_ERROR:
+		return "error"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_WARN:
+		return "warn"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_INFO:
+		return "info"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG:
+		return "debug"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_TRACE:
+		return "trace"
+	}
+	return ""
+}
+
+// otlpLabelName troca caracteres inválidos em nomes de label por "_"
+func otlpLabelName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// anyValueInterface converte um AnyValue no valor Go equivalente
+func anyValueInterface(value *commonpb.AnyValue) interface{} {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return v.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, anyValueInterface(item))
+		}
+		return values
+	case *commonpb.AnyValue_KvlistValue:
+		object := make(map[string]interface{}, len(v.KvlistValue.GetValues()))
+		for _, kv := range v.KvlistValue.GetValues() {
+			object[kv.GetKey()] = anyValueInterface(kv.GetValue())
+		}
+		return object
+	}
+	return nil
+}
+
+// anyValueString converte um AnyValue em texto; valores compostos viram JSON
+func anyValueString(value *commonpb.AnyValue) string {
+	switch v := anyValueInterface(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// OTLPPartialSuccess monta a resposta de um export em que só parte dos log
+// records foi recusada pelo dispatcher
+func OTLPPartialSuccess(rejected int, err error) *collogspb.ExportLogsServiceResponse {
+	return &collogspb.ExportLogsServiceResponse{
+		PartialSuccess: &collogspb.ExportLogsPartialSuccess{
+			RejectedLogRecords: int64(rejected),
+			ErrorMessage:       err.Error(),
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func otlpString(value string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}
+}
+
+func TestDecodeOTLPLogs_Protobuf(t *testing.T) {
+	request := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+				{Key: "service.name", Value: otlpString("checkout")},
+				{Key: "k8s.namespace.name", Value: otlpString("shop")},
+			}},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope: &commonpb.InstrumentationScope{Name: "checkout/logger", Version: "1.2.0"},
+				LogRecords: []*logspb.LogRecord{
+					{
+						TimeUnixNano:   1714557600123456789,
+						SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN2,
+						Body:           otlpString("payment retried"),
+						TraceId:        []byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+						SpanId:         []byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+						Attributes: []*commonpb.KeyValue{
+							{Key: "http.status_code", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 502}}},
+							{Key: "retry", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+								Values: []*commonpb.KeyValue{{Key: "attempt", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 2}}}},
+							}}}},
+						},
+					},
+					{
+						ObservedTimeUnixNano: 1714557601000000000,
+						SeverityText:         "ERROR",
+						Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+							Values: []*commonpb.KeyValue{{Key: "event", Value: otlpString("refund")}},
+						}}},
+					},
+				},
+			}},
+		}},
+	}
+	body, err := proto.Marshal(request)
+	require.NoError(t, err)
+
+	decoded, err := DecodeOTLPLogs(body, "application/x-protobuf", "")
+	require.NoError(t, err)
+
+	entries := OTLPEntries(decoded)
+	require.Len(t, entries, 2)
+
+	first := entries[0]
+	assert.Equal(t, time.Unix(0, 1714557600123456789), first.Timestamp)
+	assert.Equal(t, "payment retried", first.Message)
+	assert.Equal(t, "warn", first.Level)
+	assert.Equal(t, "otlp", first.SourceType)
+	assert.Equal(t, "checkout", first.SourceID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", first.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", first.SpanID)
+	assert.Equal(t, map[string]string{"service_name": "checkout", "k8s_namespace_name": "shop"}, first.Labels)
+	assert.Equal(t, int64(502), first.Fields["http.status_code"])
+	assert.Equal(t, int64(2), first.Fields["retry.attempt"])
+	assert.Equal(t, int64(logspb.SeverityNumber_SEVERITY_NUMBER_WARN2), first.Fields["severity_number"])
+	assert.Equal(t, "checkout/logger", first.Fields["otel_scope_name"])
+
+	second := entries[1]
+	assert.Equal(t, time.Unix(1714557601, 0), second.Timestamp)
+	assert.Equal(t, "error", second.Level)
+	assert.Equal(t, `{"event":"refund"}`, second.Message)
+
+	_, err = DecodeOTLPLogs([]byte{0xff, 0xff}, "application/x-protobuf", "")
+	assert.Error(t, err)
+	_, err = DecodeOTLPLogs(body, "text/plain", "")
+	assert.Error(t, err)
+}
+
+func TestDecodeOTLPLogs_JSON(t *testing.T) {
+	body := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},
+		"scopeLogs":[{"logRecords":[{"timeUnixNano":"1714557600000000001","severityNumber":17,
+		"body":{"stringValue":"db timeout"},"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174",
+		"attributes":[{"key":"db.system","value":{"stringValue":"postgresql"}}]}]}]}]}`
+
+	request, err := DecodeOTLPLogs([]byte(body), "application/json", "")
+	require.NoError(t, err)
+
+	entries := OTLPEntries(request)
+	require.Len(t, entries, 1)
+	assert.Equal(t, time.Unix(0, 1714557600000000001), entries[0].Timestamp)
+	assert.Equal(t, "db timeout", entries[0].Message)
+	assert.Equal(t, "error", entries[0].Level)
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", entries[0].TraceID)
+	assert.Equal(t, "eee19b7ec3c1b174", entries[0].SpanID)
+	assert.Equal(t, "postgresql", entries[0].Fields["db.system"])
+
+	_, err = DecodeOTLPLogs([]byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"traceId":"not-hex"}]}]}]}`), "application/json", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// Códigos de resposta do Splunk HEC
+const (
+	HECCodeSuccess      = 0
+	HECCodeTokenMissing = 2
+	HECCodeInvalidAuth  = 3
+	HECCodeInvalidToken = 4
+	HECCodeNoData       = 5
+	HECCodeInvalidData  = 6
+	HECCodeServerBusy   = 9
+	HECCodeEventMissing = 12
+	HECCodeEventBlank   = 13
+	HECCodeHealthy      = 17
+)
+
+// HECResponse é o corpo de resposta do HEC
+type HECResponse struct {
+	Text               string `json:"text"`
+	Code               int    `json:"code"`
+	InvalidEventNumber *int   `json:"invalid-event-number,omitempty"`
+}
+
+// HECError é uma falha do HEC com o status HTTP e o código correspondentes
+type HECError struct {
+	Status       int
+	Code         int
+	Text         string
+	InvalidEvent int // Índice do evento inválido, -1 quando não se aplica
+}
+
+func (e *HECError) Error() string {
+	return e.Text
+}
+
+// Response converte o erro no corpo de resposta do HEC
+func (e *HECError) Response() HECResponse {
+	response := HECResponse{Text: e.Text, Code: e.Code}
+	if e.InvalidEvent >= 0 {
+		index := e.InvalidEvent
+		response.InvalidEventNumber = &index
+	}
+	return response
+}
+
+// newHECEventError cria um erro referente ao evento de índice index
+func newHECEventError(code int, text string, index int) *HECError {
+	return &HECError{Status: http.StatusBadRequest, Code: code, Text: text, InvalidEvent: index}
+}
+
+// HECMetadata são os metadados do evento (no JSON ou nos parâmetros de /raw)
+type HECMetadata struct {
+	Host       string
+	Source     string
+	SourceType string
+	Index      string
+}
+
+// hecEvent é um evento de /services/collector/event
+type hecEvent struct {
+	Time       interface{}            `json:"time"`
+	Host       string                 `json:"host"`
+	Source     string                 `json:"source"`
+	SourceType string                 `json:"sourcetype"`
+	Index      string                 `json:"index"`
+	Event      json.RawMessage        `json:"event"`
+	Fields     map[string]interface{} `json:"fields"`
+}
+
+// HECToken extrai o token de "Authorization: Splunk <token>" ou de Basic auth
+// (token na senha), como aceito pelo Splunk
+func HECToken(authorization string) (string, error) {
+	if authorization == "" {
+		return "", &HECError{Status: http.StatusUnauthorized, Code: HECCodeTokenMissing, Text: "Token is required", InvalidEvent: -1}
+	}
+
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	credentials = strings.TrimSpace(credentials)
+	switch strings.ToLower(scheme) {
+	case "splunk":
+		if credentials != "" {
+			return credentials, nil
+		}
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err == nil {
+			if _, password, ok := strings.Cut(string(decoded), ":"); ok && password != "" {
+				return password, nil
+			}
+		}
+	}
+	return "", &HECError{Status: http.StatusUnauthorized, Code: HECCodeInvalidAuth, Text: "Invalid authorization", InvalidEvent: -1}
+}
+
+// DecodeHECEvents decodifica eventos JSON concatenados de /services/collector/event.
+// event pode ser string ou objeto; fields vira fields da entrada.
+func DecodeHECEvents(body []byte) ([]*types.LogEntry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	now := time.Now()
+
+	var entries []*types.LogEntry
+	for index := 0; ; index++ {
+		var event hecEvent
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, newHECEventError(HECCodeInvalidData, "Invalid data format", index)
+		}
+
+		if len(event.Event) == 0 || string(event.Event) == "null" {
+			return nil, newHECEventError(HECCodeEventMissing, "Event field is required", index)
+		}
+
+		entry := newHECEntry(HECMetadata{Host: event.Host, Source: event.Source, SourceType: event.SourceType, Index: event.Index}, now)
+		if event.Time != nil {
+			timestamp, ok := parseDocumentTime(event.Time, time.Second)
+			if !ok {
+				return nil, newHECEventError(HECCodeInvalidData, "Invalid data format", index)
+			}
+			entry.Timestamp = timestamp
+		}
+
+		var text string
+		var object map[string]interface{}
+		switch {
+		case json.Unmarshal(event.Event, &text) == nil:
+			if strings.TrimSpace(text) == "" {
+				return nil, newHECEventError(HECCodeEventBlank, "Event field cannot be blank", index)
+			}
+			entry.Message = text
+		case json.Unmarshal(event.Event, &object) == nil && object != nil:
+			flattenDocument("", object, entry.Fields)
+			entry.Message = popString(entry.Fields, "message", "msg", "log")
+			if entry.Message == "" {
+				// Sem campo de mensagem o evento inteiro é a mensagem
+				entry.Message = string(event.Event)
+			}
+			entry.Level = normalizeLevel(popString(entry.Fields, "level", "severity"))
+		default:
+			entry.Message = string(event.Event)
+		}
+
+		for key, value := range event.Fields {
+			entry.Fields[key] = value
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, &HECError{Status: http.StatusBadRequest, Code: HECCodeNoData, Text: "No data", InvalidEvent: -1}
+	}
+	return entries, nil
+}
+
+// DecodeHECRaw decodifica o corpo de /services/collector/raw: cada linha não vazia é um evento
+func DecodeHECRaw(body []byte, metadata HECMetadata) ([]*types.LogEntry, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	now := time.Now()
+
+	var entries []*types.LogEntry
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry := newHECEntry(metadata, now)
+		entry.Message = line
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, newHECEventError(HECCodeInvalidData, "Invalid data format", len(entries))
+	}
+
+	if len(entries) == 0 {
+		return nil, &HECError{Status: http.StatusBadRequest, Code: HECCodeNoData, Text: "No data", InvalidEvent: -1}
+	}
+	return entries, nil
+}
+
+// newHECEntry cria a entrada com host, index e sourcetype como labels e source como field
+func newHECEntry(metadata HECMetadata, now time.Time) *types.LogEntry {
+	labels := make(map[string]string, 3)
+	if metadata.Host != "" {
+		labels["host"] = metadata.Host
+	}
+	if metadata.Index != "" {
+		labels["index"] = metadata.Index
+	}
+	if metadata.SourceType != "" {
+		labels["sourcetype"] = metadata.SourceType
+	}
+
+	fields := make(map[string]interface{})
+	if metadata.Source != "" {
+		fields["splunk_source"] = metadata.Source
+	}
+
+	sourceID := metadata.SourceType
+	if sourceID == "" {
+		sourceID = "hec"
+	}
+
+	return &types.LogEntry{
+		Timestamp:   now,
+		SourceType:  "splunk_hec",
+		SourceID:    sourceID,
+		Labels:      labels,
+		Fields:      fields,
+		ProcessedAt: now,
+	}
+}
+
+// HECErrorResponse converte qualquer erro de decodificação no status e corpo do HEC
+func HECErrorResponse(err error) (int, HECResponse) {
+	var hecErr *HECError
+	if errors.As(err, &hecErr) {
+		return hecErr.Status, hecErr.Response()
+	}
+	return http.StatusBadRequest, HECResponse{Text: fmt.Sprintf("Invalid data format: %v", err), Code: HECCodeInvalidData}
+}
@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHECToken(t *testing.T) {
+	token, err := HECToken("Splunk 11111111-2222")
+	require.NoError(t, err)
+	assert.Equal(t, "11111111-2222", token)
+
+	token, err = HECToken("Basic " + base64.StdEncoding.EncodeToString([]byte("x:secret")))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+
+	_, err = HECToken("")
+	status, response := HECErrorResponse(err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, HECCodeTokenMissing, response.Code)
+
+	_, err = HECToken("Bearer abc")
+	_, response = HECErrorResponse(err)
+	assert.Equal(t, HECCodeInvalidAuth, response.Code)
+}
+
+func TestDecodeHECEvents(t *testing.T) {
+	body := `{"time":1714557600.25,"host":"web-1","source":"/var/log/app.log","sourcetype":"_json","index":"main","event":{"message":"login ok","severity":"WARN","user":{"id":42}},"fields":{"region":"eu"}}
+{"event":"plain text event"}`
+
+	entries, err := DecodeHECEvents([]byte(body))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	first := entries[0]
+	assert.Equal(t, time.Unix(1714557600, 250000000), first.Timestamp)
+	assert.Equal(t, "login ok", first.Message)
+	assert.Equal(t, "warn", first.Level)
+	assert.Equal(t, "splunk_hec", first.SourceType)
+	assert.Equal(t, "_json", first.SourceID)
+	assert.Equal(t, map[string]string{"host": "web-1", "index": "main", "sourcetype": "_json"}, first.Labels)
+	assert.Equal(t, "/var/log/app.log", first.Fields["splunk_source"])
+	assert.Equal(t, float64(42), first.Fields["user.id"])
+	assert.Equal(t, "eu", first.Fields["region"])
+
+	assert.Equal(t, "plain text event", entries[1].Message)
+	assert.Equal(t, "hec", entries[1].SourceID)
+
+	tests := []struct {
+		body  string
+		code  int
+		index int
+	}{
+		{`{"event":"ok"}{"host":"x"}`, HECCodeEventMissing, 1},
+		{`{"event":"  "}`, HECCodeEventBlank, 0},
+		{`{"event":"ok","time":"yesterday"}`, HECCodeInvalidData, 0},
+		{`{"event":"ok"} not json`, HECCodeInvalidData, 1},
+	}
+	for _, tt := range tests {
+		_, err := DecodeHECEvents([]byte(tt.body))
+		status, response := HECErrorResponse(err)
+		assert.Equal(t, http.StatusBadRequest, status, tt.body)
+		assert.Equal(t, tt.code, response.Code, tt.body)
+		require.NotNil(t, response.InvalidEventNumber, tt.body)
+		assert.Equal(t, tt.index, *response.InvalidEventNumber, tt.body)
+	}
+
+	_, err = DecodeHECEvents([]byte("  "))
+	_, response := HECErrorResponse(err)
+	assert.Equal(t, HECCodeNoData, response.Code)
+}
+
+func TestDecodeHECRaw(t *testing.T) {
+	entries, err := DecodeHECRaw([]byte("first line\r\n\nsecond line\n"), HECMetadata{Host: "db-1", SourceType: "syslog"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "first line", entries[0].Message)
+	assert.Equal(t, "second line", entries[1].Message)
+	assert.Equal(t, "db-1", entries[1].Labels["host"])
+	assert.Equal(t, "syslog", entries[1].SourceID)
+
+	_, err = DecodeHECRaw([]byte("\n\n"), HECMetadata{})
+	_, response := HECErrorResponse(err)
+	assert.Equal(t, HECCodeNoData, response.Code)
+}
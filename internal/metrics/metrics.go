@@ -391,6 +391,51 @@ var (
 		},
 		[]string{"broker", "sink_name"},
 	)
+
+	// Counter para rotações de arquivos detectadas
+	FileRotationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "file_rotations_total",
+			Help: "Total number of file rotations detected by type (rename, truncate)",
+		},
+		[]string{"type"},
+	)
+
+	// Counter para arquivos comprimidos (.gz/.zst) processados
+	FileArchivesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "file_archives_total",
+			Help: "Total number of compressed archives handled by result (completed, skipped, failed)",
+		},
+		[]string{"result"},
+	)
+
+	// Counter para sequências inválidas substituídas na transcodificação de arquivos
+	FileEncodingErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "file_encoding_invalid_sequences_total",
+			Help: "Total number of invalid byte sequences replaced while transcoding files to UTF-8",
+		},
+		[]string{"encoding"},
+	)
+
+	// Counter para linhas acima de max_line_bytes por componente e política
+	OversizedLinesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "oversized_lines_total",
+			Help: "Total number of lines exceeding max_line_bytes by component and policy (truncate, split, dlq)",
+		},
+		[]string{"component", "policy"},
+	)
+
+	// Counter para bytes descartados de linhas truncadas ou enviadas à DLQ
+	OversizedBytesDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "oversized_line_bytes_dropped_total",
+			Help: "Total number of bytes discarded from oversized lines by component",
+		},
+		[]string{"component"},
+	)
 )
 
 // MetricsServer servidor HTTP para métricas Prometheus
@@ -466,6 +511,12 @@ func NewMetricsServer(addr string, logger *logrus.Logger) *MetricsServer {
 		safeRegister(KafkaMessageSizeBytes)
 		safeRegister(KafkaDLQMessagesTotal)
 		safeRegister(KafkaConnectionStatus)
+		// File monitor metrics
+		safeRegister(FileRotationsTotal)
+		safeRegister(FileArchivesTotal)
+		safeRegister(FileEncodingErrorsTotal)
+		safeRegister(OversizedLinesTotal)
+		safeRegister(OversizedBytesDroppedTotal)
 	})
 
 	mux := http.NewServeMux()
@@ -727,6 +778,29 @@ func UpdateTotalFilesMonitored(count int) {
 	TotalFilesMonitored.Set(float64(count))
 }
 
+// RecordFileRotation records a detected file rotation (rename or truncate)
+func RecordFileRotation(rotationType string) {
+	FileRotationsTotal.WithLabelValues(rotationType).Inc()
+}
+
+// RecordFileArchive records the result of a compressed archive ingestion
+func RecordFileArchive(result string) {
+	FileArchivesTotal.WithLabelValues(result).Inc()
+}
+
+// RecordFileEncodingErrors records invalid byte sequences replaced during transcoding
+func RecordFileEncodingErrors(encoding string, count int) {
+	FileEncodingErrorsTotal.WithLabelValues(encoding).Add(float64(count))
+}
+
+// RecordOversizedLine records a line exceeding max_line_bytes and the bytes it lost
+func RecordOversizedLine(component, policy string, droppedBytes int64) {
+	OversizedLinesTotal.WithLabelValues(component, policy).Inc()
+	if droppedBytes > 0 {
+		OversizedBytesDroppedTotal.WithLabelValues(component).Add(float64(droppedBytes))
+	}
+}
+
 // UpdateTotalContainersMonitored updates the total count of monitored containers
 func UpdateTotalContainersMonitored(count int) {
 	TotalContainersMonitored.Set(float64(count))
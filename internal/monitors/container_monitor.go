@@ -12,6 +12,7 @@ import (
 
 	"ssw-logs-capture/internal/metrics"
 	"ssw-logs-capture/pkg/docker"
+	"ssw-logs-capture/pkg/linelimit"
 	"ssw-logs-capture/pkg/positions"
 	"ssw-logs-capture/pkg/selfguard"
 	"ssw-logs-capture/pkg/types"
@@ -37,6 +38,7 @@ type ContainerMonitor struct {
 	dockerPool    *docker.PoolManager
 	containers    map[string]*monitoredContainer
 	mutex         sync.RWMutex
+	lineLimit     *linelimit.Limiter // nil = linhas sem limite de tamanho
 
 	ctx       context.Context
 	cancel    context.CancelFunc
@@ -54,6 +56,9 @@ type monitoredContainer struct {
 	lastRead     time.Time
 	cancel       context.CancelFunc
 	heartbeatWg  sync.WaitGroup // Rastreia goroutine de heartbeat
+	multilineConfig *types.MultilineConfig // nil quando multiline está desabilitado
+	tty           bool      // Container com TTY: stream sem multiplexação stdout/stderr
+	lastTimestamp time.Time // Timestamp Docker da última linha enviada
 }
 
 // NewContainerMonitor cria um novo monitor de containers
@@ -84,6 +89,17 @@ func NewContainerMonitor(config types.DockerConfig, timestampConfig types.Timest
 	}
 	feedbackGuard := selfguard.NewFeedbackGuard(feedbackConfig, logger)
 
+	lineLimit, err := linelimit.New(config.LineLimit, "container_monitor", linelimit.DeadLetterQueueOf(dispatcher))
+	if err != nil {
+		return nil, fmt.Errorf("invalid line limit: %w", err)
+	}
+
+	// Sem nenhum stream selecionado, coletar ambos (comportamento padrão)
+	if !config.IncludeStdout && !config.IncludeStderr {
+		config.IncludeStdout = true
+		config.IncludeStderr = true
+	}
+
 	if !config.Enabled {
 		return &ContainerMonitor{
 			config:             config,
@@ -125,6 +141,7 @@ func NewContainerMonitor(config types.DockerConfig, timestampConfig types.Timest
 		feedbackGuard:      feedbackGuard,
 		dockerPool:         dockerPool,
 		containers:         make(map[string]*monitoredContainer),
+		lineLimit:          lineLimit,
 		ctx:                ctx,
 		cancel:             cancel,
 	}, nil
@@ -488,12 +505,16 @@ func (cm *ContainerMonitor) addContainerByID(containerID string) error {
 // shouldMonitorContainer verifica se um container deve ser monitorado baseado nos filtros
 func (cm *ContainerMonitor) shouldMonitorContainer(dockerContainer dockerTypes.Container) bool {
 	name := strings.TrimPrefix(dockerContainer.Names[0], "/")
-	labels := dockerContainer.Labels
+	return containerFilterMatches(&cm.config, name, dockerContainer.Labels)
+}
 
+// containerFilterMatches aplica os filtros include/exclude de nomes e labels.
+// Compartilhado entre a leitura via API Docker e via arquivos json-file.
+func containerFilterMatches(config *types.DockerConfig, name string, labels map[string]string) bool {
 	// Verificar nomes incluídos
-	if len(cm.config.IncludeNames) > 0 {
+	if len(config.IncludeNames) > 0 {
 		found := false
-		for _, includeName := range cm.config.IncludeNames {
+		for _, includeName := range config.IncludeNames {
 			if strings.Contains(name, includeName) {
 				found = true
 				break
@@ -505,15 +526,15 @@ func (cm *ContainerMonitor) shouldMonitorContainer(dockerContainer dockerTypes.C
 	}
 
 	// Verificar nomes excluídos
-	for _, excludeName := range cm.config.ExcludeNames {
+	for _, excludeName := range config.ExcludeNames {
 		if strings.Contains(name, excludeName) {
 			return false
 		}
 	}
 
 	// Verificar labels incluídas
-	if len(cm.config.IncludeLabels) > 0 {
-		for key, value := range cm.config.IncludeLabels {
+	if len(config.IncludeLabels) > 0 {
+		for key, value := range config.IncludeLabels {
 			labelValue, exists := labels[key]
 			if !exists {
 				return false
@@ -525,7 +546,7 @@ func (cm *ContainerMonitor) shouldMonitorContainer(dockerContainer dockerTypes.C
 	}
 
 	// Verificar labels excluídas
-	for key, value := range cm.config.ExcludeLabels {
+	for key, value := range config.ExcludeLabels {
 		labelValue, exists := labels[key]
 		if exists {
 			if value == "" || labelValue == value {
@@ -537,25 +558,29 @@ func (cm *ContainerMonitor) shouldMonitorContainer(dockerContainer dockerTypes.C
 	return true
 }
 
-// startContainerMonitoring inicia monitoramento de um container
-func (cm *ContainerMonitor) startContainerMonitoring(dockerContainer dockerTypes.Container) {
-	containerID := dockerContainer.ID[:12]
+// multilineConfigFor seleciona a configuração multiline de um container.
+// A primeira regra que corresponder vence; sem regras, usa o default global.
+func (cm *ContainerMonitor) multilineConfigFor(name string, labels map[string]string) *types.MultilineConfig {
+	return containerMultilineConfig(&cm.config, name, labels)
+}
 
-	// Verificar se deve monitorar este container
-	if !cm.shouldMonitorContainer(dockerContainer) {
-		cm.logger.WithFields(logrus.Fields{
-			"container_id":   containerID,
-			"container_name": strings.TrimPrefix(dockerContainer.Names[0], "/"),
-			"image":          dockerContainer.Image,
-		}).Debug("Container filtered out, skipping monitoring")
-		return
+// containerMultilineConfig implementa a seleção de multilineConfigFor para uma configuração
+func containerMultilineConfig(config *types.DockerConfig, name string, labels map[string]string) *types.MultilineConfig {
+	for i := range config.MultilineRules {
+		rule := &config.MultilineRules[i]
+		if multilineRuleMatches(rule, name, labels) {
+			return &rule.Multiline
+		}
 	}
 
-	// Extrair nome e imagem
-	name := strings.TrimPrefix(dockerContainer.Names[0], "/")
-	image := dockerContainer.Image
+	if config.Multiline.Enabled {
+		return &config.Multiline
+	}
+	return nil
+}
 
-	// Criar labels básicos
+// containerLabels cria as labels básicas de um container
+func containerLabels(containerID, name, image string, dockerLabels map[string]string) map[string]string {
 	labels := map[string]string{
 		"container_id":   containerID,
 		"container_name": name,
@@ -569,7 +594,7 @@ func (cm *ContainerMonitor) startContainerMonitoring(dockerContainer dockerTypes
 	}
 
 	for _, essential := range essentialDockerLabels {
-		if value, exists := dockerContainer.Labels[essential]; exists {
+		if value, exists := dockerLabels[essential]; exists {
 			// Usar nome simplificado para economizar espaço
 			switch essential {
 			case "com.docker.compose.service":
@@ -585,6 +610,57 @@ func (cm *ContainerMonitor) startContainerMonitoring(dockerContainer dockerTypes
 		}
 	}
 
+	return labels
+}
+
+// multilineRuleMatches verifica se um container corresponde a uma regra multiline
+func multilineRuleMatches(rule *types.ContainerMultilineRule, name string, labels map[string]string) bool {
+	if len(rule.Names) > 0 {
+		found := false
+		for _, ruleName := range rule.Names {
+			if strings.Contains(name, ruleName) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for key, value := range rule.Labels {
+		labelValue, exists := labels[key]
+		if !exists {
+			return false
+		}
+		if value != "" && labelValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// startContainerMonitoring inicia monitoramento de um container
+func (cm *ContainerMonitor) startContainerMonitoring(dockerContainer dockerTypes.Container) {
+	containerID := dockerContainer.ID[:12]
+
+	// Verificar se deve monitorar este container
+	if !cm.shouldMonitorContainer(dockerContainer) {
+		cm.logger.WithFields(logrus.Fields{
+			"container_id":   containerID,
+			"container_name": strings.TrimPrefix(dockerContainer.Names[0], "/"),
+			"image":          dockerContainer.Image,
+		}).Debug("Container filtered out, skipping monitoring")
+		return
+	}
+
+	// Extrair nome e imagem
+	name := strings.TrimPrefix(dockerContainer.Names[0], "/")
+	image := dockerContainer.Image
+
+	labels := containerLabels(containerID, name, image, dockerContainer.Labels)
+
 	// Get position-based since time, using container creation time for new containers
 	sinceTime := time.Now()
 	if cm.positionManager != nil {
@@ -594,13 +670,21 @@ func (cm *ContainerMonitor) startContainerMonitoring(dockerContainer dockerTypes
 		cm.positionManager.SetContainerStatus(containerID, "active")
 	}
 
+	// Configurar agregação multiline (regras por container têm precedência)
+	multilineConfig := cm.multilineConfigFor(name, dockerContainer.Labels)
+	if _, err := newMultilineAggregator(multilineConfig); err != nil {
+		cm.logger.WithError(err).WithField("container_id", containerID).Warn("Invalid multiline config, reading line by line")
+		multilineConfig = nil
+	}
+
 	mc := &monitoredContainer{
-		id:       containerID,
-		name:     name,
-		image:    image,
-		labels:   labels,
-		since:    sinceTime,
-		lastRead: time.Now(),
+		id:              containerID,
+		name:            name,
+		image:           image,
+		labels:          labels,
+		since:           sinceTime,
+		lastRead:        time.Now(),
+		multilineConfig: multilineConfig,
 	}
 
 	cm.containers[containerID] = mc
@@ -695,10 +779,17 @@ func (cm *ContainerMonitor) monitorContainer(ctx context.Context, mc *monitoredC
 		}
 	}()
 
+	// Containers com TTY enviam o stream sem frames stdout/stderr
+	inspectCtx, inspectCancel := context.WithTimeout(containerCtx, 5*time.Second)
+	if info, err := cm.dockerPool.ContainerInspect(inspectCtx, mc.id); err == nil && info.Config != nil {
+		mc.tty = info.Config.Tty
+	}
+	inspectCancel()
+
 	// Configurar opções de logs
 	logOptions := dockerTypes.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
+		ShowStdout: cm.config.IncludeStdout,
+		ShowStderr: cm.config.IncludeStderr,
 		Follow:     true,
 		Since:      mc.since.Format(time.RFC3339),
 		Timestamps: true,
@@ -754,7 +845,15 @@ func (cm *ContainerMonitor) monitorContainer(ctx context.Context, mc *monitoredC
 				return nil
 			case <-time.After(2 * time.Second):
 				// Atualizar 'since' para evitar logs duplicados usando o último timestamp lido
-				if !mc.lastRead.IsZero() {
+				if !mc.lastTimestamp.IsZero() {
+					// Timestamp Docker com nanossegundos: retomar logo após a última linha enviada
+					logOptions.Since = mc.lastTimestamp.Add(time.Nanosecond).Format(time.RFC3339Nano)
+					cm.logger.WithFields(logrus.Fields{
+						"container_id":   mc.id,
+						"container_name": mc.name,
+						"since":          logOptions.Since,
+					}).Debug("Reconnecting to container log stream with last log timestamp")
+				} else if !mc.lastRead.IsZero() {
 					logOptions.Since = mc.lastRead.Format(time.RFC3339)
 					cm.logger.WithFields(logrus.Fields{
 						"container_id":   mc.id,
@@ -775,34 +874,53 @@ func (cm *ContainerMonitor) monitorContainer(ctx context.Context, mc *monitoredC
 	}
 }
 
+// containerStreamState guarda o evento multiline pendente de um stream (stdout/stderr)
+type containerStreamState struct {
+	multiline *multilineAggregator
+	startedAt time.Time // Timestamp da primeira linha do evento pendente
+}
+
 // readContainerLogs lê logs de um stream de container
 func (cm *ContainerMonitor) readContainerLogs(ctx context.Context, mc *monitoredContainer, stream io.Reader) error {
-	incomplete := ""
 	logCount := int64(0)
 	bytesRead := int64(0)
 
-	// Canal para receber dados do stream em goroutine separada
+	// Canal para receber linhas do stream em goroutine separada
 	type readResult struct {
-		data []byte
-		err  error
+		record dockerLogRecord
+		err    error
 	}
 	readCh := make(chan readResult, 1)
 
-	// Goroutine para ler do stream
+	// Estado multiline separado por stream para não misturar stdout e stderr
+	streams := make(map[string]*containerStreamState)
+
+	// Ticker para liberar eventos multiline quando o container fica quieto
+	var flushCh <-chan time.Time
+	if mc.multilineConfig != nil {
+		flushTicker := time.NewTicker(1 * time.Second)
+		defer flushTicker.Stop()
+		flushCh = flushTicker.C
+
+		// Enviar eventos parciais quando o stream termina (container parou ou reconexão).
+		// Usa o context do monitor: o do stream normalmente já foi cancelado aqui.
+		defer func() {
+			for streamName, state := range streams {
+				if event, ok := state.multiline.Flush(); ok {
+					cm.dispatchContainerLine(cm.ctx, mc, streamName, state.startedAt, event)
+				}
+			}
+		}()
+	}
+
+	// Goroutine para demultiplexar o stream (frames stdout/stderr e mensagens parciais)
+	demuxer := newDockerStreamDemuxer(stream, mc.tty, true, cm.lineLimit)
 	go func() {
 		for {
-			localBuf := make([]byte, 8192)
-			n, err := stream.Read(localBuf)
-
-			// Copiar apenas os bytes lidos
-			var data []byte
-			if n > 0 {
-				data = make([]byte, n)
-				copy(data, localBuf[:n])
-			}
+			record, err := demuxer.Next()
 
 			select {
-			case readCh <- readResult{data: data, err: err}:
+			case readCh <- readResult{record: record, err: err}:
 				if err != nil {
 					return // Sair se houver erro (incluindo EOF)
 				}
@@ -818,133 +936,181 @@ func (cm *ContainerMonitor) readContainerLogs(ctx context.Context, mc *monitored
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case now := <-flushCh:
+			for streamName, state := range streams {
+				if event, ok := state.multiline.FlushExpired(now); ok {
+					cm.dispatchContainerLine(ctx, mc, streamName, state.startedAt, event)
+				}
+			}
+			continue
 		case result = <-readCh:
-			// Dados recebidos, processar abaixo
+			// Linha recebida, processar abaixo
 		}
 
-		data := result.data
-		err := result.err
+		// Handle read errors
+		if result.err != nil {
+			if result.err == io.EOF {
+				return nil
+			}
+			return result.err
+		}
 
-		if len(data) > 0 {
-			bytesRead += int64(len(data))
+		record := result.record
+		bytesRead += int64(len(record.message)) + 1
 
-			// Processar dados lidos
-			dataStr := incomplete + string(data)
-			lines := strings.Split(dataStr, "\n")
+		if !cm.streamEnabled(record.stream) || strings.TrimSpace(record.message) == "" {
+			continue
+		}
 
-			// Última linha pode estar incompleta
-			incomplete = lines[len(lines)-1]
-			lines = lines[:len(lines)-1]
+		timestamp := record.timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
 
-			// Processar linhas completas
-			for _, line := range lines {
-				if line == "" {
-					continue
+		if !record.oversize.IsZero() {
+			// Linhas cortadas por max_line_bytes não passam pelo multiline; o
+			// evento pendente do stream sai antes para preservar a ordem
+			if state, exists := streams[record.stream]; exists {
+				if event, ok := state.multiline.Flush(); ok {
+					cm.dispatchContainerLine(ctx, mc, record.stream, state.startedAt, event)
 				}
-
-				// Remover header do Docker (8 bytes) se presente
-				if len(line) > 8 && (line[0] == 1 || line[0] == 2) {
-					line = line[8:]
+			}
+			if cm.dispatchContainerRecord(ctx, mc, record.stream, timestamp, record.message, record.oversize) {
+				logCount++
+			}
+		} else if mc.multilineConfig == nil {
+			if cm.dispatchContainerLine(ctx, mc, record.stream, timestamp, record.message) {
+				logCount++
+			}
+		} else {
+			state, exists := streams[record.stream]
+			if !exists {
+				multiline, err := newMultilineAggregator(mc.multilineConfig)
+				if err != nil {
+					return err
 				}
+				state = &containerStreamState{multiline: multiline}
+				streams[record.stream] = state
+			}
 
-				// Parse timestamp se presente
-				if strings.Contains(line, "T") && len(line) > 30 {
-					if spaceIdx := strings.Index(line, " "); spaceIdx > 0 && spaceIdx < 35 {
-						line = line[spaceIdx+1:]
-					}
-				}
+			// Agregar linhas em eventos lógicos (stack traces, etc.); o evento
+			// usa o timestamp da sua primeira linha
+			eventStart := state.startedAt
+			startsEvent := state.multiline.PendingBytes() == 0
+			events := state.multiline.Add(record.message, int64(len(record.message)))
+			if startsEvent || len(events) > 0 {
+				state.startedAt = timestamp
+			}
 
-				line = strings.TrimSpace(line)
-				if line == "" {
-					continue
+			for _, event := range events {
+				if cm.dispatchContainerLine(ctx, mc, record.stream, eventStart, event) {
+					logCount++
 				}
+			}
+		}
 
-				// Enviar para dispatcher com labels padrão
-				sourceID := mc.id
-				standardLabels := addStandardLabels(mc.labels)
-
-				// Criar entry para validações
-				traceID := uuid.New().String()
-				entry := &types.LogEntry{
-					TraceID:     traceID,
-					Timestamp:   time.Now(),
-					Message:     line,
-					SourceType:  "docker",
-					SourceID:    sourceID,
-					Labels:      standardLabels,
-					ProcessedAt: time.Now(),
-				}
+		// Log periódico para debug (a cada 10 logs para não fazer spam)
+		if logCount > 0 && logCount%10 == 0 {
+			cm.logger.WithFields(logrus.Fields{
+				"container_id":   mc.id,
+				"container_name": mc.name,
+				"logs_processed": logCount,
+				"last_read":      mc.lastRead,
+			}).Debug("Container logs processed")
+		}
 
-				// Verificar se é self-log usando feedback guard (temporariamente desabilitado)
-				/*
-				if cm.feedbackGuard != nil {
-					guardResult := cm.feedbackGuard.CheckEntry(entry)
-					if guardResult.IsSelfLog && guardResult.Action == "drop" {
-						cm.logger.WithFields(logrus.Fields{
-							"container_id":   mc.id,
-							"container_name": mc.name,
-							"reason":         guardResult.Reason,
-							"match_pattern":  guardResult.MatchPattern,
-						}).Debug("Self-log dropped by feedback guard")
-						continue
-					}
-				}
-				*/
-
-				// Validar timestamp se o timestamp validator estiver disponível
-				if cm.timestampValidator != nil {
-					result := cm.timestampValidator.ValidateTimestamp(entry)
-					if !result.Valid && result.Action == "rejected" {
-						cm.logger.WithFields(logrus.Fields{
-							"container_id":   mc.id,
-							"container_name": mc.name,
-							"reason":         result.Reason,
-							"line":           line,
-						}).Warn("Container log line rejected due to invalid timestamp")
-						continue
-					}
-				}
+		// Update position if we processed logs successfully
+		if logCount > 0 && cm.positionManager != nil {
+			cm.positionManager.UpdateContainerPosition(mc.id, mc.lastRead, logCount, bytesRead)
+			// Reset counters for next batch
+			logCount = 0
+			bytesRead = 0
+		}
+	}
+}
 
-				if err := cm.dispatcher.Handle(ctx, "docker", sourceID, line, standardLabels); err != nil {
-					cm.logger.WithError(err).WithField("container_id", mc.id).Error("Failed to dispatch container log")
-					metrics.RecordError("container_monitor", "dispatch_error")
-				} else {
-					logCount++
-					// CRÍTICO: Só atualizar lastRead quando efetivamente processamos um log
-					mc.lastRead = time.Now()
-
-					// Log periódico para debug (a cada 10 logs para não fazer spam)
-					if logCount%10 == 0 {
-						cm.logger.WithFields(logrus.Fields{
-							"container_id":   mc.id,
-							"container_name": mc.name,
-							"logs_processed": logCount,
-							"last_read":      mc.lastRead,
-						}).Debug("Container logs processed")
-					}
-				}
+// streamEnabled verifica se o stream (stdout/stderr) deve ser coletado
+func (cm *ContainerMonitor) streamEnabled(stream string) bool {
+	if stream == "stderr" {
+		return cm.config.IncludeStderr
+	}
+	return cm.config.IncludeStdout
+}
 
-				// Métricas
-				metrics.RecordLogProcessed("docker", sourceID, "container_monitor")
-			}
+// dispatchContainerLine valida e envia uma linha (ou evento multiline) ao dispatcher.
+// Retorna true se a linha foi aceita.
+func (cm *ContainerMonitor) dispatchContainerLine(ctx context.Context, mc *monitoredContainer, stream string, timestamp time.Time, line string) bool {
+	return cm.dispatchContainerRecord(ctx, mc, stream, timestamp, line, linelimit.Oversize{})
+}
 
-			// Update position if we processed logs successfully
-			if logCount > 0 && cm.positionManager != nil {
-				cm.positionManager.UpdateContainerPosition(mc.id, mc.lastRead, logCount, bytesRead)
-				// Reset counters for next batch
-				logCount = 0
-				bytesRead = 0
-			}
+// dispatchContainerRecord envia uma linha marcando o corte por max_line_bytes
+// descrito em oversize; na política dlq a linha vai para a DLQ e retorna false
+func (cm *ContainerMonitor) dispatchContainerRecord(ctx context.Context, mc *monitoredContainer, stream string, timestamp time.Time, line string, oversize linelimit.Oversize) bool {
+	// Enviar para dispatcher com labels padrão
+	sourceID := mc.id
+	standardLabels := addStandardLabels(mc.labels)
+	standardLabels["stream"] = stream
+
+	// Criar entry para validações
+	traceID := uuid.New().String()
+	entry := &types.LogEntry{
+		TraceID:     traceID,
+		Timestamp:   timestamp,
+		Message:     line,
+		SourceType:  "docker",
+		SourceID:    sourceID,
+		Labels:      standardLabels,
+		ProcessedAt: time.Now(),
+	}
+	if cm.lineLimit != nil && !cm.lineLimit.Finish(entry, oversize) {
+		return false
+	}
+
+	// Verificar se é self-log usando feedback guard (temporariamente desabilitado)
+	/*
+	if cm.feedbackGuard != nil {
+		guardResult := cm.feedbackGuard.CheckEntry(entry)
+		if guardResult.IsSelfLog && guardResult.Action == "drop" {
+			cm.logger.WithFields(logrus.Fields{
+				"container_id":   mc.id,
+				"container_name": mc.name,
+				"reason":         guardResult.Reason,
+				"match_pattern":  guardResult.MatchPattern,
+			}).Debug("Self-log dropped by feedback guard")
+			return false
 		}
+	}
+	*/
 
-		// Handle read errors
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return err
+	// Validar timestamp se o timestamp validator estiver disponível
+	if cm.timestampValidator != nil {
+		result := cm.timestampValidator.ValidateTimestamp(entry)
+		if !result.Valid && result.Action == "rejected" {
+			cm.logger.WithFields(logrus.Fields{
+				"container_id":   mc.id,
+				"container_name": mc.name,
+				"reason":         result.Reason,
+				"line":           line,
+			}).Warn("Container log line rejected due to invalid timestamp")
+			return false
 		}
 	}
+
+	// Métricas
+	defer metrics.RecordLogProcessed("docker", sourceID, "container_monitor")
+
+	if err := cm.dispatcher.HandleEntry(ctx, entry); err != nil {
+		cm.logger.WithError(err).WithField("container_id", mc.id).Error("Failed to dispatch container log")
+		metrics.RecordError("container_monitor", "dispatch_error")
+		return false
+	}
+
+	// CRÍTICO: Só atualizar lastRead quando efetivamente processamos um log
+	mc.lastRead = time.Now()
+	if timestamp.After(mc.lastTimestamp) {
+		mc.lastTimestamp = timestamp
+	}
+	return true
 }
 
 // containerExists verifica se um container ainda existe
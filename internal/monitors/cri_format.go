@@ -0,0 +1,222 @@
+package monitors
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/types"
+)
+
+const (
+	// fileFormatCRI identifica arquivos no formato de log CRI (containerd/CRI-O)
+	fileFormatCRI = "cri"
+	// fileFormatDockerJSON identifica arquivos do driver json-file do Docker
+	fileFormatDockerJSON = "docker"
+	// criMaxMessageBytes limita a remontagem de linhas parciais (P)
+	criMaxMessageBytes = 1024 * 1024
+)
+
+// criLine é uma linha do formato CRI: "<timestamp RFC3339Nano> <stream> <P|F> <mensagem>"
+type criLine struct {
+	timestamp time.Time
+	stream    string
+	partial   bool
+	message   string
+}
+
+// parseCRILine decodifica uma linha no formato de log CRI
+func parseCRILine(line string) (criLine, error) {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) < 3 {
+		return criLine{}, fmt.Errorf("invalid CRI log line: missing fields")
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return criLine{}, fmt.Errorf("invalid CRI timestamp: %w", err)
+	}
+
+	if fields[1] != "stdout" && fields[1] != "stderr" {
+		return criLine{}, fmt.Errorf("invalid CRI stream %q", fields[1])
+	}
+
+	// A tag pode ter outros atributos separados por ":" (ex: "F:x"); só o primeiro importa
+	tag := strings.SplitN(fields[2], ":", 2)[0]
+	if tag != "P" && tag != "F" {
+		return criLine{}, fmt.Errorf("invalid CRI tag %q", fields[2])
+	}
+
+	record := criLine{
+		timestamp: timestamp,
+		stream:    fields[1],
+		partial:   tag == "P",
+	}
+	if len(fields) == 4 {
+		record.message = strings.TrimSuffix(fields[3], "\r")
+	}
+
+	return record, nil
+}
+
+// criStreamState guarda a linha parcial e o evento multiline pendentes de um stream
+type criStreamState struct {
+	partial          strings.Builder
+	partialTimestamp time.Time
+	partialBytes     int64 // Bytes lidos do arquivo ainda não emitidos como linha completa
+	multiline        *multilineAggregator
+	startedAt        time.Time // Timestamp da primeira linha do evento multiline pendente
+}
+
+// criDecoder remonta linhas parciais por stream (stdout/stderr) de um arquivo CRI.
+// Também decodifica o formato json-file do Docker, que tem a mesma estrutura
+// (timestamp, stream e marcação de linha parcial por registro).
+type criDecoder struct {
+	format          string
+	parse           func(line string) (criLine, error)
+	skipStreams     map[string]bool // Streams descartados (ex: include_stderr: false)
+	multilineConfig *types.MultilineConfig
+	mutex           sync.Mutex
+	streams         map[string]*criStreamState
+}
+
+// newCRIDecoder cria o decoder; a configuração multiline é aplicada por stream
+func newCRIDecoder(multilineConfig *types.MultilineConfig) (*criDecoder, error) {
+	return newRuntimeLogDecoder(fileFormatCRI, parseCRILine, multilineConfig)
+}
+
+// newRuntimeLogDecoder cria um decoder para um formato de log de container runtime
+func newRuntimeLogDecoder(format string, parse func(string) (criLine, error), multilineConfig *types.MultilineConfig) (*criDecoder, error) {
+	// Validar padrões antecipadamente
+	if _, err := newMultilineAggregator(multilineConfig); err != nil {
+		return nil, err
+	}
+	return &criDecoder{
+		format:          format,
+		parse:           parse,
+		multilineConfig: multilineConfig,
+		streams:         make(map[string]*criStreamState),
+	}, nil
+}
+
+// stream retorna (criando se necessário) o estado do stream
+func (d *criDecoder) stream(name string) *criStreamState {
+	state := d.streams[name]
+	if state == nil {
+		// Config já validada em newCRIDecoder
+		multiline, _ := newMultilineAggregator(d.multilineConfig)
+		state = &criStreamState{multiline: multiline}
+		d.streams[name] = state
+	}
+	return state
+}
+
+// pendingBytes retorna os bytes lidos que ainda não foram enviados ao dispatcher
+func (d *criDecoder) pendingBytes() int64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var pending int64
+	for _, state := range d.streams {
+		pending += state.partialBytes
+		if state.multiline != nil {
+			pending += state.multiline.PendingBytes()
+		}
+	}
+	return pending
+}
+
+// emitCRILine decodifica uma linha CRI, remonta mensagens parciais (P) até a
+// linha final (F) e envia a mensagem completa com o timestamp e o stream do runtime
+func (fm *FileMonitor) emitCRILine(mf *monitoredFile, line string, rawSize int64) {
+	record, err := mf.cri.parse(line)
+	if err != nil {
+		// Linha fora do formato: enviar como texto para não perder dados
+		metrics.RecordError("file_monitor", mf.cri.format+"_parse_error")
+		fm.dispatchFileRecord(mf, line, time.Time{}, "")
+		return
+	}
+
+	if mf.cri.skipStreams[record.stream] {
+		return
+	}
+
+	mf.cri.mutex.Lock()
+	defer mf.cri.mutex.Unlock()
+
+	state := mf.cri.stream(record.stream)
+	if state.partial.Len() == 0 && state.partialBytes == 0 {
+		state.partialTimestamp = record.timestamp
+	}
+	state.partial.WriteString(record.message)
+	state.partialBytes += rawSize
+
+	if record.partial && state.partial.Len() < criMaxMessageBytes {
+		return
+	}
+
+	message, timestamp, size := state.partial.String(), state.partialTimestamp, state.partialBytes
+	state.partial.Reset()
+	state.partialBytes = 0
+
+	fm.emitCRIMessage(mf, state, record.stream, timestamp, message, size)
+}
+
+// emitCRIMessage envia a mensagem completa diretamente ou pelo agregador multiline do stream
+func (fm *FileMonitor) emitCRIMessage(mf *monitoredFile, state *criStreamState, stream string, timestamp time.Time, message string, rawSize int64) {
+	if state.multiline == nil {
+		fm.dispatchFileRecord(mf, message, timestamp, stream)
+		return
+	}
+
+	// O evento usa o timestamp da sua primeira linha
+	eventStart := state.startedAt
+	startsEvent := state.multiline.PendingBytes() == 0
+	events := state.multiline.Add(message, rawSize)
+	if startsEvent || len(events) > 0 {
+		state.startedAt = timestamp
+	}
+
+	for _, event := range events {
+		fm.dispatchFileRecord(mf, event, eventStart, stream)
+	}
+}
+
+// flushCRI envia os eventos multiline pendentes dos streams. Com force (fim do
+// arquivo ou shutdown) também envia linhas parciais sem a linha final (F).
+// Retorna o número de eventos enviados.
+func (fm *FileMonitor) flushCRI(mf *monitoredFile, force bool, now time.Time) int {
+	mf.cri.mutex.Lock()
+	defer mf.cri.mutex.Unlock()
+
+	flushed := 0
+	for stream, state := range mf.cri.streams {
+		if force && state.partialBytes > 0 {
+			message, timestamp, size := state.partial.String(), state.partialTimestamp, state.partialBytes
+			state.partial.Reset()
+			state.partialBytes = 0
+			fm.emitCRIMessage(mf, state, stream, timestamp, message, size)
+			flushed++
+		}
+
+		if state.multiline == nil {
+			continue
+		}
+
+		var event string
+		var ok bool
+		if force {
+			event, ok = state.multiline.Flush()
+		} else {
+			event, ok = state.multiline.FlushExpired(now)
+		}
+		if ok {
+			fm.dispatchFileRecord(mf, event, state.startedAt, stream)
+			flushed++
+		}
+	}
+
+	return flushed
+}
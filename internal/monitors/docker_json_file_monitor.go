@@ -0,0 +1,327 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/positions"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dockerJSONLine é um registro do driver json-file: {"log":"...\n","stream":"stdout","time":"..."}
+type dockerJSONLine struct {
+	Log    string `json:"log"`
+	Stream string `json:"stream"`
+	Time   string `json:"time"`
+}
+
+// parseDockerJSONLine decodifica um registro json-file. Linhas maiores que 16KB
+// são divididas pelo Docker em registros sem o "\n" final (parciais).
+func parseDockerJSONLine(line string) (criLine, error) {
+	var record dockerJSONLine
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return criLine{}, fmt.Errorf("invalid docker json log line: %w", err)
+	}
+
+	if record.Stream != "stdout" && record.Stream != "stderr" {
+		return criLine{}, fmt.Errorf("invalid docker log stream %q", record.Stream)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, record.Time)
+	if err != nil {
+		return criLine{}, fmt.Errorf("invalid docker log timestamp: %w", err)
+	}
+
+	message := strings.TrimSuffix(record.Log, "\n")
+	return criLine{
+		timestamp: timestamp,
+		stream:    record.Stream,
+		partial:   len(message) == len(record.Log),
+		message:   strings.TrimSuffix(message, "\r"),
+	}, nil
+}
+
+// newDockerJSONDecoder cria o decoder de arquivos json-file (remontagem de parciais por stream)
+func newDockerJSONDecoder(multilineConfig *types.MultilineConfig) (*criDecoder, error) {
+	return newRuntimeLogDecoder(fileFormatDockerJSON, parseDockerJSONLine, multilineConfig)
+}
+
+// dockerContainerConfig subconjunto do config.v2.json de um container
+type dockerContainerConfig struct {
+	ID     string `json:"ID"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// readDockerContainerConfig lê o config.v2.json do diretório do container
+func readDockerContainerConfig(containerDir string) (*dockerContainerConfig, error) {
+	data, err := os.ReadFile(filepath.Join(containerDir, "config.v2.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var config dockerContainerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid config.v2.json: %w", err)
+	}
+	if config.ID == "" {
+		config.ID = filepath.Base(containerDir)
+	}
+	return &config, nil
+}
+
+// DockerJSONFileMonitor coleta logs de containers lendo diretamente os arquivos do
+// driver json-file (/var/lib/docker/containers/<id>/<id>-json.log), sem acesso ao
+// socket Docker. Filtros, multiline e labels seguem o ContainerMonitor; leitura,
+// rotação e checkpoints reutilizam o FileMonitor e o FilePositionManager.
+type DockerJSONFileMonitor struct {
+	config      types.DockerConfig
+	logger      *logrus.Logger
+	taskManager types.TaskManager
+	files       *FileMonitor
+	skipStreams map[string]bool
+
+	mutex     sync.RWMutex
+	isRunning bool
+}
+
+// NewDockerJSONFileMonitor cria um novo monitor de arquivos json-file
+func NewDockerJSONFileMonitor(config types.DockerConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, positionManager *positions.PositionBufferManager, logger *logrus.Logger) (*DockerJSONFileMonitor, error) {
+	if config.ContainersPath == "" {
+		config.ContainersPath = "/var/lib/docker/containers"
+	}
+	if config.ScanInterval <= 0 {
+		config.ScanInterval = 10 * time.Second
+	}
+
+	files, err := NewFileMonitor(types.FileConfig{Enabled: true, PollInterval: config.ScanInterval, LineLimit: config.LineLimit}, timestampConfig, dispatcher, taskManager, positionManager, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container log reader: %w", err)
+	}
+
+	skipStreams := make(map[string]bool)
+	if !config.IncludeStdout {
+		skipStreams["stdout"] = true
+	}
+	if !config.IncludeStderr {
+		skipStreams["stderr"] = true
+	}
+
+	return &DockerJSONFileMonitor{
+		config:      config,
+		logger:      logger,
+		taskManager: taskManager,
+		files:       files,
+		skipStreams: skipStreams,
+	}, nil
+}
+
+// Start inicia a descoberta e a leitura dos arquivos de log dos containers
+func (dm *DockerJSONFileMonitor) Start(ctx context.Context) error {
+	if !dm.config.Enabled {
+		dm.logger.Info("Docker json-file monitor disabled")
+		return nil
+	}
+
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	if dm.isRunning {
+		return fmt.Errorf("docker json-file monitor already running")
+	}
+
+	dm.isRunning = true
+	dm.logger.WithField("containers_path", dm.config.ContainersPath).Info("Starting docker json-file monitor")
+
+	if err := dm.taskManager.StartTask(ctx, "docker_json_file_monitor", dm.monitorLoop); err != nil {
+		return fmt.Errorf("failed to start docker json-file monitor task: %w", err)
+	}
+
+	return nil
+}
+
+// Stop para o monitor
+func (dm *DockerJSONFileMonitor) Stop() error {
+	dm.mutex.Lock()
+	if !dm.isRunning {
+		dm.mutex.Unlock()
+		return nil
+	}
+	dm.logger.Info("Stopping docker json-file monitor")
+	dm.isRunning = false
+	dm.mutex.Unlock()
+
+	// Enviar linhas parciais e eventos multiline pendentes antes de cancelar o contexto
+	dm.files.flushMultiline(true)
+	dm.files.cancel()
+
+	dm.taskManager.StopTask("docker_json_file_monitor")
+
+	dm.files.mutex.Lock()
+	dm.files.watcher.Close()
+	for _, mf := range dm.files.files {
+		if mf.file != nil {
+			mf.file.Close()
+		}
+	}
+	dm.files.mutex.Unlock()
+
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (dm *DockerJSONFileMonitor) IsHealthy() bool {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	return dm.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (dm *DockerJSONFileMonitor) GetStatus() types.MonitorStatus {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	return types.MonitorStatus{
+		Name:      "docker_json_file_monitor",
+		IsRunning: dm.isRunning,
+		IsHealthy: dm.isRunning,
+	}
+}
+
+// monitorLoop descobre containers periodicamente e acompanha os arquivos descobertos
+func (dm *DockerJSONFileMonitor) monitorLoop(ctx context.Context) error {
+	dm.scanContainers()
+
+	scanTicker := time.NewTicker(dm.config.ScanInterval)
+	defer scanTicker.Stop()
+
+	pollTicker := time.NewTicker(2 * time.Second)
+	defer pollTicker.Stop()
+
+	// Ticker para liberar linhas parciais e eventos multiline de arquivos quietos
+	multilineTicker := time.NewTicker(1 * time.Second)
+	defer multilineTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-dm.files.watcher.Events:
+			dm.files.handleFileEvent(event)
+		case err := <-dm.files.watcher.Errors:
+			dm.logger.WithError(err).Error("Container log watcher error")
+			metrics.RecordError("docker_json_file_monitor", "watcher_error")
+		case <-scanTicker.C:
+			dm.scanContainers()
+		case <-pollTicker.C:
+			dm.files.pollAllFiles()
+		case <-multilineTicker.C:
+			dm.files.flushMultiline(false)
+		}
+
+		dm.taskManager.Heartbeat("docker_json_file_monitor")
+	}
+}
+
+// scanContainers percorre <containers_path>/<id>/<id>-json.log, adiciona os
+// containers novos que passam nos filtros e remove os de containers apagados
+func (dm *DockerJSONFileMonitor) scanContainers() {
+	containerDirs, err := os.ReadDir(dm.config.ContainersPath)
+	if err != nil {
+		dm.logger.WithError(err).WithField("containers_path", dm.config.ContainersPath).Warn("Failed to read docker containers directory")
+		metrics.RecordError("docker_json_file_monitor", "scan_error")
+		return
+	}
+
+	found := make(map[string]bool)
+	for _, containerDir := range containerDirs {
+		if !containerDir.IsDir() {
+			continue
+		}
+
+		id := containerDir.Name()
+		dir := filepath.Join(dm.config.ContainersPath, id)
+		path := filepath.Join(dir, id+"-json.log")
+
+		// Containers com outro logging driver não têm o arquivo json-file
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		found[path] = true
+
+		dm.files.mutex.RLock()
+		_, exists := dm.files.files[path]
+		dm.files.mutex.RUnlock()
+		if exists {
+			continue
+		}
+
+		container, err := readDockerContainerConfig(dir)
+		if err != nil {
+			// Container sendo criado: config.v2.json é escrito logo em seguida
+			dm.logger.WithError(err).WithField("container_id", id).Debug("Failed to read container config")
+			continue
+		}
+
+		dm.addContainerLogFile(path, container)
+	}
+
+	dm.files.removeDeletedFiles(found)
+}
+
+// addContainerLogFile aplica os filtros do container e inicia a leitura do arquivo
+func (dm *DockerJSONFileMonitor) addContainerLogFile(path string, container *dockerContainerConfig) {
+	name := strings.TrimPrefix(container.Name, "/")
+	containerID := container.ID
+	if len(containerID) > 12 {
+		containerID = containerID[:12]
+	}
+
+	if !containerFilterMatches(&dm.config, name, container.Config.Labels) {
+		dm.logger.WithFields(logrus.Fields{
+			"container_id":   containerID,
+			"container_name": name,
+			"image":          container.Config.Image,
+		}).Debug("Container filtered out, skipping monitoring")
+		return
+	}
+
+	multilineConfig := containerMultilineConfig(&dm.config, name, container.Config.Labels)
+	if _, err := newMultilineAggregator(multilineConfig); err != nil {
+		dm.logger.WithError(err).WithField("container_id", containerID).Warn("Invalid multiline config, reading line by line")
+		multilineConfig = nil
+	}
+
+	opts := fileReadOptions{
+		multiline:   multilineConfig,
+		format:      fileFormatDockerJSON,
+		sourceType:  "docker",
+		sourceID:    containerID,
+		skipStreams: dm.skipStreams,
+	}
+	labels := containerLabels(containerID, name, container.Config.Image, container.Config.Labels)
+	if err := dm.files.addFileWithOptions(path, labels, opts); err != nil {
+		dm.logger.WithError(err).WithField("path", path).Warn("Failed to add container log file")
+		metrics.RecordError("docker_json_file_monitor", "add_file_error")
+		return
+	}
+
+	dm.logger.WithFields(logrus.Fields{
+		"path":           path,
+		"container_id":   containerID,
+		"container_name": name,
+		"image":          container.Config.Image,
+	}).Info("Container log file added to monitoring")
+}
@@ -0,0 +1,152 @@
+package monitors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerJSONLine(t *testing.T) {
+	record, err := parseDockerJSONLine(`{"log":"hello world\n","stream":"stderr","time":"2024-05-01T10:00:00.123456789Z"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", record.message)
+	assert.Equal(t, "stderr", record.stream)
+	assert.False(t, record.partial)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC), record.timestamp)
+
+	record, err = parseDockerJSONLine(`{"log":"first 16k chunk","stream":"stdout","time":"2024-05-01T10:00:00Z"}`)
+	require.NoError(t, err)
+	assert.True(t, record.partial)
+
+	for _, line := range []string{
+		"not json",
+		`{"log":"x\n","stream":"stdin","time":"2024-05-01T10:00:00Z"}`,
+		`{"log":"x\n","stream":"stdout","time":"yesterday"}`,
+	} {
+		_, err := parseDockerJSONLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+// writeDockerContainer cria <root>/<id>/config.v2.json e, se logs não for vazio, <id>-json.log
+func writeDockerContainer(t *testing.T, root, id, name, image string, labels map[string]string, logs string) string {
+	t.Helper()
+	dir := filepath.Join(root, id)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	labelsJSON := "{"
+	for k, v := range labels {
+		if len(labelsJSON) > 1 {
+			labelsJSON += ","
+		}
+		labelsJSON += fmt.Sprintf("%q:%q", k, v)
+	}
+	labelsJSON += "}"
+
+	config := fmt.Sprintf(`{"ID":%q,"Name":"/%s","Config":{"Image":%q,"Labels":%s},"State":{"Running":true}}`, id, name, image, labelsJSON)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.v2.json"), []byte(config), 0644))
+
+	logPath := filepath.Join(dir, id+"-json.log")
+	if logs != "" {
+		appendToFile(t, logPath, logs)
+	}
+	return logPath
+}
+
+func newTestDockerJSONFileMonitor(t *testing.T, config types.DockerConfig, dispatcher *recordingDispatcher) *DockerJSONFileMonitor {
+	t.Helper()
+	config.Enabled = true
+	dm, err := NewDockerJSONFileMonitor(config, types.TimestampValidationConfig{}, dispatcher, nil, nil, newTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		dm.files.watcher.Close()
+		for _, mf := range dm.files.files {
+			if mf.file != nil {
+				mf.file.Close()
+			}
+		}
+	})
+	return dm
+}
+
+func TestDockerJSONFileMonitor_ScanContainers(t *testing.T) {
+	root := t.TempDir()
+	webID := "a1b2c3d4e5f6a7b8c9d0"
+	webLog := writeDockerContainer(t, root, webID, "shop-web-1", "nginx:1.25",
+		map[string]string{"com.docker.compose.service": "web", "tier": "frontend"},
+		`{"log":"GET / 200\n","stream":"stdout","time":"2024-05-01T10:00:00Z"}`+"\n"+
+			`{"log":"warning on stderr\n","stream":"stderr","time":"2024-05-01T10:00:00.5Z"}`+"\n"+
+			`{"log":"long line ","stream":"stdout","time":"2024-05-01T10:00:01Z"}`+"\n"+
+			`{"log":"continues here\n","stream":"stdout","time":"2024-05-01T10:00:01.1Z"}`+"\n")
+	writeDockerContainer(t, root, "self0123456789", "log_capturer_go", "capturer:latest", nil,
+		`{"log":"own logs\n","stream":"stdout","time":"2024-05-01T10:00:00Z"}`+"\n")
+	writeDockerContainer(t, root, "debug0123456789", "debug-shell", "busybox", map[string]string{"logs.exclude": "true"},
+		`{"log":"excluded\n","stream":"stdout","time":"2024-05-01T10:00:00Z"}`+"\n")
+	// Container com outro logging driver (sem json-file)
+	writeDockerContainer(t, root, "journald0123456", "other", "busybox", nil, "")
+
+	dispatcher := &recordingDispatcher{}
+	dm := newTestDockerJSONFileMonitor(t, types.DockerConfig{
+		ContainersPath: root,
+		ExcludeNames:   []string{"log_capturer_go"},
+		ExcludeLabels:  map[string]string{"logs.exclude": "true"},
+		IncludeStdout:  true,
+		IncludeStderr:  false,
+	}, dispatcher)
+
+	dm.scanContainers()
+	require.Len(t, dm.files.files, 1)
+
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 2
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []string{"GET / 200", "long line continues here"}, dispatcher.Messages())
+
+	entry := dispatcher.entries[1]
+	assert.Equal(t, "docker", entry.SourceType)
+	assert.Equal(t, "a1b2c3d4e5f6", entry.SourceID)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), entry.Timestamp)
+	assert.Equal(t, "a1b2c3d4e5f6", entry.Labels["container_id"])
+	assert.Equal(t, "shop-web-1", entry.Labels["container_name"])
+	assert.Equal(t, "nginx:1.25", entry.Labels["image"])
+	assert.Equal(t, "web", entry.Labels["compose_service"])
+	assert.Equal(t, "docker", entry.Labels["source"])
+	assert.Equal(t, "stdout", entry.Labels["stream"])
+
+	// Container removido deixa de ser acompanhado
+	require.NoError(t, os.RemoveAll(filepath.Dir(webLog)))
+	dm.scanContainers()
+	assert.Empty(t, dm.files.files)
+}
+
+func TestDockerJSONFileMonitor_MultilineRules(t *testing.T) {
+	root := t.TempDir()
+	writeDockerContainer(t, root, "java0123456789", "java-api", "openjdk", nil,
+		`{"log":"Exception in thread main\n","stream":"stdout","time":"2024-05-01T10:00:00Z"}`+"\n"+
+			`{"log":"\tat Main.run\n","stream":"stdout","time":"2024-05-01T10:00:00Z"}`+"\n"+
+			`{"log":"next event\n","stream":"stdout","time":"2024-05-01T10:00:01Z"}`+"\n")
+
+	dispatcher := &recordingDispatcher{}
+	dm := newTestDockerJSONFileMonitor(t, types.DockerConfig{
+		ContainersPath: root,
+		IncludeStdout:  true,
+		IncludeStderr:  true,
+		MultilineRules: []types.ContainerMultilineRule{{
+			Names:     []string{"java-"},
+			Multiline: types.MultilineConfig{Enabled: true, ContinuationPattern: `^\s`},
+		}},
+	}, dispatcher)
+
+	dm.scanContainers()
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, "Exception in thread main\n\tat Main.run", dispatcher.Messages()[0])
+}
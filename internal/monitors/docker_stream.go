@@ -0,0 +1,272 @@
+package monitors
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/pkg/linelimit"
+)
+
+const (
+	// dockerHeaderSize é o tamanho do header de cada frame do stream multiplexado
+	dockerHeaderSize = 8
+	// dockerMaxMessageBytes limita a remontagem de mensagens parciais quando
+	// max_line_bytes não está configurado
+	dockerMaxMessageBytes = 1024 * 1024
+)
+
+// dockerLogRecord é uma linha completa lida do stream de logs de um container
+type dockerLogRecord struct {
+	stream    string    // stdout ou stderr
+	timestamp time.Time // Timestamp informado pelo Docker (zero se ausente)
+	message   string
+	oversize  linelimit.Oversize // Linha cortada por max_line_bytes (zero se coube)
+}
+
+// pendingDockerMessage acumula fragmentos de uma mensagem parcial (>16KB)
+type pendingDockerMessage struct {
+	timestamp time.Time
+	data      strings.Builder
+	size      int64  // Bytes recebidos da mensagem, inclusive os descartados
+	group     string // Grupo das partes já emitidas (política split)
+	part      int
+}
+
+// dockerStreamDemuxer separa o stream de logs do Docker em linhas por stream.
+//
+// Sem TTY o Docker multiplexa stdout/stderr em frames com header de 8 bytes
+// ([stream, 0, 0, 0, tamanho uint32 big-endian]); com TTY o stream é texto puro.
+// Com Timestamps habilitado cada frame começa com um timestamp RFC3339Nano.
+// Mensagens maiores que 16KB chegam divididas em vários frames sem newline
+// final e são remontadas em uma única linha, limitada por max_line_bytes.
+type dockerStreamDemuxer struct {
+	reader      *bufio.Reader
+	tty         bool
+	timestamps  bool
+	limit       *linelimit.Limiter // nil = remontagem limitada a dockerMaxMessageBytes
+	header      [dockerHeaderSize]byte
+	pending     map[string]*pendingDockerMessage
+	ready       []dockerLogRecord
+	ttyContinue bool // Próximo trecho TTY continua uma linha maior que o buffer (sem timestamp)
+}
+
+// newDockerStreamDemuxer cria um demultiplexador para o stream informado
+func newDockerStreamDemuxer(stream io.Reader, tty, timestamps bool, limit *linelimit.Limiter) *dockerStreamDemuxer {
+	return &dockerStreamDemuxer{
+		reader:     bufio.NewReaderSize(stream, 64*1024),
+		tty:        tty,
+		timestamps: timestamps,
+		limit:      limit,
+		pending:    make(map[string]*pendingDockerMessage),
+	}
+}
+
+// Next retorna a próxima linha completa. No fim do stream as mensagens
+// parciais pendentes são retornadas antes do io.EOF.
+func (d *dockerStreamDemuxer) Next() (dockerLogRecord, error) {
+	for len(d.ready) == 0 {
+		stream, payload, discarded, err := d.readFrame()
+		if err != nil {
+			if err == io.EOF {
+				d.flushPending()
+				if len(d.ready) > 0 {
+					break
+				}
+			}
+			return dockerLogRecord{}, err
+		}
+		d.addPayload(stream, payload, discarded)
+	}
+
+	record := d.ready[0]
+	d.ready = d.ready[1:]
+	return record, nil
+}
+
+// readFrame lê um frame multiplexado ou uma linha do stream TTY. Linhas TTY
+// maiores que o buffer são entregues em trechos para não acumular sem limite.
+// discarded é o número de bytes do frame descartados além de maxFrameBytes.
+func (d *dockerStreamDemuxer) readFrame() (stream string, payload []byte, discarded int64, err error) {
+	if d.tty {
+		line, err := d.reader.ReadSlice('\n')
+		if len(line) > 0 {
+			return "stdout", line, 0, nil
+		}
+		return "", nil, 0, err
+	}
+
+	if _, err := io.ReadFull(d.reader, d.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", nil, 0, fmt.Errorf("truncated docker frame header: %w", err)
+		}
+		return "", nil, 0, err
+	}
+
+	switch d.header[0] {
+	case 0, 1:
+		// stdin (0) não é esperado em logs; tratar como stdout
+		stream = "stdout"
+	case 2:
+		stream = "stderr"
+	default:
+		return "", nil, 0, fmt.Errorf("invalid docker stream type %d", d.header[0])
+	}
+
+	// O tamanho vem do header: um header corrompido não pode forçar uma
+	// alocação arbitrária. O excesso é descartado e a linha termina no corte.
+	size := int64(binary.BigEndian.Uint32(d.header[4:]))
+	limit := d.maxFrameBytes()
+	payload = make([]byte, min(size, limit))
+	if _, err := io.ReadFull(d.reader, payload); err != nil {
+		return "", nil, 0, fmt.Errorf("truncated docker frame payload: %w", err)
+	}
+	if size > limit {
+		discarded = size - limit
+		if _, err := io.CopyN(io.Discard, d.reader, discarded-1); err != nil {
+			return "", nil, 0, fmt.Errorf("truncated docker frame payload: %w", err)
+		}
+		last, err := d.reader.ReadByte()
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("truncated docker frame payload: %w", err)
+		}
+		if last == '\n' {
+			discarded--
+		}
+		payload = append(payload, '\n')
+	}
+
+	return stream, payload, discarded, nil
+}
+
+// maxFrameBytes é o maior payload de frame aceito: dockerMaxMessageBytes ou
+// max_line_bytes (com folga para o timestamp), o que for maior
+func (d *dockerStreamDemuxer) maxFrameBytes() int64 {
+	limit := int64(dockerMaxMessageBytes)
+	if d.limit != nil {
+		limit = max(limit, int64(d.limit.MaxBytes())+64)
+	}
+	return limit
+}
+
+// addPayload remove o timestamp do frame e acumula o conteúdo até o newline.
+// Os bytes descartados por readFrame contam no tamanho da última linha do frame.
+func (d *dockerStreamDemuxer) addPayload(stream string, payload []byte, discarded int64) {
+	content := string(payload)
+	continued := d.ttyContinue
+	if d.tty {
+		d.ttyContinue = !strings.HasSuffix(content, "\n")
+	}
+
+	var timestamp time.Time
+	if d.timestamps && !continued {
+		if spaceIdx := strings.IndexByte(content, ' '); spaceIdx > 0 {
+			if parsed, err := time.Parse(time.RFC3339Nano, content[:spaceIdx]); err == nil {
+				timestamp = parsed
+				content = content[spaceIdx+1:]
+			}
+		}
+	}
+
+	for content != "" {
+		pending := d.pending[stream]
+		if pending == nil {
+			pending = &pendingDockerMessage{timestamp: timestamp}
+			d.pending[stream] = pending
+		}
+
+		newlineIdx := strings.IndexByte(content, '\n')
+		if newlineIdx < 0 {
+			// Fragmento de mensagem parcial: aguardar o restante
+			d.appendPending(stream, pending, content, 0)
+			if d.limit == nil && pending.data.Len() >= dockerMaxMessageBytes {
+				d.emit(stream)
+			}
+			return
+		}
+
+		rest := content[newlineIdx+1:]
+		var dropped int64
+		if rest == "" {
+			dropped = discarded
+		}
+		d.appendPending(stream, pending, content[:newlineIdx], dropped)
+		d.emit(stream)
+		content = rest
+	}
+}
+
+// appendPending acumula texto na mensagem pendente respeitando max_line_bytes:
+// na política split as partes cheias vão para a fila de prontas; nas demais o
+// excesso é descartado e apenas contabilizado em size. discarded são bytes da
+// linha que nem chegaram a ser lidos do frame.
+func (d *dockerStreamDemuxer) appendPending(stream string, pending *pendingDockerMessage, text string, discarded int64) {
+	pending.size += int64(len(text)) + discarded
+	if d.limit == nil {
+		pending.data.WriteString(text)
+		return
+	}
+	maxBytes := d.limit.MaxBytes()
+
+	if d.limit.Policy() != linelimit.PolicySplit {
+		if room := maxBytes - pending.data.Len(); room > 0 {
+			pending.data.WriteString(text[:linelimit.CutIndex(text, room)])
+		}
+		return
+	}
+
+	pending.data.WriteString(text)
+	for pending.data.Len() > maxBytes {
+		data := pending.data.String()
+		cut := linelimit.CutIndex(data, maxBytes)
+		if cut == 0 {
+			cut = maxBytes
+		}
+		if pending.group == "" {
+			pending.group = linelimit.NewGroup()
+		}
+		pending.part++
+		d.ready = append(d.ready, dockerLogRecord{
+			stream:    stream,
+			timestamp: pending.timestamp,
+			message:   data[:cut],
+			oversize:  linelimit.Oversize{Group: pending.group, Part: pending.part},
+		})
+		pending.data.Reset()
+		pending.data.WriteString(data[cut:])
+	}
+}
+
+// emit move a mensagem pendente do stream para a fila de linhas prontas
+func (d *dockerStreamDemuxer) emit(stream string) {
+	pending := d.pending[stream]
+	if pending == nil {
+		return
+	}
+	delete(d.pending, stream)
+
+	record := dockerLogRecord{
+		stream:    stream,
+		timestamp: pending.timestamp,
+		message:   strings.TrimSuffix(pending.data.String(), "\r"),
+	}
+	switch {
+	case pending.group != "":
+		record.oversize = linelimit.Oversize{Group: pending.group, Part: pending.part + 1, Final: true}
+	case pending.size > int64(pending.data.Len()):
+		record.oversize = linelimit.Oversize{OriginalBytes: pending.size}
+	}
+	d.ready = append(d.ready, record)
+}
+
+// flushPending emite as mensagens parciais restantes no fim do stream
+func (d *dockerStreamDemuxer) flushPending() {
+	for _, stream := range []string{"stdout", "stderr"} {
+		if pending := d.pending[stream]; pending != nil && pending.data.Len() > 0 {
+			d.emit(stream)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package monitors
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"ssw-logs-capture/pkg/linelimit"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dockerFrame monta um frame do stream multiplexado do Docker
+func dockerFrame(stream byte, payload string) []byte {
+	header := make([]byte, dockerHeaderSize)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func readAllRecords(t *testing.T, demuxer *dockerStreamDemuxer) []dockerLogRecord {
+	t.Helper()
+	var records []dockerLogRecord
+	for {
+		record, err := demuxer.Next()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestDockerStreamDemuxer_Multiplexed(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:00.123456789Z hello stdout\n"))
+	buf.Write(dockerFrame(2, "2024-05-01T10:00:01.5Z oops\r\n"))
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:02Z line a\nline b\n"))
+
+	// Leitura byte a byte: frames divididos entre leituras não podem corromper o stream
+	demuxer := newDockerStreamDemuxer(iotest.OneByteReader(&buf), false, true, nil)
+	records := readAllRecords(t, demuxer)
+	require.Len(t, records, 4)
+
+	assert.Equal(t, "stdout", records[0].stream)
+	assert.Equal(t, "hello stdout", records[0].message)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC), records[0].timestamp)
+
+	assert.Equal(t, "stderr", records[1].stream)
+	assert.Equal(t, "oops", records[1].message)
+
+	assert.Equal(t, "line a", records[2].message)
+	assert.Equal(t, "line b", records[3].message)
+	assert.Equal(t, records[2].timestamp, records[3].timestamp)
+}
+
+func TestDockerStreamDemuxer_PartialMessages(t *testing.T) {
+	chunk := strings.Repeat("x", 16*1024)
+
+	var buf bytes.Buffer
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:00Z "+chunk))
+	buf.Write(dockerFrame(2, "2024-05-01T10:00:00.5Z interleaved stderr\n"))
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:01Z "+chunk))
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:02Z end\n"))
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:03Z unterminated"))
+
+	records := readAllRecords(t, newDockerStreamDemuxer(&buf, false, true, nil))
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "stderr", records[0].stream)
+	assert.Equal(t, "interleaved stderr", records[0].message)
+
+	assert.Equal(t, "stdout", records[1].stream)
+	assert.Equal(t, chunk+chunk+"end", records[1].message)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), records[1].timestamp, "partial message keeps the first fragment timestamp")
+
+	assert.Equal(t, "unterminated", records[2].message)
+}
+
+func TestDockerStreamDemuxer_TTY(t *testing.T) {
+	stream := strings.NewReader("2024-05-01T10:00:00Z first\n2024-05-01T10:00:01Z second")
+
+	records := readAllRecords(t, newDockerStreamDemuxer(stream, true, true, nil))
+	require.Len(t, records, 2)
+	assert.Equal(t, "stdout", records[0].stream)
+	assert.Equal(t, "first", records[0].message)
+	assert.Equal(t, "second", records[1].message)
+}
+
+func TestDockerStreamDemuxer_TTYLongLine(t *testing.T) {
+	// Linhas maiores que o buffer chegam em trechos; só o primeiro tem timestamp
+	long := strings.Repeat("z", 70*1024)
+	stream := strings.NewReader("2024-05-01T10:00:00Z " + long + "\n")
+
+	records := readAllRecords(t, newDockerStreamDemuxer(stream, true, true, nil))
+	require.Len(t, records, 1)
+	assert.Equal(t, long, records[0].message)
+	assert.True(t, records[0].oversize.IsZero())
+}
+
+func TestDockerStreamDemuxer_LineLimit(t *testing.T) {
+	frames := func() *bytes.Buffer {
+		var buf bytes.Buffer
+		buf.Write(dockerFrame(1, "2024-05-01T10:00:00Z "+strings.Repeat("a", 16)))
+		buf.Write(dockerFrame(1, "2024-05-01T10:00:00Z "+strings.Repeat("a", 16)+"\n"))
+		buf.Write(dockerFrame(1, "2024-05-01T10:00:01Z fits\n"))
+		return &buf
+	}
+
+	truncate, err := linelimit.New(types.LineLimitConfig{MaxLineBytes: 10}, "container_monitor", nil)
+	require.NoError(t, err)
+	records := readAllRecords(t, newDockerStreamDemuxer(frames(), false, true, truncate))
+	require.Len(t, records, 2)
+	assert.Equal(t, strings.Repeat("a", 10), records[0].message)
+	assert.Equal(t, linelimit.Oversize{OriginalBytes: 32}, records[0].oversize)
+	assert.True(t, records[1].oversize.IsZero())
+
+	split, err := linelimit.New(types.LineLimitConfig{MaxLineBytes: 10, Policy: linelimit.PolicySplit}, "container_monitor", nil)
+	require.NoError(t, err)
+	records = readAllRecords(t, newDockerStreamDemuxer(frames(), false, true, split))
+	require.Len(t, records, 5)
+	for i, record := range records[:4] {
+		assert.Equal(t, records[0].oversize.Group, record.oversize.Group)
+		assert.Equal(t, i+1, record.oversize.Part)
+	}
+	assert.Equal(t, "aa", records[3].message)
+	assert.True(t, records[3].oversize.Final)
+	assert.Equal(t, "fits", records[4].message)
+}
+
+func TestDockerStreamDemuxer_InvalidFrame(t *testing.T) {
+	demuxer := newDockerStreamDemuxer(bytes.NewReader([]byte{9, 0, 0, 0, 0, 0, 0, 1, 'x'}), false, true, nil)
+	_, err := demuxer.Next()
+	assert.Error(t, err)
+
+	demuxer = newDockerStreamDemuxer(bytes.NewReader(dockerFrame(1, "short")[:10]), false, true, nil)
+	_, err = demuxer.Next()
+	assert.Error(t, err)
+}
+
+func TestDockerStreamDemuxer_OversizedFrameHeader(t *testing.T) {
+	// Header anunciando 4GB sem o payload correspondente: erro, sem alocar o tamanho do header
+	header := []byte{1, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}
+	demuxer := newDockerStreamDemuxer(bytes.NewReader(append(header, "tiny"...)), false, false, nil)
+	_, err := demuxer.Next()
+	assert.Error(t, err)
+
+	// Frame maior que o limite: o excesso é descartado e o frame seguinte continua íntegro
+	var buf bytes.Buffer
+	buf.Write(dockerFrame(1, strings.Repeat("x", dockerMaxMessageBytes+100)+"\n"))
+	buf.Write(dockerFrame(2, "next\n"))
+	records := readAllRecords(t, newDockerStreamDemuxer(&buf, false, false, nil))
+	require.Len(t, records, 2)
+	assert.Len(t, records[0].message, dockerMaxMessageBytes)
+	assert.Equal(t, int64(dockerMaxMessageBytes+100), records[0].oversize.OriginalBytes)
+	assert.Equal(t, "next", records[1].message)
+	assert.Equal(t, "stderr", records[1].stream)
+
+	// Com max_line_bytes o tamanho original inclui os bytes descartados do frame
+	truncate, err := linelimit.New(types.LineLimitConfig{MaxLineBytes: 10}, "container_monitor", nil)
+	require.NoError(t, err)
+	buf.Reset()
+	buf.Write(dockerFrame(1, strings.Repeat("y", dockerMaxMessageBytes+500)+"\n"))
+	records = readAllRecords(t, newDockerStreamDemuxer(&buf, false, false, truncate))
+	require.Len(t, records, 1)
+	assert.Equal(t, strings.Repeat("y", 10), records[0].message)
+	assert.Equal(t, int64(dockerMaxMessageBytes+500), records[0].oversize.OriginalBytes)
+}
+
+func TestContainerMonitor_ReadContainerLogsStreams(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:00Z to stdout\n"))
+	buf.Write(dockerFrame(2, "2024-05-01T10:00:01Z to stderr\n"))
+
+	dispatcher := &recordingDispatcher{}
+	cm := &ContainerMonitor{
+		config:     types.DockerConfig{IncludeStdout: false, IncludeStderr: true},
+		dispatcher: dispatcher,
+		logger:     newTestLogger(),
+	}
+	mc := &monitoredContainer{id: "abc123", name: "api", labels: map[string]string{"container_name": "api"}}
+
+	require.NoError(t, cm.readContainerLogs(context.Background(), mc, &buf))
+
+	require.Equal(t, []string{"to stderr"}, dispatcher.Messages())
+	entry := dispatcher.entries[0]
+	assert.Equal(t, "stderr", entry.Labels["stream"])
+	assert.Equal(t, "docker", entry.SourceType)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), entry.Timestamp)
+	assert.Equal(t, entry.Timestamp, mc.lastTimestamp)
+}
+
+func TestContainerMonitor_ReadContainerLogsMultilinePerStream(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:00Z ERROR failed\n"))
+	buf.Write(dockerFrame(2, "2024-05-01T10:00:00Z stderr line\n"))
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:01Z \tat Main.main\n"))
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:02Z INFO next\n"))
+
+	dispatcher := &recordingDispatcher{}
+	cm := &ContainerMonitor{
+		config:     types.DockerConfig{IncludeStdout: true, IncludeStderr: true},
+		dispatcher: dispatcher,
+		logger:     newTestLogger(),
+	}
+	mc := &monitoredContainer{
+		id:              "abc123",
+		labels:          map[string]string{},
+		multilineConfig: &types.MultilineConfig{Enabled: true, ContinuationPattern: `^\s`},
+	}
+
+	require.NoError(t, cm.readContainerLogs(context.Background(), mc, &buf))
+
+	messages := dispatcher.Messages()
+	assert.ElementsMatch(t, []string{"ERROR failed\n\tat Main.main", "stderr line", "INFO next"}, messages)
+	for _, entry := range dispatcher.entries {
+		if entry.Message == "ERROR failed\n\tat Main.main" {
+			assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), entry.Timestamp)
+			assert.Equal(t, "stdout", entry.Labels["stream"])
+		}
+	}
+}
+
+// ctxCheckingDispatcher rejeita entradas com context cancelado, como o dispatcher real
+type ctxCheckingDispatcher struct {
+	recordingDispatcher
+}
+
+func (d *ctxCheckingDispatcher) HandleEntry(ctx context.Context, entry *types.LogEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.recordingDispatcher.HandleEntry(ctx, entry)
+}
+
+func TestContainerMonitor_ReadContainerLogsFlushesOnCancel(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	go func() {
+		writer.Write(dockerFrame(1, "2024-05-01T10:00:00Z ERROR failed\n"))
+		writer.Write(dockerFrame(1, "2024-05-01T10:00:01Z \tat Main.main\n"))
+		writer.Write(dockerFrame(1, "2024-05-01T10:00:02Z INFO last\n"))
+	}()
+
+	dispatcher := &ctxCheckingDispatcher{}
+	cm := &ContainerMonitor{
+		config:     types.DockerConfig{IncludeStdout: true, IncludeStderr: true},
+		dispatcher: dispatcher,
+		logger:     newTestLogger(),
+		ctx:        context.Background(),
+	}
+	mc := &monitoredContainer{
+		id:              "abc123",
+		labels:          map[string]string{},
+		multilineConfig: &types.MultilineConfig{Enabled: true, ContinuationPattern: `^\s`, FlushTimeout: "1h"},
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- cm.readContainerLogs(streamCtx, mc, reader) }()
+
+	// O primeiro evento sai quando "INFO last" chega; "INFO last" fica pendente
+	require.Eventually(t, func() bool { return len(dispatcher.Messages()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	assert.Equal(t, []string{"ERROR failed\n\tat Main.main", "INFO last"}, dispatcher.Messages())
+}
+
+func TestContainerMonitor_ReadContainerLogsLineLimit(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(dockerFrame(1, "2024-05-01T10:00:00Z "+strings.Repeat("x", 64)+"\n"))
+
+	lineLimit, err := linelimit.New(types.LineLimitConfig{MaxLineBytes: 16}, "container_monitor", nil)
+	require.NoError(t, err)
+	dispatcher := &recordingDispatcher{}
+	cm := &ContainerMonitor{
+		config:     types.DockerConfig{IncludeStdout: true, IncludeStderr: true},
+		dispatcher: dispatcher,
+		logger:     newTestLogger(),
+		lineLimit:  lineLimit,
+	}
+	mc := &monitoredContainer{id: "abc123", labels: map[string]string{}}
+
+	require.NoError(t, cm.readContainerLogs(context.Background(), mc, &buf))
+
+	require.Equal(t, []string{strings.Repeat("x", 16)}, dispatcher.Messages())
+	assert.Equal(t, "true", dispatcher.entries[0].Labels[linelimit.LabelTruncated])
+	assert.Equal(t, int64(64), dispatcher.entries[0].Fields[linelimit.FieldOriginalBytes])
+}
@@ -0,0 +1,569 @@
+package monitors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/types"
+	"ssw-logs-capture/pkg/validation"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Políticas de reinício dos comandos
+const (
+	ExecRestartAlways    = "always"
+	ExecRestartOnFailure = "on-failure"
+	ExecRestartNever     = "never"
+)
+
+const (
+	// execStopTimeout é o tempo entre o SIGTERM e o SIGKILL ao parar um comando
+	execStopTimeout = 5 * time.Second
+	// execHeartbeatInterval mantém a task do supervisor viva no task manager
+	execHeartbeatInterval = 30 * time.Second
+)
+
+// ExecMonitor executa os comandos configurados e captura stdout/stderr linha a
+// linha; no modo stdin lê a entrada padrão do próprio processo
+type ExecMonitor struct {
+	config             types.ExecMonitorConfig
+	dispatcher         types.Dispatcher
+	logger             *logrus.Logger
+	taskManager        types.TaskManager
+	timestampValidator *validation.TimestampValidator
+
+	commands []*execCommand
+	stdin    io.Reader
+	wg       sync.WaitGroup
+	mutex    sync.RWMutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// execCommand é um comando supervisionado com seu estado de execução
+type execCommand struct {
+	config          types.ExecCommandConfig
+	name            string
+	taskID          string
+	restartDelay    time.Duration
+	maxRestartDelay time.Duration
+
+	// Protegidos por ExecMonitor.mutex
+	failures int64
+	lastErr  string
+}
+
+// NewExecMonitor cria um novo monitor de comandos e stdin
+func NewExecMonitor(config types.ExecMonitorConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, logger *logrus.Logger) (*ExecMonitor, error) {
+	// Converter config para o formato do validation package
+	validationConfig := validation.Config{
+		Enabled:             timestampConfig.Enabled,
+		MaxPastAgeSeconds:   timestampConfig.MaxPastAgeSeconds,
+		MaxFutureAgeSeconds: timestampConfig.MaxFutureAgeSeconds,
+		ClampEnabled:        timestampConfig.ClampEnabled,
+		ClampDLQ:            timestampConfig.ClampDLQ,
+		InvalidAction:       timestampConfig.InvalidAction,
+		DefaultTimezone:     timestampConfig.DefaultTimezone,
+		AcceptedFormats:     timestampConfig.AcceptedFormats,
+	}
+
+	if config.MaxLineSize <= 0 {
+		config.MaxLineSize = 1024 * 1024
+	}
+
+	em := &ExecMonitor{
+		config:             config,
+		dispatcher:         dispatcher,
+		logger:             logger,
+		taskManager:        taskManager,
+		timestampValidator: validation.NewTimestampValidator(validationConfig, logger, nil),
+		stdin:              os.Stdin,
+	}
+
+	if !config.Enabled {
+		return em, nil
+	}
+
+	if len(config.Commands) == 0 && !config.Stdin {
+		return nil, fmt.Errorf("exec monitor enabled without commands or stdin")
+	}
+
+	names := make(map[string]bool, len(config.Commands))
+	for i, commandConfig := range config.Commands {
+		cmd, err := newExecCommand(commandConfig)
+		if err != nil {
+			return nil, fmt.Errorf("exec command %d: %w", i, err)
+		}
+		if names[cmd.name] {
+			return nil, fmt.Errorf("exec command %d: duplicate name %q", i, cmd.name)
+		}
+		names[cmd.name] = true
+		em.commands = append(em.commands, cmd)
+	}
+
+	return em, nil
+}
+
+// newExecCommand valida a configuração do comando e aplica os padrões
+func newExecCommand(config types.ExecCommandConfig) (*execCommand, error) {
+	if config.Command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	cmd := &execCommand{
+		config:          config,
+		name:            config.Name,
+		restartDelay:    time.Second,
+		maxRestartDelay: time.Minute,
+	}
+	if cmd.name == "" {
+		cmd.name = filepath.Base(config.Command)
+	}
+	cmd.taskID = "exec_monitor_" + cmd.name
+
+	switch config.RestartPolicy {
+	case "":
+		cmd.config.RestartPolicy = ExecRestartOnFailure
+	case ExecRestartAlways, ExecRestartOnFailure, ExecRestartNever:
+	default:
+		return nil, fmt.Errorf("invalid restart_policy %q (use always, on-failure or never)", config.RestartPolicy)
+	}
+
+	if config.RestartDelay != "" {
+		delay, err := time.ParseDuration(config.RestartDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid restart_delay: %w", err)
+		}
+		cmd.restartDelay = delay
+	}
+	if config.MaxRestartDelay != "" {
+		delay, err := time.ParseDuration(config.MaxRestartDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_restart_delay: %w", err)
+		}
+		cmd.maxRestartDelay = delay
+	}
+	if cmd.maxRestartDelay < cmd.restartDelay {
+		cmd.maxRestartDelay = cmd.restartDelay
+	}
+
+	return cmd, nil
+}
+
+// Start inicia a supervisão dos comandos e a leitura do stdin
+func (em *ExecMonitor) Start(ctx context.Context) error {
+	if !em.config.Enabled {
+		em.logger.Info("Exec monitor disabled")
+		return nil
+	}
+
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+
+	if em.isRunning {
+		return fmt.Errorf("exec monitor already running")
+	}
+
+	em.ctx, em.cancel = context.WithCancel(ctx)
+
+	for _, cmd := range em.commands {
+		if err := em.startTask(cmd.taskID, func(taskCtx context.Context) error {
+			return em.supervise(taskCtx, cmd)
+		}); err != nil {
+			em.cancel()
+			return fmt.Errorf("failed to start exec command %s: %w", cmd.name, err)
+		}
+	}
+
+	if em.config.Stdin {
+		if err := em.startTask("exec_monitor_stdin", em.readStdin); err != nil {
+			em.cancel()
+			return fmt.Errorf("failed to start stdin reader: %w", err)
+		}
+	}
+
+	em.isRunning = true
+	em.logger.WithFields(logrus.Fields{
+		"commands": len(em.commands),
+		"stdin":    em.config.Stdin,
+	}).Info("Exec monitor started")
+
+	return nil
+}
+
+// startTask executa fn pelo task manager ou, sem ele, numa goroutine própria
+func (em *ExecMonitor) startTask(taskID string, fn func(context.Context) error) error {
+	em.wg.Add(1)
+	run := func(ctx context.Context) error {
+		defer em.wg.Done()
+		return fn(ctx)
+	}
+
+	if em.taskManager == nil {
+		go run(em.ctx)
+		return nil
+	}
+	if err := em.taskManager.StartTask(em.ctx, taskID, run); err != nil {
+		em.wg.Done()
+		return err
+	}
+	return nil
+}
+
+// Stop encerra os comandos (SIGTERM, depois SIGKILL) e aguarda os supervisores
+func (em *ExecMonitor) Stop() error {
+	em.mutex.Lock()
+	if !em.isRunning {
+		em.mutex.Unlock()
+		return nil
+	}
+	em.logger.Info("Stopping exec monitor")
+	em.isRunning = false
+	em.cancel()
+	em.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		em.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(execStopTimeout + 5*time.Second):
+		em.logger.Warn("Timeout waiting for exec commands to stop")
+	}
+
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (em *ExecMonitor) IsHealthy() bool {
+	em.mutex.RLock()
+	defer em.mutex.RUnlock()
+	return em.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (em *ExecMonitor) GetStatus() types.MonitorStatus {
+	em.mutex.RLock()
+	defer em.mutex.RUnlock()
+
+	status := types.MonitorStatus{
+		Name:      "exec_monitor",
+		IsRunning: em.isRunning,
+		IsHealthy: em.isRunning,
+	}
+	for _, cmd := range em.commands {
+		status.ErrorCount += cmd.failures
+		if cmd.lastErr != "" {
+			status.LastError = fmt.Sprintf("%s: %s", cmd.name, cmd.lastErr)
+		}
+	}
+	return status
+}
+
+// waitWithHeartbeat aguarda done ou o cancelamento de ctx, sinalizando ao task
+// manager que a task continua ativa. Retorna false quando ctx foi cancelado.
+func (em *ExecMonitor) waitWithHeartbeat(ctx context.Context, taskID string, done <-chan struct{}) bool {
+	ticker := time.NewTicker(execHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-done:
+			return true
+		case <-ticker.C:
+			if em.taskManager != nil {
+				em.taskManager.Heartbeat(taskID)
+			}
+		}
+	}
+}
+
+// supervise executa o comando e o reinicia conforme a política, com atraso
+// exponencial entre reinícios consecutivos
+func (em *ExecMonitor) supervise(ctx context.Context, cmd *execCommand) error {
+	delay := cmd.restartDelay
+	restarts := 0
+
+	for {
+		started := time.Now()
+		err := em.runCommand(ctx, cmd)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		em.mutex.Lock()
+		cmd.lastErr = ""
+		if err != nil {
+			cmd.failures++
+			cmd.lastErr = err.Error()
+		}
+		em.mutex.Unlock()
+
+		fields := logrus.Fields{"command": cmd.name, "duration": time.Since(started)}
+		if err != nil {
+			metrics.RecordError("exec_monitor", "command_failed")
+			em.logger.WithError(err).WithFields(fields).Warn("Exec command failed")
+		} else {
+			em.logger.WithFields(fields).Info("Exec command exited")
+		}
+
+		policy := cmd.config.RestartPolicy
+		if policy == ExecRestartNever || (policy == ExecRestartOnFailure && err == nil) {
+			return err
+		}
+
+		// Um comando que rodou por mais que o atraso máximo volta ao atraso inicial
+		if time.Since(started) >= cmd.maxRestartDelay {
+			delay = cmd.restartDelay
+			restarts = 0
+		}
+		if cmd.config.MaxRestarts > 0 && restarts >= cmd.config.MaxRestarts {
+			em.logger.WithField("command", cmd.name).Error("Exec command reached max_restarts; giving up")
+			if err == nil {
+				return fmt.Errorf("exec command %s gave up after %d restarts", cmd.name, restarts)
+			}
+			return fmt.Errorf("exec command %s gave up after %d restarts: %w", cmd.name, restarts, err)
+		}
+		restarts++
+
+		em.logger.WithFields(logrus.Fields{"command": cmd.name, "delay": delay, "restarts": restarts}).Info("Restarting exec command")
+		delayCtx, cancel := context.WithTimeout(ctx, delay)
+		em.waitWithHeartbeat(ctx, cmd.taskID, delayCtx.Done())
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		delay *= 2
+		if delay > cmd.maxRestartDelay {
+			delay = cmd.maxRestartDelay
+		}
+	}
+}
+
+// runCommand executa o comando uma vez, enviando cada linha de stdout/stderr
+// ao dispatcher, e retorna quando o processo termina
+func (em *ExecMonitor) runCommand(ctx context.Context, cmd *execCommand) error {
+	process := exec.CommandContext(ctx, cmd.config.Command, cmd.config.Args...)
+	process.Dir = cmd.config.WorkDir
+	process.Env = execEnv(cmd.config.Env)
+	process.Cancel = func() error {
+		return process.Process.Signal(syscall.SIGTERM)
+	}
+	process.WaitDelay = execStopTimeout
+
+	// process.Process é definido antes do início das goroutines de cópia da saída
+	newWriter := func(stream string) *execLineWriter {
+		return &execLineWriter{maxLineSize: em.config.MaxLineSize, emit: func(line []byte) {
+			em.handleLine(cmd, stream, process.Process.Pid, line)
+		}}
+	}
+	stdout, stderr := newWriter("stdout"), newWriter("stderr")
+	process.Stdout = stdout
+	process.Stderr = stderr
+
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("failed to start: %w", err)
+	}
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		err = process.Wait()
+		close(done)
+	}()
+
+	// Com ctx cancelado o processo recebe SIGTERM; ainda assim aguarda o Wait
+	em.waitWithHeartbeat(ctx, cmd.taskID, done)
+	<-done
+	stdout.Flush()
+	stderr.Flush()
+	return err
+}
+
+// execEnv acrescenta as variáveis configuradas ao ambiente do processo
+func execEnv(extra map[string]string) []string {
+	env := os.Environ()
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+extra[key])
+	}
+	return env
+}
+
+// readStdin lê linhas da entrada padrão até EOF
+func (em *ExecMonitor) readStdin(ctx context.Context) error {
+	writer := &execLineWriter{maxLineSize: em.config.MaxLineSize, emit: func(line []byte) {
+		em.handleLine(nil, "stdin", 0, line)
+	}}
+
+	// A leitura do stdin não pode ser interrompida: ao parar, a goroutine de
+	// leitura descarta o que chegar e termina no EOF ou com o processo
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, readErr := em.stdin.Read(buf)
+			if ctx.Err() != nil {
+				return
+			}
+			if n > 0 {
+				writer.Write(buf[:n])
+			}
+			if readErr != nil {
+				writer.Flush()
+				if !errors.Is(readErr, io.EOF) {
+					metrics.RecordError("exec_monitor", "read_error")
+					err = fmt.Errorf("failed to read stdin: %w", readErr)
+				}
+				return
+			}
+		}
+	}()
+
+	if !em.waitWithHeartbeat(ctx, "exec_monitor_stdin", done) {
+		return nil
+	}
+	if err == nil {
+		em.logger.Info("Stdin closed; stdin input finished")
+	}
+	return err
+}
+
+// handleLine converte a linha em LogEntry e envia ao dispatcher. cmd é nil
+// para linhas do stdin.
+func (em *ExecMonitor) handleLine(cmd *execCommand, stream string, pid int, line []byte) {
+	entry := em.buildEntry(cmd, stream, pid, string(line))
+
+	// Validar timestamp se o timestamp validator estiver disponível
+	if em.timestampValidator != nil {
+		result := em.timestampValidator.ValidateTimestamp(entry)
+		if !result.Valid && result.Action == "rejected" {
+			em.logger.WithFields(logrus.Fields{
+				"command": entry.Labels["command"],
+				"reason":  result.Reason,
+			}).Warn("Exec line rejected due to invalid timestamp")
+			return
+		}
+	}
+
+	if err := em.dispatcher.HandleEntry(em.ctx, entry); err != nil {
+		em.logger.WithError(err).WithField("command", entry.Labels["command"]).Error("Failed to dispatch exec line")
+		metrics.RecordError("exec_monitor", "dispatch_error")
+		return
+	}
+
+	metrics.RecordLogProcessed(entry.SourceType, entry.SourceID, "exec_monitor")
+}
+
+// buildEntry cria a entrada com as labels command e stream; o pid vira field
+func (em *ExecMonitor) buildEntry(cmd *execCommand, stream string, pid int, line string) *types.LogEntry {
+	labels := make(map[string]string, len(em.config.Labels)+4)
+	for k, v := range em.config.Labels {
+		labels[k] = v
+	}
+
+	sourceType := "stdin"
+	name := "stdin"
+	fields := make(map[string]interface{}, 1)
+	if cmd != nil {
+		for k, v := range cmd.config.Labels {
+			labels[k] = v
+		}
+		sourceType = "exec"
+		name = cmd.name
+		fields["pid"] = pid
+	}
+	labels["source"] = sourceType
+	labels["command"] = name
+	labels["stream"] = stream
+
+	now := time.Now()
+	return &types.LogEntry{
+		TraceID:     uuid.New().String(),
+		Timestamp:   now,
+		Message:     line,
+		Level:       "info",
+		SourceType:  sourceType,
+		SourceID:    name,
+		Labels:      labels,
+		Fields:      fields,
+		ProcessedAt: now,
+	}
+}
+
+// execLineWriter divide a saída em linhas e chama emit para cada uma; linhas
+// maiores que maxLineSize são truncadas
+type execLineWriter struct {
+	maxLineSize int
+	emit        func(line []byte)
+	buf         []byte
+	truncating  bool
+}
+
+// Write acumula os bytes recebidos e emite as linhas completas
+func (w *execLineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		chunk := p
+		if i >= 0 {
+			chunk = p[:i]
+		}
+		if !w.truncating {
+			if room := w.maxLineSize - len(w.buf); len(chunk) > room {
+				w.buf = append(w.buf, chunk[:room]...)
+				w.truncating = true
+			} else {
+				w.buf = append(w.buf, chunk...)
+			}
+		}
+		if i < 0 {
+			break
+		}
+		w.emitLine()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Flush emite a última linha sem quebra final
+func (w *execLineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emitLine()
+	}
+}
+
+func (w *execLineWriter) emitLine() {
+	line := bytes.TrimSuffix(w.buf, []byte("\r"))
+	if len(line) > 0 {
+		w.emit(line)
+	}
+	w.buf = w.buf[:0]
+	w.truncating = false
+}
@@ -0,0 +1,136 @@
+package monitors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExecMonitor(t *testing.T, config types.ExecMonitorConfig, stdin string) (*ExecMonitor, *recordingDispatcher) {
+	t.Helper()
+	config.Enabled = true
+	dispatcher := &recordingDispatcher{}
+	em, err := NewExecMonitor(config, types.TimestampValidationConfig{}, dispatcher, nil, newTestLogger())
+	require.NoError(t, err)
+	em.stdin = strings.NewReader(stdin)
+	require.NoError(t, em.Start(t.Context()))
+	t.Cleanup(func() { em.Stop() })
+	return em, dispatcher
+}
+
+func TestExecMonitor_CapturesStdoutAndStderr(t *testing.T) {
+	dir := t.TempDir()
+	_, dispatcher := newTestExecMonitor(t, types.ExecMonitorConfig{
+		Labels: map[string]string{"env": "test"},
+		Commands: []types.ExecCommandConfig{{
+			Name:          "job",
+			Command:       "sh",
+			Args:          []string{"-c", `echo "out $GREETING"; echo "err line" >&2; pwd; printf 'no newline'`},
+			Env:           map[string]string{"GREETING": "hello"},
+			WorkDir:       dir,
+			RestartPolicy: ExecRestartNever,
+			Labels:        map[string]string{"team": "batch"},
+		}},
+	}, "")
+
+	waitForMessages(t, dispatcher, 4)
+	messages := dispatcher.Messages()
+	assert.ElementsMatch(t, []string{"out hello", "err line", dir, "no newline"}, messages)
+
+	for _, entry := range dispatcher.entries {
+		assert.Equal(t, "exec", entry.SourceType)
+		assert.Equal(t, "job", entry.SourceID)
+		assert.Equal(t, "job", entry.Labels["command"])
+		assert.Equal(t, "test", entry.Labels["env"])
+		assert.Equal(t, "batch", entry.Labels["team"])
+		assert.NotZero(t, entry.Fields["pid"])
+		if entry.Message == "err line" {
+			assert.Equal(t, "stderr", entry.Labels["stream"])
+		} else {
+			assert.Equal(t, "stdout", entry.Labels["stream"])
+		}
+	}
+}
+
+func TestExecMonitor_RestartPolicy(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	script := `echo run >> "$COUNTER"; echo started; exit 3`
+
+	em, dispatcher := newTestExecMonitor(t, types.ExecMonitorConfig{
+		Commands: []types.ExecCommandConfig{{
+			Name:            "flaky",
+			Command:         "sh",
+			Args:            []string{"-c", script},
+			Env:             map[string]string{"COUNTER": counter},
+			RestartDelay:    "10ms",
+			MaxRestartDelay: "40ms",
+			MaxRestarts:     2,
+		}},
+	}, "")
+
+	// Uma execução inicial e dois reinícios, depois desiste
+	waitForMessages(t, dispatcher, 3)
+	require.Eventually(t, func() bool {
+		return em.GetStatus().ErrorCount == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(runs), "run"))
+	assert.Contains(t, em.GetStatus().LastError, "exit status 3")
+
+	// on-failure não reinicia comandos que terminam com sucesso
+	_, dispatcher = newTestExecMonitor(t, types.ExecMonitorConfig{
+		Commands: []types.ExecCommandConfig{{Command: "echo", Args: []string{"once"}, RestartDelay: "10ms"}},
+	}, "")
+	waitForMessages(t, dispatcher, 1)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"once"}, dispatcher.Messages())
+	assert.Equal(t, "echo", dispatcher.entries[0].Labels["command"])
+}
+
+func TestExecMonitor_StopTerminatesCommand(t *testing.T) {
+	em, dispatcher := newTestExecMonitor(t, types.ExecMonitorConfig{
+		Commands: []types.ExecCommandConfig{{Name: "sleeper", Command: "sh", Args: []string{"-c", "echo ready; exec sleep 60"}}},
+	}, "")
+	waitForMessages(t, dispatcher, 1)
+
+	start := time.Now()
+	require.NoError(t, em.Stop())
+	assert.Less(t, time.Since(start), execStopTimeout)
+	assert.False(t, em.IsHealthy())
+}
+
+func TestExecMonitor_Stdin(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	_, dispatcher := newTestExecMonitor(t, types.ExecMonitorConfig{Stdin: true, MaxLineSize: 16}, "first\r\n\nsecond\n"+long+"\nlast")
+
+	waitForMessages(t, dispatcher, 4)
+	assert.Equal(t, []string{"first", "second", long[:16], "last"}, dispatcher.Messages())
+	entry := dispatcher.entries[0]
+	assert.Equal(t, "stdin", entry.SourceType)
+	assert.Equal(t, "stdin", entry.Labels["command"])
+	assert.Equal(t, "stdin", entry.Labels["stream"])
+}
+
+func TestNewExecMonitor_InvalidConfig(t *testing.T) {
+	invalid := []types.ExecMonitorConfig{
+		{Enabled: true},
+		{Enabled: true, Commands: []types.ExecCommandConfig{{Name: "x"}}},
+		{Enabled: true, Commands: []types.ExecCommandConfig{{Command: "true", RestartPolicy: "sometimes"}}},
+		{Enabled: true, Commands: []types.ExecCommandConfig{{Command: "true", RestartDelay: "soon"}}},
+		{Enabled: true, Commands: []types.ExecCommandConfig{{Command: "/bin/true"}, {Command: "true"}}},
+	}
+	for _, config := range invalid {
+		_, err := NewExecMonitor(config, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+		assert.Error(t, err, "%+v", config)
+	}
+}
@@ -0,0 +1,361 @@
+package monitors
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/positions"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+)
+
+// archiveStatusCompleted marca no position manager arquivos comprimidos já ingeridos
+const archiveStatusCompleted = "completed"
+
+// rotationSuffixPattern remove sufixos de rotação (app.log.1, app.log-20240101)
+var rotationSuffixPattern = regexp.MustCompile(`(\.\d+|-\d{8,14})$`)
+
+// archiveFile representa um arquivo comprimido descoberto aguardando ingestão
+type archiveFile struct {
+	path   string
+	labels map[string]string
+	opts   fileReadOptions
+}
+
+// archiveCompression retorna o formato de compressão do arquivo pela extensão ("" se não comprimido)
+func archiveCompression(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return "gzip"
+	case ".zst", ".zstd":
+		return "zstd"
+	}
+	return ""
+}
+
+// matchesArchivePatterns verifica os padrões de uma entrada contra um arquivo comprimido.
+// Os padrões de inclusão são testados contra o nome completo, o nome sem a extensão de
+// compressão e o nome sem o sufixo de rotação (app.log.1.gz casa com "*.log"). Os padrões
+// de exclusão são testados contra o nome sem a extensão de compressão, para que a exclusão
+// usual de "*.gz" não anule a opção include_compressed.
+func matchesArchivePatterns(path string, include, exclude []string) bool {
+	fileName := filepath.Base(path)
+	decompressed := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	candidates := []string{fileName, decompressed, rotationSuffixPattern.ReplaceAllString(decompressed, "")}
+
+	for _, pattern := range exclude {
+		if matched, err := filepath.Match(pattern, decompressed); err == nil && matched {
+			return false
+		}
+	}
+
+	for _, pattern := range include {
+		for _, candidate := range candidates {
+			if matched, err := filepath.Match(pattern, candidate); err == nil && matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// queueArchive agenda um arquivo comprimido para ingestão ao fim da descoberta
+func (fm *FileMonitor) queueArchive(path string, labels map[string]string, opts fileReadOptions) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	if fm.ingestedArchives[path] {
+		return
+	}
+	for _, pending := range fm.pendingArchives {
+		if pending.path == path {
+			return
+		}
+	}
+
+	fm.pendingArchives = append(fm.pendingArchives, archiveFile{path: path, labels: labels, opts: opts})
+}
+
+// startArchiveIngestion ingere em background os arquivos comprimidos agendados,
+// sem bloquear a descoberta. Uma única goroutine consome a fila por vez.
+func (fm *FileMonitor) startArchiveIngestion() {
+	fm.mutex.Lock()
+	if fm.ingestingArchives || len(fm.pendingArchives) == 0 || fm.ctx.Err() != nil {
+		fm.mutex.Unlock()
+		return
+	}
+	fm.ingestingArchives = true
+	fm.mutex.Unlock()
+
+	fm.wg.Add(1)
+	go func() {
+		defer fm.wg.Done()
+		for {
+			fm.ingestPendingArchives()
+
+			// Arquivos agendados durante a ingestão são processados na sequência
+			fm.mutex.Lock()
+			if len(fm.pendingArchives) == 0 || fm.ctx.Err() != nil {
+				fm.ingestingArchives = false
+				fm.mutex.Unlock()
+				return
+			}
+			fm.mutex.Unlock()
+		}
+	}()
+}
+
+// ingestPendingArchives ingere os arquivos comprimidos agendados, do mais antigo ao mais recente
+func (fm *FileMonitor) ingestPendingArchives() {
+	fm.mutex.Lock()
+	archives := fm.pendingArchives
+	fm.pendingArchives = nil
+	fm.mutex.Unlock()
+
+	if len(archives) == 0 {
+		return
+	}
+
+	modTimes := make(map[string]time.Time, len(archives))
+	for _, archive := range archives {
+		if info, err := os.Stat(archive.path); err == nil {
+			modTimes[archive.path] = info.ModTime()
+		}
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		return modTimes[archives[i].path].Before(modTimes[archives[j].path])
+	})
+
+	fm.logger.WithField("count", len(archives)).Info("Ingesting compressed archives")
+
+	for _, archive := range archives {
+		if fm.ctx.Err() != nil {
+			return
+		}
+
+		if err := fm.ingestArchive(archive); err != nil {
+			fm.logger.WithError(err).WithField("path", archive.path).Warn("Failed to ingest compressed archive")
+			metrics.RecordError("file_monitor", "archive_error")
+			metrics.RecordFileArchive("failed")
+		}
+	}
+}
+
+// ingestArchive lê um arquivo comprimido por completo, descomprimindo em streaming.
+// Se o conteúdo já foi seguido como arquivo texto antes da compressão, a leitura
+// continua do offset salvo. Ao terminar, o arquivo é marcado como "completed" no
+// position manager e nunca é relido, mesmo se renomeado por rotações posteriores.
+func (fm *FileMonitor) ingestArchive(archive archiveFile) error {
+	file, err := os.Open(archive.path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	fingerprint, fingerprintSize, err := positions.ComputeFingerprint(file, info.Size())
+	if err != nil {
+		return err
+	}
+
+	if fm.archiveCompleted(archive.path, info, fingerprint, fingerprintSize) {
+		fm.logger.WithField("path", archive.path).Debug("Compressed archive already ingested, skipping")
+		metrics.RecordFileArchive("skipped")
+		return nil
+	}
+
+	decompressor, err := newArchiveReader(file, archiveCompression(archive.path))
+	if err != nil {
+		return err
+	}
+	defer decompressor.Close()
+
+	multiline, cri, err := newLineDecoders(archive.opts)
+	if err != nil {
+		return fmt.Errorf("invalid multiline config: %w", err)
+	}
+	decoder, err := newFileDecoder(archive.opts.encoding)
+	if err != nil {
+		return fmt.Errorf("invalid encoding: %w", err)
+	}
+
+	startTime := time.Now()
+	mf := &monitoredFile{
+		path:       archive.path,
+		labels:     archive.labels,
+		multiline:  multiline,
+		cri:        cri,
+		sourceType: archive.opts.sourceType,
+		sourceID:   archive.opts.sourceID,
+		lastRead:   startTime,
+		decoder:    decoder,
+	}
+
+	// Leitura interrompida (erro ou shutdown) não marca o arquivo como concluído
+	reader := &archiveReader{ctx: fm.ctx, reader: decompressor}
+	buffered := bufio.NewReader(reader)
+
+	// Conteúdo já seguido antes da rotação: pular o que foi lido
+	head, _ := buffered.Peek(int(positions.FingerprintSize))
+	if tailedPath, offset := fm.tailedOffset(head); offset > 0 {
+		skipped, _ := io.CopyN(io.Discard, buffered, offset)
+		mf.position = skipped
+		fm.logger.WithFields(logrus.Fields{
+			"path":        archive.path,
+			"tailed_path": tailedPath,
+			"offset":      skipped,
+		}).Info("Compressed archive was already tailed, resuming from saved offset")
+	}
+
+	linesRead := fm.readLines(mf, buffered)
+	if reader.err != nil {
+		return fmt.Errorf("failed to decompress archive: %w", reader.err)
+	}
+	linesRead += fm.finishFile(mf)
+
+	fm.mutex.Lock()
+	fm.ingestedArchives[archive.path] = true
+	fm.mutex.Unlock()
+
+	if fm.positionManager != nil {
+		inode, device := positions.FileIdentity(info)
+		fm.positionManager.UpdateFilePosition(archive.path, info.Size(), info.Size(), info.ModTime(), inode, device, mf.position, int64(linesRead))
+		fm.positionManager.SetFileFingerprint(archive.path, fingerprint, fingerprintSize)
+		fm.positionManager.SetFileStatus(archive.path, archiveStatusCompleted)
+	}
+
+	fm.logger.WithFields(logrus.Fields{
+		"path":              archive.path,
+		"lines_read":        linesRead,
+		"compressed_size":   info.Size(),
+		"uncompressed_size": mf.position,
+		"duration_ms":       time.Since(startTime).Milliseconds(),
+	}).Info("Compressed archive ingested")
+
+	metrics.RecordFileArchive(archiveStatusCompleted)
+	if linesRead > 0 {
+		metrics.RecordProcessingDuration("file_monitor", "read_archive", time.Since(startTime))
+		metrics.RecordLogProcessed("file", fm.getSourceID(archive.path), "file_monitor")
+	}
+
+	return nil
+}
+
+// archiveCompleted verifica se o arquivo já foi ingerido, pelo path ou pela identidade
+// (inode/device ou fingerprint) caso tenha sido renomeado por uma rotação posterior
+func (fm *FileMonitor) archiveCompleted(path string, info os.FileInfo, fingerprint string, fingerprintSize int64) bool {
+	fm.mutex.RLock()
+	ingested := fm.ingestedArchives[path]
+	fm.mutex.RUnlock()
+	if ingested {
+		return true
+	}
+
+	if fm.positionManager == nil {
+		return false
+	}
+
+	inode, device := positions.FileIdentity(info)
+	for completedPath, pos := range fm.positionManager.GetAllFilePositions() {
+		if pos.Status != archiveStatusCompleted || pos.Size != info.Size() {
+			continue
+		}
+
+		sameFile := inode != 0 && pos.Inode == inode && pos.Device == device
+		sameContent := fingerprint != "" && pos.Fingerprint == fingerprint && pos.FingerprintSize == fingerprintSize
+		if !sameFile && !sameContent {
+			// Mesmo nome com outro conteúdo é um arquivo novo gerado pela rotação
+			continue
+		}
+
+		if completedPath != path {
+			// Arquivo renomeado pela rotação: registrar também o novo path
+			fm.positionManager.UpdateFilePosition(path, info.Size(), info.Size(), info.ModTime(), inode, device, 0, 0)
+			fm.positionManager.SetFileFingerprint(path, fingerprint, fingerprintSize)
+			fm.positionManager.SetFileStatus(path, archiveStatusCompleted)
+		}
+		return true
+	}
+
+	return false
+}
+
+// tailedOffset procura, pelo início do conteúdo descomprimido, um arquivo texto
+// já seguido (ativo ou rotacionado) e retorna até onde ele foi lido. Havendo mais
+// de um candidato, vence o fingerprint mais longo.
+func (fm *FileMonitor) tailedOffset(head []byte) (string, int64) {
+	if fm.positionManager == nil || len(head) == 0 {
+		return "", 0
+	}
+
+	var bestPath string
+	var bestOffset, bestSize int64
+	for path, pos := range fm.positionManager.GetAllFilePositions() {
+		if pos.Status == archiveStatusCompleted || pos.Fingerprint == "" || pos.FingerprintSize <= 0 || pos.FingerprintSize > int64(len(head)) {
+			continue
+		}
+		if pos.FingerprintSize < bestSize || (pos.FingerprintSize == bestSize && pos.Offset <= bestOffset) {
+			continue
+		}
+		if fingerprint, _, err := positions.ComputeFingerprint(bytes.NewReader(head), pos.FingerprintSize); err == nil && fingerprint == pos.Fingerprint {
+			bestPath, bestOffset, bestSize = path, pos.Offset, pos.FingerprintSize
+		}
+	}
+	return bestPath, bestOffset
+}
+
+// newArchiveReader cria o leitor de descompressão para o formato informado
+func newArchiveReader(file io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case "gzip":
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return reader, nil
+	case "zstd":
+		decoder, err := zstd.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported archive compression: %q", compression)
+}
+
+// archiveReader interrompe a leitura no shutdown e guarda o primeiro erro que não seja EOF
+type archiveReader struct {
+	ctx    context.Context
+	reader io.Reader
+	err    error
+}
+
+func (r *archiveReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		return 0, err
+	}
+
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,230 @@
+package monitors
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeGzipFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+}
+
+func writeZstdFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw, err := zstd.NewWriter(f)
+	require.NoError(t, err)
+	_, err = zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+}
+
+func TestMatchesArchivePatterns(t *testing.T) {
+	assert.True(t, matchesArchivePatterns("/var/log/app.log.1.gz", []string{"*.log"}, nil))
+	assert.True(t, matchesArchivePatterns("/var/log/app.log-20240101.zst", []string{"*.log"}, nil))
+	assert.True(t, matchesArchivePatterns("/var/log/syslog.2.gz", []string{"syslog*"}, []string{"*.gz"}))
+	assert.False(t, matchesArchivePatterns("/var/log/app.log.1.gz", []string{"*.txt"}, nil))
+	assert.False(t, matchesArchivePatterns("/var/log/debug.log.1.gz", []string{"*.log"}, []string{"debug*"}))
+
+	assert.Equal(t, "gzip", archiveCompression("/var/log/app.log.1.gz"))
+	assert.Equal(t, "zstd", archiveCompression("/var/log/app.log.1.zst"))
+	assert.Equal(t, "", archiveCompression("/var/log/app.log"))
+
+	opts, err := parseFileReadOptions(map[string]interface{}{"path": "/var/log", "include_compressed": true})
+	require.NoError(t, err)
+	assert.True(t, opts.includeCompressed)
+}
+
+func TestFileMonitor_IngestCompressedArchives(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "logs")
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+
+	older := filepath.Join(logDir, "app.log.2.zst")
+	newer := filepath.Join(logDir, "app.log.1.gz")
+	writeZstdFile(t, older, "oldest 1\noldest 2\n")
+	writeGzipFile(t, newer, "newer 1\nnewer 2")
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(older, past, past))
+
+	positionManager := newTestPositionManager(filepath.Join(dir, "positions"))
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, positionManager)
+	fm.config.IncludePatterns = []string{"*.log"}
+	fm.config.ExcludePatterns = []string{"*.gz"}
+
+	require.NoError(t, fm.scanDirectory(logDir, fileReadOptions{includeCompressed: true}))
+	assert.Empty(t, fm.files, "archives must never be tailed")
+	fm.ingestPendingArchives()
+
+	assert.Equal(t, []string{"oldest 1", "oldest 2", "newer 1", "newer 2"}, dispatcher.Messages())
+
+	for _, path := range []string{older, newer} {
+		pos := positionManager.GetFilePosition(path)
+		require.NotNil(t, pos)
+		assert.Equal(t, archiveStatusCompleted, pos.Status)
+	}
+
+	// Após restart e nova rotação (app.log.1.gz -> app.log.2.gz) nada é reingerido
+	require.NoError(t, os.Remove(older))
+	require.NoError(t, os.Rename(newer, older[:len(older)-len(".zst")]+".gz"))
+
+	second := &recordingDispatcher{}
+	fm2 := newTestFileMonitor(t, second, positionManager)
+	fm2.config.IncludePatterns = []string{"*.log"}
+	require.NoError(t, fm2.scanDirectory(logDir, fileReadOptions{includeCompressed: true}))
+	fm2.ingestPendingArchives()
+	assert.Empty(t, second.Messages())
+}
+
+func TestFileMonitor_CompressedArchivesOptIn(t *testing.T) {
+	dir := t.TempDir()
+	writeGzipFile(t, filepath.Join(dir, "app.log.1.gz"), "archived\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	fm.config.IncludePatterns = []string{"*.log", "*.gz"}
+
+	require.NoError(t, fm.scanDirectory(dir, fileReadOptions{}))
+	fm.ingestPendingArchives()
+
+	assert.Empty(t, fm.files)
+	assert.Empty(t, dispatcher.Messages())
+}
+
+func TestFileMonitor_ScanPipelineDirectoryArchives(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log.1.gz")
+	writeGzipFile(t, path, "GET /\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+
+	require.NoError(t, fm.scanPipelineDirectory(types.FilePipelineDirEntry{
+		Path:              dir,
+		Patterns:          []string{"*.log"},
+		DefaultLabels:     map[string]string{"team": "web"},
+		IncludeCompressed: true,
+	}))
+	fm.ingestPendingArchives()
+
+	require.Equal(t, []string{"GET /"}, dispatcher.Messages())
+	assert.Equal(t, "web", dispatcher.labels[0]["team"])
+	assert.Equal(t, path, dispatcher.labels[0]["file_path"])
+
+	// Segunda descoberta na mesma execução não reingere o arquivo
+	require.NoError(t, fm.scanPipelineDirectory(types.FilePipelineDirEntry{
+		Path:              dir,
+		Patterns:          []string{"*.log"},
+		IncludeCompressed: true,
+	}))
+	fm.ingestPendingArchives()
+	assert.Len(t, dispatcher.Messages(), 1)
+}
+
+// compressFile substitui o arquivo pela sua versão .gz, como o logrotate
+func compressFile(t *testing.T, path string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	writeGzipFile(t, path+".gz", string(content))
+	require.NoError(t, os.Remove(path))
+}
+
+func TestFileMonitor_TailedFileRotatedAndCompressedNotReingested(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "logs")
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+	path := filepath.Join(logDir, "app.log")
+	appendToFile(t, path, "line 1\nline 2\n")
+
+	positionManager := newTestPositionManager(filepath.Join(dir, "positions"))
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, positionManager)
+	fm.config.IncludePatterns = []string{"*.log"}
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.readFile(mf)
+
+	// app.log -> app.log.1 -> app.log.1.gz
+	appendToFile(t, path, "line 3\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendToFile(t, path, "new 1\n")
+	fm.readFile(mf)
+	compressFile(t, path+".1")
+
+	require.NoError(t, fm.scanDirectory(logDir, fileReadOptions{includeCompressed: true}))
+	fm.ingestPendingArchives()
+	assert.Equal(t, []string{"line 1", "line 2", "line 3", "new 1"}, dispatcher.Messages())
+	assert.Equal(t, archiveStatusCompleted, positionManager.GetFilePosition(path+".1.gz").Status)
+
+	// Próximo restart também não reingere
+	second := &recordingDispatcher{}
+	fm2 := newTestFileMonitor(t, second, positionManager)
+	fm2.config.IncludePatterns = []string{"*.log"}
+	require.NoError(t, fm2.scanDirectory(logDir, fileReadOptions{includeCompressed: true}))
+	fm2.ingestPendingArchives()
+	assert.Empty(t, second.Messages())
+}
+
+func TestFileMonitor_ArchiveResumesFromTailedOffset(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "logs")
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+	path := filepath.Join(logDir, "app.log")
+	appendToFile(t, path, "line 1\nline 2\n")
+
+	positionManager := newTestPositionManager(filepath.Join(dir, "positions"))
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, positionManager)
+	fm.readFile(&monitoredFile{path: path, labels: map[string]string{}})
+	require.Equal(t, []string{"line 1", "line 2"}, dispatcher.Messages())
+
+	// Com o serviço parado: mais uma linha, rotação e compressão
+	appendToFile(t, path, "line 3\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	compressFile(t, path+".1")
+	appendToFile(t, path, "new 1\n")
+
+	second := &recordingDispatcher{}
+	fm2 := newTestFileMonitor(t, second, positionManager)
+	fm2.config.IncludePatterns = []string{"*.log"}
+	require.NoError(t, fm2.scanDirectory(logDir, fileReadOptions{includeCompressed: true}))
+	fm2.ingestPendingArchives()
+
+	// O novo app.log é lido do início; do arquivo comprimido só a linha não seguida
+	require.Eventually(t, func() bool { return len(second.Messages()) >= 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"new 1", "line 3"}, second.Messages())
+}
+
+func TestFileMonitor_ArchiveIngestionRunsInBackground(t *testing.T) {
+	dir := t.TempDir()
+	writeGzipFile(t, filepath.Join(dir, "app.log.1.gz"), "archived\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	fm.config.IncludePatterns = []string{"*.log"}
+	require.NoError(t, fm.scanDirectory(dir, fileReadOptions{includeCompressed: true}))
+
+	fm.startArchiveIngestion()
+	fm.wg.Wait()
+	assert.Equal(t, []string{"archived"}, dispatcher.Messages())
+	assert.False(t, fm.ingestingArchives)
+}
@@ -0,0 +1,203 @@
+package monitors
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"ssw-logs-capture/internal/metrics"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Codificação "auto" detecta pelo BOM e assume UTF-8 na ausência dele
+const fileEncodingAuto = "auto"
+
+// textEncoding descreve como separar linhas e decodificar uma codificação
+type textEncoding struct {
+	enc       encoding.Encoding // nil = UTF-8
+	unit      int               // Tamanho da unidade de código (1 ou 2 bytes)
+	bigEndian bool              // Ordem dos bytes para unidade de 2 bytes
+}
+
+var (
+	utf8Encoding    = textEncoding{unit: 1}
+	utf16LEEncoding = textEncoding{enc: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), unit: 2}
+	utf16BEEncoding = textEncoding{enc: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), unit: 2, bigEndian: true}
+)
+
+// fileDecoder transcodifica as linhas de um arquivo para UTF-8. Sequências
+// inválidas são substituídas por U+FFFD e contabilizadas.
+type fileDecoder struct {
+	name       string       // Codificação configurada (label da métrica)
+	configured textEncoding // Codificação usada quando não há BOM
+	current    textEncoding // Codificação em uso no arquivo aberto
+}
+
+// newFileDecoder cria o decoder para a codificação configurada ("" = sem transcodificação)
+func newFileDecoder(name string) (*fileDecoder, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	configured, err := lookupTextEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return &fileDecoder{name: strings.ToLower(name), configured: configured, current: configured}, nil
+}
+
+// lookupTextEncoding resolve o nome da codificação (UTF-16 e nomes IANA, ex: ISO-8859-1)
+func lookupTextEncoding(name string) (textEncoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case fileEncodingAuto, "utf-8", "utf8":
+		return utf8Encoding, nil
+	case "utf-16le", "utf16le", "utf-16", "utf16", "ucs-2", "ucs-2le":
+		return utf16LEEncoding, nil
+	case "utf-16be", "utf16be", "ucs-2be":
+		return utf16BEEncoding, nil
+	}
+
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return textEncoding{}, fmt.Errorf("unsupported encoding %q", name)
+	}
+
+	// A separação de linhas exige que "\n" seja o byte 0x0A (compatível com ASCII)
+	if newline, err := enc.NewEncoder().Bytes([]byte("\n")); err != nil || !bytes.Equal(newline, []byte("\n")) {
+		return textEncoding{}, fmt.Errorf("unsupported encoding %q: not ASCII compatible", name)
+	}
+	return textEncoding{enc: enc, unit: 1}, nil
+}
+
+// detectBOM ajusta a codificação pelo BOM no início do arquivo. Retorna false
+// quando os bytes disponíveis ainda podem ser o início de um BOM.
+func (d *fileDecoder) detectBOM(prefix []byte) bool {
+	switch {
+	case bytes.HasPrefix(prefix, []byte{0xEF, 0xBB, 0xBF}):
+		d.current = utf8Encoding
+	case bytes.HasPrefix(prefix, []byte{0xFF, 0xFE}):
+		d.current = utf16LEEncoding
+	case bytes.HasPrefix(prefix, []byte{0xFE, 0xFF}):
+		d.current = utf16BEEncoding
+	case len(prefix) == 0, bytes.Equal(prefix, []byte{0xEF}), bytes.Equal(prefix, []byte{0xEF, 0xBB}),
+		bytes.Equal(prefix, []byte{0xFF}), bytes.Equal(prefix, []byte{0xFE}):
+		return false
+	default:
+		d.current = d.configured
+	}
+	return true
+}
+
+// detectFileBOM lê o início do arquivo aberto para detectar o BOM
+func (d *fileDecoder) detectFileBOM(file io.ReaderAt) {
+	prefix := make([]byte, 3)
+	n, _ := file.ReadAt(prefix, 0)
+	d.detectBOM(prefix[:n])
+}
+
+// readLine lê os bytes brutos até o newline codificado, inclusive. Para UTF-16
+// o newline só é reconhecido alinhado à unidade de 2 bytes, contando os bytes
+// já pendentes em partial. Com maxBytes > 0 devolve no máximo maxBytes por
+// chamada, com bufio.ErrBufferFull enquanto o newline não aparece.
+func (d *fileDecoder) readLine(reader *bufio.Reader, partial string, maxBytes int) (string, error) {
+	if d.current.unit == 1 {
+		if maxBytes > 0 {
+			chunk, err := reader.ReadSlice('\n')
+			return string(chunk), err
+		}
+		return reader.ReadString('\n')
+	}
+
+	var chunk []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return string(chunk), err
+		}
+		chunk = append(chunk, b)
+
+		if (len(partial)+len(chunk))%2 != 0 {
+			continue
+		}
+		if maxBytes > 0 && len(chunk) >= maxBytes {
+			return string(chunk), bufio.ErrBufferFull
+		}
+		var prev byte
+		if len(chunk) >= 2 {
+			prev = chunk[len(chunk)-2]
+		} else {
+			prev = partial[len(partial)-1]
+		}
+		if (!d.current.bigEndian && prev == '\n' && b == 0) || (d.current.bigEndian && prev == 0 && b == '\n') {
+			return string(chunk), nil
+		}
+	}
+}
+
+// decode remove o newline codificado e converte a linha para UTF-8.
+// atStart indica que a linha começa no offset 0 (onde fica o BOM).
+func (d *fileDecoder) decode(raw string, atStart bool) string {
+	var line string
+	invalid := 0
+
+	if d.current.unit == 2 {
+		newline := "\n\x00"
+		if d.current.bigEndian {
+			newline = "\x00\n"
+		}
+		if len(raw)%2 == 0 {
+			raw = strings.TrimSuffix(raw, newline)
+		}
+	} else {
+		raw = strings.TrimSuffix(raw, "\n")
+	}
+
+	if d.current.enc == nil {
+		line, invalid = toValidUTF8(raw)
+	} else {
+		decoded, err := d.current.enc.NewDecoder().String(raw)
+		if err != nil {
+			// Decoders do x/text substituem sequências inválidas; erro aqui é inesperado
+			decoded, invalid = toValidUTF8(raw)
+		} else {
+			invalid = strings.Count(decoded, string(utf8.RuneError))
+		}
+		line = decoded
+	}
+
+	if invalid > 0 {
+		metrics.RecordFileEncodingErrors(d.name, invalid)
+	}
+	if atStart {
+		line = strings.TrimPrefix(line, "\uFEFF")
+	}
+	return line
+}
+
+// toValidUTF8 substitui cada byte inválido por U+FFFD e retorna quantos foram substituídos
+func toValidUTF8(s string) (string, int) {
+	if utf8.ValidString(s) {
+		return s, 0
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	invalid := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteRune(utf8.RuneError)
+			invalid++
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String(), invalid
+}
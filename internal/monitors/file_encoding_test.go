@@ -0,0 +1,93 @@
+package monitors
+
+import (
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"ssw-logs-capture/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeUTF16 codifica s em UTF-16 com a ordem de bytes informada
+func encodeUTF16(s string, bigEndian bool) string {
+	var out []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(unit>>8), byte(unit))
+		} else {
+			out = append(out, byte(unit), byte(unit>>8))
+		}
+	}
+	return string(out)
+}
+
+func newEncodedTestFile(t *testing.T, encoding string) (*FileMonitor, *monitoredFile, *recordingDispatcher) {
+	t.Helper()
+	decoder, err := newFileDecoder(encoding)
+	require.NoError(t, err)
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	mf := &monitoredFile{path: filepath.Join(t.TempDir(), "vendor.log"), labels: map[string]string{}, decoder: decoder}
+	return fm, mf, dispatcher
+}
+
+func TestFileMonitor_UTF16LittleEndianWithBOM(t *testing.T) {
+	fm, mf, dispatcher := newEncodedTestFile(t, "utf-16le")
+
+	// Escrita parcial no meio de um caractere: a linha só sai quando completa
+	content := "\xFF\xFE" + encodeUTF16("ação ok\n", false)
+	appendToFile(t, mf.path, content[:7])
+	fm.readFile(mf)
+	assert.Empty(t, dispatcher.Messages())
+
+	appendToFile(t, mf.path, content[7:]+encodeUTF16("ਅĀsecond\n", false))
+	fm.readFile(mf)
+	assert.Equal(t, []string{"ação ok", "ਅĀsecond"}, dispatcher.Messages())
+	assert.Equal(t, int64(len(content)+len(encodeUTF16("ਅĀsecond\n", false))), mf.position)
+}
+
+func TestFileMonitor_EncodingBOMDetection(t *testing.T) {
+	fm, mf, dispatcher := newEncodedTestFile(t, "auto")
+	appendToFile(t, mf.path, "\xFE\xFF"+encodeUTF16("big endian\nline 2\n", true))
+	fm.readFile(mf)
+	assert.Equal(t, []string{"big endian", "line 2"}, dispatcher.Messages())
+
+	// Sem BOM, auto assume UTF-8 e remove o BOM UTF-8 se houver
+	fm, mf, dispatcher = newEncodedTestFile(t, "auto")
+	appendToFile(t, mf.path, "\xEF\xBB\xBFplain\n")
+	fm.readFile(mf)
+	assert.Equal(t, []string{"plain"}, dispatcher.Messages())
+}
+
+func TestFileMonitor_Latin1AndInvalidSequences(t *testing.T) {
+	fm, mf, dispatcher := newEncodedTestFile(t, "ISO-8859-1")
+	appendToFile(t, mf.path, "S\xE3o Paulo\n")
+	fm.readFile(mf)
+	assert.Equal(t, []string{"São Paulo"}, dispatcher.Messages())
+
+	before := testutil.ToFloat64(metrics.FileEncodingErrorsTotal.WithLabelValues("utf-8"))
+	fm, mf, dispatcher = newEncodedTestFile(t, "utf-8")
+	appendToFile(t, mf.path, "bad \xC3\x28 byte\xFF\n")
+	fm.readFile(mf)
+	assert.Equal(t, []string{"bad �( byte�"}, dispatcher.Messages())
+	assert.Equal(t, before+2, testutil.ToFloat64(metrics.FileEncodingErrorsTotal.WithLabelValues("utf-8")))
+}
+
+func TestNewFileDecoder(t *testing.T) {
+	decoder, err := newFileDecoder("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoder)
+
+	for _, name := range []string{"utf-16", "UTF-16BE", "latin1", "windows-1252", "Shift_JIS"} {
+		_, err := newFileDecoder(name)
+		assert.NoError(t, err, name)
+	}
+	for _, name := range []string{"klingon", "UTF-32"} {
+		_, err := newFileDecoder(name)
+		assert.Error(t, err, name)
+	}
+}
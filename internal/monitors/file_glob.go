@@ -0,0 +1,109 @@
+package monitors
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Posição inicial de arquivos sem posição salva
+const (
+	fileStartAtBeginning = "beginning"
+	fileStartAtEnd       = "end"
+)
+
+// matchesGlob verifica um padrão contra um arquivo descoberto em root.
+// Padrões sem "/" casam apenas com o nome do arquivo (comportamento original);
+// padrões com "/" casam com o caminho relativo a root, ou com o caminho completo
+// quando o padrão é absoluto. "**" casa com zero ou mais diretórios.
+func matchesGlob(pattern, root, filePath string) bool {
+	if !strings.Contains(pattern, "/") {
+		if strings.Contains(pattern, "**") {
+			pattern = "**/" + pattern
+		} else {
+			matched, err := filepath.Match(pattern, filepath.Base(filePath))
+			return (err == nil && matched) || pattern == filepath.Base(filePath)
+		}
+	}
+
+	target := filepath.ToSlash(filePath)
+	if !path.IsAbs(pattern) {
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return false
+		}
+		target = filepath.ToSlash(rel)
+	}
+
+	return matchGlobSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(target, "/"), "/"))
+}
+
+// matchGlobSegments casa segmento a segmento; "**" consome zero ou mais segmentos
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// "**" consecutivos equivalem a um só
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchGlobSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		// "**" dentro de um segmento ("app-**.log") equivale a "*"
+		segment := strings.ReplaceAll(pattern[0], "**", "*")
+		if matched, err := path.Match(segment, name[0]); err != nil || !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// globsNeedRecursion indica se algum padrão exige descer em subdiretórios
+func globsNeedRecursion(patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") || strings.Contains(pattern, "**") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStartOptions valida start_at e ignore_older_than de uma entrada do pipeline
+func parseStartOptions(startAt, ignoreOlderThan string) (string, time.Duration, error) {
+	switch startAt {
+	case "", fileStartAtBeginning, fileStartAtEnd:
+	default:
+		return "", 0, fmt.Errorf("invalid start_at %q (expected %q or %q)", startAt, fileStartAtBeginning, fileStartAtEnd)
+	}
+
+	var maxAge time.Duration
+	if ignoreOlderThan != "" {
+		var err error
+		maxAge, err = time.ParseDuration(ignoreOlderThan)
+		if err != nil || maxAge < 0 {
+			return "", 0, fmt.Errorf("invalid ignore_older_than %q", ignoreOlderThan)
+		}
+	}
+
+	return startAt, maxAge, nil
+}
+
+// isTooOld indica se o arquivo não é modificado há mais que maxAge (0 = sem limite)
+func isTooOld(info os.FileInfo, maxAge time.Duration) bool {
+	return maxAge > 0 && time.Since(info.ModTime()) > maxAge
+}
@@ -0,0 +1,117 @@
+package monitors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesGlob(t *testing.T) {
+	root := "/var/log"
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.log", "/var/log/app.log", true},
+		{"*.log", "/var/log/nginx/access.log", true},
+		{"syslog", "/var/log/syslog", true},
+		{"**/*.log", "/var/log/app.log", true},
+		{"**/*.log", "/var/log/a/b/c/app.log", true},
+		{"**/*.log", "/var/log/a/b/app.txt", false},
+		{"nginx/**/*.log", "/var/log/nginx/access.log", true},
+		{"nginx/**/*.log", "/var/log/nginx/vhosts/site/error.log", true},
+		{"nginx/**/*.log", "/var/log/apache/access.log", false},
+		{"*/access.log", "/var/log/nginx/access.log", true},
+		{"*/access.log", "/var/log/a/nginx/access.log", false},
+		{"**/archive/**", "/var/log/app/archive/2024/old.log", true},
+		{"**.log", "/var/log/deep/dir/x.log", true},
+		{"/var/log/**/debug-*.log", "/var/log/svc/debug-1.log", true},
+		{"/opt/**/*.log", "/var/log/svc/debug-1.log", false},
+		{"**/*.log", "/other/app.log", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, matchesGlob(c.pattern, root, c.path), "%s vs %s", c.pattern, c.path)
+	}
+
+	assert.True(t, globsNeedRecursion([]string{"*.log", "**/*.log"}))
+	assert.False(t, globsNeedRecursion([]string{"*.log", "syslog*"}))
+}
+
+func TestFileMonitor_ScanPipelineDirectoryGlobs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"top.log", "a/b/deep.log", "a/b/deep.txt", "archive/old.log"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		appendToFile(t, path, "line\n")
+	}
+
+	fm := newTestFileMonitor(t, &recordingDispatcher{}, nil)
+
+	// "**" desce em subdiretórios mesmo com recursive: false
+	require.NoError(t, fm.scanPipelineDirectory(types.FilePipelineDirEntry{
+		Path:            dir,
+		Patterns:        []string{"**/*.log"},
+		ExcludePatterns: []string{"archive/**"},
+	}))
+
+	assert.ElementsMatch(t, []string{filepath.Join(dir, "top.log"), filepath.Join(dir, "a/b/deep.log")}, monitoredPaths(fm))
+}
+
+func TestFileMonitor_StartAtAndIgnoreOlderThan(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "history.log")
+	stale := filepath.Join(dir, "stale.log")
+	appendToFile(t, history, "old 1\nold 2\n")
+	appendToFile(t, stale, "ancient\n")
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	positionManager := newTestPositionManager(t.TempDir())
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, positionManager)
+
+	require.NoError(t, fm.scanPipelineDirectory(types.FilePipelineDirEntry{
+		Path:            dir,
+		Patterns:        []string{"*.log"},
+		StartAt:         fileStartAtEnd,
+		IgnoreOlderThan: "24h",
+	}))
+	require.Equal(t, []string{history}, monitoredPaths(fm))
+
+	// Apenas linhas escritas depois da descoberta são lidas
+	mf := fm.files[history]
+	appendToFile(t, history, "new 1\n")
+	fm.readFile(mf)
+	assert.Equal(t, []string{"new 1"}, dispatcher.Messages())
+
+	// Com posição salva, start_at não se aplica: o restart retoma do offset
+	appendToFile(t, history, "while down\n")
+	restarted := &recordingDispatcher{}
+	fm2 := newTestFileMonitor(t, restarted, positionManager)
+	require.NoError(t, fm2.addFileWithOptions(history, map[string]string{}, fileReadOptions{startAt: fileStartAtEnd}))
+	waitForMessages(t, restarted, 1)
+	assert.Equal(t, []string{"while down"}, restarted.Messages())
+
+	_, _, err := parseStartOptions("middle", "")
+	assert.Error(t, err)
+	_, _, err = parseStartOptions("", "yesterday")
+	assert.Error(t, err)
+}
+
+func monitoredPaths(fm *FileMonitor) []string {
+	fm.mutex.RLock()
+	defer fm.mutex.RUnlock()
+	paths := make([]string, 0, len(fm.files))
+	for path := range fm.files {
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,168 @@
+package monitors
+
+import (
+	"bufio"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/pkg/linelimit"
+)
+
+// readChunk lê até o próximo newline. Com max_line_bytes a leitura é limitada
+// ao buffer do reader e retorna bufio.ErrBufferFull para linhas maiores.
+func (fm *FileMonitor) readChunk(mf *monitoredFile, reader *bufio.Reader) (string, error) {
+	maxBytes := 0
+	if fm.lineLimit != nil {
+		maxBytes = fm.lineLimit.MaxBytes()
+	}
+
+	if mf.decoder != nil {
+		return mf.decoder.readLine(reader, mf.partial, maxBytes)
+	}
+	if maxBytes == 0 {
+		return reader.ReadString('\n')
+	}
+	chunk, err := reader.ReadSlice('\n')
+	return string(chunk), err
+}
+
+// completeLine envia uma linha lida do arquivo (com o newline, se houver),
+// avançando mf.position pelos bytes brutos, inclusive os descartados
+func (fm *FileMonitor) completeLine(mf *monitoredFile, raw string) int {
+	rawSize := int64(len(raw))
+	atStart := mf.position == 0
+	mf.position += rawSize + mf.overflow
+
+	// Remover newline (transcodificando para UTF-8 quando configurado)
+	line := raw
+	if mf.decoder != nil {
+		line = mf.decoder.decode(line, atStart)
+	} else {
+		line = strings.TrimSuffix(line, "\n")
+	}
+	if atStart && line != "" {
+		mf.startPending.Store(true)
+	}
+
+	if fm.lineLimit != nil && (mf.overflow > 0 || mf.splitGroup != "" || len(line) > fm.lineLimit.MaxBytes()) {
+		return fm.emitOversizedLine(mf, line)
+	}
+	if line == "" {
+		return 0
+	}
+
+	fm.emitLine(mf, line, rawSize)
+	return 1
+}
+
+// limitPartial mantém a linha incompleta dentro de max_line_bytes. Na política
+// split as partes cheias são enviadas à medida que chegam; nas demais o excesso
+// é descartado e apenas contabilizado em mf.overflow.
+func (fm *FileMonitor) limitPartial(mf *monitoredFile) int {
+	if fm.lineLimit == nil || len(mf.partial) <= fm.lineLimit.MaxBytes() {
+		return 0
+	}
+	maxBytes := fm.lineLimit.MaxBytes()
+
+	if fm.lineLimit.Policy() != linelimit.PolicySplit {
+		cut := cutRawLine(mf, mf.partial, maxBytes)
+		mf.overflow += int64(len(mf.partial) - cut)
+		mf.partial = mf.partial[:cut]
+		return 0
+	}
+
+	linesRead := 0
+	for len(mf.partial) > maxBytes {
+		cut := cutRawLine(mf, mf.partial, maxBytes)
+		piece := mf.partial[:cut]
+		mf.partial = mf.partial[cut:]
+
+		atStart := mf.position == 0
+		mf.position += int64(len(piece))
+		if mf.decoder != nil {
+			piece = mf.decoder.decode(piece, atStart)
+		}
+		if atStart {
+			mf.startPending.Store(true)
+		}
+
+		if mf.splitGroup == "" {
+			mf.splitGroup = linelimit.NewGroup()
+		}
+		mf.splitPart++
+		linesRead += fm.emitLimitedLine(mf, piece, linelimit.Oversize{Group: mf.splitGroup, Part: mf.splitPart})
+	}
+	return linesRead
+}
+
+// emitOversizedLine aplica a política de max_line_bytes ao fim de uma linha
+// que excedeu o limite
+func (fm *FileMonitor) emitOversizedLine(mf *monitoredFile, line string) int {
+	overflow, group, part := mf.overflow, mf.splitGroup, mf.splitPart
+	mf.overflow, mf.splitGroup, mf.splitPart = 0, "", 0
+	maxBytes := fm.lineLimit.MaxBytes()
+
+	if fm.lineLimit.Policy() != linelimit.PolicySplit {
+		original := int64(len(line)) + overflow
+		line = line[:linelimit.CutIndex(line, maxBytes)]
+		return fm.emitLimitedLine(mf, line, linelimit.Oversize{OriginalBytes: original})
+	}
+
+	if group == "" {
+		group = linelimit.NewGroup()
+	}
+	linesRead := 0
+	for {
+		cut := linelimit.CutIndex(line, maxBytes)
+		if cut == 0 && line != "" {
+			cut = maxBytes
+		}
+		piece := line[:cut]
+		line = line[cut:]
+		part++
+		linesRead += fm.emitLimitedLine(mf, piece, linelimit.Oversize{Group: group, Part: part, Final: line == ""})
+		if line == "" {
+			return linesRead
+		}
+	}
+}
+
+// emitLimitedLine envia uma linha cortada por max_line_bytes sem passar pelo
+// multiline/CRI, preservando a ordem dos eventos multiline pendentes
+func (fm *FileMonitor) emitLimitedLine(mf *monitoredFile, line string, oversize linelimit.Oversize) int {
+	if mf.multiline != nil {
+		if event, ok := mf.multiline.Flush(); ok {
+			fm.dispatchFileLine(mf, event)
+		}
+	}
+
+	entry := fm.newFileEntry(mf, line, time.Time{}, "")
+	if !fm.lineLimit.Finish(entry, oversize) {
+		return 0
+	}
+	fm.sendFileEntry(mf, entry)
+	return 1
+}
+
+// cutRawLine escolhe onde cortar bytes ainda não decodificados sem partir caracteres
+func cutRawLine(mf *monitoredFile, raw string, maxBytes int) int {
+	if mf.decoder == nil || mf.decoder.current.enc == nil {
+		if cut := linelimit.CutIndex(raw, maxBytes); cut > 0 {
+			return cut
+		}
+		return maxBytes
+	}
+
+	unit := mf.decoder.current.unit
+	if cut := maxBytes - maxBytes%unit; cut > 0 {
+		return cut
+	}
+	return unit
+}
+
+// resetPartial descarta a linha incompleta e o estado de max_line_bytes
+func (mf *monitoredFile) resetPartial() {
+	mf.partial = ""
+	mf.overflow = 0
+	mf.splitGroup, mf.splitPart = "", 0
+}
@@ -0,0 +1,83 @@
+package monitors
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ssw-logs-capture/pkg/linelimit"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLimitedTestFile(t *testing.T, config types.LineLimitConfig) (*FileMonitor, *monitoredFile, *recordingDispatcher) {
+	t.Helper()
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	limiter, err := linelimit.New(config, "file_monitor", nil)
+	require.NoError(t, err)
+	fm.lineLimit = limiter
+	mf := &monitoredFile{path: filepath.Join(t.TempDir(), "runaway.log"), labels: map[string]string{}}
+	return fm, mf, dispatcher
+}
+
+func TestFileMonitor_OversizedLineTruncate(t *testing.T) {
+	fm, mf, dispatcher := newLimitedTestFile(t, types.LineLimitConfig{MaxLineBytes: 100})
+	content := "short\n" + strings.Repeat("x", 10000) + "\ntail\n"
+	appendToFile(t, mf.path, content)
+
+	fm.readFile(mf)
+	require.Equal(t, []string{"short", strings.Repeat("x", 100), "tail"}, dispatcher.Messages())
+	assert.Equal(t, "true", dispatcher.entries[1].Labels[linelimit.LabelTruncated])
+	assert.Equal(t, int64(10000), dispatcher.entries[1].Fields[linelimit.FieldOriginalBytes])
+	assert.NotContains(t, dispatcher.entries[2].Labels, linelimit.LabelTruncated)
+	assert.Equal(t, int64(len(content)), mf.position)
+
+	// Linha sem newline: o pendente nunca passa de max_line_bytes
+	appendToFile(t, mf.path, strings.Repeat("b", 5000))
+	fm.readFile(mf)
+	assert.Len(t, dispatcher.Messages(), 3)
+	assert.LessOrEqual(t, len(mf.partial), 100)
+
+	appendToFile(t, mf.path, "bb\n")
+	fm.readFile(mf)
+	require.Len(t, dispatcher.Messages(), 4)
+	assert.Equal(t, strings.Repeat("b", 100), dispatcher.Messages()[3])
+	assert.Equal(t, int64(5002), dispatcher.entries[3].Fields[linelimit.FieldOriginalBytes])
+	assert.Equal(t, int64(len(content)+5003), mf.position)
+}
+
+func TestFileMonitor_OversizedLineSplit(t *testing.T) {
+	fm, mf, dispatcher := newLimitedTestFile(t, types.LineLimitConfig{MaxLineBytes: 4000, Policy: linelimit.PolicySplit})
+	line := strings.Repeat("0123456789", 1000)
+	content := line + "\nnext\n"
+	appendToFile(t, mf.path, content)
+
+	fm.readFile(mf)
+	messages := dispatcher.Messages()
+	require.Len(t, messages, 4)
+	assert.Equal(t, line, strings.Join(messages[:3], ""))
+	assert.Equal(t, "next", messages[3])
+
+	group := dispatcher.entries[0].Fields[linelimit.FieldSplitGroup]
+	assert.NotEmpty(t, group)
+	for i, entry := range dispatcher.entries[:3] {
+		assert.Equal(t, group, entry.Fields[linelimit.FieldSplitGroup])
+		assert.Equal(t, i+1, entry.Fields[linelimit.FieldSplitPart])
+	}
+	assert.Equal(t, true, dispatcher.entries[2].Fields[linelimit.FieldSplitFinal])
+	assert.NotContains(t, dispatcher.entries[3].Fields, linelimit.FieldSplitGroup)
+	assert.Equal(t, int64(len(content)), mf.position)
+}
+
+func TestFileMonitor_OversizedLineDLQ(t *testing.T) {
+	fm, mf, dispatcher := newLimitedTestFile(t, types.LineLimitConfig{MaxLineBytes: 10, Policy: linelimit.PolicyDLQ})
+	content := "ok\n" + strings.Repeat("y", 50) + "\nafter\n"
+	appendToFile(t, mf.path, content)
+
+	fm.readFile(mf)
+	assert.Equal(t, []string{"ok", "after"}, dispatcher.Messages())
+	assert.Equal(t, int64(len(content)), mf.position)
+}
@@ -11,10 +11,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/linelimit"
 	"ssw-logs-capture/pkg/positions"
 	"ssw-logs-capture/pkg/selfguard"
 	"ssw-logs-capture/pkg/types"
@@ -23,6 +24,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 )
 
 
@@ -35,6 +37,7 @@ type FileMonitor struct {
 	positionManager    *positions.PositionBufferManager
 	timestampValidator *validation.TimestampValidator
 	feedbackGuard      *selfguard.FeedbackGuard
+	enricher           LabelEnricher // Metadados externos adicionados às labels (opcional)
 
 	watcher         *fsnotify.Watcher
 	files           map[string]*monitoredFile
@@ -42,12 +45,23 @@ type FileMonitor struct {
 	specificFiles   map[string]bool // Arquivos específicos do pipeline (precedência)
 	mutex           sync.RWMutex
 	wg              sync.WaitGroup // Rastreia goroutines de descoberta
+	pendingArchives []archiveFile   // Arquivos comprimidos aguardando ingestão
+	ingestedArchives map[string]bool // Arquivos comprimidos já processados nesta execução
+	ingestingArchives bool           // Goroutine de ingestão de arquivos comprimidos ativa
+
+	lineLimit    *linelimit.Limiter // nil = linhas sem limite de tamanho
 
 	ctx          context.Context
 	cancel       context.CancelFunc
 	isRunning    bool
 }
 
+// LabelEnricher adiciona metadados externos (ex: cache de pods do Kubernetes)
+// às labels de uma entrada antes do envio
+type LabelEnricher interface {
+	EnrichLabels(labels map[string]string)
+}
+
 // monitoredFile representa um arquivo sendo monitorado
 type monitoredFile struct {
 	path        string
@@ -57,6 +71,39 @@ type monitoredFile struct {
 	labels      map[string]string
 	lastModTime time.Time
 	lastRead    time.Time
+	multiline   *multilineAggregator // nil quando multiline está desabilitado
+	cri         *criDecoder          // nil quando o arquivo não está no formato CRI/docker-json
+	sourceType  string               // Tipo de origem do LogEntry ("" = file)
+	sourceID    string               // SourceID fixo ("" = hash do path)
+	decoder     *fileDecoder         // nil quando o arquivo já é lido como UTF-8 sem transcodificação
+
+	readMutex       sync.Mutex // Serializa leituras do arquivo
+	partial         string     // Linha incompleta no fim do arquivo
+	overflow        int64      // Bytes da linha atual descartados além de max_line_bytes
+	splitGroup      string     // Grupo da linha atual dividida (política split)
+	splitPart       int        // Última parte enviada da linha dividida
+	inode           uint64     // Identidade do arquivo aberto
+	device          uint64
+	fingerprint     string // Hash do início do arquivo aberto
+	fingerprintSize int64
+	checkedSize     int64 // Tamanho e mtime da última conferência do fingerprint
+	checkedModTime  time.Time
+	drainPath       string // Arquivo rotacionado a drenar antes do atual (após restart)
+	drainOffset     int64
+	startPending    atomic.Bool // A próxima entrada contém a linha do offset 0
+}
+
+// fileReadOptions opções de leitura definidas por entrada do pipeline
+type fileReadOptions struct {
+	multiline         *types.MultilineConfig
+	includeCompressed bool            // Ingerir arquivos rotacionados .gz/.zst uma única vez
+	format            string          // Formato das linhas: "" (texto), "cri" ou "docker"
+	sourceType        string          // Tipo de origem do LogEntry ("" = file)
+	sourceID          string          // SourceID fixo do LogEntry ("" = hash do path)
+	skipStreams       map[string]bool // Streams descartados nos formatos cri/docker
+	startAt           string          // Posição inicial sem posição salva: "beginning" (padrão) ou "end"
+	ignoreOlderThan   time.Duration   // Ignorar na descoberta arquivos sem escrita há mais que isso (0 = sem limite)
+	encoding          string          // Codificação do arquivo ("" = UTF-8 sem transcodificação, "auto" = BOM)
 }
 
 // NewFileMonitor cria um novo monitor de arquivos
@@ -94,6 +141,13 @@ func NewFileMonitor(config types.FileConfig, timestampConfig types.TimestampVali
 	}
 	feedbackGuard := selfguard.NewFeedbackGuard(feedbackConfig, logger)
 
+	lineLimit, err := linelimit.New(config.LineLimit, "file_monitor", linelimit.DeadLetterQueueOf(dispatcher))
+	if err != nil {
+		cancel()
+		watcher.Close()
+		return nil, fmt.Errorf("invalid line limit: %w", err)
+	}
+
 	fm := &FileMonitor{
 		config:             config,
 		dispatcher:         dispatcher,
@@ -106,6 +160,8 @@ func NewFileMonitor(config types.FileConfig, timestampConfig types.TimestampVali
 		files:              make(map[string]*monitoredFile),
 		lastQuietLogTime:   make(map[string]time.Time),
 		specificFiles:      make(map[string]bool),
+		ingestedArchives:   make(map[string]bool),
+		lineLimit:          lineLimit,
 		ctx:                ctx,
 		cancel:             cancel,
 	}
@@ -184,6 +240,9 @@ func (fm *FileMonitor) Stop() error {
 	fm.isRunning = false
 	fm.mutex.Unlock() // Unlock early to allow goroutines to finish
 
+	// Enviar eventos multiline pendentes antes de cancelar o contexto
+	fm.flushMultiline(true)
+
 	// Cancelar contexto
 	fm.cancel()
 
@@ -245,6 +304,20 @@ func (fm *FileMonitor) GetStatus() types.MonitorStatus {
 
 // AddFile adiciona um arquivo para monitoramento
 func (fm *FileMonitor) AddFile(filePath string, labels map[string]string) error {
+	return fm.addFileWithOptions(filePath, labels, fileReadOptions{})
+}
+
+// addFileWithOptions adiciona um arquivo para monitoramento com opções de leitura do pipeline
+func (fm *FileMonitor) addFileWithOptions(filePath string, labels map[string]string, opts fileReadOptions) error {
+	multiline, cri, err := newLineDecoders(opts)
+	if err != nil {
+		return fmt.Errorf("invalid multiline config for %s: %w", filePath, err)
+	}
+	decoder, err := newFileDecoder(opts.encoding)
+	if err != nil {
+		return fmt.Errorf("invalid encoding for %s: %w", filePath, err)
+	}
+
 	fm.mutex.Lock()
 	defer fm.mutex.Unlock()
 
@@ -269,11 +342,26 @@ func (fm *FileMonitor) AddFile(filePath string, labels map[string]string) error
 		labels:      labels,
 		lastModTime: info.ModTime(),
 		lastRead:    time.Now(),
+		multiline:   multiline,
+		cri:         cri,
+		sourceType:  opts.sourceType,
+		sourceID:    opts.sourceID,
+		decoder:     decoder,
 	}
 
-	// Carregar posição salva se existir
+	// Carregar posição salva se existir (validando inode/device e fingerprint)
+	resumed := false
 	if fm.positionManager != nil {
-		mf.position = fm.positionManager.GetFileOffset(filePath)
+		resumed = fm.positionManager.GetFilePosition(filePath) != nil
+		fm.resumePosition(mf, info)
+	}
+
+	// Arquivo nunca visto com start_at: end começa no fim, ignorando o histórico
+	if !resumed && opts.startAt == fileStartAtEnd {
+		mf.position = info.Size()
+		if fm.positionManager != nil && mf.position > 0 {
+			fm.savePosition(mf, 0)
+		}
 	}
 
 	fm.files[filePath] = mf
@@ -298,8 +386,8 @@ func (fm *FileMonitor) AddFile(filePath string, labels map[string]string) error
 		"size":      info.Size(),
 	}).Info("File added to monitoring")
 
-	// Read initial content if file has data
-	if info.Size() > mf.position {
+	// Read initial content if file has data (or a rotated file must be drained)
+	if info.Size() > mf.position || mf.drainPath != "" {
 		fm.logger.WithFields(logrus.Fields{
 			"path": filePath,
 			"size": info.Size(),
@@ -345,6 +433,35 @@ func (fm *FileMonitor) RemoveFile(filePath string) error {
 	return nil
 }
 
+// removeDeletedFiles para de acompanhar arquivos ausentes de found que não existem
+// mais no disco (pod/container removido), lendo antes o restante do handle aberto
+func (fm *FileMonitor) removeDeletedFiles(found map[string]bool) {
+	fm.mutex.RLock()
+	var removed []*monitoredFile
+	for path, mf := range fm.files {
+		if !found[path] {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				removed = append(removed, mf)
+			}
+		}
+	}
+	fm.mutex.RUnlock()
+
+	for _, mf := range removed {
+		if mf.file != nil {
+			fm.readFile(mf)
+
+			mf.readMutex.Lock()
+			fm.finishFile(mf)
+			mf.readMutex.Unlock()
+		}
+
+		if err := fm.RemoveFile(mf.path); err != nil {
+			fm.logger.WithError(err).WithField("path", mf.path).Debug("Failed to remove deleted file")
+		}
+	}
+}
+
 // GetMonitoredFiles retorna lista de arquivos monitorados
 func (fm *FileMonitor) GetMonitoredFiles() []map[string]string {
 	fm.mutex.RLock()
@@ -371,6 +488,10 @@ func (fm *FileMonitor) monitorLoop(ctx context.Context) error {
 	pollTicker := time.NewTicker(2 * time.Second)
 	defer pollTicker.Stop()
 
+	// Ticker para liberar eventos multiline de arquivos que ficaram quietos
+	multilineTicker := time.NewTicker(1 * time.Second)
+	defer multilineTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -385,6 +506,8 @@ func (fm *FileMonitor) monitorLoop(ctx context.Context) error {
 		case <-pollTicker.C:
 			// Active polling - check all files for changes
 			fm.pollAllFiles()
+		case <-multilineTicker.C:
+			fm.flushMultiline(false)
 		}
 
 		// Heartbeat
@@ -407,12 +530,15 @@ func (fm *FileMonitor) pollAllFiles() {
 			continue
 		}
 
-		// Check if file has grown (new content)
-		if info.Size() > mf.position {
+		// Check if file has grown (new content), was truncated or replaced by rotation
+		inode, device := positions.FileIdentity(info)
+		rotated := mf.inode != 0 && (inode != mf.inode || device != mf.device)
+		if info.Size() != mf.readOffset() || rotated {
 			fm.logger.WithFields(logrus.Fields{
 				"path": mf.path,
 				"old_position": mf.position,
 				"new_size": info.Size(),
+				"rotated": rotated,
 			}).Debug("File has new content, reading...")
 			fm.readFile(mf)
 		}
@@ -438,13 +564,13 @@ func (fm *FileMonitor) healthCheckFiles() {
 
 		// Lógica para verificar se o arquivo foi rotacionado ou truncado silenciosamente
 		// Esta é uma verificação de segurança caso o fsnotify falhe.
+		// readFile detecta o truncamento e reinicia a leitura do início
 		if info.Size() < mf.position {
 			fm.logger.WithFields(logrus.Fields{
 				"path": mf.path,
 				"stored_position": mf.position,
 				"actual_size": info.Size(),
 			}).Warn("Health check detected file truncation. Forcing re-read.")
-			mf.position = 0 // Reseta a posição
 			fm.readFile(mf) // Força a releitura
 		}
 	}
@@ -459,10 +585,13 @@ func (fm *FileMonitor) handleFileEvent(event fsnotify.Event) {
 		"file": event.Name,
 	}).Debug("File event received")
 
-	// Process WRITE, CREATE, and CHMOD events
+	// Process WRITE, CREATE, CHMOD, RENAME and REMOVE events
+	// (rename/remove permitem drenar o arquivo rotacionado o quanto antes)
 	if event.Op&fsnotify.Write == fsnotify.Write ||
 	   event.Op&fsnotify.Create == fsnotify.Create ||
-	   event.Op&fsnotify.Chmod == fsnotify.Chmod {
+	   event.Op&fsnotify.Chmod == fsnotify.Chmod ||
+	   event.Op&fsnotify.Rename == fsnotify.Rename ||
+	   event.Op&fsnotify.Remove == fsnotify.Remove {
 		fm.mutex.RLock()
 		mf, exists := fm.files[event.Name]
 		fm.mutex.RUnlock()
@@ -583,49 +712,126 @@ func (fm *FileMonitor) pollFiles() {
 
 // readFile lê novas linhas de um arquivo
 func (fm *FileMonitor) readFile(mf *monitoredFile) {
+	// Serializar leituras do mesmo arquivo (eventos, polling e leitura inicial)
+	mf.readMutex.Lock()
+	defer mf.readMutex.Unlock()
+
+	startTime := time.Now()
+	linesRead := 0
+
+	// Drenar arquivo rotacionado enquanto o serviço estava parado
+	if mf.drainPath != "" {
+		linesRead += fm.drainRotatedFile(mf)
+	}
+
+	// Detectar rotação (rename) ou truncamento (copytruncate) do handle aberto
+	if mf.file != nil {
+		linesRead += fm.checkRotation(mf)
+	}
+
 	// Abrir arquivo se necessário
 	if mf.file == nil {
-		file, err := os.Open(mf.path)
-		if err != nil {
-			fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to open file")
-			metrics.RecordError("file_monitor", "file_open_error")
-			return
-		}
+		fm.openFile(mf)
+	}
 
-		// Garantir fechamento em caso de erro
-		defer func() {
-			if mf.file == nil && file != nil {
-				file.Close()
-			}
-		}()
+	if mf.file != nil {
+		linesRead += fm.readLines(mf, mf.reader)
+	}
 
-		mf.file = file
-		mf.reader = bufio.NewReader(file)
-
-		// Buscar posição salva
-		if _, err := file.Seek(mf.position, 0); err != nil {
-			fm.logger.WithError(err).WithField("path", mf.path).Warn("Failed to seek to saved position")
-			mf.position = 0
-			if _, seekErr := file.Seek(0, 0); seekErr != nil {
-				fm.logger.WithError(seekErr).WithField("path", mf.path).Error("Failed to seek to beginning")
-				// Fechar arquivo em caso de erro fatal
-				mf.file.Close()
-				mf.file = nil
-				mf.reader = nil
-				return
-			}
+	mf.lastRead = time.Now()
+
+	// Atualizar posição no position manager
+	if fm.positionManager != nil && linesRead > 0 {
+		fm.savePosition(mf, int64(linesRead))
+	}
+
+	// Métricas
+	if linesRead > 0 {
+		duration := time.Since(startTime)
+		metrics.RecordProcessingDuration("file_monitor", "read_file", duration)
+		metrics.RecordLogProcessed("file", fm.getSourceID(mf.path), "file_monitor")
+	}
+}
+
+// openFile abre o arquivo na posição salva e registra sua identidade (inode/device/fingerprint)
+func (fm *FileMonitor) openFile(mf *monitoredFile) bool {
+	file, err := os.Open(mf.path)
+	if err != nil {
+		fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to open file")
+		metrics.RecordError("file_monitor", "file_open_error")
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to stat opened file")
+		metrics.RecordError("file_monitor", "file_open_error")
+		file.Close()
+		return false
+	}
+
+	// Posição além do fim do arquivo: arquivo foi truncado enquanto estava fechado
+	if mf.position > info.Size() {
+		fm.logger.WithFields(logrus.Fields{
+			"path":            mf.path,
+			"stored_position": mf.position,
+			"actual_size":     info.Size(),
+		}).Warn("Saved position beyond end of file, reading from beginning")
+		mf.position = 0
+		mf.resetPartial()
+	}
+
+	// Buscar posição salva
+	if _, err := file.Seek(mf.position, io.SeekStart); err != nil {
+		fm.logger.WithError(err).WithField("path", mf.path).Warn("Failed to seek to saved position")
+		mf.position = 0
+		if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+			fm.logger.WithError(seekErr).WithField("path", mf.path).Error("Failed to seek to beginning")
+			// Fechar arquivo em caso de erro fatal
+			file.Close()
+			return false
 		}
 	}
 
-	// Ler linhas
-	startTime := time.Now()
+	mf.file = file
+	mf.reader = bufio.NewReader(file)
+	mf.inode, mf.device = positions.FileIdentity(info)
+	if mf.decoder != nil {
+		mf.decoder.detectFileBOM(file)
+	}
+	mf.fingerprint, mf.fingerprintSize, _ = positions.ComputeFingerprint(file, info.Size())
+	mf.checkedSize, mf.checkedModTime = info.Size(), info.ModTime()
+
+	return true
+}
+
+// readLines lê as linhas completas disponíveis no reader, avançando mf.position.
+// Linhas sem newline no fim do arquivo ficam pendentes até serem completadas.
+// Com encoding configurado, mf.position continua em bytes do arquivo original.
+func (fm *FileMonitor) readLines(mf *monitoredFile, reader *bufio.Reader) int {
 	linesRead := 0
 
+	// No início do arquivo o BOM define a codificação
+	if mf.decoder != nil && mf.position == 0 && mf.partial == "" {
+		prefix, _ := reader.Peek(3)
+		if !mf.decoder.detectBOM(prefix) {
+			return 0
+		}
+	}
+
 	for {
-		line, err := mf.reader.ReadString('\n')
+		chunk, err := fm.readChunk(mf, reader)
+		if err == bufio.ErrBufferFull {
+			// Linha maior que o buffer: acumular respeitando max_line_bytes
+			mf.partial += chunk
+			linesRead += fm.limitPartial(mf)
+			continue
+		}
 		if err != nil {
 			if err == io.EOF {
-				// Fim do arquivo
+				// Fim do arquivo - guardar linha incompleta
+				mf.partial += chunk
+				linesRead += fm.limitPartial(mf)
 				break
 			}
 			fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to read line")
@@ -633,105 +839,222 @@ func (fm *FileMonitor) readFile(mf *monitoredFile) {
 			break
 		}
 
-		// Remover newline
-		line = strings.TrimSuffix(line, "\n")
-		if line == "" {
-			continue
-		}
+		line := mf.partial + chunk
+		mf.partial = ""
+		linesRead += fm.completeLine(mf, line)
+	}
+
+	return linesRead
+}
+
+// emitLine envia a linha diretamente ou através do agregador multiline
+func (fm *FileMonitor) emitLine(mf *monitoredFile, line string, rawSize int64) {
+	if mf.cri != nil {
+		fm.emitCRILine(mf, line, rawSize)
+		return
+	}
+
+	if mf.multiline == nil {
+		fm.dispatchFileLine(mf, line)
+		return
+	}
 
-		// Processar linha com labels padrão
-		sourceID := fm.getSourceID(mf.path)
-		standardLabels := addStandardLabelsFile(mf.labels)
+	// Agregar linhas em eventos lógicos (stack traces, etc.)
+	for _, event := range mf.multiline.Add(line, rawSize) {
+		fm.dispatchFileLine(mf, event)
+	}
+}
 
-		// Criar entry para validações
-		traceID := uuid.New().String()
-		entry := &types.LogEntry{
-			TraceID:     traceID,
-			Timestamp:   time.Now(),
-			Message:     line,
-			SourceType:  "file",
-			SourceID:    sourceID,
-			Labels:      standardLabels,
-			ProcessedAt: time.Now(),
+// dispatchFileLine valida e envia uma linha (ou evento multiline) ao dispatcher
+func (fm *FileMonitor) dispatchFileLine(mf *monitoredFile, line string) {
+	fm.dispatchFileRecord(mf, line, time.Time{}, "")
+}
+
+// dispatchFileRecord envia uma linha com o timestamp e o stream informados pela
+// origem (formato CRI). Timestamp zero usa o horário de leitura.
+func (fm *FileMonitor) dispatchFileRecord(mf *monitoredFile, line string, timestamp time.Time, stream string) {
+	fm.sendFileEntry(mf, fm.newFileEntry(mf, line, timestamp, stream))
+}
+
+// newFileEntry cria o LogEntry de uma linha com as labels do arquivo
+func (fm *FileMonitor) newFileEntry(mf *monitoredFile, line string, timestamp time.Time, stream string) *types.LogEntry {
+	// Processar linha com labels padrão
+	sourceID := mf.sourceID
+	if sourceID == "" {
+		sourceID = fm.getSourceID(mf.path)
+	}
+	standardLabels := addStandardLabelsFile(mf.labels)
+
+	sourceType := "file"
+	if mf.sourceType != "" {
+		sourceType = mf.sourceType
+		standardLabels["source"] = mf.sourceType
+	}
+	if stream != "" {
+		standardLabels["stream"] = stream
+	}
+	if mf.startPending.Swap(false) {
+		standardLabels[types.LabelFileStart] = "true"
+	}
+	if fm.enricher != nil {
+		fm.enricher.EnrichLabels(standardLabels)
+	}
+
+	now := time.Now()
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+
+	// Criar entry para validações
+	traceID := uuid.New().String()
+	entry := &types.LogEntry{
+		TraceID:     traceID,
+		Timestamp:   timestamp,
+		Message:     line,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		Labels:      standardLabels,
+		ProcessedAt: now,
+	}
+	return entry
+}
+
+// sendFileEntry valida o timestamp e envia a entrada ao dispatcher
+func (fm *FileMonitor) sendFileEntry(mf *monitoredFile, entry *types.LogEntry) {
+	// Verificar se é self-log usando feedback guard (temporariamente desabilitado)
+	/*
+	if fm.feedbackGuard != nil {
+		guardResult := fm.feedbackGuard.CheckEntry(entry)
+		if guardResult.IsSelfLog && guardResult.Action == "drop" {
+			fm.logger.WithFields(logrus.Fields{
+				"path":          mf.path,
+				"reason":        guardResult.Reason,
+				"match_pattern": guardResult.MatchPattern,
+			}).Debug("Self-log dropped by feedback guard")
+			return
 		}
+	}
+	*/
 
-		// Verificar se é self-log usando feedback guard (temporariamente desabilitado)
-		/*
-		if fm.feedbackGuard != nil {
-			guardResult := fm.feedbackGuard.CheckEntry(entry)
-			if guardResult.IsSelfLog && guardResult.Action == "drop" {
-				fm.logger.WithFields(logrus.Fields{
-					"path":          mf.path,
-					"reason":        guardResult.Reason,
-					"match_pattern": guardResult.MatchPattern,
-				}).Debug("Self-log dropped by feedback guard")
-				continue
-			}
+	// Validar timestamp se o timestamp validator estiver disponível
+	if fm.timestampValidator != nil {
+		result := fm.timestampValidator.ValidateTimestamp(entry)
+		if !result.Valid && result.Action == "rejected" {
+			fm.logger.WithFields(logrus.Fields{
+				"path":   mf.path,
+				"reason": result.Reason,
+				"line":   entry.Message,
+			}).Warn("Log line rejected due to invalid timestamp")
+			return
 		}
-		*/
+	}
 
-		// Validar timestamp se o timestamp validator estiver disponível
-		if fm.timestampValidator != nil {
-			result := fm.timestampValidator.ValidateTimestamp(entry)
-			if !result.Valid && result.Action == "rejected" {
-				fm.logger.WithFields(logrus.Fields{
-					"path":   mf.path,
-					"reason": result.Reason,
-					"line":   line,
-				}).Warn("Log line rejected due to invalid timestamp")
-				continue
-			}
+	if err := fm.dispatcher.HandleEntry(fm.ctx, entry); err != nil {
+		fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to dispatch log line")
+		metrics.RecordError("file_monitor", "dispatch_error")
+	}
+}
+
+// flushMultiline envia eventos multiline pendentes. Sem force, apenas os
+// eventos de arquivos ociosos há mais que o flush_timeout são liberados.
+func (fm *FileMonitor) flushMultiline(force bool) {
+	fm.mutex.RLock()
+	files := make([]*monitoredFile, 0, len(fm.files))
+	for _, mf := range fm.files {
+		if mf.multiline != nil || mf.cri != nil {
+			files = append(files, mf)
 		}
+	}
+	fm.mutex.RUnlock()
+
+	now := time.Now()
+	for _, mf := range files {
+		fm.flushFileMultiline(mf, force, now)
+	}
+}
 
-		if err := fm.dispatcher.Handle(fm.ctx, "file", sourceID, line, standardLabels); err != nil {
-			fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to dispatch log line")
-			metrics.RecordError("file_monitor", "dispatch_error")
+// flushFileMultiline libera o evento pendente de um arquivo. Segura o
+// readMutex para não intercalar o evento com linhas de um readFile em curso.
+func (fm *FileMonitor) flushFileMultiline(mf *monitoredFile, force bool, now time.Time) {
+	mf.readMutex.Lock()
+	defer mf.readMutex.Unlock()
+
+	if mf.cri != nil {
+		if fm.flushCRI(mf, force, now) > 0 && fm.positionManager != nil {
+			fm.savePosition(mf, 0)
 		}
+		return
+	}
 
-		linesRead++
-		mf.position += int64(len(line)) + 1 // +1 para o newline
+	var event string
+	var ok bool
+	if force {
+		event, ok = mf.multiline.Flush()
+	} else {
+		event, ok = mf.multiline.FlushExpired(now)
+	}
+	if !ok {
+		return
 	}
 
-	mf.lastRead = time.Now()
+	fm.dispatchFileLine(mf, event)
+	if fm.positionManager != nil {
+		fm.savePosition(mf, 0)
+	}
+}
 
-	// Atualizar posição no position manager
-	if fm.positionManager != nil && linesRead > 0 {
-		// Get file info for inode and device
-		info, err := os.Stat(mf.path)
-		if err == nil {
-			// Get file size
-			fileSize := info.Size()
-			lastModTime := info.ModTime()
-
-			// Get inode and device (will be 0 on non-Unix systems, but that's ok)
-			var inode, device uint64
-			if stat, ok := info.Sys().(*syscall.Stat_t); ok {
-				inode = stat.Ino
-				device = stat.Dev
-			}
+// savePosition persiste a posição do arquivo no position manager
+func (fm *FileMonitor) savePosition(mf *monitoredFile, linesRead int64) {
+	// Usar o handle aberto: após uma rotação o path pode apontar para outro arquivo
+	var info os.FileInfo
+	var err error
+	if mf.file != nil {
+		info, err = mf.file.Stat()
+	} else {
+		info, err = os.Stat(mf.path)
+	}
+	if err != nil {
+		return
+	}
 
-			bytesRead := int64(linesRead * 10) // Rough estimate, could be improved
-			if fm.positionManager != nil {
-			fm.positionManager.UpdateFilePosition(
-				mf.path,
-				mf.position,
-				fileSize,
-				lastModTime,
-				inode,
-				device,
-				bytesRead,
-				int64(linesRead),
-			)
-			}
+	// Get file size
+	fileSize := info.Size()
+	lastModTime := info.ModTime()
+
+	// Get inode and device (will be 0 on non-Unix systems, but that's ok)
+	inode, device := positions.FileIdentity(info)
+
+	// Completar o fingerprint enquanto o arquivo ainda é menor que o tamanho padrão
+	if mf.file != nil && mf.fingerprintSize < positions.FingerprintSize && fileSize > mf.fingerprintSize {
+		if fingerprint, size, err := positions.ComputeFingerprint(mf.file, fileSize); err == nil {
+			mf.fingerprint, mf.fingerprintSize = fingerprint, size
 		}
 	}
+	if mf.fingerprint != "" {
+		fm.positionManager.SetFileFingerprint(mf.path, mf.fingerprint, mf.fingerprintSize)
+	}
 
-	// Métricas
-	if linesRead > 0 {
-		duration := time.Since(startTime)
-		metrics.RecordProcessingDuration("file_monitor", "read_file", duration)
-		metrics.RecordLogProcessed("file", fm.getSourceID(mf.path), "file_monitor")
+	// Não persistir além do último evento completo: linhas ainda agregadas
+	// seriam perdidas em um restart
+	position := mf.position
+	if mf.multiline != nil {
+		position -= mf.multiline.PendingBytes()
+	}
+	if mf.cri != nil {
+		position -= mf.cri.pendingBytes()
 	}
+
+	bytesRead := linesRead * 10 // Rough estimate, could be improved
+	fm.positionManager.UpdateFilePosition(
+		mf.path,
+		position,
+		fileSize,
+		lastModTime,
+		inode,
+		device,
+		bytesRead,
+		linesRead,
+	)
 }
 
 
@@ -746,6 +1069,97 @@ func (fm *FileMonitor) getTaskName(path string) string {
 	return "file_" + fm.getSourceID(path)
 }
 
+// toStringMap converte mapas YAML (map[interface{}]interface{}) para map[string]interface{}
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if keyStr, ok := key.(string); ok {
+				result[keyStr] = val
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// decodeEntryOption decodifica uma seção genérica do pipeline em uma struct tipada
+func decodeEntryOption(raw interface{}, out interface{}) error {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+// newLineDecoders cria o agregador multiline ou, nos formatos CRI e docker-json,
+// o decoder que remonta linhas parciais e aplica a agregação multiline por stream
+func newLineDecoders(opts fileReadOptions) (*multilineAggregator, *criDecoder, error) {
+	switch opts.format {
+	case fileFormatCRI:
+		cri, err := newCRIDecoder(opts.multiline)
+		if cri != nil {
+			cri.skipStreams = opts.skipStreams
+		}
+		return nil, cri, err
+	case fileFormatDockerJSON:
+		decoder, err := newDockerJSONDecoder(opts.multiline)
+		if decoder != nil {
+			decoder.skipStreams = opts.skipStreams
+		}
+		return nil, decoder, err
+	}
+	multiline, err := newMultilineAggregator(opts.multiline)
+	return multiline, nil, err
+}
+
+// parseFileReadOptions extrai opções de leitura de uma entrada files/directories do pipeline
+func parseFileReadOptions(entry map[string]interface{}) (fileReadOptions, error) {
+	var opts fileReadOptions
+
+	if raw, ok := entry["multiline"]; ok && raw != nil {
+		var multiline types.MultilineConfig
+		if err := decodeEntryOption(raw, &multiline); err != nil {
+			return opts, fmt.Errorf("invalid multiline section: %w", err)
+		}
+		// Validar padrões antecipadamente
+		if _, err := newMultilineAggregator(&multiline); err != nil {
+			return opts, err
+		}
+		opts.multiline = &multiline
+	}
+
+	if includeCompressed, ok := entry["include_compressed"].(bool); ok {
+		opts.includeCompressed = includeCompressed
+	}
+
+	if format, ok := entry["format"].(string); ok && format != "" {
+		if format != fileFormatCRI && format != fileFormatDockerJSON {
+			return opts, fmt.Errorf("unsupported file format %q", format)
+		}
+		opts.format = format
+	}
+
+	if encoding, ok := entry["encoding"].(string); ok && encoding != "" {
+		if _, err := newFileDecoder(encoding); err != nil {
+			return opts, err
+		}
+		opts.encoding = encoding
+	}
+
+	startAt, _ := entry["start_at"].(string)
+	ignoreOlderThan, _ := entry["ignore_older_than"].(string)
+	var err error
+	if opts.startAt, opts.ignoreOlderThan, err = parseStartOptions(startAt, ignoreOlderThan); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
 // getMapKeys helper function to get keys from a map
 func getMapKeys(m map[string]interface{}) []string {
 	keys := make([]string, 0, len(m))
@@ -782,7 +1196,7 @@ func (fm *FileMonitor) discoverFiles() error {
 		for _, directory := range fm.config.WatchDirectories {
 			fm.logger.WithField("directory", directory).Info("Scanning directory for log files")
 
-			if err := fm.scanDirectory(directory); err != nil {
+			if err := fm.scanDirectory(directory, fileReadOptions{}); err != nil {
 				fm.logger.WithError(err).WithField("directory", directory).Error("Failed to scan directory")
 				continue
 			}
@@ -790,6 +1204,10 @@ func (fm *FileMonitor) discoverFiles() error {
 	}
 
 	fm.logger.WithField("monitored_files", len(fm.files)).Info("File discovery completed")
+
+	// Arquivos comprimidos são ingeridos em background, após os arquivos ativos
+	// já estarem sendo seguidos
+	fm.startArchiveIngestion()
 	return nil
 }
 
@@ -886,11 +1304,26 @@ func (fm *FileMonitor) processSpecificFiles() error {
 			}
 		}
 
+		// Extrair opções de leitura (multiline, etc.)
+		opts, err := parseFileReadOptions(fileMap)
+		if err != nil {
+			fm.logger.WithError(err).WithField("path", path).Warn("Invalid read options for specific file, skipping")
+			continue
+		}
+
 		// Marcar como arquivo específico para evitar duplicação
 		fm.specificFiles[path] = true
 
+		if info, err := os.Stat(path); err == nil && isTooOld(info, opts.ignoreOlderThan) {
+			fm.logger.WithFields(logrus.Fields{
+				"path":     path,
+				"mod_time": info.ModTime(),
+			}).Info("Specific file older than ignore_older_than, skipping")
+			continue
+		}
+
 		// Adicionar arquivo para monitoramento
-		if err := fm.AddFile(path, labels); err != nil {
+		if err := fm.addFileWithOptions(path, labels, opts); err != nil {
 			fm.logger.WithError(err).WithField("path", path).Warn("Failed to add specific file from pipeline")
 		} else {
 			fm.logger.WithField("path", path).Info("Added specific file from pipeline")
@@ -920,7 +1353,7 @@ func (fm *FileMonitor) processPipelineDirectories() error {
 		if dirsStringSlice, ok := dirsInterface.([]string); ok {
 			for _, dir := range dirsStringSlice {
 				fm.logger.WithField("directory", dir).Info("Scanning directory from pipeline")
-				if err := fm.scanDirectory(dir); err != nil {
+				if err := fm.scanDirectory(dir, fileReadOptions{}); err != nil {
 					fm.logger.WithError(err).WithField("directory", dir).Warn("Failed to scan directory from pipeline")
 				}
 			}
@@ -939,13 +1372,14 @@ func (fm *FileMonitor) processPipelineDirectories() error {
 		case string:
 			// Diretório simples
 			fm.logger.WithField("directory", dir).Info("Scanning directory from pipeline")
-			if err := fm.scanDirectory(dir); err != nil {
+			if err := fm.scanDirectory(dir, fileReadOptions{}); err != nil {
 				fm.logger.WithError(err).WithField("directory", dir).Warn("Failed to scan directory from pipeline")
 			}
 
-		case map[string]interface{}:
-			// Diretório com configurações
-			pathInterface, ok := dir["path"]
+		case map[string]interface{}, map[interface{}]interface{}:
+			// Diretório com configurações (YAML pode retornar qualquer um dos tipos de mapa)
+			dirMap, _ := toStringMap(dir)
+			pathInterface, ok := dirMap["path"]
 			if !ok {
 				fm.logger.Warn("Directory entry missing 'path' field")
 				continue
@@ -958,7 +1392,7 @@ func (fm *FileMonitor) processPipelineDirectories() error {
 			}
 
 			// Verificar se está habilitado
-			enabledInterface, ok := dir["enabled"]
+			enabledInterface, ok := dirMap["enabled"]
 			if ok {
 				enabled, ok := enabledInterface.(bool)
 				if ok && !enabled {
@@ -967,8 +1401,29 @@ func (fm *FileMonitor) processPipelineDirectories() error {
 				}
 			}
 
+			opts, err := parseFileReadOptions(dirMap)
+			if err != nil {
+				fm.logger.WithError(err).WithField("path", path).Warn("Invalid read options for directory, skipping")
+				continue
+			}
+
+			// Entradas com "patterns" usam os próprios padrões, exclusões e labels;
+			// as demais seguem os padrões globais de files_config
+			if _, hasPatterns := dirMap["patterns"]; hasPatterns {
+				var dirEntry types.FilePipelineDirEntry
+				if err := decodeEntryOption(dirMap, &dirEntry); err != nil {
+					fm.logger.WithError(err).WithField("path", path).Warn("Invalid directory entry, skipping")
+					continue
+				}
+				fm.logger.WithField("directory", path).Info("Scanning directory from pipeline")
+				if err := fm.scanPipelineDirectory(dirEntry); err != nil {
+					fm.logger.WithError(err).WithField("directory", path).Warn("Failed to scan directory from pipeline")
+				}
+				continue
+			}
+
 			fm.logger.WithField("directory", path).Info("Scanning directory from pipeline")
-			if err := fm.scanDirectory(path); err != nil {
+			if err := fm.scanDirectory(path, opts); err != nil {
 				fm.logger.WithError(err).WithField("directory", path).Warn("Failed to scan directory from pipeline")
 			}
 
@@ -981,7 +1436,9 @@ func (fm *FileMonitor) processPipelineDirectories() error {
 }
 
 // scanDirectory escaneia um diretório procurando por arquivos que correspondem aos padrões
-func (fm *FileMonitor) scanDirectory(directory string) error {
+func (fm *FileMonitor) scanDirectory(directory string, opts fileReadOptions) error {
+	recursive := fm.config.Recursive || globsNeedRecursion(fm.config.IncludePatterns)
+
 	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			// Ignorar erros de permissão e continuar
@@ -1001,7 +1458,7 @@ func (fm *FileMonitor) scanDirectory(directory string) error {
 			}
 
 			// Se não for recursivo, pular subdiretórios
-			if !fm.config.Recursive && path != directory {
+			if !recursive && path != directory {
 				return filepath.SkipDir
 			}
 			return nil
@@ -1013,8 +1470,22 @@ func (fm *FileMonitor) scanDirectory(directory string) error {
 			return nil
 		}
 
+		// Arquivos sem escrita recente não são seguidos
+		if isTooOld(info, opts.ignoreOlderThan) {
+			fm.logger.WithField("path", path).Debug("Skipping file older than ignore_older_than")
+			return nil
+		}
+
+		// Arquivos comprimidos nunca são seguidos como texto
+		if archiveCompression(path) != "" {
+			if opts.includeCompressed && matchesArchivePatterns(path, fm.config.IncludePatterns, fm.config.ExcludePatterns) {
+				fm.queueArchive(path, fm.generateLabelsForFile(path), opts)
+			}
+			return nil
+		}
+
 		// Verificar se o arquivo corresponde aos padrões de inclusão
-		if fm.matchesIncludePatterns(path) && !fm.matchesExcludePatterns(path) {
+		if fm.matchesIncludePatterns(directory, path) && !fm.matchesExcludePatterns(directory, path) {
 			// Verificar se o arquivo já está sendo monitorado
 			fm.mutex.RLock()
 			_, exists := fm.files[path]
@@ -1022,7 +1493,7 @@ func (fm *FileMonitor) scanDirectory(directory string) error {
 
 			if !exists {
 				labels := fm.generateLabelsForFile(path)
-				if err := fm.AddFile(path, labels); err != nil {
+				if err := fm.addFileWithOptions(path, labels, opts); err != nil {
 					fm.logger.WithError(err).WithField("path", path).Warn("Failed to add discovered file")
 				} else {
 					fm.logger.WithField("path", path).Info("Auto-discovered and added file for monitoring")
@@ -1036,6 +1507,23 @@ func (fm *FileMonitor) scanDirectory(directory string) error {
 
 // scanPipelineDirectory escaneia um diretório do pipeline
 func (fm *FileMonitor) scanPipelineDirectory(dirEntry types.FilePipelineDirEntry) error {
+	startAt, ignoreOlderThan, err := parseStartOptions(dirEntry.StartAt, dirEntry.IgnoreOlderThan)
+	if err != nil {
+		return err
+	}
+	opts := fileReadOptions{
+		multiline:         dirEntry.Multiline,
+		includeCompressed: dirEntry.IncludeCompressed,
+		format:            dirEntry.Format,
+		startAt:           startAt,
+		ignoreOlderThan:   ignoreOlderThan,
+		encoding:          dirEntry.Encoding,
+	}
+	if _, err := newFileDecoder(dirEntry.Encoding); err != nil {
+		return err
+	}
+	recursive := dirEntry.Recursive || globsNeedRecursion(dirEntry.Patterns)
+
 	return filepath.Walk(dirEntry.Path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			// Ignorar erros de permissão
@@ -1055,7 +1543,7 @@ func (fm *FileMonitor) scanPipelineDirectory(dirEntry types.FilePipelineDirEntry
 			}
 
 			// Se não for recursivo, pular subdiretórios
-			if !dirEntry.Recursive && path != dirEntry.Path {
+			if !recursive && path != dirEntry.Path {
 				return filepath.SkipDir
 			}
 			return nil
@@ -1067,9 +1555,29 @@ func (fm *FileMonitor) scanPipelineDirectory(dirEntry types.FilePipelineDirEntry
 			return nil
 		}
 
+		// Arquivos sem escrita recente não são seguidos
+		if isTooOld(info, opts.ignoreOlderThan) {
+			fm.logger.WithField("path", path).Debug("Skipping file older than ignore_older_than")
+			return nil
+		}
+
+		// Arquivos comprimidos nunca são seguidos como texto
+		if archiveCompression(path) != "" {
+			if opts.includeCompressed && matchesArchivePatterns(path, dirEntry.Patterns, dirEntry.ExcludePatterns) {
+				labels := make(map[string]string)
+				for k, v := range dirEntry.DefaultLabels {
+					labels[k] = v
+				}
+				labels["file_path"] = path
+				labels["file_name"] = filepath.Base(path)
+				fm.queueArchive(path, labels, opts)
+			}
+			return nil
+		}
+
 		// Verificar padrões do diretório
-		if fm.matchesPipelinePatterns(path, dirEntry.Patterns) &&
-		   !fm.matchesPipelineExcludePatterns(path, dirEntry.ExcludePatterns) {
+		if fm.matchesPipelinePatterns(dirEntry.Path, path, dirEntry.Patterns) &&
+		   !fm.matchesPipelineExcludePatterns(dirEntry.Path, path, dirEntry.ExcludePatterns) {
 
 			// Verificar se já está sendo monitorado
 			fm.mutex.RLock()
@@ -1086,7 +1594,7 @@ func (fm *FileMonitor) scanPipelineDirectory(dirEntry types.FilePipelineDirEntry
 				labels["file_path"] = path
 				labels["file_name"] = filepath.Base(path)
 
-				if err := fm.AddFile(path, labels); err != nil {
+				if err := fm.addFileWithOptions(path, labels, opts); err != nil {
 					fm.logger.WithError(err).WithField("path", path).Warn("Failed to add file from pipeline directory")
 				} else {
 					fm.logger.WithFields(logrus.Fields{
@@ -1102,17 +1610,10 @@ func (fm *FileMonitor) scanPipelineDirectory(dirEntry types.FilePipelineDirEntry
 }
 
 // matchesIncludePatterns verifica se o arquivo corresponde aos padrões de inclusão
-func (fm *FileMonitor) matchesIncludePatterns(filePath string) bool {
-	fileName := filepath.Base(filePath)
-
+// (padrões com "/" ou "**" são relativos ao diretório escaneado)
+func (fm *FileMonitor) matchesIncludePatterns(root, filePath string) bool {
 	for _, pattern := range fm.config.IncludePatterns {
-		matched, err := filepath.Match(pattern, fileName)
-		if err == nil && matched {
-			return true
-		}
-
-		// Para padrões sem wildcards, verificar match exato do nome
-		if pattern == fileName {
+		if matchesGlob(pattern, root, filePath) {
 			return true
 		}
 	}
@@ -1121,12 +1622,9 @@ func (fm *FileMonitor) matchesIncludePatterns(filePath string) bool {
 }
 
 // matchesExcludePatterns verifica se o arquivo corresponde aos padrões de exclusão
-func (fm *FileMonitor) matchesExcludePatterns(filePath string) bool {
-	fileName := filepath.Base(filePath)
-
+func (fm *FileMonitor) matchesExcludePatterns(root, filePath string) bool {
 	for _, pattern := range fm.config.ExcludePatterns {
-		matched, err := filepath.Match(pattern, fileName)
-		if err == nil && matched {
+		if matchesGlob(pattern, root, filePath) {
 			return true
 		}
 	}
@@ -1161,17 +1659,9 @@ func (fm *FileMonitor) matchesExcludeDirectories(dirPath string) bool {
 }
 
 // matchesPipelinePatterns verifica se arquivo corresponde aos padrões do pipeline
-func (fm *FileMonitor) matchesPipelinePatterns(filePath string, patterns []string) bool {
-	fileName := filepath.Base(filePath)
-
+func (fm *FileMonitor) matchesPipelinePatterns(root, filePath string, patterns []string) bool {
 	for _, pattern := range patterns {
-		matched, err := filepath.Match(pattern, fileName)
-		if err == nil && matched {
-			return true
-		}
-
-		// Match exato para padrões sem wildcards
-		if pattern == fileName {
+		if matchesGlob(pattern, root, filePath) {
 			return true
 		}
 	}
@@ -1180,12 +1670,9 @@ func (fm *FileMonitor) matchesPipelinePatterns(filePath string, patterns []strin
 }
 
 // matchesPipelineExcludePatterns verifica exclusões do pipeline
-func (fm *FileMonitor) matchesPipelineExcludePatterns(filePath string, patterns []string) bool {
-	fileName := filepath.Base(filePath)
-
+func (fm *FileMonitor) matchesPipelineExcludePatterns(root, filePath string, patterns []string) bool {
 	for _, pattern := range patterns {
-		matched, err := filepath.Match(pattern, fileName)
-		if err == nil && matched {
+		if matchesGlob(pattern, root, filePath) {
 			return true
 		}
 	}
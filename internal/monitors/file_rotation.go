@@ -0,0 +1,279 @@
+package monitors
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/positions"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileStatusRotated marca no position manager gerações anteriores de um arquivo
+// seguido, usadas para não reingerir o conteúdo quando ele reaparece comprimido
+const fileStatusRotated = "rotated"
+
+// checkRotation detecta rotação por rename e copytruncate do arquivo aberto.
+// Em rename, o handle antigo é drenado até o EOF antes de trocar para o novo
+// arquivo; em truncamento a leitura recomeça do início. Retorna as linhas lidas.
+func (fm *FileMonitor) checkRotation(mf *monitoredFile) int {
+	openInfo, err := mf.file.Stat()
+	if err != nil {
+		return 0
+	}
+
+	pathInfo, err := os.Stat(mf.path)
+	if err != nil {
+		// Arquivo renomeado e ainda não recriado: continuar lendo o handle antigo
+		return 0
+	}
+
+	if !os.SameFile(openInfo, pathInfo) {
+		return fm.switchRotatedFile(mf)
+	}
+
+	truncated := openInfo.Size() < mf.readOffset()
+	if !truncated && mf.fingerprintSize > 0 && (openInfo.Size() != mf.checkedSize || !openInfo.ModTime().Equal(mf.checkedModTime)) {
+		// Truncado e reescrito além da posição antiga: o início do arquivo mudou.
+		// Só é conferido quando o arquivo mudou desde a última conferência.
+		truncated = !positions.MatchesFingerprint(mf.file, openInfo.Size(), mf.fingerprint, mf.fingerprintSize)
+		mf.checkedSize, mf.checkedModTime = openInfo.Size(), openInfo.ModTime()
+	}
+
+	if truncated {
+		fm.rememberRotatedFile(mf.path, mf.fingerprint, mf.fingerprintSize, mf.position)
+		fm.logger.WithFields(logrus.Fields{
+			"path":            mf.path,
+			"stored_position": mf.position,
+			"actual_size":     openInfo.Size(),
+		}).Info("File truncation detected (copytruncate), reading from beginning")
+		metrics.RecordFileRotation("truncate")
+
+		if _, err := mf.file.Seek(0, io.SeekStart); err != nil {
+			fm.logger.WithError(err).WithField("path", mf.path).Error("Failed to seek truncated file")
+			fm.closeFile(mf)
+		} else {
+			mf.reader.Reset(mf.file)
+		}
+		mf.position = 0
+		mf.resetPartial()
+		mf.fingerprint, mf.fingerprintSize, _ = positions.ComputeFingerprint(mf.file, openInfo.Size())
+		mf.checkedSize, mf.checkedModTime = openInfo.Size(), openInfo.ModTime()
+	}
+
+	return 0
+}
+
+// switchRotatedFile drena o handle antigo até o EOF e fecha-o para que o novo
+// arquivo no mesmo path seja aberto do início
+func (fm *FileMonitor) switchRotatedFile(mf *monitoredFile) int {
+	linesRead := fm.readLines(mf, mf.reader)
+	linesRead += fm.finishFile(mf)
+
+	if info, err := mf.file.Stat(); err == nil {
+		if fingerprint, size, err := positions.ComputeFingerprint(mf.file, info.Size()); err == nil && size > mf.fingerprintSize {
+			mf.fingerprint, mf.fingerprintSize = fingerprint, size
+		}
+	}
+	fm.rememberRotatedFile(mf.path, mf.fingerprint, mf.fingerprintSize, mf.position)
+
+	fm.logger.WithFields(logrus.Fields{
+		"path":       mf.path,
+		"old_inode":  mf.inode,
+		"drained_at": mf.position,
+		"lines_read": linesRead,
+	}).Info("File rotation detected (rename), switching to new file")
+	metrics.RecordFileRotation("rename")
+
+	fm.closeFile(mf)
+	mf.position = 0
+
+	// O watcher acompanha o inode antigo; registrar novamente o path
+	if fm.watcher != nil {
+		fm.watcher.Remove(mf.path)
+		if err := fm.watcher.Add(mf.path); err != nil {
+			fm.logger.WithError(err).WithField("path", mf.path).Debug("Failed to re-add rotated file to watcher")
+		}
+	}
+
+	return linesRead
+}
+
+// finishFile envia a última linha sem newline e o evento multiline pendente
+// de um arquivo que não receberá mais dados
+func (fm *FileMonitor) finishFile(mf *monitoredFile) int {
+	linesRead := 0
+
+	if mf.partial != "" || mf.overflow > 0 || mf.splitGroup != "" {
+		line := mf.partial
+		mf.partial = ""
+		linesRead += fm.completeLine(mf, line)
+	}
+
+	if mf.multiline != nil {
+		if event, ok := mf.multiline.Flush(); ok {
+			fm.dispatchFileLine(mf, event)
+		}
+	}
+
+	if mf.cri != nil {
+		fm.flushCRI(mf, true, time.Now())
+	}
+
+	return linesRead
+}
+
+// closeFile fecha o handle atual e limpa a identidade associada
+func (fm *FileMonitor) closeFile(mf *monitoredFile) {
+	if mf.file != nil {
+		mf.file.Close()
+	}
+	mf.file = nil
+	mf.reader = nil
+	mf.resetPartial()
+	mf.inode, mf.device = 0, 0
+	mf.fingerprint, mf.fingerprintSize = "", 0
+	mf.checkedSize, mf.checkedModTime = 0, time.Time{}
+}
+
+// readOffset retorna até onde o arquivo aberto já foi lido, incluindo a linha
+// incompleta pendente e os bytes descartados por max_line_bytes
+func (mf *monitoredFile) readOffset() int64 {
+	return mf.position + int64(len(mf.partial)) + mf.overflow
+}
+
+// drainRotatedFile lê o restante de um arquivo rotacionado enquanto o serviço
+// estava parado, a partir da posição salva antes do restart
+func (fm *FileMonitor) drainRotatedFile(mf *monitoredFile) int {
+	rotatedPath, offset := mf.drainPath, mf.drainOffset
+	mf.drainPath, mf.drainOffset = "", 0
+
+	file, err := os.Open(rotatedPath)
+	if err != nil {
+		fm.logger.WithError(err).WithField("path", rotatedPath).Warn("Failed to open rotated file for draining")
+		return 0
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		fm.logger.WithError(err).WithField("path", rotatedPath).Warn("Failed to seek rotated file")
+		return 0
+	}
+
+	// Reutilizar o fluxo normal de leitura preservando o estado do arquivo atual
+	position, partial := mf.position, mf.partial
+	mf.position, mf.partial = offset, ""
+	linesRead := fm.readLines(mf, bufio.NewReader(file))
+	linesRead += fm.finishFile(mf)
+	if info, err := file.Stat(); err == nil {
+		if fingerprint, size, err := positions.ComputeFingerprint(file, info.Size()); err == nil {
+			fm.rememberRotatedFile(mf.path, fingerprint, size, mf.position)
+		}
+	}
+	mf.position, mf.partial = position, partial
+
+	fm.logger.WithFields(logrus.Fields{
+		"path":         mf.path,
+		"rotated_path": rotatedPath,
+		"offset":       offset,
+		"lines_read":   linesRead,
+	}).Info("Drained rotated file after restart")
+	metrics.RecordFileRotation("rename")
+
+	return linesRead
+}
+
+// resumePosition decide a posição inicial de um arquivo com base na posição salva.
+// A posição só é reutilizada se o arquivo for o mesmo (inode/device) e o início
+// do conteúdo corresponder ao fingerprint salvo. Se o arquivo foi rotacionado
+// enquanto o serviço estava parado, o arquivo antigo é localizado pelo inode para
+// ser drenado antes do novo.
+func (fm *FileMonitor) resumePosition(mf *monitoredFile, info os.FileInfo) {
+	saved := fm.positionManager.GetFilePosition(mf.path)
+	if saved == nil || saved.Offset <= 0 {
+		return
+	}
+
+	inode, device := positions.FileIdentity(info)
+	sameFile := saved.Inode == 0 || (saved.Inode == inode && saved.Device == device)
+
+	contentMatches := false
+	if file, err := os.Open(mf.path); err == nil {
+		contentMatches = positions.MatchesFingerprint(file, info.Size(), saved.Fingerprint, saved.FingerprintSize)
+		file.Close()
+	}
+
+	// Mesmo arquivo, ou arquivo copiado para outro inode com o mesmo conteúdo
+	if contentMatches && info.Size() >= saved.Offset && (sameFile || saved.Fingerprint != "") {
+		mf.position = saved.Offset
+		return
+	}
+
+	fm.logger.WithFields(logrus.Fields{
+		"path":            mf.path,
+		"stored_position": saved.Offset,
+		"stored_inode":    saved.Inode,
+		"current_inode":   inode,
+		"content_matches": contentMatches,
+	}).Info("Saved position does not match current file, reading from beginning")
+
+	// A posição salva pertence a uma geração anterior do arquivo
+	fm.rememberRotatedFile(mf.path, saved.Fingerprint, saved.FingerprintSize, saved.Offset)
+
+	if !sameFile {
+		if rotatedPath := findRotatedFile(mf.path, saved.Inode, saved.Device); rotatedPath != "" {
+			mf.drainPath = rotatedPath
+			mf.drainOffset = saved.Offset
+		}
+	}
+}
+
+// rememberRotatedFile guarda até onde uma geração anterior do arquivo foi lida,
+// identificada pelo fingerprint do seu início. Se o conteúdo reaparecer como
+// arquivo comprimido, a ingestão continua desse offset em vez de repetir linhas.
+func (fm *FileMonitor) rememberRotatedFile(path, fingerprint string, fingerprintSize, offset int64) {
+	if fm.positionManager == nil || fingerprint == "" || fingerprintSize <= 0 {
+		return
+	}
+
+	key := path + "#" + fingerprint[:16]
+	fm.positionManager.UpdateFilePosition(key, offset, offset, time.Now(), 0, 0, 0, 0)
+	fm.positionManager.SetFileFingerprint(key, fingerprint, fingerprintSize)
+	fm.positionManager.SetFileStatus(key, fileStatusRotated)
+}
+
+// findRotatedFile procura no diretório do arquivo o arquivo com o inode/device informados
+func findRotatedFile(path string, inode, device uint64) string {
+	if inode == 0 {
+		return ""
+	}
+
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		candidate := filepath.Join(dir, entry.Name())
+		if candidate == path || entry.IsDir() {
+			continue
+		}
+
+		info, err := os.Stat(candidate)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		candidateInode, candidateDevice := positions.FileIdentity(info)
+		if candidateInode == inode && candidateDevice == device {
+			return candidate
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,256 @@
+package monitors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/positions"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileMonitor(t *testing.T, dispatcher types.Dispatcher, positionManager *positions.PositionBufferManager) *FileMonitor {
+	t.Helper()
+	fm, err := NewFileMonitor(types.FileConfig{Enabled: true}, types.TimestampValidationConfig{}, dispatcher, nil, positionManager, newTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		fm.watcher.Close()
+		for _, mf := range fm.files {
+			if mf.file != nil {
+				mf.file.Close()
+			}
+		}
+	})
+	return fm
+}
+
+func newTestPositionManager(dir string) *positions.PositionBufferManager {
+	logger := newTestLogger()
+	return positions.NewPositionBufferManager(
+		positions.NewContainerPositionManager(dir, logger),
+		positions.NewFilePositionManager(dir, logger),
+		nil,
+		logger,
+	)
+}
+
+func appendToFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestFileMonitor_RenameRotationDrainsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "line 1\nline 2\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+
+	fm.readFile(mf)
+	require.Equal(t, []string{"line 1", "line 2"}, dispatcher.Messages())
+
+	// Linhas escritas pouco antes da rotação, sem newline final
+	appendToFile(t, path, "line 3\nline 4")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendToFile(t, path, "new 1\n")
+
+	fm.readFile(mf)
+	assert.Equal(t, []string{"line 1", "line 2", "line 3", "line 4", "new 1"}, dispatcher.Messages())
+	assert.Equal(t, int64(len("new 1\n")), mf.position)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	inode, _ := positions.FileIdentity(info)
+	assert.Equal(t, inode, mf.inode)
+}
+
+func TestFileMonitor_CopyTruncate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "first line\nsecond line\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.readFile(mf)
+
+	// Truncado e reescrito com conteúdo menor que a posição atual
+	require.NoError(t, os.Truncate(path, 0))
+	appendToFile(t, path, "after\n")
+	fm.readFile(mf)
+
+	assert.Equal(t, []string{"first line", "second line", "after"}, dispatcher.Messages())
+	assert.Equal(t, int64(len("after\n")), mf.position)
+}
+
+func TestFileMonitor_CopyTruncateRewrittenPastOffset(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "aaa\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.readFile(mf)
+
+	// Truncado e reescrito além da posição antiga antes da próxima leitura
+	require.NoError(t, os.Truncate(path, 0))
+	appendToFile(t, path, "bbb\nccc\n")
+	fm.readFile(mf)
+
+	assert.Equal(t, []string{"aaa", "bbb", "ccc"}, dispatcher.Messages())
+}
+
+func TestFileMonitor_PartialLineWaitsForNewline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "complete\nparti")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+
+	fm.readFile(mf)
+	assert.Equal(t, []string{"complete"}, dispatcher.Messages())
+	assert.Equal(t, int64(len("complete\n")), mf.position)
+
+	appendToFile(t, path, "al\n")
+	fm.readFile(mf)
+	assert.Equal(t, []string{"complete", "partial"}, dispatcher.Messages())
+	assert.Equal(t, int64(len("complete\npartial\n")), mf.position)
+}
+
+func TestFileMonitor_PollSkipsPendingPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "complete\nparti")
+
+	fm := newTestFileMonitor(t, &recordingDispatcher{}, nil)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.files[path] = mf
+	fm.readFile(mf)
+	lastRead := mf.lastRead
+
+	// Sem dados novos, a linha pendente não provoca releitura a cada poll
+	fm.pollAllFiles()
+	assert.Equal(t, lastRead, mf.lastRead)
+
+	appendToFile(t, path, "al\n")
+	fm.pollAllFiles()
+	assert.True(t, mf.lastRead.After(lastRead))
+	assert.Equal(t, int64(len("complete\npartial\n")), mf.position)
+}
+
+func TestFileMonitor_FileStartLabel(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.csv")
+	appendToFile(t, path, "id,user\n1,ana\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.files[path] = mf
+	fm.readFile(mf)
+	appendToFile(t, path, "2,bia\n")
+	fm.readFile(mf)
+
+	// Após truncamento a leitura recomeça no offset 0
+	require.NoError(t, os.WriteFile(path, []byte("id\n"), 0644))
+	fm.readFile(mf)
+
+	require.Equal(t, []string{"id,user", "1,ana", "2,bia", "id"}, dispatcher.messages)
+	for i, expected := range []bool{true, false, false, true} {
+		_, marked := dispatcher.labels[i][types.LabelFileStart]
+		assert.Equal(t, expected, marked, dispatcher.messages[i])
+	}
+}
+
+func TestFileMonitor_RestartResumesAndDrainsRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "logs")
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+	path := filepath.Join(logDir, "app.log")
+	appendToFile(t, path, "before restart\n")
+
+	positionManager := newTestPositionManager(filepath.Join(dir, "positions"))
+
+	// Primeira execução: lê e persiste posição + fingerprint
+	first := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, first, positionManager)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.readFile(mf)
+	mf.file.Close()
+	mf.file = nil
+
+	saved := positionManager.GetFilePosition(path)
+	require.NotNil(t, saved)
+	assert.Equal(t, int64(len("before restart\n")), saved.Offset)
+	assert.NotEmpty(t, saved.Fingerprint)
+
+	// Enquanto o serviço estava parado: mais linhas, rotação e novo arquivo
+	appendToFile(t, path, "missed line\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendToFile(t, path, "fresh line\n")
+
+	second := &recordingDispatcher{}
+	fm2 := newTestFileMonitor(t, second, positionManager)
+	require.NoError(t, fm2.AddFile(path, map[string]string{}))
+
+	require.Eventually(t, func() bool {
+		return len(second.Messages()) == 2
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []string{"missed line", "fresh line"}, second.Messages())
+}
+
+func TestFileMonitor_RestartResumesSameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "old line\n")
+
+	positionManager := newTestPositionManager(filepath.Join(dir, "positions"))
+
+	fm := newTestFileMonitor(t, &recordingDispatcher{}, positionManager)
+	mf := &monitoredFile{path: path, labels: map[string]string{}}
+	fm.readFile(mf)
+	mf.file.Close()
+	mf.file = nil
+
+	appendToFile(t, path, "new line\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm2 := newTestFileMonitor(t, dispatcher, positionManager)
+	require.NoError(t, fm2.AddFile(path, map[string]string{}))
+
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []string{"new line"}, dispatcher.Messages())
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "hello\n")
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	fingerprint, size, err := positions.ComputeFingerprint(file, 6)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), size)
+	assert.True(t, positions.MatchesFingerprint(file, 6, fingerprint, size))
+	assert.False(t, positions.MatchesFingerprint(file, 3, fingerprint, size), "shorter file cannot match")
+	assert.False(t, positions.MatchesFingerprint(file, 6, "deadbeef", size))
+	assert.True(t, positions.MatchesFingerprint(file, 6, "", 0), "missing fingerprint is accepted")
+}
@@ -0,0 +1,710 @@
+package monitors
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/types"
+	"ssw-logs-capture/pkg/validation"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// forwardHandshakeMaxSize limita o tamanho da mensagem PING do handshake
+	forwardHandshakeMaxSize = 64 * 1024
+	// forwardMaxDecompressionRatio limita a expansão do CompressedPackedForward
+	forwardMaxDecompressionRatio = 10
+	// forwardEventTimeExt é o tipo de extensão msgpack do EventTime
+	forwardEventTimeExt = 0
+)
+
+// forwardMessageKeys são os campos do record usados como mensagem, em ordem
+var forwardMessageKeys = []string{"log", "message", "msg"}
+
+// forwardLevelKeys são os campos do record usados como nível, em ordem
+var forwardLevelKeys = []string{"level", "severity", "log_level"}
+
+// FluentForwardMonitor recebe registros do protocolo Forward (Fluent Bit,
+// Fluentd, driver fluentd do Docker) via TCP e socket unix
+type FluentForwardMonitor struct {
+	config             types.FluentForwardConfig
+	dispatcher         types.Dispatcher
+	logger             *logrus.Logger
+	taskManager        types.TaskManager
+	timestampValidator *validation.TimestampValidator
+
+	readTimeout  time.Duration
+	selfHostname string
+
+	listeners []forwardListener
+	conns     map[net.Conn]struct{}
+	connSlots chan struct{}
+	wg        sync.WaitGroup
+	mutex     sync.RWMutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// forwardListener associa um listener ao transporte usado nas labels
+type forwardListener struct {
+	listener  net.Listener
+	transport string
+}
+
+// forwardEntry é um par [time, record] de uma mensagem Forward
+type forwardEntry struct {
+	timestamp time.Time
+	record    map[string]interface{}
+}
+
+// NewFluentForwardMonitor cria um novo monitor do protocolo Forward
+func NewFluentForwardMonitor(config types.FluentForwardConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, logger *logrus.Logger) (*FluentForwardMonitor, error) {
+	// Converter config para o formato do validation package
+	validationConfig := validation.Config{
+		Enabled:             timestampConfig.Enabled,
+		MaxPastAgeSeconds:   timestampConfig.MaxPastAgeSeconds,
+		MaxFutureAgeSeconds: timestampConfig.MaxFutureAgeSeconds,
+		ClampEnabled:        timestampConfig.ClampEnabled,
+		ClampDLQ:            timestampConfig.ClampDLQ,
+		InvalidAction:       timestampConfig.InvalidAction,
+		DefaultTimezone:     timestampConfig.DefaultTimezone,
+		AcceptedFormats:     timestampConfig.AcceptedFormats,
+	}
+
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 16 * 1024 * 1024
+	}
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = 1000
+	}
+
+	fm := &FluentForwardMonitor{
+		config:             config,
+		dispatcher:         dispatcher,
+		logger:             logger,
+		taskManager:        taskManager,
+		timestampValidator: validation.NewTimestampValidator(validationConfig, logger, nil),
+		readTimeout:        5 * time.Minute,
+		selfHostname:       config.SelfHostname,
+		conns:              make(map[net.Conn]struct{}),
+		connSlots:          make(chan struct{}, config.MaxConnections),
+	}
+
+	if !config.Enabled {
+		return fm, nil
+	}
+
+	if config.Address == "" && config.SocketPath == "" {
+		return nil, fmt.Errorf("fluent forward monitor enabled without address or socket_path")
+	}
+
+	if config.ReadTimeout != "" {
+		timeout, err := time.ParseDuration(config.ReadTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fluent forward read_timeout: %w", err)
+		}
+		fm.readTimeout = timeout
+	}
+
+	if fm.selfHostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "ssw-logs-capture"
+		}
+		fm.selfHostname = hostname
+	}
+
+	return fm, nil
+}
+
+// Start abre os listeners configurados e inicia o recebimento de mensagens
+func (fm *FluentForwardMonitor) Start(ctx context.Context) error {
+	if !fm.config.Enabled {
+		fm.logger.Info("Fluent forward monitor disabled")
+		return nil
+	}
+
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	if fm.isRunning {
+		return fmt.Errorf("fluent forward monitor already running")
+	}
+
+	fm.ctx, fm.cancel = context.WithCancel(ctx)
+
+	if err := fm.openListeners(); err != nil {
+		fm.closeListeners()
+		fm.cancel()
+		return err
+	}
+
+	for _, l := range fm.listeners {
+		fm.wg.Add(1)
+		go fm.acceptLoop(l.listener, l.transport)
+	}
+
+	if fm.taskManager != nil {
+		if err := fm.taskManager.StartTask(fm.ctx, "fluent_forward_monitor", fm.heartbeatLoop); err != nil {
+			fm.closeListeners()
+			fm.cancel()
+			return fmt.Errorf("failed to start fluent forward monitor task: %w", err)
+		}
+	}
+
+	fm.isRunning = true
+	fm.logger.WithFields(logrus.Fields{
+		"address":     fm.config.Address,
+		"socket_path": fm.config.SocketPath,
+		"shared_key":  fm.config.SharedKey != "",
+	}).Info("Fluent forward monitor started")
+
+	return nil
+}
+
+// openListeners abre o socket TCP e o socket unix configurados
+func (fm *FluentForwardMonitor) openListeners() error {
+	fm.listeners = nil
+
+	if fm.config.Address != "" {
+		listener, err := net.Listen("tcp", fm.config.Address)
+		if err != nil {
+			return fmt.Errorf("failed to listen on fluent forward address %s: %w", fm.config.Address, err)
+		}
+		fm.listeners = append(fm.listeners, forwardListener{listener: listener, transport: "tcp"})
+	}
+
+	if fm.config.SocketPath != "" {
+		// Remover socket deixado por uma execução anterior
+		if err := os.Remove(fm.config.SocketPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale fluent forward socket %s: %w", fm.config.SocketPath, err)
+		}
+		listener, err := net.Listen("unix", fm.config.SocketPath)
+		if err != nil {
+			return fmt.Errorf("failed to listen on fluent forward socket %s: %w", fm.config.SocketPath, err)
+		}
+		fm.listeners = append(fm.listeners, forwardListener{listener: listener, transport: "unix"})
+	}
+
+	return nil
+}
+
+// closeListeners fecha os sockets de escuta
+func (fm *FluentForwardMonitor) closeListeners() {
+	for _, l := range fm.listeners {
+		l.listener.Close()
+	}
+}
+
+// closeConnections fecha as conexões ativas
+func (fm *FluentForwardMonitor) closeConnections() {
+	fm.mutex.RLock()
+	defer fm.mutex.RUnlock()
+	for conn := range fm.conns {
+		conn.Close()
+	}
+}
+
+// Stop fecha os listeners e aguarda o término das conexões
+func (fm *FluentForwardMonitor) Stop() error {
+	fm.mutex.Lock()
+	if !fm.isRunning {
+		fm.mutex.Unlock()
+		return nil
+	}
+	fm.logger.Info("Stopping fluent forward monitor")
+	fm.isRunning = false
+	fm.cancel()
+	fm.mutex.Unlock()
+
+	if fm.taskManager != nil {
+		fm.taskManager.StopTask("fluent_forward_monitor")
+	}
+
+	fm.closeListeners()
+	fm.closeConnections()
+
+	done := make(chan struct{})
+	go func() {
+		fm.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		fm.logger.Warn("Timeout waiting for fluent forward connections to close")
+	}
+
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (fm *FluentForwardMonitor) IsHealthy() bool {
+	fm.mutex.RLock()
+	defer fm.mutex.RUnlock()
+	return fm.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (fm *FluentForwardMonitor) GetStatus() types.MonitorStatus {
+	fm.mutex.RLock()
+	defer fm.mutex.RUnlock()
+
+	return types.MonitorStatus{
+		Name:      "fluent_forward_monitor",
+		IsRunning: fm.isRunning,
+		IsHealthy: fm.isRunning,
+	}
+}
+
+// heartbeatLoop mantém a task viva enquanto os listeners estão ativos
+func (fm *FluentForwardMonitor) heartbeatLoop(ctx context.Context) error {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			fm.taskManager.Heartbeat("fluent_forward_monitor")
+		}
+	}
+}
+
+// acceptLoop aceita conexões respeitando o limite de conexões simultâneas
+func (fm *FluentForwardMonitor) acceptLoop(listener net.Listener, transport string) {
+	defer fm.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if fm.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			fm.logger.WithError(err).WithField("transport", transport).Warn("Fluent forward accept error")
+			metrics.RecordError("fluent_forward_monitor", "accept_error")
+			select {
+			case <-fm.ctx.Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case fm.connSlots <- struct{}{}:
+		default:
+			fm.logger.WithField("transport", transport).Warn("Fluent forward connection limit reached, rejecting connection")
+			metrics.RecordError("fluent_forward_monitor", "connection_limit")
+			conn.Close()
+			continue
+		}
+
+		fm.mutex.Lock()
+		fm.conns[conn] = struct{}{}
+		fm.mutex.Unlock()
+
+		fm.wg.Add(1)
+		go fm.handleConnection(conn, transport)
+	}
+}
+
+// handleConnection faz o handshake (com shared_key) e lê mensagens até EOF,
+// timeout ou shutdown. Um erro de protocolo fecha a conexão, pois o stream
+// msgpack não pode ser ressincronizado.
+func (fm *FluentForwardMonitor) handleConnection(conn net.Conn, transport string) {
+	defer fm.wg.Done()
+	defer func() {
+		conn.Close()
+		fm.mutex.Lock()
+		delete(fm.conns, conn)
+		fm.mutex.Unlock()
+		<-fm.connSlots
+	}()
+
+	host := remoteHost(conn.RemoteAddr())
+	decoder := newMsgpackDecoder(bufio.NewReaderSize(conn, 64*1024))
+
+	if fm.config.SharedKey != "" {
+		if err := fm.handshake(conn, decoder); err != nil {
+			fm.logger.WithError(err).WithField("remote_addr", host).Warn("Fluent forward handshake failed")
+			metrics.RecordError("fluent_forward_monitor", "auth_error")
+			return
+		}
+	}
+
+	for {
+		if fm.readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(fm.readTimeout))
+		}
+
+		message, err := decoder.Decode(fm.config.MaxMessageSize)
+		if err == nil {
+			err = fm.handleMessage(conn, message, transport, host)
+		}
+		if err != nil {
+			if err == io.EOF || fm.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				fm.logger.WithField("remote_addr", host).Debug("Closing idle fluent forward connection")
+				return
+			}
+			fm.logger.WithError(err).WithFields(logrus.Fields{
+				"remote_addr": host,
+				"transport":   transport,
+			}).Warn("Invalid fluent forward message, closing connection")
+			metrics.RecordError("fluent_forward_monitor", "decode_error")
+			return
+		}
+	}
+}
+
+// handshake executa HELO/PING/PONG do protocolo Forward com shared_key
+func (fm *FluentForwardMonitor) handshake(conn net.Conn, decoder *msgpackDecoder) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	helo := []interface{}{"HELO", map[string]interface{}{"nonce": nonce, "auth": "", "keepalive": true}}
+	if _, err := conn.Write(appendMsgpack(nil, helo)); err != nil {
+		return fmt.Errorf("failed to send HELO: %w", err)
+	}
+
+	if fm.readTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(fm.readTimeout))
+	}
+	message, err := decoder.Decode(forwardHandshakeMaxSize)
+	if err != nil {
+		return fmt.Errorf("failed to read PING: %w", err)
+	}
+	ping, ok := message.([]interface{})
+	if !ok || len(ping) < 4 || msgpackString(ping[0]) != "PING" {
+		return fmt.Errorf("expected PING message")
+	}
+
+	clientHostname := msgpackString(ping[1])
+	salt := msgpackString(ping[2])
+	digest := msgpackString(ping[3])
+
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(forwardDigest(salt, clientHostname, nonce, fm.config.SharedKey))) != 1 {
+		pong := []interface{}{"PONG", false, "shared_key mismatch", "", ""}
+		conn.Write(appendMsgpack(nil, pong))
+		return fmt.Errorf("shared_key mismatch from %s", clientHostname)
+	}
+
+	pong := []interface{}{"PONG", true, "", fm.selfHostname, forwardDigest(salt, fm.selfHostname, nonce, fm.config.SharedKey)}
+	if _, err := conn.Write(appendMsgpack(nil, pong)); err != nil {
+		return fmt.Errorf("failed to send PONG: %w", err)
+	}
+	return nil
+}
+
+// forwardDigest calcula hex(sha512(salt + hostname + nonce + shared_key))
+func forwardDigest(salt, hostname string, nonce []byte, sharedKey string) string {
+	hash := sha512.New()
+	hash.Write([]byte(salt))
+	hash.Write([]byte(hostname))
+	hash.Write(nonce)
+	hash.Write([]byte(sharedKey))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// handleMessage decodifica os modos Message, Forward, PackedForward e
+// CompressedPackedForward, envia os registros ao dispatcher e responde o ack
+// quando a opção chunk está presente
+func (fm *FluentForwardMonitor) handleMessage(conn net.Conn, message interface{}, transport, host string) error {
+	tag, entries, options, err := fm.decodeForwardMessage(message)
+	if err != nil {
+		return err
+	}
+
+	logEntries := make([]*types.LogEntry, 0, len(entries))
+	for _, e := range entries {
+		entry := fm.buildEntry(tag, e, transport, host)
+
+		// Validar timestamp se o timestamp validator estiver disponível
+		if fm.timestampValidator != nil {
+			result := fm.timestampValidator.ValidateTimestamp(entry)
+			if !result.Valid && result.Action == "rejected" {
+				fm.logger.WithFields(logrus.Fields{
+					"tag":    tag,
+					"reason": result.Reason,
+				}).Warn("Fluent forward record rejected due to invalid timestamp")
+				continue
+			}
+		}
+		logEntries = append(logEntries, entry)
+	}
+
+	if len(logEntries) > 0 {
+		if err := fm.dispatcher.HandleBatch(fm.ctx, logEntries); err != nil {
+			// Sem ack o cliente reenvia o chunk
+			fm.logger.WithError(err).WithField("tag", tag).Error("Failed to dispatch fluent forward records")
+			metrics.RecordError("fluent_forward_monitor", "dispatch_error")
+			return nil
+		}
+		for _, entry := range logEntries {
+			metrics.RecordLogProcessed("fluent_forward", entry.SourceID, "fluent_forward_monitor")
+		}
+	}
+
+	if chunk := msgpackString(options["chunk"]); chunk != "" {
+		if _, err := conn.Write(appendMsgpack(nil, map[string]interface{}{"ack": chunk})); err != nil {
+			return fmt.Errorf("failed to send ack: %w", err)
+		}
+	}
+	return nil
+}
+
+// decodeForwardMessage identifica o modo pela forma do segundo elemento:
+// time (Message), array (Forward) ou str/bin (PackedForward)
+func (fm *FluentForwardMonitor) decodeForwardMessage(message interface{}) (string, []forwardEntry, map[string]interface{}, error) {
+	array, ok := message.([]interface{})
+	if !ok || len(array) < 2 {
+		return "", nil, nil, fmt.Errorf("forward message must be an array with tag and entries")
+	}
+
+	tag := msgpackString(array[0])
+	if tag == "" {
+		return "", nil, nil, fmt.Errorf("forward message without tag")
+	}
+
+	optionsAt := 2
+	var entries []forwardEntry
+
+	switch value := array[1].(type) {
+	case []interface{}:
+		// Forward: [tag, [[time, record], ...], option]
+		for _, item := range value {
+			entry, err := forwardEntryFromArray(item)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			entries = append(entries, entry)
+		}
+	case string, []byte:
+		// PackedForward: [tag, bin(msgpack stream de [time, record]), option]
+		var options map[string]interface{}
+		if len(array) > 2 {
+			options, _ = array[2].(map[string]interface{})
+		}
+		packed := []byte(msgpackString(value))
+		if compressed := msgpackString(options["compressed"]); compressed != "" {
+			if compressed != "gzip" {
+				return "", nil, nil, fmt.Errorf("unsupported forward compression %q", compressed)
+			}
+			decompressed, err := forwardGunzip(packed, fm.config.MaxMessageSize*forwardMaxDecompressionRatio)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			packed = decompressed
+		}
+		var err error
+		entries, err = decodePackedForwardEntries(packed)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	default:
+		// Message: [tag, time, record, option]
+		if len(array) < 3 {
+			return "", nil, nil, fmt.Errorf("forward message mode requires time and record")
+		}
+		entry, err := forwardEntryFromArray([]interface{}{array[1], array[2]})
+		if err != nil {
+			return "", nil, nil, err
+		}
+		entries = append(entries, entry)
+		optionsAt = 3
+	}
+
+	var options map[string]interface{}
+	if len(array) > optionsAt {
+		options, _ = array[optionsAt].(map[string]interface{})
+	}
+
+	return tag, entries, options, nil
+}
+
+// decodePackedForwardEntries lê os pares [time, record] concatenados
+func decodePackedForwardEntries(packed []byte) ([]forwardEntry, error) {
+	decoder := newMsgpackDecoder(bufio.NewReader(bytes.NewReader(packed)))
+
+	var entries []forwardEntry
+	for {
+		item, err := decoder.Decode(len(packed))
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid packed forward entries: %w", err)
+		}
+		entry, err := forwardEntryFromArray(item)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// forwardGunzip descomprime o CompressedPackedForward (um ou mais membros gzip)
+func forwardGunzip(data []byte, limit int) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed forward entries: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed forward entries: %w", err)
+	}
+	if len(decompressed) > limit {
+		return nil, fmt.Errorf("decompressed forward entries exceed %d bytes", limit)
+	}
+	return decompressed, nil
+}
+
+// forwardEntryFromArray converte [time, record] em forwardEntry
+func forwardEntryFromArray(item interface{}) (forwardEntry, error) {
+	pair, ok := item.([]interface{})
+	if !ok || len(pair) < 2 {
+		return forwardEntry{}, fmt.Errorf("forward entry must be [time, record]")
+	}
+	record, ok := pair[1].(map[string]interface{})
+	if !ok {
+		return forwardEntry{}, fmt.Errorf("forward record must be a map")
+	}
+	timestamp, ok := forwardTime(pair[0])
+	if !ok {
+		metrics.RecordError("fluent_forward_monitor", "invalid_time")
+		timestamp = time.Now()
+	}
+	return forwardEntry{timestamp: timestamp, record: record}, nil
+}
+
+// forwardTime aceita EventTime (ext 0: segundos e nanossegundos uint32) ou
+// segundos inteiros/fracionários
+func forwardTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case msgpackExt:
+		if v.Type != forwardEventTimeExt || len(v.Data) != 8 {
+			return time.Time{}, false
+		}
+		sec := binary.BigEndian.Uint32(v.Data[:4])
+		nsec := binary.BigEndian.Uint32(v.Data[4:])
+		return time.Unix(int64(sec), int64(nsec)), true
+	case int64:
+		return time.Unix(v, 0), true
+	case uint64:
+		return time.Unix(int64(v), 0), true
+	case float64:
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*1e9)), true
+	}
+	return time.Time{}, false
+}
+
+// buildEntry converte um record em LogEntry. A tag vira label; log/message/msg
+// vira a mensagem e os demais campos do record viram fields.
+func (fm *FluentForwardMonitor) buildEntry(tag string, e forwardEntry, transport, host string) *types.LogEntry {
+	labels := make(map[string]string, len(fm.config.Labels)+3)
+	for k, v := range fm.config.Labels {
+		labels[k] = v
+	}
+	labels["source"] = "fluent_forward"
+	labels["transport"] = transport
+	labels["tag"] = tag
+
+	fields := make(map[string]interface{}, len(e.record)+1)
+	for key, value := range e.record {
+		fields[key] = forwardValue(value)
+	}
+	if host != "" {
+		fields["remote_addr"] = host
+	}
+
+	var message string
+	for _, key := range forwardMessageKeys {
+		if value, ok := fields[key].(string); ok {
+			message = strings.TrimRight(value, "\r\n")
+			delete(fields, key)
+			break
+		}
+	}
+	if message == "" {
+		// Sem campo de mensagem o record inteiro é a mensagem
+		data, _ := json.Marshal(forwardValue(e.record))
+		message = string(data)
+	}
+
+	var level string
+	for _, key := range forwardLevelKeys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			level = strings.ToLower(value)
+			break
+		}
+	}
+
+	now := time.Now()
+	return &types.LogEntry{
+		Timestamp:   e.timestamp,
+		Message:     message,
+		Level:       level,
+		SourceType:  "fluent_forward",
+		SourceID:    tag,
+		Labels:      labels,
+		Fields:      fields,
+		ProcessedAt: now,
+	}
+}
+
+// forwardValue converte bin em string e EventTime em time.Time, recursivamente
+func forwardValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case msgpackExt:
+		if timestamp, ok := forwardTime(v); ok {
+			return timestamp
+		}
+		return v.Data
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = forwardValue(item)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			values[key] = forwardValue(item)
+		}
+		return values
+	}
+	return value
+}
@@ -0,0 +1,242 @@
+package monitors
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forwardEventTime codifica um EventTime (ext 0) como o Fluent Bit envia
+func forwardEventTime(t time.Time) msgpackExt {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[:4], uint32(t.Unix()))
+	binary.BigEndian.PutUint32(data[4:], uint32(t.Nanosecond()))
+	return msgpackExt{Type: forwardEventTimeExt, Data: data}
+}
+
+func decodeMsgpack(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	value, err := newMsgpackDecoder(bufio.NewReader(bytes.NewReader(data))).Decode(len(data))
+	require.NoError(t, err)
+	return value
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 300))
+	value := map[string]interface{}{
+		"nil":    nil,
+		"bool":   true,
+		"small":  int64(7),
+		"neg":    int64(-5),
+		"large":  int64(1 << 40),
+		"float":  1.5,
+		"string": "hello",
+		"long":   long,
+		"bin":    []byte{0, 1, 2},
+		"array":  []interface{}{"a", int64(1), false},
+		"nested": map[string]interface{}{"k": "v"},
+		"ext":    msgpackExt{Type: 0, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+	}
+
+	data := appendMsgpack(nil, value)
+	assert.Equal(t, value, decodeMsgpack(t, data))
+
+	// Formatos gerados por outros encoders
+	assert.Equal(t, int64(200), decodeMsgpack(t, []byte{0xcc, 0xc8}))
+	assert.Equal(t, int64(-200), decodeMsgpack(t, []byte{0xd1, 0xff, 0x38}))
+	assert.Equal(t, float64(float32(0.25)), decodeMsgpack(t, []byte{0xca, 0x3e, 0x80, 0x00, 0x00}))
+	assert.Equal(t, "abc", decodeMsgpack(t, []byte{0xd9, 0x03, 'a', 'b', 'c'}))
+
+	// Limite de tamanho e dados truncados
+	_, err := newMsgpackDecoder(bufio.NewReader(bytes.NewReader(data))).Decode(10)
+	assert.ErrorIs(t, err, errMsgpackTooLarge)
+	_, err = newMsgpackDecoder(bufio.NewReader(bytes.NewReader([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}))).Decode(1024)
+	assert.ErrorIs(t, err, errMsgpackTooLarge)
+	_, err = newMsgpackDecoder(bufio.NewReader(bytes.NewReader(data[:len(data)-1]))).Decode(len(data))
+	assert.Error(t, err)
+	_, err = newMsgpackDecoder(bufio.NewReader(bytes.NewReader([]byte{0xc1}))).Decode(1)
+	assert.Error(t, err)
+}
+
+func newTestFluentForwardMonitor(t *testing.T, config types.FluentForwardConfig) (*FluentForwardMonitor, *recordingDispatcher) {
+	t.Helper()
+	config.Enabled = true
+	dispatcher := &recordingDispatcher{}
+	fm, err := NewFluentForwardMonitor(config, types.TimestampValidationConfig{}, dispatcher, nil, newTestLogger())
+	require.NoError(t, err)
+	require.NoError(t, fm.Start(t.Context()))
+	t.Cleanup(func() { fm.Stop() })
+	return fm, dispatcher
+}
+
+// readForwardResponse lê uma resposta msgpack do servidor (ack, HELO ou PONG)
+func readForwardResponse(t *testing.T, reader *bufio.Reader) interface{} {
+	t.Helper()
+	value, err := newMsgpackDecoder(reader).Decode(64 * 1024)
+	require.NoError(t, err)
+	return value
+}
+
+func TestFluentForwardMonitor_Modes(t *testing.T) {
+	fm, dispatcher := newTestFluentForwardMonitor(t, types.FluentForwardConfig{
+		Address: "127.0.0.1:0",
+		Labels:  map[string]string{"env": "test"},
+	})
+
+	conn, err := net.Dial("tcp", fm.listeners[0].listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	eventTime := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+
+	// Message mode com ack, como o driver fluentd do Docker
+	message := []interface{}{"docker.web", forwardEventTime(eventTime), map[string]interface{}{
+		"log":            "GET / 200\n",
+		"container_name": "/web",
+		"source":         "stdout",
+	}, map[string]interface{}{"chunk": "chunk-1"}}
+	_, err = conn.Write(appendMsgpack(nil, message))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ack": "chunk-1"}, readForwardResponse(t, reader))
+
+	// Forward mode
+	forward := []interface{}{"app.api", []interface{}{
+		[]interface{}{int64(1714557600), map[string]interface{}{"message": "first", "level": "WARN"}},
+		[]interface{}{int64(1714557601), map[string]interface{}{"user": "42"}},
+	}}
+	_, err = conn.Write(appendMsgpack(nil, forward))
+	require.NoError(t, err)
+
+	// PackedForward e CompressedPackedForward
+	var packed []byte
+	packed = appendMsgpack(packed, []interface{}{forwardEventTime(eventTime), map[string]interface{}{"log": "packed one"}})
+	packed = appendMsgpack(packed, []interface{}{forwardEventTime(eventTime), map[string]interface{}{"log": "packed two"}})
+	_, err = conn.Write(appendMsgpack(nil, []interface{}{"fluentbit.tail", packed, map[string]interface{}{"size": int64(2)}}))
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(appendMsgpack(nil, []interface{}{forwardEventTime(eventTime), map[string]interface{}{"log": "compressed"}}))
+	writer.Close()
+	_, err = conn.Write(appendMsgpack(nil, []interface{}{"fluentbit.gz", compressed.Bytes(), map[string]interface{}{"compressed": "gzip", "chunk": "chunk-2"}}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ack": "chunk-2"}, readForwardResponse(t, reader))
+
+	waitForMessages(t, dispatcher, 6)
+	assert.Equal(t, []string{"GET / 200", "first", `{"user":"42"}`, "packed one", "packed two", "compressed"}, dispatcher.Messages())
+
+	entry := dispatcher.entries[0]
+	assert.Equal(t, eventTime, entry.Timestamp.UTC())
+	assert.Equal(t, "fluent_forward", entry.SourceType)
+	assert.Equal(t, "docker.web", entry.SourceID)
+	assert.Equal(t, "docker.web", entry.Labels["tag"])
+	assert.Equal(t, "tcp", entry.Labels["transport"])
+	assert.Equal(t, "test", entry.Labels["env"])
+	assert.Equal(t, "/web", entry.Fields["container_name"])
+	assert.Equal(t, "stdout", entry.Fields["source"])
+	assert.Equal(t, "127.0.0.1", entry.Fields["remote_addr"])
+
+	assert.Equal(t, "warn", dispatcher.entries[1].Level)
+	assert.Equal(t, time.Unix(1714557600, 0), dispatcher.entries[1].Timestamp)
+	assert.Equal(t, "fluentbit.gz", dispatcher.entries[5].Labels["tag"])
+}
+
+func TestFluentForwardMonitor_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "forward.sock")
+	_, dispatcher := newTestFluentForwardMonitor(t, types.FluentForwardConfig{SocketPath: socketPath})
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	_, err = conn.Write(appendMsgpack(nil, []interface{}{"unix.tag", int64(1714557600), map[string]interface{}{"log": "over unix"}}))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	waitForMessages(t, dispatcher, 1)
+	assert.Equal(t, "over unix", dispatcher.Messages()[0])
+	assert.Equal(t, "unix", dispatcher.entries[0].Labels["transport"])
+}
+
+func TestFluentForwardMonitor_InvalidMessageClosesConnection(t *testing.T) {
+	fm, dispatcher := newTestFluentForwardMonitor(t, types.FluentForwardConfig{Address: "127.0.0.1:0", MaxMessageSize: 1024})
+
+	conn, err := net.Dial("tcp", fm.listeners[0].listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write(appendMsgpack(nil, map[string]interface{}{"not": "an array"}))
+	require.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.Empty(t, dispatcher.Messages())
+}
+
+func TestFluentForwardMonitor_SharedKeyHandshake(t *testing.T) {
+	fm, dispatcher := newTestFluentForwardMonitor(t, types.FluentForwardConfig{
+		Address:      "127.0.0.1:0",
+		SharedKey:    "secret",
+		SelfHostname: "capturer",
+	})
+	addr := fm.listeners[0].listener.Addr().String()
+
+	handshake := func(sharedKey string) (net.Conn, *bufio.Reader, []interface{}) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		reader := bufio.NewReader(conn)
+
+		helo, ok := readForwardResponse(t, reader).([]interface{})
+		require.True(t, ok)
+		require.Equal(t, "HELO", helo[0])
+		nonce := helo[1].(map[string]interface{})["nonce"].([]byte)
+
+		ping := []interface{}{"PING", "client-host", "salt", forwardDigest("salt", "client-host", nonce, sharedKey), "", ""}
+		_, err = conn.Write(appendMsgpack(nil, ping))
+		require.NoError(t, err)
+
+		pong, ok := readForwardResponse(t, reader).([]interface{})
+		require.True(t, ok)
+		require.Len(t, pong, 5)
+		assert.Equal(t, "PONG", pong[0])
+		if pong[1] == true {
+			assert.Equal(t, "capturer", pong[3])
+			assert.Equal(t, forwardDigest("salt", "capturer", nonce, "secret"), pong[4])
+		}
+		return conn, reader, pong
+	}
+
+	conn, _, pong := handshake("wrong")
+	defer conn.Close()
+	assert.Equal(t, false, pong[1])
+	_, err := conn.Read(make([]byte, 1))
+	assert.Error(t, err, "connection closed after failed authentication")
+
+	conn, reader, pong := handshake("secret")
+	defer conn.Close()
+	require.Equal(t, true, pong[1])
+
+	_, err = conn.Write(appendMsgpack(nil, []interface{}{"secure", int64(1714557600), map[string]interface{}{"log": "authenticated"}, map[string]interface{}{"chunk": "c1"}}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ack": "c1"}, readForwardResponse(t, reader))
+	assert.Equal(t, []string{"authenticated"}, dispatcher.Messages())
+}
+
+func TestNewFluentForwardMonitor_InvalidConfig(t *testing.T) {
+	_, err := NewFluentForwardMonitor(types.FluentForwardConfig{Enabled: true}, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+	assert.Error(t, err)
+
+	_, err = NewFluentForwardMonitor(types.FluentForwardConfig{Enabled: true, Address: ":0", ReadTimeout: "soon"}, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+	assert.Error(t, err)
+}
@@ -0,0 +1,362 @@
+package monitors
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// msgpackMaxDepth limita o aninhamento de arrays/maps decodificados
+const msgpackMaxDepth = 64
+
+// errMsgpackTooLarge indica que a mensagem excedeu o limite configurado
+var errMsgpackTooLarge = errors.New("msgpack message exceeds size limit")
+
+// msgpackExt é um valor de extensão msgpack (EventTime do Forward usa o tipo 0)
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// msgpackDecoder decodifica valores msgpack de um stream, limitando o total de
+// bytes lidos por mensagem
+type msgpackDecoder struct {
+	reader    *bufio.Reader
+	remaining int
+}
+
+// newMsgpackDecoder cria um decoder sobre o reader
+func newMsgpackDecoder(reader *bufio.Reader) *msgpackDecoder {
+	return &msgpackDecoder{reader: reader}
+}
+
+// Decode lê o próximo valor completo com no máximo limit bytes. Strings viram
+// string, bin vira []byte, inteiros viram int64 (ou uint64 acima de MaxInt64),
+// floats viram float64, arrays []interface{} e maps map[string]interface{}.
+func (d *msgpackDecoder) Decode(limit int) (interface{}, error) {
+	d.remaining = limit
+	return d.decode(0)
+}
+
+// readByte lê um byte descontando do limite
+func (d *msgpackDecoder) readByte() (byte, error) {
+	if d.remaining < 1 {
+		return 0, errMsgpackTooLarge
+	}
+	d.remaining--
+	return d.reader.ReadByte()
+}
+
+// readN lê n bytes descontando do limite
+func (d *msgpackDecoder) readN(n int) ([]byte, error) {
+	if n < 0 || n > d.remaining {
+		return nil, errMsgpackTooLarge
+	}
+	d.remaining -= n
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.reader, buf); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readUint lê um inteiro big-endian sem sinal de size bytes
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	buf, err := d.readN(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(buf[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(buf)), nil
+	default:
+		return binary.BigEndian.Uint64(buf), nil
+	}
+}
+
+// readLength lê o tamanho de str/bin/array/map/ext
+func (d *msgpackDecoder) readLength(size int) (int, error) {
+	length, err := d.readUint(size)
+	if err != nil {
+		return 0, err
+	}
+	if length > uint64(d.remaining) {
+		// Cada elemento ocupa ao menos um byte
+		return 0, errMsgpackTooLarge
+	}
+	return int(length), nil
+}
+
+func (d *msgpackDecoder) decode(depth int) (interface{}, error) {
+	if depth > msgpackMaxDepth {
+		return nil, fmt.Errorf("msgpack nesting deeper than %d levels", msgpackMaxDepth)
+	}
+
+	code, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code >= 0x80 && code <= 0x8f:
+		return d.decodeMap(int(code&0x0f), depth)
+	case code >= 0x90 && code <= 0x9f:
+		return d.decodeArray(int(code&0x0f), depth)
+	case code >= 0xa0 && code <= 0xbf:
+		return d.decodeString(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		length, err := d.readLength(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.readN(length)
+	case 0xc7, 0xc8, 0xc9:
+		length, err := d.readLength(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(length)
+	case 0xca:
+		raw, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(raw))), nil
+	case 0xcb:
+		raw, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(raw), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		value, err := d.readUint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if value > math.MaxInt64 {
+			return value, nil
+		}
+		return int64(value), nil
+	case 0xd0:
+		value, err := d.readUint(1)
+		return int64(int8(value)), err
+	case 0xd1:
+		value, err := d.readUint(2)
+		return int64(int16(value)), err
+	case 0xd2:
+		value, err := d.readUint(4)
+		return int64(int32(value)), err
+	case 0xd3:
+		value, err := d.readUint(8)
+		return int64(value), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (code - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		length, err := d.readLength(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(length)
+	case 0xdc, 0xdd:
+		length, err := d.readLength(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(length, depth)
+	case 0xde, 0xdf:
+		length, err := d.readLength(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(length, depth)
+	}
+
+	return nil, fmt.Errorf("invalid msgpack type code 0x%02x", code)
+}
+
+func (d *msgpackDecoder) decodeString(length int) (interface{}, error) {
+	buf, err := d.readN(length)
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+func (d *msgpackDecoder) decodeExt(length int) (interface{}, error) {
+	extType, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.readN(length)
+	if err != nil {
+		return nil, err
+	}
+	return msgpackExt{Type: int8(extType), Data: data}, nil
+}
+
+func (d *msgpackDecoder) decodeArray(length, depth int) (interface{}, error) {
+	values := make([]interface{}, 0, length)
+	for i := 0; i < length; i++ {
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (d *msgpackDecoder) decodeMap(length, depth int) (interface{}, error) {
+	values := make(map[string]interface{}, length)
+	for i := 0; i < length; i++ {
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[msgpackString(key)] = value
+	}
+	return values, nil
+}
+
+// msgpackString converte str ou bin em string; outros tipos usam fmt
+func msgpackString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// appendMsgpack codifica value no formato msgpack. Suporta os tipos usados nas
+// respostas do Forward (nil, bool, inteiros, string, []byte, msgpackExt, arrays
+// e maps com chave string, em ordem de chave).
+func appendMsgpack(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int:
+		return appendMsgpackInt(buf, int64(v))
+	case int64:
+		return appendMsgpackInt(buf, v)
+	case float64:
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(v))
+	case string:
+		buf = appendMsgpackHeader(buf, len(v), 0xa0, 31, 0xd9)
+		return append(buf, v...)
+	case []byte:
+		buf = appendMsgpackHeader(buf, len(v), 0, 0, 0xc4)
+		return append(buf, v...)
+	case msgpackExt:
+		switch len(v.Data) {
+		case 1, 2, 4, 8, 16:
+			buf = append(buf, 0xd4+byte(bits.TrailingZeros(uint(len(v.Data)))))
+		default:
+			buf = appendMsgpackHeader(buf, len(v.Data), 0, 0, 0xc7)
+		}
+		buf = append(buf, byte(v.Type))
+		return append(buf, v.Data...)
+	case []interface{}:
+		buf = appendMsgpackCollection(buf, len(v), 0x90, 0xdc)
+		for _, item := range v {
+			buf = appendMsgpack(buf, item)
+		}
+		return buf
+	case map[string]interface{}:
+		buf = appendMsgpackCollection(buf, len(v), 0x80, 0xde)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			buf = appendMsgpack(buf, key)
+			buf = appendMsgpack(buf, v[key])
+		}
+		return buf
+	default:
+		return appendMsgpack(buf, fmt.Sprint(v))
+	}
+}
+
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0 && v <= 0x7f:
+		return append(buf, byte(v))
+	case v < 0 && v >= -32:
+		return append(buf, byte(int8(v)))
+	default:
+		buf = append(buf, 0xd3)
+		return binary.BigEndian.AppendUint64(buf, uint64(v))
+	}
+}
+
+// appendMsgpackHeader escreve o cabeçalho de str/bin/ext: forma fixa quando
+// length <= fixMax (e fixCode != 0), senão os formatos de 8, 16 e 32 bits
+func appendMsgpackHeader(buf []byte, length int, fixCode byte, fixMax int, code8 byte) []byte {
+	switch {
+	case fixCode != 0 && length <= fixMax:
+		return append(buf, fixCode|byte(length))
+	case length <= math.MaxUint8:
+		return append(buf, code8, byte(length))
+	case length <= math.MaxUint16:
+		buf = append(buf, code8+1)
+		return binary.BigEndian.AppendUint16(buf, uint16(length))
+	default:
+		buf = append(buf, code8+2)
+		return binary.BigEndian.AppendUint32(buf, uint32(length))
+	}
+}
+
+// appendMsgpackCollection escreve o cabeçalho de array/map
+func appendMsgpackCollection(buf []byte, length int, fixCode, code16 byte) []byte {
+	switch {
+	case length <= 15:
+		return append(buf, fixCode|byte(length))
+	case length <= math.MaxUint16:
+		buf = append(buf, code16)
+		return binary.BigEndian.AppendUint16(buf, uint16(length))
+	default:
+		buf = append(buf, code16+1)
+		return binary.BigEndian.AppendUint32(buf, uint32(length))
+	}
+}
@@ -0,0 +1,598 @@
+package monitors
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/internal/sinks"
+	"ssw-logs-capture/pkg/types"
+	"ssw-logs-capture/pkg/validation"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// kafkaDispatchMaxBackoff limita a espera entre tentativas de entregar uma mensagem ao dispatcher
+	kafkaDispatchMaxBackoff = 5 * time.Second
+	// kafkaConsumeRetryInterval espera entre reconexões do consumer group após erro
+	kafkaConsumeRetryInterval = 5 * time.Second
+)
+
+// Chaves reconhecidas no formato json; as demais viram fields
+var (
+	kafkaMessageKeys   = []string{"message", "msg", "log"}
+	kafkaTimestampKeys = []string{"timestamp", "@timestamp", "time", "ts"}
+	kafkaLevelKeys     = []string{"level", "severity", "lvl"}
+)
+
+// KafkaMonitor consome logs de tópicos Kafka via consumer group. O offset de cada
+// mensagem só é marcado para commit depois que o dispatcher aceita a entrada, então
+// mensagens não entregues são reprocessadas após restart ou rebalance.
+type KafkaMonitor struct {
+	config             types.KafkaMonitorConfig
+	dispatcher         types.Dispatcher
+	logger             *logrus.Logger
+	taskManager        types.TaskManager
+	timestampValidator *validation.TimestampValidator
+	saramaConfig       *sarama.Config
+
+	group  sarama.ConsumerGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mutex     sync.RWMutex
+	isRunning bool
+}
+
+// NewKafkaMonitor cria um novo monitor Kafka
+func NewKafkaMonitor(config types.KafkaMonitorConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, logger *logrus.Logger) (*KafkaMonitor, error) {
+	// Converter config para o formato do validation package
+	validationConfig := validation.Config{
+		Enabled:             timestampConfig.Enabled,
+		MaxPastAgeSeconds:   timestampConfig.MaxPastAgeSeconds,
+		MaxFutureAgeSeconds: timestampConfig.MaxFutureAgeSeconds,
+		ClampEnabled:        timestampConfig.ClampEnabled,
+		ClampDLQ:            timestampConfig.ClampDLQ,
+		InvalidAction:       timestampConfig.InvalidAction,
+		DefaultTimezone:     timestampConfig.DefaultTimezone,
+		AcceptedFormats:     timestampConfig.AcceptedFormats,
+	}
+
+	if config.GroupID == "" {
+		config.GroupID = "ssw-logs-capture"
+	}
+	if config.Format == "" {
+		config.Format = "json"
+	}
+
+	km := &KafkaMonitor{
+		config:             config,
+		dispatcher:         dispatcher,
+		logger:             logger,
+		taskManager:        taskManager,
+		timestampValidator: validation.NewTimestampValidator(validationConfig, logger, nil),
+	}
+
+	if !config.Enabled {
+		return km, nil
+	}
+
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka monitor enabled without brokers")
+	}
+	if len(config.Topics) == 0 {
+		return nil, fmt.Errorf("kafka monitor enabled without topics")
+	}
+	if config.Format != "json" && config.Format != "raw" {
+		return nil, fmt.Errorf("invalid kafka monitor format %q (expected json or raw)", config.Format)
+	}
+
+	saramaConfig, err := newKafkaConsumerConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	km.saramaConfig = saramaConfig
+
+	return km, nil
+}
+
+// newKafkaConsumerConfig monta a configuração sarama do consumer group
+func newKafkaConsumerConfig(config types.KafkaMonitorConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = "ssw-logs-capture"
+	saramaConfig.Consumer.Return.Errors = true
+
+	if config.Version != "" {
+		version, err := sarama.ParseKafkaVersion(config.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kafka monitor version: %w", err)
+		}
+		saramaConfig.Version = version
+	}
+
+	switch strings.ToLower(config.InitialOffset) {
+	case "", "newest":
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	case "oldest":
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	default:
+		return nil, fmt.Errorf("invalid kafka monitor initial_offset %q (expected newest or oldest)", config.InitialOffset)
+	}
+
+	// O auto-commit só envia offsets marcados, e a marcação acontece após o dispatch
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
+	if config.CommitInterval != "" {
+		interval, err := time.ParseDuration(config.CommitInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kafka monitor commit_interval: %w", err)
+		}
+		saramaConfig.Consumer.Offsets.AutoCommit.Interval = interval
+	}
+
+	// Configurar autenticação SASL
+	if config.Auth.Enabled {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.User = config.Auth.Username
+		saramaConfig.Net.SASL.Password = config.Auth.Password
+
+		switch strings.ToUpper(config.Auth.Mechanism) {
+		case "", "PLAIN":
+			saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case "SCRAM-SHA-256":
+			saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &sinks.XDGSCRAMClient{HashGeneratorFcn: sinks.SHA256}
+			}
+		case "SCRAM-SHA-512":
+			saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &sinks.XDGSCRAMClient{HashGeneratorFcn: sinks.SHA512}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported kafka monitor SASL mechanism %q", config.Auth.Mechanism)
+		}
+	}
+
+	// Configurar TLS
+	if config.TLS.Enabled {
+		tlsConfig, err := newKafkaClientTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	if err := saramaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka monitor configuration: %w", err)
+	}
+
+	return saramaConfig, nil
+}
+
+// newKafkaClientTLSConfig cria a configuração TLS do cliente. cert_file/key_file
+// habilitam autenticação mútua; ca_file substitui os CAs do sistema.
+func newKafkaClientTLSConfig(config types.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: !config.VerifyCertificate,
+	}
+
+	if config.CAFile != "" {
+		caCert, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kafka CA file: %w", err)
+		}
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse kafka CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = caPool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Start conecta ao cluster e inicia o consumo dos tópicos
+func (km *KafkaMonitor) Start(ctx context.Context) error {
+	if !km.config.Enabled {
+		km.logger.Info("Kafka monitor disabled")
+		return nil
+	}
+
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	if km.isRunning {
+		return fmt.Errorf("kafka monitor already running")
+	}
+
+	group, err := sarama.NewConsumerGroup(km.config.Brokers, km.config.GroupID, km.saramaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create kafka consumer group: %w", err)
+	}
+	km.group = group
+	km.ctx, km.cancel = context.WithCancel(ctx)
+
+	km.wg.Add(2)
+	go km.consumeLoop()
+	go km.errorLoop()
+
+	if km.taskManager != nil {
+		if err := km.taskManager.StartTask(km.ctx, "kafka_monitor", km.heartbeatLoop); err != nil {
+			km.cancel()
+			km.group.Close()
+			return fmt.Errorf("failed to start kafka monitor task: %w", err)
+		}
+	}
+
+	km.isRunning = true
+	km.logger.WithFields(logrus.Fields{
+		"brokers":  km.config.Brokers,
+		"topics":   km.config.Topics,
+		"group_id": km.config.GroupID,
+	}).Info("Kafka monitor started")
+
+	return nil
+}
+
+// Stop encerra o consumer group, enviando o commit dos offsets já marcados
+func (km *KafkaMonitor) Stop() error {
+	km.mutex.Lock()
+	if !km.isRunning {
+		km.mutex.Unlock()
+		return nil
+	}
+	km.logger.Info("Stopping kafka monitor")
+	km.isRunning = false
+	km.cancel()
+	km.mutex.Unlock()
+
+	if km.taskManager != nil {
+		km.taskManager.StopTask("kafka_monitor")
+	}
+
+	if err := km.group.Close(); err != nil {
+		km.logger.WithError(err).Warn("Failed to close kafka consumer group")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		km.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		km.logger.Warn("Timeout waiting for kafka consumer to stop")
+	}
+
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (km *KafkaMonitor) IsHealthy() bool {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+	return km.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (km *KafkaMonitor) GetStatus() types.MonitorStatus {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+
+	return types.MonitorStatus{
+		Name:      "kafka_monitor",
+		IsRunning: km.isRunning,
+		IsHealthy: km.isRunning,
+	}
+}
+
+// heartbeatLoop mantém a task viva enquanto o consumer está ativo
+func (km *KafkaMonitor) heartbeatLoop(ctx context.Context) error {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			km.taskManager.Heartbeat("kafka_monitor")
+		}
+	}
+}
+
+// consumeLoop participa do consumer group; Consume retorna a cada rebalance
+func (km *KafkaMonitor) consumeLoop() {
+	defer km.wg.Done()
+
+	handler := &kafkaGroupHandler{km: km}
+	for {
+		if err := km.group.Consume(km.ctx, km.config.Topics, handler); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) || km.ctx.Err() != nil {
+				return
+			}
+			km.logger.WithError(err).Error("Kafka consume error")
+			metrics.RecordError("kafka_monitor", "consume_error")
+
+			select {
+			case <-km.ctx.Done():
+				return
+			case <-time.After(kafkaConsumeRetryInterval):
+			}
+		}
+
+		if km.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// errorLoop registra os erros assíncronos do consumer group
+func (km *KafkaMonitor) errorLoop() {
+	defer km.wg.Done()
+
+	for err := range km.group.Errors() {
+		km.logger.WithError(err).Warn("Kafka consumer group error")
+		metrics.RecordError("kafka_monitor", "group_error")
+	}
+}
+
+// kafkaGroupHandler implementa sarama.ConsumerGroupHandler para o monitor
+type kafkaGroupHandler struct {
+	km *KafkaMonitor
+}
+
+// Setup é chamado no início de cada sessão do consumer group
+func (h *kafkaGroupHandler) Setup(sess sarama.ConsumerGroupSession) error {
+	h.km.logger.WithFields(logrus.Fields{
+		"member_id":  sess.MemberID(),
+		"generation": sess.GenerationID(),
+		"claims":     sess.Claims(),
+	}).Info("Kafka consumer group session started")
+	return nil
+}
+
+// Cleanup é chamado ao fim da sessão, antes do commit final
+func (h *kafkaGroupHandler) Cleanup(sess sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim entrega as mensagens da partição ao dispatcher, marcando o offset
+// só depois que cada uma é aceita
+func (h *kafkaGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-sess.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !h.km.dispatchMessage(sess.Context(), msg) {
+				// Sessão encerrada antes da entrega: a mensagem será lida de novo
+				return nil
+			}
+			sess.MarkMessage(msg, "")
+		}
+	}
+}
+
+// dispatchMessage envia a mensagem ao dispatcher, repetindo com backoff enquanto a
+// sessão estiver ativa. Retorna false se a sessão terminou sem a entrega.
+// Mensagens rejeitadas pela validação de timestamp contam como processadas.
+func (km *KafkaMonitor) dispatchMessage(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	backoff := 100 * time.Millisecond
+	for {
+		entry := km.buildEntry(msg)
+
+		// Validar timestamp se o timestamp validator estiver disponível
+		if km.timestampValidator != nil {
+			result := km.timestampValidator.ValidateTimestamp(entry)
+			if !result.Valid && result.Action == "rejected" {
+				km.logger.WithFields(logrus.Fields{
+					"topic":     msg.Topic,
+					"partition": msg.Partition,
+					"offset":    msg.Offset,
+					"reason":    result.Reason,
+				}).Warn("Kafka message rejected due to invalid timestamp")
+				return true
+			}
+		}
+
+		err := km.dispatcher.HandleEntry(ctx, entry)
+		if err == nil {
+			metrics.RecordLogProcessed("kafka", entry.SourceID, "kafka_monitor")
+			return true
+		}
+
+		km.logger.WithError(err).WithFields(logrus.Fields{
+			"topic":     msg.Topic,
+			"partition": msg.Partition,
+			"offset":    msg.Offset,
+		}).Warn("Failed to dispatch kafka message, retrying")
+		metrics.RecordError("kafka_monitor", "dispatch_error")
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > kafkaDispatchMaxBackoff {
+			backoff = kafkaDispatchMaxBackoff
+		}
+	}
+}
+
+// buildEntry converte a mensagem Kafka em LogEntry. Tópico vira label; partição,
+// offset e chave viram fields.
+func (km *KafkaMonitor) buildEntry(msg *sarama.ConsumerMessage) *types.LogEntry {
+	now := time.Now()
+
+	labels := make(map[string]string, len(km.config.Labels)+2)
+	for k, v := range km.config.Labels {
+		labels[k] = v
+	}
+
+	// Brokers antigos (formato de mensagem v0) não enviam timestamp
+	timestamp := msg.Timestamp
+	if timestamp.Unix() <= 0 {
+		timestamp = now
+	}
+
+	entry := &types.LogEntry{
+		TraceID:     uuid.New().String(),
+		Timestamp:   timestamp,
+		Message:     string(msg.Value),
+		SourceType:  "kafka",
+		SourceID:    msg.Topic,
+		Labels:      labels,
+		Fields:      make(map[string]interface{}),
+		ProcessedAt: now,
+	}
+
+	if km.config.Format == "json" {
+		if err := decodeKafkaJSON(msg.Value, entry); err != nil {
+			// Mensagem fora do formato esperado segue como texto
+			metrics.RecordError("kafka_monitor", "decode_error")
+			entry.Message = string(msg.Value)
+		}
+	}
+
+	entry.Labels["source"] = "kafka"
+	entry.Labels["topic"] = msg.Topic
+	entry.Fields["kafka_partition"] = msg.Partition
+	entry.Fields["kafka_offset"] = msg.Offset
+	if len(msg.Key) > 0 {
+		entry.Fields["kafka_key"] = string(msg.Key)
+	}
+
+	return entry
+}
+
+// decodeKafkaJSON preenche a entrada a partir de um objeto JSON. message/msg/log,
+// timestamp, level, trace_id, span_id, labels e fields são reconhecidos; as demais
+// chaves viram fields. Sem campo de mensagem, o JSON inteiro é a mensagem.
+func decodeKafkaJSON(value []byte, entry *types.LogEntry) error {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return fmt.Errorf("invalid kafka json message: %w", err)
+	}
+	if doc == nil {
+		return fmt.Errorf("kafka json message is not an object")
+	}
+
+	for _, key := range kafkaMessageKeys {
+		if message, ok := doc[key].(string); ok {
+			entry.Message = message
+			delete(doc, key)
+			break
+		}
+	}
+
+	for _, key := range kafkaTimestampKeys {
+		if timestamp, ok := parseKafkaTimestamp(doc[key]); ok {
+			entry.Timestamp = timestamp
+			delete(doc, key)
+			break
+		}
+	}
+
+	for _, key := range kafkaLevelKeys {
+		if level, ok := doc[key].(string); ok {
+			entry.Level = strings.ToLower(level)
+			delete(doc, key)
+			break
+		}
+	}
+
+	if traceID, ok := doc["trace_id"].(string); ok {
+		entry.TraceID = traceID
+		delete(doc, "trace_id")
+	}
+	if spanID, ok := doc["span_id"].(string); ok {
+		entry.SpanID = spanID
+		delete(doc, "span_id")
+	}
+
+	if labels, ok := doc["labels"].(map[string]interface{}); ok {
+		for k, v := range labels {
+			if s, ok := v.(string); ok {
+				entry.Labels[k] = s
+			} else {
+				entry.Labels[k] = fmt.Sprint(v)
+			}
+		}
+		delete(doc, "labels")
+	}
+
+	if fields, ok := doc["fields"].(map[string]interface{}); ok {
+		for k, v := range fields {
+			entry.Fields[k] = v
+		}
+		delete(doc, "fields")
+	}
+
+	for k, v := range doc {
+		entry.Fields[k] = v
+	}
+
+	return nil
+}
+
+// parseKafkaTimestamp aceita RFC 3339 ou epoch numérico em segundos, milissegundos
+// ou nanossegundos (detectado pela magnitude)
+func parseKafkaTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return epochToTime(f), true
+		}
+	case float64:
+		return epochToTime(v), true
+	}
+	return time.Time{}, false
+}
+
+// epochToTime converte epoch em segundos, milissegundos, microssegundos ou nanossegundos
+func epochToTime(epoch float64) time.Time {
+	switch {
+	case epoch > 1e17:
+		return time.Unix(0, int64(epoch))
+	case epoch > 1e14:
+		return time.UnixMicro(int64(epoch))
+	case epoch > 1e11:
+		return time.UnixMilli(int64(epoch))
+	default:
+		sec := int64(epoch)
+		return time.Unix(sec, int64((epoch-float64(sec))*1e9))
+	}
+}
@@ -0,0 +1,209 @@
+package monitors
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedDispatcher rejeita entradas até que accept seja habilitado
+type gatedDispatcher struct {
+	recordingDispatcher
+	accept   atomic.Bool
+	attempts atomic.Int32
+}
+
+func (d *gatedDispatcher) HandleEntry(ctx context.Context, entry *types.LogEntry) error {
+	d.attempts.Add(1)
+	if !d.accept.Load() {
+		return errors.New("queue full")
+	}
+	return d.recordingDispatcher.HandleEntry(ctx, entry)
+}
+
+// newMockKafkaCluster cria um broker com um grupo e uma partição contendo as mensagens informadas
+func newMockKafkaCluster(t *testing.T, topic, group string, messages ...string) *sarama.MockBroker {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 0)
+	t.Cleanup(broker.Close)
+
+	fetch := sarama.NewMockFetchResponse(t, 1)
+	for i, message := range messages {
+		fetch.SetMessageWithKey(topic, 0, int64(i), sarama.StringEncoder("key-1"), sarama.StringEncoder(message))
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(topic, 0, sarama.OffsetOldest, 0).
+			SetOffset(topic, 0, sarama.OffsetNewest, int64(len(messages))),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, group, broker),
+		"HeartbeatRequest":  sarama.NewMockHeartbeatResponse(t),
+		"JoinGroupRequest":  sarama.NewMockJoinGroupResponse(t).SetGroupProtocol(sarama.RangeBalanceStrategyName),
+		"LeaveGroupRequest": sarama.NewMockLeaveGroupResponse(t),
+		"SyncGroupRequest": sarama.NewMockSyncGroupResponse(t).SetMemberAssignment(
+			&sarama.ConsumerGroupMemberAssignment{Topics: map[string][]int32{topic: {0}}}),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset(group, topic, 0, 0, "", sarama.ErrNoError).SetError(sarama.ErrNoError),
+		"FetchRequest":        fetch,
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+	})
+	return broker
+}
+
+// committedOffset retorna o maior offset enviado em OffsetCommitRequest (-1 se nenhum)
+func committedOffset(broker *sarama.MockBroker, topic string) int64 {
+	committed := int64(-1)
+	for _, rr := range broker.History() {
+		req, ok := rr.Request.(*sarama.OffsetCommitRequest)
+		if !ok {
+			continue
+		}
+		if offset, _, err := req.Offset(topic, 0); err == nil && offset > committed {
+			committed = offset
+		}
+	}
+	return committed
+}
+
+func newTestKafkaMonitor(t *testing.T, broker *sarama.MockBroker, dispatcher types.Dispatcher, format string) *KafkaMonitor {
+	t.Helper()
+	km, err := NewKafkaMonitor(types.KafkaMonitorConfig{
+		Enabled:        true,
+		Brokers:        []string{broker.Addr()},
+		Topics:         []string{"app-logs"},
+		GroupID:        "capturer",
+		Version:        "2.0.0",
+		InitialOffset:  "oldest",
+		Format:         format,
+		CommitInterval: "50ms",
+		Labels:         map[string]string{"env": "test"},
+	}, types.TimestampValidationConfig{}, dispatcher, nil, newTestLogger())
+	require.NoError(t, err)
+	return km
+}
+
+func TestKafkaMonitor_ConsumeAndCommit(t *testing.T) {
+	broker := newMockKafkaCluster(t, "app-logs", "capturer",
+		`{"message":"user logged in","level":"INFO","timestamp":"2024-05-01T10:00:00Z","labels":{"service":"auth"},"user_id":42}`,
+		"plain text line")
+
+	dispatcher := &recordingDispatcher{}
+	km := newTestKafkaMonitor(t, broker, dispatcher, "json")
+	require.NoError(t, km.Start(context.Background()))
+	defer km.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 2
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []string{"user logged in", "plain text line"}, dispatcher.Messages())
+
+	entry := dispatcher.entries[0]
+	assert.Equal(t, "kafka", entry.SourceType)
+	assert.Equal(t, "app-logs", entry.SourceID)
+	assert.Equal(t, "info", entry.Level)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), entry.Timestamp)
+	assert.Equal(t, "auth", entry.Labels["service"])
+	assert.Equal(t, "test", entry.Labels["env"])
+	assert.Equal(t, "kafka", entry.Labels["source"])
+	assert.Equal(t, "app-logs", entry.Labels["topic"])
+	assert.Equal(t, float64(42), entry.Fields["user_id"])
+	assert.Equal(t, int64(0), entry.Fields["kafka_offset"])
+	assert.Equal(t, "key-1", entry.Fields["kafka_key"])
+
+	// Mensagem que não é JSON segue como texto
+	assert.Equal(t, int64(1), dispatcher.entries[1].Fields["kafka_offset"])
+
+	require.Eventually(t, func() bool {
+		return committedOffset(broker, "app-logs") == 2
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestKafkaMonitor_CommitsOnlyAfterDispatch(t *testing.T) {
+	broker := newMockKafkaCluster(t, "app-logs", "capturer", "first", "second")
+
+	dispatcher := &gatedDispatcher{}
+	km := newTestKafkaMonitor(t, broker, dispatcher, "raw")
+	require.NoError(t, km.Start(context.Background()))
+	defer km.Stop()
+
+	// Dispatcher recusando: a mensagem é repetida e nenhum offset é enviado
+	require.Eventually(t, func() bool {
+		return dispatcher.attempts.Load() >= 3
+	}, 10*time.Second, 20*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int64(-1), committedOffset(broker, "app-logs"))
+	assert.Empty(t, dispatcher.Messages())
+
+	dispatcher.accept.Store(true)
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 2
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.Equal(t, []string{"first", "second"}, dispatcher.Messages())
+
+	require.Eventually(t, func() bool {
+		return committedOffset(broker, "app-logs") == 2
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestDecodeKafkaJSON(t *testing.T) {
+	entry := &types.LogEntry{Labels: map[string]string{}, Fields: map[string]interface{}{}}
+	err := decodeKafkaJSON([]byte(`{"msg":"hi","ts":1714557600123,"severity":"WARN","trace_id":"abc","fields":{"path":"/"},"status":200}`), entry)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", entry.Message)
+	assert.Equal(t, time.UnixMilli(1714557600123), entry.Timestamp)
+	assert.Equal(t, "warn", entry.Level)
+	assert.Equal(t, "abc", entry.TraceID)
+	assert.Equal(t, map[string]interface{}{"path": "/", "status": float64(200)}, entry.Fields)
+
+	assert.Equal(t, time.Unix(1714557600, 500000000), epochToTime(1714557600.5))
+	assert.Equal(t, time.UnixMicro(1714557600123456), epochToTime(1714557600123456))
+	assert.Equal(t, time.Unix(0, 1714557600000000000), epochToTime(1714557600000000000))
+
+	for _, value := range []string{"not json", `["array"]`, "null"} {
+		assert.Error(t, decodeKafkaJSON([]byte(value), entry), value)
+	}
+}
+
+func TestNewKafkaMonitor_Config(t *testing.T) {
+	base := types.KafkaMonitorConfig{Enabled: true, Brokers: []string{"localhost:9092"}, Topics: []string{"logs"}}
+
+	config := base
+	config.Auth = types.KafkaAuthConfig{Enabled: true, Mechanism: "SCRAM-SHA-512", Username: "u", Password: "p"}
+	km, err := NewKafkaMonitor(config, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+	require.NoError(t, err)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), km.saramaConfig.Net.SASL.Mechanism)
+	assert.NotNil(t, km.saramaConfig.Net.SASL.SCRAMClientGeneratorFunc())
+
+	certFile, keyFile, _ := writeTestCertificate(t)
+	config = base
+	config.TLS = types.TLSConfig{Enabled: true, VerifyCertificate: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}
+	km, err = NewKafkaMonitor(config, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+	require.NoError(t, err)
+	require.True(t, km.saramaConfig.Net.TLS.Enable)
+	assert.NotNil(t, km.saramaConfig.Net.TLS.Config.RootCAs)
+	assert.Len(t, km.saramaConfig.Net.TLS.Config.Certificates, 1)
+	assert.False(t, km.saramaConfig.Net.TLS.Config.InsecureSkipVerify)
+
+	for _, invalid := range []types.KafkaMonitorConfig{
+		{Enabled: true, Topics: []string{"logs"}},
+		{Enabled: true, Brokers: []string{"localhost:9092"}},
+		{Enabled: true, Brokers: []string{"localhost:9092"}, Topics: []string{"logs"}, Format: "avro"},
+		{Enabled: true, Brokers: []string{"localhost:9092"}, Topics: []string{"logs"}, Auth: types.KafkaAuthConfig{Enabled: true, Mechanism: "GSSAPI"}},
+	} {
+		_, err := NewKafkaMonitor(invalid, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package monitors
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// kmsgBootTime calcula o instante do boot pelo CLOCK_MONOTONIC, a mesma base
+// dos timestamps do /dev/kmsg
+func kmsgBootTime() (time.Time, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read monotonic clock: %w", err)
+	}
+	return time.Now().Add(-time.Duration(ts.Nano())), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package monitors
+
+import (
+	"errors"
+	"time"
+)
+
+// kmsgBootTime não está disponível fora do Linux
+func kmsgBootTime() (time.Time, error) {
+	return time.Time{}, errors.New("kmsg input is only supported on Linux")
+}
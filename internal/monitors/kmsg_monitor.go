@@ -0,0 +1,478 @@
+package monitors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/types"
+	"ssw-logs-capture/pkg/validation"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// kmsgDefaultDevicePath é o ring buffer do kernel
+	kmsgDefaultDevicePath = "/dev/kmsg"
+	// kmsgReadBufferSize comporta o maior registro do /dev/kmsg (uma leitura por registro)
+	kmsgReadBufferSize = 64 * 1024
+	// kmsgPositionFile guarda o último sequence number no diretório de posições
+	kmsgPositionFile = "kmsg_position.json"
+	// kmsgBootIDPath identifica o boot atual; a sequência do kernel recomeça a cada boot
+	kmsgBootIDPath = "/proc/sys/kernel/random/boot_id"
+)
+
+// KmsgMonitor lê os registros do ring buffer do kernel (/dev/kmsg)
+type KmsgMonitor struct {
+	config             types.KmsgMonitorConfig
+	dispatcher         types.Dispatcher
+	logger             *logrus.Logger
+	taskManager        types.TaskManager
+	timestampValidator *validation.TimestampValidator
+
+	positionsDir  string
+	bootIDPath    string
+	pollInterval  time.Duration
+	flushInterval time.Duration
+	bootTime      time.Time
+	bootID        string
+
+	file *os.File
+	wg   sync.WaitGroup
+
+	mutex         sync.RWMutex
+	lastSequence  uint64
+	hasSequence   bool
+	positionDirty bool
+	recordsLost   int64
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// kmsgPosition é o checkpoint persistido no diretório de posições
+type kmsgPosition struct {
+	BootID    string    `json:"boot_id"`
+	Sequence  uint64    `json:"sequence"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewKmsgMonitor cria um novo monitor do /dev/kmsg. positionsDir vazio
+// desabilita o checkpoint do sequence number.
+func NewKmsgMonitor(config types.KmsgMonitorConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, positionsDir string, logger *logrus.Logger) (*KmsgMonitor, error) {
+	// Converter config para o formato do validation package
+	validationConfig := validation.Config{
+		Enabled:             timestampConfig.Enabled,
+		MaxPastAgeSeconds:   timestampConfig.MaxPastAgeSeconds,
+		MaxFutureAgeSeconds: timestampConfig.MaxFutureAgeSeconds,
+		ClampEnabled:        timestampConfig.ClampEnabled,
+		ClampDLQ:            timestampConfig.ClampDLQ,
+		InvalidAction:       timestampConfig.InvalidAction,
+		DefaultTimezone:     timestampConfig.DefaultTimezone,
+		AcceptedFormats:     timestampConfig.AcceptedFormats,
+	}
+
+	if config.DevicePath == "" {
+		config.DevicePath = kmsgDefaultDevicePath
+	}
+
+	km := &KmsgMonitor{
+		config:             config,
+		dispatcher:         dispatcher,
+		logger:             logger,
+		taskManager:        taskManager,
+		timestampValidator: validation.NewTimestampValidator(validationConfig, logger, nil),
+		positionsDir:       positionsDir,
+		bootIDPath:         kmsgBootIDPath,
+		pollInterval:       time.Second,
+		flushInterval:      5 * time.Second,
+	}
+
+	if !config.Enabled {
+		return km, nil
+	}
+
+	if config.FlushInterval != "" {
+		interval, err := time.ParseDuration(config.FlushInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid kmsg flush_interval: %s", config.FlushInterval)
+		}
+		km.flushInterval = interval
+	}
+
+	return km, nil
+}
+
+// Start abre o dispositivo, carrega o checkpoint e inicia a leitura
+func (km *KmsgMonitor) Start(ctx context.Context) error {
+	if !km.config.Enabled {
+		km.logger.Info("Kmsg monitor disabled")
+		return nil
+	}
+
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	if km.isRunning {
+		return fmt.Errorf("kmsg monitor already running")
+	}
+
+	bootTime, err := kmsgBootTime()
+	if err != nil {
+		return err
+	}
+	km.bootTime = bootTime
+	if data, err := os.ReadFile(km.bootIDPath); err == nil {
+		km.bootID = strings.TrimSpace(string(data))
+	}
+	km.loadPosition()
+
+	file, err := os.Open(km.config.DevicePath)
+	if err != nil {
+		return fmt.Errorf("failed to open kmsg device %s: %w", km.config.DevicePath, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat kmsg device %s: %w", km.config.DevicePath, err)
+	}
+	km.file = file
+
+	km.ctx, km.cancel = context.WithCancel(ctx)
+
+	km.wg.Add(1)
+	go km.readLoop(file, info.Mode().IsRegular())
+
+	if km.taskManager != nil {
+		if err := km.taskManager.StartTask(km.ctx, "kmsg_monitor", km.heartbeatLoop); err != nil {
+			km.cancel()
+			file.Close()
+			return fmt.Errorf("failed to start kmsg monitor task: %w", err)
+		}
+	} else {
+		km.wg.Add(1)
+		go func() {
+			defer km.wg.Done()
+			km.heartbeatLoop(km.ctx)
+		}()
+	}
+
+	km.isRunning = true
+	km.logger.WithFields(logrus.Fields{
+		"device":        km.config.DevicePath,
+		"last_sequence": km.lastSequence,
+		"boot_time":     km.bootTime,
+	}).Info("Kmsg monitor started")
+
+	return nil
+}
+
+// Stop fecha o dispositivo e grava o checkpoint
+func (km *KmsgMonitor) Stop() error {
+	km.mutex.Lock()
+	if !km.isRunning {
+		km.mutex.Unlock()
+		return nil
+	}
+	km.logger.Info("Stopping kmsg monitor")
+	km.isRunning = false
+	km.cancel()
+	km.mutex.Unlock()
+
+	if km.taskManager != nil {
+		km.taskManager.StopTask("kmsg_monitor")
+	}
+
+	// Fechar o dispositivo desbloqueia a leitura em andamento
+	km.file.Close()
+
+	done := make(chan struct{})
+	go func() {
+		km.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		km.logger.Warn("Timeout waiting for kmsg reader to stop")
+	}
+
+	km.savePosition()
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (km *KmsgMonitor) IsHealthy() bool {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+	return km.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (km *KmsgMonitor) GetStatus() types.MonitorStatus {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+
+	return types.MonitorStatus{
+		Name:       "kmsg_monitor",
+		IsRunning:  km.isRunning,
+		IsHealthy:  km.isRunning,
+		ErrorCount: km.recordsLost,
+	}
+}
+
+// heartbeatLoop mantém a task viva e grava o checkpoint periodicamente
+func (km *KmsgMonitor) heartbeatLoop(ctx context.Context) error {
+	ticker := time.NewTicker(km.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			km.savePosition()
+			if km.taskManager != nil {
+				km.taskManager.Heartbeat("kmsg_monitor")
+			}
+		}
+	}
+}
+
+// readLoop lê os registros. No dispositivo cada read retorna exatamente um
+// registro; num arquivo regular (testes) os registros são separados pela
+// próxima linha de cabeçalho e o fim do arquivo é acompanhado como tail.
+func (km *KmsgMonitor) readLoop(file *os.File, regular bool) {
+	defer km.wg.Done()
+
+	buf := make([]byte, kmsgReadBufferSize)
+	var partial []byte
+	var pending []string
+
+	flush := func() {
+		if len(pending) > 0 {
+			km.handleRecord(strings.Join(pending, "\n"))
+			pending = pending[:0]
+		}
+	}
+
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			data := append(partial, buf[:n]...)
+			lines := strings.Split(string(data), "\n")
+			partial = append(partial[:0], lines[len(lines)-1]...)
+			for _, line := range lines[:len(lines)-1] {
+				switch {
+				case strings.HasPrefix(line, " "):
+					if len(pending) > 0 {
+						pending = append(pending, line)
+					}
+				case line != "":
+					flush()
+					pending = append(pending, line)
+				}
+			}
+			if !regular {
+				flush()
+			}
+		}
+
+		if err == nil {
+			continue
+		}
+		if km.ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case errors.Is(err, syscall.EPIPE):
+			// O kernel sobrescreveu registros ainda não lidos
+			km.logger.Warn("Kmsg records overwritten before being read")
+			metrics.RecordError("kmsg_monitor", "records_lost")
+		case errors.Is(err, io.EOF) && regular:
+			flush()
+			select {
+			case <-km.ctx.Done():
+				return
+			case <-time.After(km.pollInterval):
+			}
+		default:
+			km.logger.WithError(err).Error("Failed to read kmsg device")
+			metrics.RecordError("kmsg_monitor", "read_error")
+			return
+		}
+	}
+}
+
+// handleRecord decodifica o registro e envia ao dispatcher, ignorando
+// registros já entregues antes do último checkpoint
+func (km *KmsgMonitor) handleRecord(data string) {
+	record, err := parseKmsgRecord(data)
+	if err != nil {
+		km.logger.WithError(err).Debug("Invalid kmsg record")
+		metrics.RecordError("kmsg_monitor", "parse_error")
+		return
+	}
+
+	km.mutex.Lock()
+	if km.hasSequence && record.sequence <= km.lastSequence {
+		km.mutex.Unlock()
+		return
+	}
+	if km.hasSequence && record.sequence > km.lastSequence+1 {
+		km.recordsLost += int64(record.sequence - km.lastSequence - 1)
+	}
+	km.mutex.Unlock()
+
+	entry := km.buildEntry(record)
+
+	// Validar timestamp se o timestamp validator estiver disponível
+	if km.timestampValidator != nil {
+		result := km.timestampValidator.ValidateTimestamp(entry)
+		if !result.Valid && result.Action == "rejected" {
+			km.logger.WithFields(logrus.Fields{
+				"sequence": record.sequence,
+				"reason":   result.Reason,
+			}).Warn("Kmsg record rejected due to invalid timestamp")
+			km.advance(record.sequence)
+			return
+		}
+	}
+
+	if err := km.dispatcher.HandleEntry(km.ctx, entry); err != nil {
+		km.logger.WithError(err).WithField("sequence", record.sequence).Error("Failed to dispatch kmsg record")
+		metrics.RecordError("kmsg_monitor", "dispatch_error")
+		return
+	}
+
+	km.advance(record.sequence)
+	metrics.RecordLogProcessed("kmsg", entry.SourceID, "kmsg_monitor")
+}
+
+// advance registra sequence como o último registro tratado
+func (km *KmsgMonitor) advance(sequence uint64) {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+	km.lastSequence = sequence
+	km.hasSequence = true
+	km.positionDirty = true
+}
+
+// buildEntry converte o registro em LogEntry. Facility e severity viram
+// labels; sequence, o tempo monotônico e o dicionário viram fields.
+func (km *KmsgMonitor) buildEntry(record *kmsgRecord) *types.LogEntry {
+	labels := make(map[string]string, len(km.config.Labels)+3)
+	for k, v := range km.config.Labels {
+		labels[k] = v
+	}
+	labels["source"] = "kmsg"
+	labels["facility"] = "unknown"
+	if record.facility < len(syslogFacilityNames) {
+		labels["facility"] = syslogFacilityNames[record.facility]
+	}
+	labels["severity"] = syslogSeverityNames[record.severity]
+
+	fields := make(map[string]interface{}, len(record.fields)+2)
+	for k, v := range record.fields {
+		fields[k] = v
+	}
+	fields["sequence"] = record.sequence
+	fields["monotonic_usec"] = record.monotonic.Microseconds()
+
+	return &types.LogEntry{
+		TraceID:     uuid.New().String(),
+		Timestamp:   km.bootTime.Add(record.monotonic),
+		Message:     record.message,
+		Level:       syslogSeverityLevels[record.severity],
+		SourceType:  "kmsg",
+		SourceID:    "kernel",
+		Labels:      labels,
+		Fields:      fields,
+		ProcessedAt: time.Now(),
+	}
+}
+
+// loadPosition carrega o checkpoint; é descartado quando o boot mudou, pois a
+// sequência do kernel recomeça
+func (km *KmsgMonitor) loadPosition() {
+	if km.positionsDir == "" {
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(km.positionsDir, kmsgPositionFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			km.logger.WithError(err).Warn("Failed to read kmsg position")
+		}
+		return
+	}
+
+	var position kmsgPosition
+	if err := json.Unmarshal(data, &position); err != nil {
+		km.logger.WithError(err).Warn("Invalid kmsg position file; starting from the ring buffer start")
+		return
+	}
+	if position.BootID != km.bootID {
+		km.logger.Info("Kmsg position from a previous boot; starting from the ring buffer start")
+		return
+	}
+
+	km.lastSequence = position.Sequence
+	km.hasSequence = true
+}
+
+// savePosition grava o checkpoint quando houve avanço (escrita atômica via rename)
+func (km *KmsgMonitor) savePosition() {
+	if km.positionsDir == "" {
+		return
+	}
+
+	km.mutex.Lock()
+	if !km.positionDirty {
+		km.mutex.Unlock()
+		return
+	}
+	position := kmsgPosition{BootID: km.bootID, Sequence: km.lastSequence, UpdatedAt: time.Now()}
+	km.positionDirty = false
+	km.mutex.Unlock()
+
+	err := func() error {
+		data, err := json.Marshal(position)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(km.positionsDir, 0755); err != nil {
+			return err
+		}
+		path := filepath.Join(km.positionsDir, kmsgPositionFile)
+		tempFile := path + ".tmp"
+		if err := os.WriteFile(tempFile, data, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tempFile, path); err != nil {
+			os.Remove(tempFile)
+			return err
+		}
+		return nil
+	}()
+	if err != nil {
+		km.logger.WithError(err).Warn("Failed to save kmsg position")
+		metrics.RecordError("kmsg_monitor", "position_error")
+		km.mutex.Lock()
+		km.positionDirty = true
+		km.mutex.Unlock()
+	}
+}
@@ -0,0 +1,140 @@
+package monitors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kmsgTestRecords = `6,100,5000000,-;usb 1-1: new high-speed USB device
+ SUBSYSTEM=usb
+ DEVICE=c189:1
+3,101,6500000,-,caller=T42;Out of memory: Killed process 1234 (java)
+4,102,7000000,c;tab\x09separated
+`
+
+func TestParseKmsgRecord(t *testing.T) {
+	record, err := parseKmsgRecord("3,101,6500000,-,caller=T42;Out of memory\n SUBSYSTEM=mem\n plain continuation")
+	require.NoError(t, err)
+	assert.Equal(t, 0, record.facility)
+	assert.Equal(t, 3, record.severity)
+	assert.Equal(t, uint64(101), record.sequence)
+	assert.Equal(t, 6500*time.Millisecond, record.monotonic)
+	assert.Equal(t, "Out of memory\nplain continuation", record.message)
+	assert.Equal(t, map[string]string{"caller": "T42", "SUBSYSTEM": "mem"}, record.fields)
+
+	// Mensagens de userspace trazem a facility no prio
+	record, err = parseKmsgRecord("14,7,1,-;from \\x5cuser\\x")
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.facility)
+	assert.Equal(t, 6, record.severity)
+	assert.Equal(t, `from \user\x`, record.message)
+
+	for _, invalid := range []string{"no separator", "6,1;short", "x,1,2,-;msg", "6,-1,2,-;msg", "6,1,z,-;msg"} {
+		_, err := parseKmsgRecord(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func newTestKmsgMonitor(t *testing.T, devicePath, positionsDir string) (*KmsgMonitor, *recordingDispatcher) {
+	t.Helper()
+	dispatcher := &recordingDispatcher{}
+	km, err := NewKmsgMonitor(types.KmsgMonitorConfig{
+		Enabled:    true,
+		DevicePath: devicePath,
+		Labels:     map[string]string{"host": "node-1"},
+	}, types.TimestampValidationConfig{}, dispatcher, nil, positionsDir, newTestLogger())
+	require.NoError(t, err)
+	km.pollInterval = 10 * time.Millisecond
+	km.bootIDPath = filepath.Join(positionsDir, "boot_id")
+	require.NoError(t, km.Start(t.Context()))
+	t.Cleanup(func() { km.Stop() })
+	return km, dispatcher
+}
+
+func TestKmsgMonitor_ReadsRecords(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "kmsg")
+	require.NoError(t, os.WriteFile(device, []byte(kmsgTestRecords), 0644))
+
+	km, dispatcher := newTestKmsgMonitor(t, device, dir)
+	waitForMessages(t, dispatcher, 3)
+	assert.Equal(t, []string{"usb 1-1: new high-speed USB device", "Out of memory: Killed process 1234 (java)", "tab\tseparated"}, dispatcher.Messages())
+
+	first := dispatcher.entries[0]
+	assert.Equal(t, km.bootTime.Add(5*time.Second), first.Timestamp)
+	assert.Equal(t, "kmsg", first.SourceType)
+	assert.Equal(t, "info", first.Level)
+	assert.Equal(t, "kern", first.Labels["facility"])
+	assert.Equal(t, "info", first.Labels["severity"])
+	assert.Equal(t, "node-1", first.Labels["host"])
+	assert.Equal(t, "usb", first.Fields["SUBSYSTEM"])
+	assert.Equal(t, "c189:1", first.Fields["DEVICE"])
+	assert.Equal(t, uint64(100), first.Fields["sequence"])
+	assert.Equal(t, int64(5000000), first.Fields["monotonic_usec"])
+
+	oom := dispatcher.entries[1]
+	assert.Equal(t, "error", oom.Level)
+	assert.Equal(t, "T42", oom.Fields["caller"])
+
+	// Registros acrescentados depois são acompanhados
+	file, err := os.OpenFile(device, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("6,104,8000000,-;after gap\n")
+	require.NoError(t, err)
+	file.Close()
+
+	waitForMessages(t, dispatcher, 4)
+	assert.Equal(t, int64(1), km.GetStatus().ErrorCount, "sequence 103 was lost")
+}
+
+func TestKmsgMonitor_Checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	device := filepath.Join(dir, "kmsg")
+	require.NoError(t, os.WriteFile(device, []byte(kmsgTestRecords), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "boot_id"), []byte("boot-a\n"), 0644))
+
+	km, dispatcher := newTestKmsgMonitor(t, device, dir)
+	waitForMessages(t, dispatcher, 3)
+	require.NoError(t, km.Stop())
+
+	var position kmsgPosition
+	data, err := os.ReadFile(filepath.Join(dir, kmsgPositionFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &position))
+	assert.Equal(t, "boot-a", position.BootID)
+	assert.Equal(t, uint64(102), position.Sequence)
+
+	// Reinício no mesmo boot não duplica os registros já entregues
+	file, err := os.OpenFile(device, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("6,103,8000000,-;new record\n")
+	require.NoError(t, err)
+	file.Close()
+
+	_, dispatcher = newTestKmsgMonitor(t, device, dir)
+	waitForMessages(t, dispatcher, 1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"new record"}, dispatcher.Messages())
+
+	// Em outro boot a sequência recomeça e o checkpoint é descartado
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "boot_id"), []byte("boot-b\n"), 0644))
+	_, dispatcher = newTestKmsgMonitor(t, device, dir)
+	waitForMessages(t, dispatcher, 4)
+}
+
+func TestNewKmsgMonitor_InvalidConfig(t *testing.T) {
+	_, err := NewKmsgMonitor(types.KmsgMonitorConfig{Enabled: true, FlushInterval: "often"}, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, "", newTestLogger())
+	assert.Error(t, err)
+
+	km, err := NewKmsgMonitor(types.KmsgMonitorConfig{Enabled: true, DevicePath: filepath.Join(t.TempDir(), "missing")}, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, "", newTestLogger())
+	require.NoError(t, err)
+	assert.Error(t, km.Start(t.Context()))
+}
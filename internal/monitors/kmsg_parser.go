@@ -0,0 +1,97 @@
+package monitors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kmsgRecord é um registro do /dev/kmsg decodificado
+type kmsgRecord struct {
+	facility  int
+	severity  int
+	sequence  uint64
+	monotonic time.Duration // Tempo desde o boot (CLOCK_MONOTONIC)
+	message   string
+	fields    map[string]string // Pares key=value do cabeçalho e do dicionário
+}
+
+// parseKmsgRecord decodifica um registro no formato
+// "prio,seq,usec,flags[,key=value...];mensagem" seguido de linhas de
+// continuação " KEY=value" (dicionário). Caracteres não imprimíveis chegam
+// escapados como \xHH e são restaurados.
+func parseKmsgRecord(data string) (*kmsgRecord, error) {
+	lines := strings.Split(strings.TrimRight(data, "\n"), "\n")
+	header, message, ok := strings.Cut(lines[0], ";")
+	if !ok {
+		return nil, fmt.Errorf("kmsg record without ';' separator")
+	}
+
+	parts := strings.Split(header, ",")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("kmsg header %q has fewer than 3 fields", header)
+	}
+
+	priority, err := strconv.Atoi(parts[0])
+	if err != nil || priority < 0 {
+		return nil, fmt.Errorf("invalid kmsg priority %q", parts[0])
+	}
+	sequence, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kmsg sequence %q", parts[1])
+	}
+	usec, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || usec < 0 {
+		return nil, fmt.Errorf("invalid kmsg timestamp %q", parts[2])
+	}
+
+	record := &kmsgRecord{
+		facility:  priority >> 3,
+		severity:  priority & 7,
+		sequence:  sequence,
+		monotonic: time.Duration(usec) * time.Microsecond,
+		message:   unescapeKmsg(message),
+		fields:    make(map[string]string),
+	}
+
+	// Campos extras do cabeçalho, como caller=T123 (após as flags)
+	for _, extra := range parts[min(len(parts), 4):] {
+		if key, value, ok := strings.Cut(extra, "="); ok && key != "" {
+			record.fields[key] = value
+		}
+	}
+
+	for _, line := range lines[1:] {
+		line = strings.TrimPrefix(line, " ")
+		if key, value, ok := strings.Cut(line, "="); ok && key != "" {
+			record.fields[key] = unescapeKmsg(value)
+		} else if line != "" {
+			// Continuação sem chave: parte da mensagem
+			record.message += "\n" + unescapeKmsg(line)
+		}
+	}
+
+	return record, nil
+}
+
+// unescapeKmsg restaura as sequências \xHH geradas pelo kernel
+func unescapeKmsg(s string) string {
+	if !strings.Contains(s, `\x`) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] == 'x' {
+			if value, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,321 @@
+package monitors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/positions"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// podLogFilePattern casa os arquivos ativos do kubelet (N.log, N = restart count).
+// Arquivos rotacionados (0.log.20240101-120000) são drenados pela detecção de rotação.
+var podLogFilePattern = regexp.MustCompile(`^\d+\.log$`)
+
+// KubernetesPodMonitor coleta logs de pods do nó a partir de /var/log/pods.
+// Os arquivos estão no formato CRI (containerd/CRI-O); leitura, rotação e
+// checkpoints reutilizam o FileMonitor e o FilePositionManager.
+type KubernetesPodMonitor struct {
+	config       types.KubernetesPodsConfig
+	logger       *logrus.Logger
+	taskManager  types.TaskManager
+	files        *FileMonitor
+	scanInterval time.Duration
+	multiline    *types.MultilineConfig // nil quando multiline está desabilitado
+
+	mutex     sync.RWMutex
+	isRunning bool
+}
+
+// NewKubernetesPodMonitor cria um novo monitor de logs de pods
+func NewKubernetesPodMonitor(config types.KubernetesPodsConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, positionManager *positions.PositionBufferManager, logger *logrus.Logger) (*KubernetesPodMonitor, error) {
+	if config.LogsPath == "" {
+		config.LogsPath = "/var/log/pods"
+	}
+
+	scanInterval := 10 * time.Second
+	if config.ScanInterval != "" {
+		interval, err := time.ParseDuration(config.ScanInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubernetes_pods scan_interval: %w", err)
+		}
+		scanInterval = interval
+	}
+
+	var multiline *types.MultilineConfig
+	if config.Multiline.Enabled {
+		if _, err := newMultilineAggregator(&config.Multiline); err != nil {
+			return nil, fmt.Errorf("invalid kubernetes_pods multiline config: %w", err)
+		}
+		multiline = &config.Multiline
+	}
+
+	files, err := NewFileMonitor(types.FileConfig{Enabled: true, PollInterval: scanInterval}, timestampConfig, dispatcher, taskManager, positionManager, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod log reader: %w", err)
+	}
+
+	return &KubernetesPodMonitor{
+		config:       config,
+		logger:       logger,
+		taskManager:  taskManager,
+		files:        files,
+		scanInterval: scanInterval,
+		multiline:    multiline,
+	}, nil
+}
+
+// SetEnricher define a fonte de metadados dos pods (labels, workload, nó).
+// Deve ser chamado antes de Start.
+func (km *KubernetesPodMonitor) SetEnricher(enricher LabelEnricher) {
+	km.files.enricher = enricher
+}
+
+// Start inicia a descoberta e a leitura dos logs de pods
+func (km *KubernetesPodMonitor) Start(ctx context.Context) error {
+	if !km.config.Enabled {
+		km.logger.Info("Kubernetes pod monitor disabled")
+		return nil
+	}
+
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	if km.isRunning {
+		return fmt.Errorf("kubernetes pod monitor already running")
+	}
+
+	km.isRunning = true
+	km.logger.WithField("logs_path", km.config.LogsPath).Info("Starting kubernetes pod monitor")
+
+	if err := km.taskManager.StartTask(ctx, "kubernetes_pod_monitor", km.monitorLoop); err != nil {
+		return fmt.Errorf("failed to start kubernetes pod monitor task: %w", err)
+	}
+
+	return nil
+}
+
+// Stop para o monitor de logs de pods
+func (km *KubernetesPodMonitor) Stop() error {
+	km.mutex.Lock()
+	if !km.isRunning {
+		km.mutex.Unlock()
+		return nil
+	}
+	km.logger.Info("Stopping kubernetes pod monitor")
+	km.isRunning = false
+	km.mutex.Unlock()
+
+	// Enviar linhas parciais e eventos multiline pendentes antes de cancelar o contexto
+	km.files.flushMultiline(true)
+	km.files.cancel()
+
+	km.taskManager.StopTask("kubernetes_pod_monitor")
+
+	km.files.mutex.Lock()
+	km.files.watcher.Close()
+	for _, mf := range km.files.files {
+		if mf.file != nil {
+			mf.file.Close()
+		}
+	}
+	km.files.mutex.Unlock()
+
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (km *KubernetesPodMonitor) IsHealthy() bool {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+	return km.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (km *KubernetesPodMonitor) GetStatus() types.MonitorStatus {
+	km.mutex.RLock()
+	defer km.mutex.RUnlock()
+
+	return types.MonitorStatus{
+		Name:      "kubernetes_pod_monitor",
+		IsRunning: km.isRunning,
+		IsHealthy: km.isRunning,
+	}
+}
+
+// monitorLoop descobre pods periodicamente e acompanha os arquivos descobertos
+func (km *KubernetesPodMonitor) monitorLoop(ctx context.Context) error {
+	km.scanPods()
+
+	scanTicker := time.NewTicker(km.scanInterval)
+	defer scanTicker.Stop()
+
+	pollTicker := time.NewTicker(2 * time.Second)
+	defer pollTicker.Stop()
+
+	// Ticker para liberar linhas parciais e eventos multiline de arquivos quietos
+	multilineTicker := time.NewTicker(1 * time.Second)
+	defer multilineTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-km.files.watcher.Events:
+			km.files.handleFileEvent(event)
+		case err := <-km.files.watcher.Errors:
+			km.logger.WithError(err).Error("Pod log watcher error")
+			metrics.RecordError("kubernetes_pod_monitor", "watcher_error")
+		case <-scanTicker.C:
+			km.scanPods()
+		case <-pollTicker.C:
+			km.files.pollAllFiles()
+		case <-multilineTicker.C:
+			km.files.flushMultiline(false)
+		}
+
+		km.taskManager.Heartbeat("kubernetes_pod_monitor")
+	}
+}
+
+// scanPods percorre <logs_path>/<namespace>_<pod>_<uid>/<container>/N.log, adiciona
+// os arquivos novos e remove os de pods apagados após drenar o conteúdo restante
+func (km *KubernetesPodMonitor) scanPods() {
+	podDirs, err := os.ReadDir(km.config.LogsPath)
+	if err != nil {
+		km.logger.WithError(err).WithField("logs_path", km.config.LogsPath).Warn("Failed to read pod logs directory")
+		metrics.RecordError("kubernetes_pod_monitor", "scan_error")
+		return
+	}
+
+	found := make(map[string]bool)
+	for _, podDir := range podDirs {
+		if !podDir.IsDir() {
+			continue
+		}
+
+		namespace, pod, uid, ok := parsePodDirName(podDir.Name())
+		if !ok || !km.namespaceAllowed(namespace) {
+			continue
+		}
+
+		podPath := filepath.Join(km.config.LogsPath, podDir.Name())
+		containerDirs, err := os.ReadDir(podPath)
+		if err != nil {
+			continue
+		}
+
+		for _, containerDir := range containerDirs {
+			if !containerDir.IsDir() || km.containerExcluded(containerDir.Name()) {
+				continue
+			}
+
+			containerPath := filepath.Join(podPath, containerDir.Name())
+			logFiles, err := os.ReadDir(containerPath)
+			if err != nil {
+				continue
+			}
+
+			for _, logFile := range logFiles {
+				if logFile.IsDir() || !podLogFilePattern.MatchString(logFile.Name()) {
+					continue
+				}
+
+				path := filepath.Join(containerPath, logFile.Name())
+				found[path] = true
+				km.addPodLogFile(path, km.podLabels(namespace, pod, uid, containerDir.Name()))
+			}
+		}
+	}
+
+	km.files.removeDeletedFiles(found)
+}
+
+// addPodLogFile inicia a leitura de um arquivo de log de container, se ainda não monitorado
+func (km *KubernetesPodMonitor) addPodLogFile(path string, labels map[string]string) {
+	km.files.mutex.RLock()
+	_, exists := km.files.files[path]
+	km.files.mutex.RUnlock()
+	if exists {
+		return
+	}
+
+	opts := fileReadOptions{
+		multiline:  km.multiline,
+		format:     fileFormatCRI,
+		sourceType: "kubernetes",
+	}
+	if err := km.files.addFileWithOptions(path, labels, opts); err != nil {
+		km.logger.WithError(err).WithField("path", path).Warn("Failed to add pod log file")
+		metrics.RecordError("kubernetes_pod_monitor", "add_file_error")
+		return
+	}
+
+	km.logger.WithFields(logrus.Fields{
+		"path":      path,
+		"namespace": labels["namespace"],
+		"pod":       labels["pod"],
+		"container": labels["container"],
+	}).Info("Pod log file added to monitoring")
+}
+
+// podLabels monta as labels derivadas do path do arquivo
+func (km *KubernetesPodMonitor) podLabels(namespace, pod, uid, container string) map[string]string {
+	labels := make(map[string]string, len(km.config.Labels)+4)
+	for k, v := range km.config.Labels {
+		labels[k] = v
+	}
+	labels["namespace"] = namespace
+	labels["pod"] = pod
+	labels["pod_uid"] = uid
+	labels["container"] = container
+	return labels
+}
+
+// namespaceAllowed aplica include_namespaces/exclude_namespaces
+func (km *KubernetesPodMonitor) namespaceAllowed(namespace string) bool {
+	for _, excluded := range km.config.ExcludeNamespaces {
+		if excluded == namespace {
+			return false
+		}
+	}
+
+	if len(km.config.IncludeNamespaces) == 0 {
+		return true
+	}
+	for _, included := range km.config.IncludeNamespaces {
+		if included == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// containerExcluded verifica os padrões de exclude_containers
+func (km *KubernetesPodMonitor) containerExcluded(container string) bool {
+	for _, pattern := range km.config.ExcludeContainers {
+		if matched, err := filepath.Match(pattern, container); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePodDirName separa "<namespace>_<pod>_<uid>". Namespace e nome do pod são
+// nomes DNS e não contêm "_", então a divisão é inequívoca.
+func parsePodDirName(name string) (string, string, string, bool) {
+	parts := strings.Split(name, "_")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
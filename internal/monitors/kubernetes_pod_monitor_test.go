@@ -0,0 +1,211 @@
+package monitors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCRILine(t *testing.T) {
+	record, err := parseCRILine("2024-05-01T10:00:00.123456789Z stderr F boom: failed")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC), record.timestamp)
+	assert.Equal(t, "stderr", record.stream)
+	assert.False(t, record.partial)
+	assert.Equal(t, "boom: failed", record.message)
+
+	record, err = parseCRILine("2024-05-01T10:00:00+02:00 stdout P ")
+	require.NoError(t, err)
+	assert.True(t, record.partial)
+	assert.Empty(t, record.message)
+
+	record, err = parseCRILine("2024-05-01T10:00:00Z stdout F")
+	require.NoError(t, err)
+	assert.Empty(t, record.message)
+
+	for _, line := range []string{"plain text line", "2024-05-01T10:00:00Z stdin F x", "2024-05-01T10:00:00Z stdout X x"} {
+		_, err := parseCRILine(line)
+		assert.Error(t, err, line)
+	}
+
+	assert.True(t, podLogFilePattern.MatchString("0.log"))
+	assert.True(t, podLogFilePattern.MatchString("12.log"))
+	assert.False(t, podLogFilePattern.MatchString("0.log.20240501-100000"))
+	assert.False(t, podLogFilePattern.MatchString("0.log.20240501-100000.gz"))
+}
+
+func TestFileMonitor_CRIPartialReassembly(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "0.log")
+	appendToFile(t, path,
+		"2024-05-01T10:00:00Z stdout P first half, \n"+
+			"2024-05-01T10:00:00.5Z stderr F interleaved\n"+
+			"2024-05-01T10:00:01Z stdout F second half\n"+
+			"2024-05-01T10:00:02Z stdout P dangling")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	cri, err := newCRIDecoder(nil)
+	require.NoError(t, err)
+	mf := &monitoredFile{path: path, labels: map[string]string{}, cri: cri, sourceType: "kubernetes"}
+
+	fm.readFile(mf)
+	require.Equal(t, []string{"interleaved", "first half, second half"}, dispatcher.Messages())
+
+	entry := dispatcher.entries[1]
+	assert.Equal(t, "kubernetes", entry.SourceType)
+	assert.Equal(t, "kubernetes", entry.Labels["source"])
+	assert.Equal(t, "stdout", entry.Labels["stream"])
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), entry.Timestamp, "reassembled line keeps the first fragment timestamp")
+	assert.Equal(t, "stderr", dispatcher.entries[0].Labels["stream"])
+
+	// Linha sem newline e fragmento P pendente não entram na posição persistida
+	assert.Equal(t, int64(len("2024-05-01T10:00:02Z stdout P dangling")), int64(len(mf.partial)))
+	assert.Zero(t, cri.pendingBytes())
+
+	// Fim do arquivo (pod removido): o restante é enviado
+	fm.finishFile(mf)
+	assert.Equal(t, "dangling", dispatcher.Messages()[2])
+}
+
+func TestFileMonitor_CRIMultilinePerStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "0.log")
+	appendToFile(t, path,
+		"2024-05-01T10:00:00Z stdout F Exception in thread main\n"+
+			"2024-05-01T10:00:00Z stderr F unrelated\n"+
+			"2024-05-01T10:00:01Z stdout F \tat Main.run\n"+
+			"2024-05-01T10:00:02Z stdout F next event\n")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, nil)
+	cri, err := newCRIDecoder(&types.MultilineConfig{Enabled: true, ContinuationPattern: `^\s`})
+	require.NoError(t, err)
+	mf := &monitoredFile{path: path, labels: map[string]string{}, cri: cri}
+
+	fm.readFile(mf)
+	assert.Equal(t, []string{"Exception in thread main\n\tat Main.run"}, dispatcher.Messages())
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), dispatcher.entries[0].Timestamp)
+	assert.Positive(t, cri.pendingBytes())
+
+	fm.flushCRI(mf, true, time.Now())
+	assert.ElementsMatch(t, []string{"Exception in thread main\n\tat Main.run", "unrelated", "next event"}, dispatcher.Messages())
+	assert.Zero(t, cri.pendingBytes())
+}
+
+func newTestKubernetesPodMonitor(t *testing.T, config types.KubernetesPodsConfig, dispatcher *recordingDispatcher) *KubernetesPodMonitor {
+	t.Helper()
+	config.Enabled = true
+	km, err := NewKubernetesPodMonitor(config, types.TimestampValidationConfig{}, dispatcher, nil, nil, newTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		km.files.watcher.Close()
+		for _, mf := range km.files.files {
+			if mf.file != nil {
+				mf.file.Close()
+			}
+		}
+	})
+	return km
+}
+
+func TestKubernetesPodMonitor_ScanPods(t *testing.T) {
+	root := t.TempDir()
+	apiDir := filepath.Join(root, "shop_api-7d9f_1f2e-3a4b", "api")
+	sidecarDir := filepath.Join(root, "shop_api-7d9f_1f2e-3a4b", "istio-proxy")
+	systemDir := filepath.Join(root, "kube-system_coredns-1_9a8b", "coredns")
+	for _, dir := range []string{apiDir, sidecarDir, systemDir} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	appendToFile(t, filepath.Join(apiDir, "0.log"), "2024-05-01T10:00:00Z stdout F hello from api\n")
+	appendToFile(t, filepath.Join(apiDir, "0.log.20240501-090000"), "2024-05-01T09:00:00Z stdout F rotated\n")
+	appendToFile(t, filepath.Join(sidecarDir, "0.log"), "2024-05-01T10:00:00Z stdout F sidecar\n")
+	appendToFile(t, filepath.Join(systemDir, "0.log"), "2024-05-01T10:00:00Z stdout F dns\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "not-a-pod-dir", "c"), 0755))
+
+	dispatcher := &recordingDispatcher{}
+	km := newTestKubernetesPodMonitor(t, types.KubernetesPodsConfig{
+		LogsPath:          root,
+		ExcludeNamespaces: []string{"kube-system"},
+		ExcludeContainers: []string{"istio-*"},
+		Labels:            map[string]string{"cluster": "prod"},
+	}, dispatcher)
+
+	km.scanPods()
+	require.Len(t, km.files.files, 1)
+
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, "hello from api", dispatcher.Messages()[0])
+
+	labels := dispatcher.entries[0].Labels
+	assert.Equal(t, "shop", labels["namespace"])
+	assert.Equal(t, "api-7d9f", labels["pod"])
+	assert.Equal(t, "1f2e-3a4b", labels["pod_uid"])
+	assert.Equal(t, "api", labels["container"])
+	assert.Equal(t, "prod", labels["cluster"])
+	assert.Equal(t, "kubernetes", labels["source"])
+
+	// Container reiniciado cria 1.log; pod removido deixa de ser acompanhado
+	appendToFile(t, filepath.Join(apiDir, "1.log"), "2024-05-01T10:05:00Z stdout F restarted\n")
+	km.scanPods()
+	assert.Len(t, km.files.files, 2)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "shop_api-7d9f_1f2e-3a4b")))
+	km.scanPods()
+	assert.Empty(t, km.files.files)
+}
+
+// staticEnricher simula o cache de metadados de pods
+type staticEnricher map[string]string
+
+func (e staticEnricher) EnrichLabels(labels map[string]string) {
+	for k, v := range e {
+		if _, exists := labels[k]; !exists {
+			labels[k] = v
+		}
+	}
+}
+
+func TestKubernetesPodMonitor_Enricher(t *testing.T) {
+	root := t.TempDir()
+	containerDir := filepath.Join(root, "shop_api-7d9f_1f2e", "api")
+	require.NoError(t, os.MkdirAll(containerDir, 0755))
+	appendToFile(t, filepath.Join(containerDir, "0.log"), "2024-05-01T10:00:00Z stdout F hello\n")
+
+	dispatcher := &recordingDispatcher{}
+	km := newTestKubernetesPodMonitor(t, types.KubernetesPodsConfig{LogsPath: root}, dispatcher)
+	km.SetEnricher(staticEnricher{"workload": "api", "namespace": "overridden"})
+
+	km.scanPods()
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	labels := dispatcher.entries[0].Labels
+	assert.Equal(t, "api", labels["workload"])
+	assert.Equal(t, "shop", labels["namespace"])
+}
+
+func TestKubernetesPodMonitor_IncludeNamespaces(t *testing.T) {
+	km := newTestKubernetesPodMonitor(t, types.KubernetesPodsConfig{
+		IncludeNamespaces: []string{"shop", "billing"},
+		ExcludeNamespaces: []string{"billing"},
+	}, &recordingDispatcher{})
+
+	assert.True(t, km.namespaceAllowed("shop"))
+	assert.False(t, km.namespaceAllowed("billing"))
+	assert.False(t, km.namespaceAllowed("default"))
+
+	_, _, _, ok := parsePodDirName("default_web_abc")
+	assert.True(t, ok)
+	_, _, _, ok = parsePodDirName("default_web")
+	assert.False(t, ok)
+}
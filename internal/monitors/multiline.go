@@ -0,0 +1,164 @@
+package monitors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+const (
+	defaultMultilineMaxLines     = 500
+	defaultMultilineMaxBytes     = 1024 * 1024
+	defaultMultilineFlushTimeout = 5 * time.Second
+)
+
+// multilineAggregator agrupa linhas consecutivas em um único evento lógico
+// (ex: stack traces Java/Python) antes do envio ao dispatcher.
+type multilineAggregator struct {
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	negate       bool
+	maxLines     int
+	maxBytes     int
+	flushTimeout time.Duration
+
+	mutex      sync.Mutex
+	lines      []string
+	size       int   // Tamanho do evento pendente (linhas + separadores)
+	rawBytes   int64 // Bytes lidos da origem ainda não emitidos
+	lastAppend time.Time
+}
+
+// newMultilineAggregator cria um agregador a partir da configuração.
+// Retorna nil quando a configuração está desabilitada.
+func newMultilineAggregator(config *types.MultilineConfig) (*multilineAggregator, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+
+	if config.StartPattern == "" && config.ContinuationPattern == "" {
+		return nil, fmt.Errorf("multiline requires start_pattern or continuation_pattern")
+	}
+
+	ma := &multilineAggregator{
+		negate:       config.Negate,
+		maxLines:     config.MaxLines,
+		maxBytes:     config.MaxBytes,
+		flushTimeout: defaultMultilineFlushTimeout,
+	}
+
+	if config.StartPattern != "" {
+		re, err := regexp.Compile(config.StartPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiline start_pattern: %w", err)
+		}
+		ma.start = re
+	}
+
+	if config.ContinuationPattern != "" {
+		re, err := regexp.Compile(config.ContinuationPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiline continuation_pattern: %w", err)
+		}
+		ma.continuation = re
+	}
+
+	if ma.maxLines <= 0 {
+		ma.maxLines = defaultMultilineMaxLines
+	}
+	if ma.maxBytes <= 0 {
+		ma.maxBytes = defaultMultilineMaxBytes
+	}
+	if config.FlushTimeout != "" {
+		timeout, err := time.ParseDuration(config.FlushTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiline flush_timeout: %w", err)
+		}
+		if timeout > 0 {
+			ma.flushTimeout = timeout
+		}
+	}
+
+	return ma, nil
+}
+
+// isContinuation verifica se a linha deve ser anexada ao evento atual
+func (ma *multilineAggregator) isContinuation(line string) bool {
+	if ma.continuation != nil {
+		// Linha de início explícita sempre abre um novo evento
+		if ma.start != nil && ma.start.MatchString(line) {
+			return false
+		}
+		return ma.continuation.MatchString(line) != ma.negate
+	}
+
+	return ma.start.MatchString(line) == ma.negate
+}
+
+// Add processa uma linha e retorna os eventos completos que ela liberou.
+// rawSize é o número de bytes que a linha ocupava na origem.
+func (ma *multilineAggregator) Add(line string, rawSize int64) []string {
+	ma.mutex.Lock()
+	defer ma.mutex.Unlock()
+
+	var events []string
+
+	if len(ma.lines) > 0 {
+		exceedsLimits := len(ma.lines) >= ma.maxLines || ma.size+1+len(line) > ma.maxBytes
+		if !ma.isContinuation(line) || exceedsLimits {
+			events = append(events, ma.flushLocked())
+		}
+	}
+
+	if len(ma.lines) > 0 {
+		ma.size++ // separador "\n"
+	}
+	ma.lines = append(ma.lines, line)
+	ma.size += len(line)
+	ma.rawBytes += rawSize
+	ma.lastAppend = time.Now()
+
+	return events
+}
+
+// Flush retorna o evento pendente, se houver
+func (ma *multilineAggregator) Flush() (string, bool) {
+	ma.mutex.Lock()
+	defer ma.mutex.Unlock()
+
+	if len(ma.lines) == 0 {
+		return "", false
+	}
+	return ma.flushLocked(), true
+}
+
+// FlushExpired retorna o evento pendente se ele estiver ocioso há mais que flushTimeout
+func (ma *multilineAggregator) FlushExpired(now time.Time) (string, bool) {
+	ma.mutex.Lock()
+	defer ma.mutex.Unlock()
+
+	if len(ma.lines) == 0 || now.Sub(ma.lastAppend) < ma.flushTimeout {
+		return "", false
+	}
+	return ma.flushLocked(), true
+}
+
+// PendingBytes retorna quantos bytes da origem ainda não foram emitidos.
+// Usado para não persistir posições além do último evento completo.
+func (ma *multilineAggregator) PendingBytes() int64 {
+	ma.mutex.Lock()
+	defer ma.mutex.Unlock()
+	return ma.rawBytes
+}
+
+func (ma *multilineAggregator) flushLocked() string {
+	event := strings.Join(ma.lines, "\n")
+	ma.lines = ma.lines[:0]
+	ma.size = 0
+	ma.rawBytes = 0
+	return event
+}
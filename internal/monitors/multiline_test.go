@@ -0,0 +1,337 @@
+package monitors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDispatcher armazena as mensagens recebidas para asserções
+type recordingDispatcher struct {
+	mu       sync.Mutex
+	messages []string
+	labels   []map[string]string
+	entries  []*types.LogEntry
+}
+
+func (d *recordingDispatcher) AddSink(sink types.Sink) {}
+
+func (d *recordingDispatcher) Handle(ctx context.Context, sourceType, sourceID, message string, labels map[string]string) error {
+	return d.HandleEntry(ctx, &types.LogEntry{SourceType: sourceType, SourceID: sourceID, Message: message, Labels: labels})
+}
+
+func (d *recordingDispatcher) HandleEntry(ctx context.Context, entry *types.LogEntry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, entry.Message)
+	d.labels = append(d.labels, entry.Labels)
+	d.entries = append(d.entries, entry.DeepCopy())
+	return nil
+}
+
+func (d *recordingDispatcher) HandleBatch(ctx context.Context, entries []*types.LogEntry) error {
+	for _, entry := range entries {
+		d.HandleEntry(ctx, entry)
+	}
+	return nil
+}
+
+func (d *recordingDispatcher) Start(ctx context.Context) error { return nil }
+
+func (d *recordingDispatcher) Stop() error { return nil }
+
+func (d *recordingDispatcher) GetStats() types.DispatcherStats { return types.DispatcherStats{} }
+
+func (d *recordingDispatcher) Messages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.messages...)
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return logger
+}
+
+func TestMultilineAggregator_Disabled(t *testing.T) {
+	ma, err := newMultilineAggregator(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ma)
+
+	ma, err = newMultilineAggregator(&types.MultilineConfig{Enabled: false, StartPattern: "^x"})
+	assert.NoError(t, err)
+	assert.Nil(t, ma)
+}
+
+func TestMultilineAggregator_InvalidConfig(t *testing.T) {
+	_, err := newMultilineAggregator(&types.MultilineConfig{Enabled: true})
+	assert.Error(t, err)
+
+	_, err = newMultilineAggregator(&types.MultilineConfig{Enabled: true, StartPattern: "("})
+	assert.Error(t, err)
+
+	_, err = newMultilineAggregator(&types.MultilineConfig{Enabled: true, StartPattern: "^x", FlushTimeout: "soon"})
+	assert.Error(t, err)
+}
+
+func TestMultilineAggregator_StartPattern(t *testing.T) {
+	ma, err := newMultilineAggregator(&types.MultilineConfig{
+		Enabled:      true,
+		StartPattern: `^\d{4}-\d{2}-\d{2}`,
+	})
+	require.NoError(t, err)
+
+	var events []string
+	lines := []string{
+		"2024-01-01 10:00:00 ERROR request failed",
+		"java.lang.IllegalStateException: boom",
+		"\tat com.example.Service.run(Service.java:42)",
+		"\tat com.example.Main.main(Main.java:7)",
+		"2024-01-01 10:00:01 INFO recovered",
+	}
+	for _, line := range lines {
+		events = append(events, ma.Add(line, int64(len(line))+1)...)
+	}
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "2024-01-01 10:00:00 ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Service.run(Service.java:42)\n\tat com.example.Main.main(Main.java:7)", events[0])
+	assert.Equal(t, int64(len(lines[4])+1), ma.PendingBytes())
+
+	event, ok := ma.Flush()
+	assert.True(t, ok)
+	assert.Equal(t, "2024-01-01 10:00:01 INFO recovered", event)
+	assert.Equal(t, int64(0), ma.PendingBytes())
+
+	_, ok = ma.Flush()
+	assert.False(t, ok)
+}
+
+func TestMultilineAggregator_ContinuationPattern(t *testing.T) {
+	ma, err := newMultilineAggregator(&types.MultilineConfig{
+		Enabled:             true,
+		ContinuationPattern: `^(\s+|Traceback|\w+Error:)`,
+	})
+	require.NoError(t, err)
+
+	var events []string
+	for _, line := range []string{
+		"processing job 1",
+		"Traceback (most recent call last):",
+		`  File "app.py", line 3, in <module>`,
+		"ValueError: bad value",
+		"processing job 2",
+	} {
+		events = append(events, ma.Add(line, 0)...)
+	}
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "processing job 1\nTraceback (most recent call last):\n  File \"app.py\", line 3, in <module>\nValueError: bad value", events[0])
+}
+
+func TestMultilineAggregator_Negate(t *testing.T) {
+	// Linhas que NÃO começam com '[' são continuação (padrão invertido)
+	ma, err := newMultilineAggregator(&types.MultilineConfig{
+		Enabled:             true,
+		ContinuationPattern: `^\[`,
+		Negate:              true,
+	})
+	require.NoError(t, err)
+
+	var events []string
+	for _, line := range []string{"[1] first", "detail a", "[2] second", "detail b"} {
+		events = append(events, ma.Add(line, 0)...)
+	}
+	event, ok := ma.Flush()
+	require.True(t, ok)
+	events = append(events, event)
+
+	assert.Equal(t, []string{"[1] first\ndetail a", "[2] second\ndetail b"}, events)
+}
+
+func TestMultilineAggregator_MaxLinesAndBytes(t *testing.T) {
+	ma, err := newMultilineAggregator(&types.MultilineConfig{
+		Enabled:      true,
+		StartPattern: "^START",
+		MaxLines:     2,
+	})
+	require.NoError(t, err)
+
+	var events []string
+	for _, line := range []string{"START", "a", "b", "c"} {
+		events = append(events, ma.Add(line, 0)...)
+	}
+	assert.Equal(t, []string{"START\na", "b\nc"}, append(events, mustFlush(t, ma)))
+
+	ma, err = newMultilineAggregator(&types.MultilineConfig{
+		Enabled:      true,
+		StartPattern: "^START",
+		MaxBytes:     10,
+	})
+	require.NoError(t, err)
+
+	events = nil
+	for _, line := range []string{"START", "12345", "x"} {
+		events = append(events, ma.Add(line, 0)...)
+	}
+	assert.Equal(t, []string{"START", "12345\nx"}, append(events, mustFlush(t, ma)))
+}
+
+func TestMultilineAggregator_FlushExpired(t *testing.T) {
+	ma, err := newMultilineAggregator(&types.MultilineConfig{
+		Enabled:      true,
+		StartPattern: "^START",
+		FlushTimeout: "50ms",
+	})
+	require.NoError(t, err)
+
+	ma.Add("START", 0)
+	ma.Add("  continuation", 0)
+
+	_, ok := ma.FlushExpired(time.Now())
+	assert.False(t, ok, "event should not be flushed before timeout")
+
+	event, ok := ma.FlushExpired(time.Now().Add(100 * time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, "START\n  continuation", event)
+}
+
+func TestParseFileReadOptions(t *testing.T) {
+	entry := map[string]interface{}{
+		"path": "/var/log/app.log",
+		"multiline": map[interface{}]interface{}{
+			"enabled":       true,
+			"start_pattern": `^\d{4}`,
+			"max_lines":     100,
+			"flush_timeout": "2s",
+		},
+	}
+
+	opts, err := parseFileReadOptions(entry)
+	require.NoError(t, err)
+	require.NotNil(t, opts.multiline)
+	assert.True(t, opts.multiline.Enabled)
+	assert.Equal(t, `^\d{4}`, opts.multiline.StartPattern)
+	assert.Equal(t, 100, opts.multiline.MaxLines)
+	assert.Equal(t, "2s", opts.multiline.FlushTimeout)
+
+	_, err = parseFileReadOptions(map[string]interface{}{
+		"multiline": map[string]interface{}{"enabled": true, "start_pattern": "("},
+	})
+	assert.Error(t, err)
+
+	opts, err = parseFileReadOptions(map[string]interface{}{"path": "/x"})
+	require.NoError(t, err)
+	assert.Nil(t, opts.multiline)
+}
+
+func TestMultilineRuleMatches(t *testing.T) {
+	rule := &types.ContainerMultilineRule{
+		Names:  []string{"java-"},
+		Labels: map[string]string{"lang": "java", "team": ""},
+	}
+
+	assert.True(t, multilineRuleMatches(rule, "java-api", map[string]string{"lang": "java", "team": "core"}))
+	assert.False(t, multilineRuleMatches(rule, "python-api", map[string]string{"lang": "java", "team": "core"}))
+	assert.False(t, multilineRuleMatches(rule, "java-api", map[string]string{"lang": "go", "team": "core"}))
+	assert.False(t, multilineRuleMatches(rule, "java-api", map[string]string{"lang": "java"}))
+	assert.True(t, multilineRuleMatches(&types.ContainerMultilineRule{}, "anything", nil))
+}
+
+func TestFileMonitor_ReadFileMultiline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	content := "2024-01-01 ERROR failed\n" +
+		"java.lang.RuntimeException: boom\n" +
+		"\tat Main.main(Main.java:1)\n" +
+		"2024-01-01 INFO next\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	dispatcher := &recordingDispatcher{}
+	fm, err := NewFileMonitor(types.FileConfig{Enabled: true}, types.TimestampValidationConfig{}, dispatcher, nil, nil, newTestLogger())
+	require.NoError(t, err)
+
+	multiline, err := newMultilineAggregator(&types.MultilineConfig{Enabled: true, StartPattern: `^\d{4}-`})
+	require.NoError(t, err)
+
+	mf := &monitoredFile{path: path, labels: map[string]string{}, multiline: multiline}
+	fm.files[path] = mf
+
+	fm.readFile(mf)
+	require.Equal(t, []string{"2024-01-01 ERROR failed\njava.lang.RuntimeException: boom\n\tat Main.main(Main.java:1)"}, dispatcher.Messages())
+	assert.Equal(t, int64(len(content)), mf.position)
+
+	// Arquivo ficou quieto: evento pendente deve ser liberado
+	fm.flushMultiline(true)
+	messages := dispatcher.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "2024-01-01 INFO next", messages[1])
+
+	if mf.file != nil {
+		mf.file.Close()
+	}
+}
+
+func TestFileMonitor_FlushMultilineDuringRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(t, path, "")
+
+	dispatcher := &recordingDispatcher{}
+	fm := newTestFileMonitor(t, dispatcher, newTestPositionManager(filepath.Join(dir, "positions")))
+	multiline, err := newMultilineAggregator(&types.MultilineConfig{Enabled: true, StartPattern: `^event`})
+	require.NoError(t, err)
+	mf := &monitoredFile{path: path, labels: map[string]string{}, multiline: multiline}
+	fm.files[path] = mf
+
+	// Ticker do flush concorrendo com leituras
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				fm.flushMultiline(true)
+			}
+		}
+	}()
+
+	var expected []string
+	for i := 0; i < 200; i++ {
+		appendToFile(t, path, fmt.Sprintf("event %d\n  detail %d\n", i, i))
+		fm.readFile(mf)
+		expected = append(expected, fmt.Sprintf("event %d", i), fmt.Sprintf("  detail %d", i))
+	}
+	close(done)
+	wg.Wait()
+	fm.flushMultiline(true)
+
+	// Eventos podem ser liberados em partes, mas nunca fora de ordem
+	var lines []string
+	for _, message := range dispatcher.Messages() {
+		lines = append(lines, strings.Split(message, "\n")...)
+	}
+	assert.Equal(t, expected, lines)
+}
+
+func mustFlush(t *testing.T, ma *multilineAggregator) string {
+	t.Helper()
+	event, ok := ma.Flush()
+	require.True(t, ok)
+	return event
+}
@@ -0,0 +1,633 @@
+package monitors
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/types"
+	"ssw-logs-capture/pkg/validation"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// syslogUDPBufferSize comporta o maior datagrama UDP possível
+	syslogUDPBufferSize = 64 * 1024
+	// syslogMaxFrameDigits limita o prefixo de tamanho do octet-counting
+	syslogMaxFrameDigits = 10
+)
+
+// SyslogMonitor recebe mensagens syslog (RFC 5424 e RFC 3164) via UDP, TCP e TLS
+type SyslogMonitor struct {
+	config             types.SyslogMonitorConfig
+	dispatcher         types.Dispatcher
+	logger             *logrus.Logger
+	taskManager        types.TaskManager
+	timestampValidator *validation.TimestampValidator
+
+	readTimeout time.Duration
+	tlsConfig   *tls.Config
+
+	udpConn   net.PacketConn
+	listeners []syslogListener
+	conns     map[net.Conn]struct{}
+	connSlots chan struct{}
+	wg        sync.WaitGroup
+	mutex     sync.RWMutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	isRunning bool
+}
+
+// syslogListener associa um listener de stream ao transporte usado nas labels
+type syslogListener struct {
+	listener  net.Listener
+	transport string
+}
+
+// NewSyslogMonitor cria um novo monitor syslog
+func NewSyslogMonitor(config types.SyslogMonitorConfig, timestampConfig types.TimestampValidationConfig, dispatcher types.Dispatcher, taskManager types.TaskManager, logger *logrus.Logger) (*SyslogMonitor, error) {
+	// Converter config para o formato do validation package
+	validationConfig := validation.Config{
+		Enabled:             timestampConfig.Enabled,
+		MaxPastAgeSeconds:   timestampConfig.MaxPastAgeSeconds,
+		MaxFutureAgeSeconds: timestampConfig.MaxFutureAgeSeconds,
+		ClampEnabled:        timestampConfig.ClampEnabled,
+		ClampDLQ:            timestampConfig.ClampDLQ,
+		InvalidAction:       timestampConfig.InvalidAction,
+		DefaultTimezone:     timestampConfig.DefaultTimezone,
+		AcceptedFormats:     timestampConfig.AcceptedFormats,
+	}
+
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 64 * 1024
+	}
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = 1000
+	}
+
+	sm := &SyslogMonitor{
+		config:             config,
+		dispatcher:         dispatcher,
+		logger:             logger,
+		taskManager:        taskManager,
+		timestampValidator: validation.NewTimestampValidator(validationConfig, logger, nil),
+		readTimeout:        5 * time.Minute,
+		conns:              make(map[net.Conn]struct{}),
+		connSlots:          make(chan struct{}, config.MaxConnections),
+	}
+
+	if !config.Enabled {
+		return sm, nil
+	}
+
+	if config.UDPAddress == "" && config.TCPAddress == "" && config.TLSAddress == "" {
+		return nil, fmt.Errorf("syslog monitor enabled without udp_address, tcp_address or tls_address")
+	}
+
+	if config.ReadTimeout != "" {
+		timeout, err := time.ParseDuration(config.ReadTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog read_timeout: %w", err)
+		}
+		sm.readTimeout = timeout
+	}
+
+	if config.TLSAddress != "" {
+		tlsConfig, err := newSyslogTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		sm.tlsConfig = tlsConfig
+	}
+
+	return sm, nil
+}
+
+// newSyslogTLSConfig cria a configuração TLS do servidor. Com ca_file e
+// verify_certificate o cliente precisa apresentar certificado válido.
+func newSyslogTLSConfig(config types.TLSConfig) (*tls.Config, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, fmt.Errorf("syslog tls_address requires tls.cert_file and tls.key_file")
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load syslog TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.CAFile != "" {
+		caCert, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read syslog CA file: %w", err)
+		}
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse syslog CA file %s", config.CAFile)
+		}
+		tlsConfig.ClientCAs = caPool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if config.VerifyCertificate {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// Start abre os listeners configurados e inicia o recebimento de mensagens
+func (sm *SyslogMonitor) Start(ctx context.Context) error {
+	if !sm.config.Enabled {
+		sm.logger.Info("Syslog monitor disabled")
+		return nil
+	}
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	if sm.isRunning {
+		return fmt.Errorf("syslog monitor already running")
+	}
+
+	sm.ctx, sm.cancel = context.WithCancel(ctx)
+
+	if err := sm.openListeners(); err != nil {
+		sm.closeListeners()
+		sm.cancel()
+		return err
+	}
+
+	if sm.udpConn != nil {
+		sm.wg.Add(1)
+		go sm.serveUDP()
+	}
+	for _, l := range sm.listeners {
+		sm.wg.Add(1)
+		go sm.acceptLoop(l.listener, l.transport)
+	}
+
+	if sm.taskManager != nil {
+		if err := sm.taskManager.StartTask(sm.ctx, "syslog_monitor", sm.heartbeatLoop); err != nil {
+			sm.closeListeners()
+			sm.cancel()
+			return fmt.Errorf("failed to start syslog monitor task: %w", err)
+		}
+	}
+
+	sm.isRunning = true
+	sm.logger.WithFields(logrus.Fields{
+		"udp_address": sm.config.UDPAddress,
+		"tcp_address": sm.config.TCPAddress,
+		"tls_address": sm.config.TLSAddress,
+	}).Info("Syslog monitor started")
+
+	return nil
+}
+
+// openListeners abre os sockets UDP, TCP e TLS configurados
+func (sm *SyslogMonitor) openListeners() error {
+	sm.udpConn = nil
+	sm.listeners = nil
+
+	if sm.config.UDPAddress != "" {
+		conn, err := net.ListenPacket("udp", sm.config.UDPAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen on syslog UDP address %s: %w", sm.config.UDPAddress, err)
+		}
+		sm.udpConn = conn
+	}
+
+	if sm.config.TCPAddress != "" {
+		listener, err := net.Listen("tcp", sm.config.TCPAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen on syslog TCP address %s: %w", sm.config.TCPAddress, err)
+		}
+		sm.listeners = append(sm.listeners, syslogListener{listener: listener, transport: "tcp"})
+	}
+
+	if sm.config.TLSAddress != "" {
+		listener, err := net.Listen("tcp", sm.config.TLSAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen on syslog TLS address %s: %w", sm.config.TLSAddress, err)
+		}
+		sm.listeners = append(sm.listeners, syslogListener{listener: tls.NewListener(listener, sm.tlsConfig), transport: "tls"})
+	}
+
+	return nil
+}
+
+// closeListeners fecha os sockets de escuta
+func (sm *SyslogMonitor) closeListeners() {
+	if sm.udpConn != nil {
+		sm.udpConn.Close()
+	}
+	for _, l := range sm.listeners {
+		l.listener.Close()
+	}
+}
+
+// closeConnections fecha as conexões TCP/TLS ativas
+func (sm *SyslogMonitor) closeConnections() {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	for conn := range sm.conns {
+		conn.Close()
+	}
+}
+
+// Stop fecha os listeners e aguarda o término das conexões
+func (sm *SyslogMonitor) Stop() error {
+	sm.mutex.Lock()
+	if !sm.isRunning {
+		sm.mutex.Unlock()
+		return nil
+	}
+	sm.logger.Info("Stopping syslog monitor")
+	sm.isRunning = false
+	sm.cancel()
+	sm.mutex.Unlock()
+
+	if sm.taskManager != nil {
+		sm.taskManager.StopTask("syslog_monitor")
+	}
+
+	sm.closeListeners()
+	sm.closeConnections()
+
+	done := make(chan struct{})
+	go func() {
+		sm.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		sm.logger.Warn("Timeout waiting for syslog connections to close")
+	}
+
+	return nil
+}
+
+// IsHealthy verifica se o monitor está saudável
+func (sm *SyslogMonitor) IsHealthy() bool {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.isRunning
+}
+
+// GetStatus retorna o status do monitor
+func (sm *SyslogMonitor) GetStatus() types.MonitorStatus {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	return types.MonitorStatus{
+		Name:      "syslog_monitor",
+		IsRunning: sm.isRunning,
+		IsHealthy: sm.isRunning,
+	}
+}
+
+// heartbeatLoop mantém a task viva enquanto os listeners estão ativos
+func (sm *SyslogMonitor) heartbeatLoop(ctx context.Context) error {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			sm.taskManager.Heartbeat("syslog_monitor")
+		}
+	}
+}
+
+// serveUDP recebe uma mensagem por datagrama
+func (sm *SyslogMonitor) serveUDP() {
+	defer sm.wg.Done()
+
+	buf := make([]byte, syslogUDPBufferSize)
+	for {
+		n, addr, err := sm.udpConn.ReadFrom(buf)
+		if err != nil {
+			if sm.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			sm.logger.WithError(err).Warn("Syslog UDP read error")
+			metrics.RecordError("syslog_monitor", "read_error")
+			continue
+		}
+
+		data := buf[:n]
+		if len(data) > sm.config.MaxMessageSize {
+			data = data[:sm.config.MaxMessageSize]
+			metrics.RecordError("syslog_monitor", "message_truncated")
+		}
+		sm.handleMessage(data, "udp", remoteHost(addr))
+	}
+}
+
+// acceptLoop aceita conexões TCP/TLS respeitando o limite de conexões simultâneas
+func (sm *SyslogMonitor) acceptLoop(listener net.Listener, transport string) {
+	defer sm.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if sm.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			sm.logger.WithError(err).WithField("transport", transport).Warn("Syslog accept error")
+			metrics.RecordError("syslog_monitor", "accept_error")
+			select {
+			case <-sm.ctx.Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case sm.connSlots <- struct{}{}:
+		default:
+			sm.logger.WithField("remote_addr", conn.RemoteAddr().String()).Warn("Syslog connection limit reached, rejecting connection")
+			metrics.RecordError("syslog_monitor", "connection_limit")
+			conn.Close()
+			continue
+		}
+
+		sm.mutex.Lock()
+		sm.conns[conn] = struct{}{}
+		sm.mutex.Unlock()
+
+		sm.wg.Add(1)
+		go sm.handleConnection(conn, transport)
+	}
+}
+
+// handleConnection lê os frames de uma conexão até EOF, timeout ou shutdown
+func (sm *SyslogMonitor) handleConnection(conn net.Conn, transport string) {
+	defer sm.wg.Done()
+	defer func() {
+		conn.Close()
+		sm.mutex.Lock()
+		delete(sm.conns, conn)
+		sm.mutex.Unlock()
+		<-sm.connSlots
+	}()
+
+	host := remoteHost(conn.RemoteAddr())
+	reader := bufio.NewReaderSize(conn, 64*1024)
+
+	for {
+		if sm.readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(sm.readTimeout))
+		}
+
+		frame, truncated, err := readSyslogFrame(reader, sm.config.MaxMessageSize)
+		if truncated {
+			metrics.RecordError("syslog_monitor", "message_truncated")
+		}
+		if len(frame) > 0 {
+			sm.handleMessage(frame, transport, host)
+		}
+
+		if err != nil {
+			if err == io.EOF || sm.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				sm.logger.WithField("remote_addr", host).Debug("Closing idle syslog connection")
+				return
+			}
+			sm.logger.WithError(err).WithFields(logrus.Fields{
+				"remote_addr": host,
+				"transport":   transport,
+			}).Warn("Syslog connection read error")
+			metrics.RecordError("syslog_monitor", "read_error")
+			return
+		}
+	}
+}
+
+// readSyslogFrame lê um frame de um stream TCP (RFC 6587). Frames iniciados por
+// dígito usam octet-counting ("LEN MSG"); os demais são delimitados por newline.
+// Mensagens acima de maxSize são truncadas e o restante é descartado.
+func readSyslogFrame(reader *bufio.Reader, maxSize int) ([]byte, bool, error) {
+	// Ignorar delimitadores extras entre frames
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, false, err
+		}
+		if b != '\n' && b != '\r' && b != 0 {
+			reader.UnreadByte()
+			if b >= '1' && b <= '9' {
+				return readOctetCountedFrame(reader, maxSize)
+			}
+			return readNewlineFrame(reader, maxSize)
+		}
+	}
+}
+
+// readOctetCountedFrame lê "LEN SP MSG"
+func readOctetCountedFrame(reader *bufio.Reader, maxSize int) ([]byte, bool, error) {
+	var digits []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, false, err
+		}
+		if b == ' ' {
+			break
+		}
+		if b < '0' || b > '9' || len(digits) >= syslogMaxFrameDigits {
+			return nil, false, fmt.Errorf("invalid syslog octet-counting frame length")
+		}
+		digits = append(digits, b)
+	}
+
+	length, err := strconv.Atoi(string(digits))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid syslog octet-counting frame length: %w", err)
+	}
+
+	readSize := length
+	if readSize > maxSize {
+		readSize = maxSize
+	}
+
+	frame := make([]byte, readSize)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return nil, false, fmt.Errorf("truncated syslog frame: %w", err)
+	}
+
+	if length > readSize {
+		if _, err := reader.Discard(length - readSize); err != nil {
+			return frame, true, fmt.Errorf("truncated syslog frame: %w", err)
+		}
+		return frame, true, nil
+	}
+
+	return frame, false, nil
+}
+
+// readNewlineFrame lê até o próximo LF, removendo o CR final
+func readNewlineFrame(reader *bufio.Reader, maxSize int) ([]byte, bool, error) {
+	var frame []byte
+	truncated := false
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if remaining := maxSize - len(frame); len(chunk) > remaining {
+			frame = append(frame, chunk[:remaining]...)
+			truncated = true
+		} else {
+			frame = append(frame, chunk...)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(frame) > 0 {
+				// Último frame sem newline: entregar e sinalizar EOF na próxima leitura
+				return trimSyslogFrame(frame), truncated, nil
+			}
+			return trimSyslogFrame(frame), truncated, err
+		}
+		return trimSyslogFrame(frame), truncated, nil
+	}
+}
+
+// trimSyslogFrame remove o delimitador final do frame
+func trimSyslogFrame(frame []byte) []byte {
+	for len(frame) > 0 {
+		last := frame[len(frame)-1]
+		if last != '\n' && last != '\r' && last != 0 {
+			break
+		}
+		frame = frame[:len(frame)-1]
+	}
+	return frame
+}
+
+// remoteHost retorna apenas o IP do endereço remoto
+func remoteHost(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// handleMessage decodifica a mensagem e envia ao dispatcher
+func (sm *SyslogMonitor) handleMessage(data []byte, transport, host string) {
+	msg, err := parseSyslogMessage(data, time.Now())
+	if err != nil {
+		metrics.RecordError("syslog_monitor", "parse_error")
+		return
+	}
+
+	entry := sm.buildEntry(msg, transport, host)
+
+	// Validar timestamp se o timestamp validator estiver disponível
+	if sm.timestampValidator != nil {
+		result := sm.timestampValidator.ValidateTimestamp(entry)
+		if !result.Valid && result.Action == "rejected" {
+			sm.logger.WithFields(logrus.Fields{
+				"remote_addr": host,
+				"reason":      result.Reason,
+			}).Warn("Syslog message rejected due to invalid timestamp")
+			return
+		}
+	}
+
+	if err := sm.dispatcher.HandleEntry(sm.ctx, entry); err != nil {
+		sm.logger.WithError(err).WithField("remote_addr", host).Error("Failed to dispatch syslog message")
+		metrics.RecordError("syslog_monitor", "dispatch_error")
+		return
+	}
+
+	metrics.RecordLogProcessed("syslog", entry.SourceID, "syslog_monitor")
+}
+
+// buildEntry converte a mensagem syslog em LogEntry. Facility, severity, hostname
+// e app name viram labels; procid, msgid e structured data ("<sd-id>.<param>") viram fields.
+func (sm *SyslogMonitor) buildEntry(msg *syslogMessage, transport, host string) *types.LogEntry {
+	labels := make(map[string]string, len(sm.config.Labels)+6)
+	for k, v := range sm.config.Labels {
+		labels[k] = v
+	}
+	labels["source"] = "syslog"
+	labels["transport"] = transport
+	labels["facility"] = msg.facilityName()
+	labels["severity"] = msg.severityName()
+	if msg.hostname != "" {
+		labels["hostname"] = msg.hostname
+	}
+	if msg.appName != "" {
+		labels["app_name"] = msg.appName
+	}
+
+	fields := map[string]interface{}{
+		"remote_addr": host,
+	}
+	if msg.procID != "" {
+		fields["proc_id"] = msg.procID
+	}
+	if msg.msgID != "" {
+		fields["msg_id"] = msg.msgID
+	}
+	for id, params := range msg.structuredData {
+		for name, value := range params {
+			fields[id+"."+name] = value
+		}
+	}
+
+	sourceID := msg.hostname
+	if sourceID == "" {
+		sourceID = host
+	}
+
+	now := time.Now()
+	timestamp := msg.timestamp
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+
+	return &types.LogEntry{
+		TraceID:     uuid.New().String(),
+		Timestamp:   timestamp,
+		Message:     msg.message,
+		Level:       msg.level(),
+		SourceType:  "syslog",
+		SourceID:    sourceID,
+		Labels:      labels,
+		Fields:      fields,
+		ProcessedAt: now,
+	}
+}
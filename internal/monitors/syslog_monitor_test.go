@@ -0,0 +1,297 @@
+package monitors
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyslogMessage_RFC5424(t *testing.T) {
+	raw := `<165>1 2024-05-01T10:00:00.123Z web01 nginx 4242 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication\]"][meta seq="7"] ` + "\xEF\xBB\xBF" + `request served`
+
+	msg, err := parseSyslogMessage([]byte(raw), time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, msg.version)
+	assert.Equal(t, "local4", msg.facilityName())
+	assert.Equal(t, "notice", msg.severityName())
+	assert.Equal(t, "info", msg.level())
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC), msg.timestamp)
+	assert.Equal(t, "web01", msg.hostname)
+	assert.Equal(t, "nginx", msg.appName)
+	assert.Equal(t, "4242", msg.procID)
+	assert.Equal(t, "ID47", msg.msgID)
+	assert.Equal(t, "request served", msg.message)
+	assert.Equal(t, map[string]map[string]string{
+		"exampleSDID@32473": {"iut": "3", "eventSource": `App"lication]`},
+		"meta":              {"seq": "7"},
+	}, msg.structuredData)
+}
+
+func TestParseSyslogMessage_RFC5424NilValues(t *testing.T) {
+	msg, err := parseSyslogMessage([]byte("<11>1 - - - - - -"), time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, "user", msg.facilityName())
+	assert.Equal(t, "err", msg.severityName())
+	assert.True(t, msg.timestamp.IsZero())
+	assert.Empty(t, msg.hostname)
+	assert.Empty(t, msg.appName)
+	assert.Nil(t, msg.structuredData)
+	assert.Empty(t, msg.message)
+}
+
+func TestParseSyslogMessage_RFC3164(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	msg, err := parseSyslogMessage([]byte("<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed\n"), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, msg.version)
+	assert.Equal(t, "auth", msg.facilityName())
+	assert.Equal(t, "crit", msg.severityName())
+	assert.Equal(t, "fatal", msg.level())
+	assert.Equal(t, time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC), msg.timestamp, "future date belongs to the previous year")
+	assert.Equal(t, "mymachine", msg.hostname)
+	assert.Equal(t, "su", msg.appName)
+	assert.Equal(t, "123", msg.procID)
+	assert.Equal(t, "'su root' failed", msg.message)
+
+	msg, err = parseSyslogMessage([]byte("<13>Apr  3 08:00:00 host cron: job done"), now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 3, 8, 0, 0, 0, time.UTC), msg.timestamp)
+	assert.Equal(t, "host", msg.hostname)
+	assert.Equal(t, "cron", msg.appName)
+	assert.Equal(t, "job done", msg.message)
+}
+
+func TestParseSyslogMessage_Lenient(t *testing.T) {
+	now := time.Now()
+
+	// Sem PRI, timestamp ou hostname
+	msg, err := parseSyslogMessage([]byte("just a message"), now)
+	require.NoError(t, err)
+	assert.Equal(t, "user", msg.facilityName())
+	assert.Equal(t, "notice", msg.severityName())
+	assert.Empty(t, msg.hostname)
+	assert.Equal(t, "just a message", msg.message)
+
+	// Sem timestamp, só TAG
+	msg, err = parseSyslogMessage([]byte("<14>myapp: started"), now)
+	require.NoError(t, err)
+	assert.Equal(t, "myapp", msg.appName)
+	assert.Equal(t, "started", msg.message)
+
+	// Timestamp RFC 3339 em mensagem BSD (rsyslog high-precision)
+	msg, err = parseSyslogMessage([]byte("<14>2024-05-01T10:00:00+02:00 host app: hi"), now)
+	require.NoError(t, err)
+	assert.True(t, msg.timestamp.Equal(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "host", msg.hostname)
+
+	// RFC 5424 malformado cai no parser tolerante
+	msg, err = parseSyslogMessage([]byte("<14>1 not-a-timestamp host app - - - text"), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, msg.version)
+
+	_, err = parseSyslogMessage([]byte("\r\n"), now)
+	assert.Error(t, err)
+}
+
+func TestReadSyslogFrame(t *testing.T) {
+	stream := "23 <13>1 - h app - - - one\n" + // octet-counting com LF extra
+		"<13>two\r\n" +
+		"<13>" + strings.Repeat("x", 20) + "\n" +
+		"13 <13>123456789" +
+		"<13>last"
+
+	reader := bufio.NewReaderSize(strings.NewReader(stream), 16)
+	var frames []string
+	var truncatedCount int
+	for {
+		frame, truncated, err := readSyslogFrame(reader, 16)
+		if truncated {
+			truncatedCount++
+		}
+		if len(frame) > 0 {
+			frames = append(frames, string(frame))
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{
+		"<13>1 - h app - ",
+		"<13>two",
+		"<13>xxxxxxxxxxxx",
+		"<13>123456789",
+		"<13>last",
+	}, frames)
+	assert.Equal(t, 2, truncatedCount)
+}
+
+func newTestSyslogMonitor(t *testing.T, config types.SyslogMonitorConfig) (*SyslogMonitor, *recordingDispatcher) {
+	t.Helper()
+	config.Enabled = true
+	dispatcher := &recordingDispatcher{}
+	sm, err := NewSyslogMonitor(config, types.TimestampValidationConfig{}, dispatcher, nil, newTestLogger())
+	require.NoError(t, err)
+	require.NoError(t, sm.Start(t.Context()))
+	t.Cleanup(func() { sm.Stop() })
+	return sm, dispatcher
+}
+
+func waitForMessages(t *testing.T, dispatcher *recordingDispatcher, count int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return len(dispatcher.Messages()) >= count
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSyslogMonitor_UDP(t *testing.T) {
+	sm, dispatcher := newTestSyslogMonitor(t, types.SyslogMonitorConfig{
+		UDPAddress: "127.0.0.1:0",
+		Labels:     map[string]string{"env": "test"},
+	})
+
+	conn, err := net.Dial("udp", sm.udpConn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(`<165>1 2024-05-01T10:00:00Z web01 nginx 42 ACCESS [req@1 status="200"] GET /`))
+	require.NoError(t, err)
+
+	waitForMessages(t, dispatcher, 1)
+	entry := dispatcher.entries[0]
+	assert.Equal(t, "GET /", entry.Message)
+	assert.Equal(t, "syslog", entry.SourceType)
+	assert.Equal(t, "web01", entry.SourceID)
+	assert.Equal(t, "info", entry.Level)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), entry.Timestamp)
+	assert.Equal(t, "test", entry.Labels["env"])
+	assert.Equal(t, "udp", entry.Labels["transport"])
+	assert.Equal(t, "local4", entry.Labels["facility"])
+	assert.Equal(t, "notice", entry.Labels["severity"])
+	assert.Equal(t, "web01", entry.Labels["hostname"])
+	assert.Equal(t, "nginx", entry.Labels["app_name"])
+	assert.Equal(t, "42", entry.Fields["proc_id"])
+	assert.Equal(t, "ACCESS", entry.Fields["msg_id"])
+	assert.Equal(t, "200", entry.Fields["req@1.status"])
+	assert.Equal(t, "127.0.0.1", entry.Fields["remote_addr"])
+}
+
+func TestSyslogMonitor_TCPFraming(t *testing.T) {
+	sm, dispatcher := newTestSyslogMonitor(t, types.SyslogMonitorConfig{TCPAddress: "127.0.0.1:0"})
+
+	conn, err := net.Dial("tcp", sm.listeners[0].listener.Addr().String())
+	require.NoError(t, err)
+
+	first := "<13>1 - host app - - - octet\ncounted"
+	_, err = fmt.Fprintf(conn, "%d %s<14>Jan  2 03:04:05 host app: newline framed\n", len(first), first)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	waitForMessages(t, dispatcher, 2)
+	assert.Equal(t, []string{"octet\ncounted", "newline framed"}, dispatcher.Messages())
+	assert.Equal(t, "tcp", dispatcher.entries[0].Labels["transport"])
+	assert.Equal(t, "host", dispatcher.entries[1].SourceID)
+}
+
+func TestSyslogMonitor_ConnectionLimit(t *testing.T) {
+	sm, dispatcher := newTestSyslogMonitor(t, types.SyslogMonitorConfig{TCPAddress: "127.0.0.1:0", MaxConnections: 1})
+	addr := sm.listeners[0].listener.Addr().String()
+
+	first, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer first.Close()
+	_, err = first.Write([]byte("<13>first\n"))
+	require.NoError(t, err)
+	waitForMessages(t, dispatcher, 1)
+
+	// Segunda conexão é fechada pelo servidor sem ser lida
+	second, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer second.Close()
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = second.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
+
+func TestSyslogMonitor_TLS(t *testing.T) {
+	certFile, keyFile, certPEM := writeTestCertificate(t)
+
+	sm, dispatcher := newTestSyslogMonitor(t, types.SyslogMonitorConfig{
+		TLSAddress: "127.0.0.1:0",
+		TLS:        types.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile},
+	})
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(certPEM))
+	conn, err := tls.Dial("tcp", sm.listeners[0].listener.Addr().String(), &tls.Config{RootCAs: pool, ServerName: "localhost"})
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("<13>1 - host app - - - over tls\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	waitForMessages(t, dispatcher, 1)
+	assert.Equal(t, "over tls", dispatcher.Messages()[0])
+	assert.Equal(t, "tls", dispatcher.entries[0].Labels["transport"])
+}
+
+func TestNewSyslogMonitor_InvalidConfig(t *testing.T) {
+	_, err := NewSyslogMonitor(types.SyslogMonitorConfig{Enabled: true}, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+	assert.Error(t, err)
+
+	_, err = NewSyslogMonitor(types.SyslogMonitorConfig{Enabled: true, TLSAddress: ":0"}, types.TimestampValidationConfig{}, &recordingDispatcher{}, nil, newTestLogger())
+	assert.Error(t, err, "TLS requires a certificate")
+}
+
+// writeTestCertificate gera um certificado autoassinado para localhost
+func writeTestCertificate(t *testing.T) (string, string, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile, certPEM
+}
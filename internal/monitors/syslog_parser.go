@@ -0,0 +1,310 @@
+package monitors
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogDefaultPriority é usado quando a mensagem não traz PRI (user.notice, RFC 3164 4.3.3)
+const syslogDefaultPriority = 13
+
+// syslogFacilityNames nomes das facilities na ordem do código numérico (RFC 5424 6.2.1)
+var syslogFacilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "clock",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogSeverityNames nomes das severities na ordem do código numérico
+var syslogSeverityNames = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// syslogSeverityLevels mapeia severity syslog para o nível padronizado do LogEntry
+var syslogSeverityLevels = []string{
+	"fatal", "fatal", "fatal", "error", "warn", "info", "info", "debug",
+}
+
+// syslogMessage é uma mensagem syslog decodificada (RFC 5424 ou RFC 3164)
+type syslogMessage struct {
+	facility       int
+	severity       int
+	version        int       // 1 para RFC 5424, 0 para RFC 3164
+	timestamp      time.Time // Zero quando a mensagem não informa timestamp
+	hostname       string
+	appName        string
+	procID         string
+	msgID          string
+	structuredData map[string]map[string]string
+	message        string
+}
+
+// facilityName retorna o nome da facility
+func (m *syslogMessage) facilityName() string {
+	if m.facility >= 0 && m.facility < len(syslogFacilityNames) {
+		return syslogFacilityNames[m.facility]
+	}
+	return strconv.Itoa(m.facility)
+}
+
+// severityName retorna o nome da severity
+func (m *syslogMessage) severityName() string {
+	return syslogSeverityNames[m.severity]
+}
+
+// level retorna o nível padronizado correspondente à severity
+func (m *syslogMessage) level() string {
+	return syslogSeverityLevels[m.severity]
+}
+
+// parseSyslogMessage decodifica uma mensagem syslog. Mensagens com cabeçalho
+// "<PRI>1 " são tratadas como RFC 5424; as demais (ou RFC 5424 malformadas)
+// seguem o parser tolerante de RFC 3164, que aceita a mensagem sem PRI,
+// timestamp ou hostname. now é usado para inferir o ano do timestamp BSD.
+func parseSyslogMessage(data []byte, now time.Time) (*syslogMessage, error) {
+	content := strings.TrimRight(string(data), "\r\n\x00")
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("empty syslog message")
+	}
+
+	priority, rest, ok := parseSyslogPriority(content)
+	if !ok {
+		priority, rest = syslogDefaultPriority, content
+	}
+
+	if strings.HasPrefix(rest, "1 ") {
+		if msg, err := parseRFC5424(rest[2:]); err == nil {
+			msg.facility, msg.severity = priority/8, priority%8
+			return msg, nil
+		}
+	}
+
+	msg := parseRFC3164(rest, now)
+	msg.facility, msg.severity = priority/8, priority%8
+	return msg, nil
+}
+
+// parseSyslogPriority extrai o campo <PRI> (0-191) do início da mensagem
+func parseSyslogPriority(content string) (int, string, bool) {
+	if len(content) < 3 || content[0] != '<' {
+		return 0, content, false
+	}
+
+	end := strings.IndexByte(content, '>')
+	if end < 2 || end > 4 {
+		return 0, content, false
+	}
+
+	priority, err := strconv.Atoi(content[1:end])
+	if err != nil || priority < 0 || priority > 191 {
+		return 0, content, false
+	}
+
+	return priority, content[end+1:], true
+}
+
+// parseRFC5424 decodifica "TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]"
+// (o "VERSION " já foi consumido)
+func parseRFC5424(content string) (*syslogMessage, error) {
+	header := make([]string, 5)
+	rest := content
+	for i := range header {
+		spaceIdx := strings.IndexByte(rest, ' ')
+		if spaceIdx <= 0 {
+			return nil, fmt.Errorf("truncated RFC 5424 header")
+		}
+		header[i] = rest[:spaceIdx]
+		rest = rest[spaceIdx+1:]
+	}
+
+	msg := &syslogMessage{
+		version:  1,
+		hostname: syslogNilValue(header[1]),
+		appName:  syslogNilValue(header[2]),
+		procID:   syslogNilValue(header[3]),
+		msgID:    syslogNilValue(header[4]),
+	}
+
+	if header[0] != "-" {
+		timestamp, err := time.Parse(time.RFC3339Nano, header[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid RFC 5424 timestamp: %w", err)
+		}
+		msg.timestamp = timestamp
+	}
+
+	structuredData, rest, err := parseStructuredData(rest)
+	if err != nil {
+		return nil, err
+	}
+	msg.structuredData = structuredData
+
+	if rest != "" {
+		if rest[0] != ' ' {
+			return nil, fmt.Errorf("missing space after structured data")
+		}
+		rest = rest[1:]
+	}
+	msg.message = strings.TrimPrefix(rest, "\xEF\xBB\xBF")
+
+	return msg, nil
+}
+
+// parseStructuredData decodifica os elementos [SD-ID PARAM="VALUE" ...] ou o NILVALUE "-"
+func parseStructuredData(content string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(content, "-") {
+		return nil, content[1:], nil
+	}
+	if !strings.HasPrefix(content, "[") {
+		return nil, content, fmt.Errorf("invalid structured data")
+	}
+
+	elements := make(map[string]map[string]string)
+	rest := content
+	for strings.HasPrefix(rest, "[") {
+		rest = rest[1:]
+
+		idEnd := strings.IndexAny(rest, " ]")
+		if idEnd <= 0 {
+			return nil, rest, fmt.Errorf("invalid structured data element id")
+		}
+		id := rest[:idEnd]
+		rest = rest[idEnd:]
+
+		params := make(map[string]string)
+		for strings.HasPrefix(rest, " ") {
+			rest = rest[1:]
+
+			eqIdx := strings.Index(rest, `="`)
+			if eqIdx <= 0 {
+				return nil, rest, fmt.Errorf("invalid structured data param in %q", id)
+			}
+			name := rest[:eqIdx]
+			rest = rest[eqIdx+2:]
+
+			value, remaining, err := parseSDParamValue(rest)
+			if err != nil {
+				return nil, rest, fmt.Errorf("invalid structured data param %q: %w", name, err)
+			}
+			params[name] = value
+			rest = remaining
+		}
+
+		if !strings.HasPrefix(rest, "]") {
+			return nil, rest, fmt.Errorf("unterminated structured data element %q", id)
+		}
+		rest = rest[1:]
+		elements[id] = params
+	}
+
+	return elements, rest, nil
+}
+
+// parseSDParamValue lê o valor entre aspas tratando os escapes \" \\ e \]
+func parseSDParamValue(content string) (string, string, error) {
+	var value strings.Builder
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '\\':
+			if i+1 < len(content) && (content[i+1] == '"' || content[i+1] == '\\' || content[i+1] == ']') {
+				i++
+				value.WriteByte(content[i])
+			} else {
+				value.WriteByte(c)
+			}
+		case '"':
+			return value.String(), content[i+1:], nil
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", content, errors.New("unterminated param value")
+}
+
+// parseRFC3164 decodifica "[TIMESTAMP HOSTNAME ]TAG[PID]: MSG" de forma tolerante
+func parseRFC3164(content string, now time.Time) *syslogMessage {
+	msg := &syslogMessage{}
+	rest := content
+
+	timestamp, remaining, ok := parseBSDTimestamp(rest, now)
+	if ok {
+		msg.timestamp = timestamp
+		rest = remaining
+
+		// Hostname só existe após o timestamp e não termina com ":" (seria a TAG)
+		if spaceIdx := strings.IndexByte(rest, ' '); spaceIdx > 0 {
+			candidate := rest[:spaceIdx]
+			if !strings.HasSuffix(candidate, ":") && !strings.ContainsAny(candidate, "[]") {
+				msg.hostname = candidate
+				rest = rest[spaceIdx+1:]
+			}
+		}
+	}
+
+	if appName, procID, message, ok := parseSyslogTag(rest); ok {
+		msg.appName, msg.procID, rest = appName, procID, message
+	}
+	msg.message = rest
+
+	return msg
+}
+
+// parseBSDTimestamp aceita "Mmm dd hh:mm:ss" (ano inferido) ou um timestamp RFC 3339
+func parseBSDTimestamp(content string, now time.Time) (time.Time, string, bool) {
+	if len(content) >= len(time.Stamp)+1 && content[len(time.Stamp)] == ' ' {
+		if parsed, err := time.ParseInLocation(time.Stamp, content[:len(time.Stamp)], now.Location()); err == nil {
+			timestamp := time.Date(now.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, now.Location())
+			// Mensagem de dezembro recebida em janeiro pertence ao ano anterior
+			if timestamp.After(now.Add(24 * time.Hour)) {
+				timestamp = timestamp.AddDate(-1, 0, 0)
+			}
+			return timestamp, content[len(time.Stamp)+1:], true
+		}
+	}
+
+	if spaceIdx := strings.IndexByte(content, ' '); spaceIdx > 0 {
+		if parsed, err := time.Parse(time.RFC3339Nano, content[:spaceIdx]); err == nil {
+			return parsed, content[spaceIdx+1:], true
+		}
+	}
+
+	return time.Time{}, content, false
+}
+
+// parseSyslogTag separa "TAG[PID]: MSG" ou "TAG: MSG"
+func parseSyslogTag(content string) (string, string, string, bool) {
+	end := strings.IndexAny(content, ":[ ")
+	if end <= 0 || end > 48 {
+		return "", "", "", false
+	}
+
+	tag := content[:end]
+	rest := content[end:]
+
+	var procID string
+	if strings.HasPrefix(rest, "[") {
+		closeIdx := strings.IndexByte(rest, ']')
+		if closeIdx < 0 {
+			return "", "", "", false
+		}
+		procID = rest[1:closeIdx]
+		rest = rest[closeIdx+1:]
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", "", false
+	}
+
+	return tag, procID, strings.TrimPrefix(rest[1:], " "), true
+}
+
+// syslogNilValue converte o NILVALUE "-" em string vazia
+func syslogNilValue(value string) string {
+	if value == "-" {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,810 @@
+package processing
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// Condition é uma expressão compilada avaliada sobre um LogEntry.
+//
+// Exemplos:
+//
+//	labels.container_name =~ "^web-" && fields.status >= 500
+//	level in ["error", "fatal"] or exists(fields.exception)
+//	source_type == "docker" and not (labels.env == "dev")
+//
+// Identificadores: message, level, source_type, source_id, trace_id,
+// labels.<nome>, fields.<nome>[.<aninhado>] e labels["nome-com.pontos"].
+// Operadores: && || ! (ou and, or, not), == != < <= > >=, =~ !~ (regex),
+// in / not in (lista literal, lista do campo ou substring). Funções: exists,
+// starts_with, ends_with, contains, lower, upper, number, string.
+// Comparações são numéricas quando um dos lados é número e o outro converte
+// (ex: o campo "500" extraído por regex contra 500); entre strings são
+// lexicográficas. Valores ausentes só são iguais a outro valor ausente.
+type Condition struct {
+	source string
+	root   conditionNode
+}
+
+// CompileCondition compila uma expressão de condição
+func CompileCondition(source string) (*Condition, error) {
+	tokens, err := tokenizeCondition(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &conditionParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != condEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+	}
+
+	return &Condition{source: source, root: root}, nil
+}
+
+// compileStepCondition compila a condição de um step ou pipeline. Condições
+// antigas (regex sobre message) continuam aceitas: o texto só é expressão se
+// compila e usa algum operador ou função (ex: "timeout or reset" não compila,
+// "500" e "fields.foo" não têm operador); nos demais casos vira regex.
+func compileStepCondition(source string) (*Condition, bool, error) {
+	condition, err := CompileCondition(source)
+	if err == nil && hasConditionOperator(source) {
+		return condition, false, nil
+	}
+
+	regex, regexErr := regexp.Compile(source)
+	if regexErr != nil {
+		return nil, false, fmt.Errorf("invalid condition %q: %w", source, err)
+	}
+	return &Condition{source: source, root: &matchNode{operand: &pathNode{root: "message"}, re: regex}}, true, nil
+}
+
+// hasConditionOperator indica se a expressão usa comparação, operador lógico
+// (inclusive and/or/not/in) ou chamada de função
+func hasConditionOperator(source string) bool {
+	tokens, err := tokenizeCondition(source)
+	if err != nil {
+		return false
+	}
+	for i, token := range tokens {
+		switch token.kind {
+		case condOperator:
+			return true
+		case condIdent:
+			switch token.text {
+			case "and", "or", "not", "in":
+				return true
+			}
+			if next := tokens[i+1]; next.kind == condPunct && next.text == "(" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Match avalia a condição sobre a entrada
+func (c *Condition) Match(entry *types.LogEntry) bool {
+	return conditionTruthy(c.root.eval(entry))
+}
+
+// String retorna a expressão original
+func (c *Condition) String() string {
+	return c.source
+}
+
+// Tokens da expressão
+
+type conditionTokenKind int
+
+const (
+	condEOF conditionTokenKind = iota
+	condIdent
+	condString
+	condNumber
+	condOperator
+	condPunct
+)
+
+type conditionToken struct {
+	kind  conditionTokenKind
+	text  string
+	value interface{} // Valor de strings e números
+	pos   int
+}
+
+// Operadores em ordem de preferência (os de dois caracteres primeiro)
+var conditionOperators = []string{"==", "!=", "=~", "!~", ">=", "<=", "&&", "||", ">", "<", "!"}
+
+// tokenizeCondition separa a expressão em tokens
+func tokenizeCondition(source string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	i := 0
+
+	for i < len(source) {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"' || c == '\'':
+			value, end, err := scanConditionString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, conditionToken{kind: condString, text: source[i:end], value: value, pos: i})
+			i = end
+
+		case c >= '0' && c <= '9' || (c == '-' && i+1 < len(source) && source[i+1] >= '0' && source[i+1] <= '9' && expectsOperand(tokens)):
+			end := i + 1
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.') {
+				end++
+			}
+			number, err := strconv.ParseFloat(source[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", source[i:end], i)
+			}
+			tokens = append(tokens, conditionToken{kind: condNumber, text: source[i:end], value: number, pos: i})
+			i = end
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			end := i + 1
+			for end < len(source) && (source[end] == '_' || source[end] == '-' || unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end]))) {
+				end++
+			}
+			tokens = append(tokens, conditionToken{kind: condIdent, text: source[i:end], pos: i})
+			i = end
+
+		case strings.ContainsRune("()[],.", rune(c)):
+			tokens = append(tokens, conditionToken{kind: condPunct, text: string(c), pos: i})
+			i++
+
+		default:
+			matched := false
+			for _, op := range conditionOperators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, conditionToken{kind: condOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, conditionToken{kind: condEOF, pos: len(source)}), nil
+}
+
+// expectsOperand indica se o próximo token deve ser um operando (para "-5")
+func expectsOperand(tokens []conditionToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == condOperator || (last.kind == condPunct && last.text != ")" && last.text != "]")
+}
+
+// scanConditionString lê uma string entre aspas simples ou duplas. Escapes
+// desconhecidos preservam a barra, para que regex como "\d+" funcionem.
+func scanConditionString(source string, start int) (string, int, error) {
+	quote := source[start]
+	var b strings.Builder
+
+	for i := start + 1; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(source):
+			i++
+			switch source[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(source[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(source[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+// Parser (descendente recursivo)
+
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (p *conditionParser) peek() conditionToken {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() conditionToken {
+	token := p.tokens[p.pos]
+	if token.kind != condEOF {
+		p.pos++
+	}
+	return token
+}
+
+// accept consome o token se for o operador, pontuação ou palavra-chave informada
+func (p *conditionParser) accept(texts ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != condOperator && token.kind != condPunct && token.kind != condIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if token.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *conditionParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		token := p.peek()
+		return fmt.Errorf("expected %q at position %d, got %q", text, token.pos, token.text)
+	}
+	return nil
+}
+
+func (p *conditionParser) parseOr() (conditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: false, left: left, right: right}
+	}
+}
+
+func (p *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+}
+
+func (p *conditionParser) parseNot() (conditionNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := p.accept("==", "!=", "<", "<=", ">", ">="); ok {
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: op, left: left, right: right}, nil
+	}
+
+	if op, ok := p.accept("=~", "!~"); ok {
+		token := p.next()
+		if token.kind != condString {
+			return nil, fmt.Errorf("%s requires a string literal at position %d", op, token.pos)
+		}
+		re, err := regexp.Compile(token.value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex at position %d: %w", token.pos, err)
+		}
+		return &matchNode{negate: op == "!~", operand: left, re: re}, nil
+	}
+
+	negate := false
+	if token := p.peek(); token.kind == condIdent && token.text == "not" && p.tokens[p.pos+1].text == "in" {
+		p.pos++
+		negate = true
+	}
+	if _, ok := p.accept("in"); ok {
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &inNode{negate: negate, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *conditionParser) parseOperand() (conditionNode, error) {
+	token := p.next()
+
+	switch token.kind {
+	case condString, condNumber:
+		return &literalNode{value: token.value}, nil
+
+	case condPunct:
+		switch token.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			list := &listNode{}
+			if _, ok := p.accept("]"); ok {
+				return list, nil
+			}
+			for {
+				item, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if _, ok := p.accept(","); !ok {
+					return list, p.expect("]")
+				}
+			}
+		}
+
+	case condIdent:
+		switch token.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		if _, isFunc := conditionFuncs[token.text]; isFunc && p.peek().text == "(" {
+			return p.parseCall(token)
+		}
+		return p.parsePath(token)
+	}
+
+	if token.kind == condEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+}
+
+func (p *conditionParser) parseCall(name conditionToken) (conditionNode, error) {
+	fn := conditionFuncs[name.text]
+	p.next() // "("
+
+	call := &callNode{name: name.text, fn: fn.fn}
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.accept(","); !ok {
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	if len(call.args) != fn.arity {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d", name.text, fn.arity, len(call.args))
+	}
+	return call, nil
+}
+
+func (p *conditionParser) parsePath(root conditionToken) (conditionNode, error) {
+	path := &pathNode{root: root.text}
+
+	switch root.text {
+	case "message", "level", "source_type", "source_id", "trace_id":
+		return path, nil
+	case "labels", "fields":
+	default:
+		return nil, fmt.Errorf("unknown identifier %q at position %d", root.text, root.pos)
+	}
+
+	for {
+		if _, ok := p.accept("."); ok {
+			key := p.next()
+			if key.kind != condIdent {
+				return nil, fmt.Errorf("expected name after '.' at position %d", key.pos)
+			}
+			path.keys = append(path.keys, key.text)
+			continue
+		}
+		if p.peek().text == "[" && p.peek().kind == condPunct {
+			p.next()
+			key := p.next()
+			if key.kind != condString {
+				return nil, fmt.Errorf("expected string key at position %d", key.pos)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			path.keys = append(path.keys, key.value.(string))
+			continue
+		}
+		break
+	}
+
+	if len(path.keys) == 0 {
+		return nil, fmt.Errorf("%s requires a key at position %d", root.text, root.pos)
+	}
+	if root.text == "labels" && len(path.keys) > 1 {
+		return nil, fmt.Errorf("labels are not nested; use labels[%q]", strings.Join(path.keys, "."))
+	}
+	return path, nil
+}
+
+// Nós da expressão
+
+type conditionNode interface {
+	eval(entry *types.LogEntry) interface{}
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(entry *types.LogEntry) interface{} {
+	return n.value
+}
+
+type listNode struct {
+	items []conditionNode
+}
+
+func (n *listNode) eval(entry *types.LogEntry) interface{} {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		values[i] = item.eval(entry)
+	}
+	return values
+}
+
+// pathNode lê um atributo, label ou field (nil quando ausente)
+type pathNode struct {
+	root string
+	keys []string
+}
+
+func (n *pathNode) eval(entry *types.LogEntry) interface{} {
+	switch n.root {
+	case "message":
+		return entry.Message
+	case "level":
+		return entry.Level
+	case "source_type":
+		return entry.SourceType
+	case "source_id":
+		return entry.SourceID
+	case "trace_id":
+		return entry.TraceID
+	case "labels":
+		if value, ok := entry.GetLabel(n.keys[0]); ok {
+			return value
+		}
+		return nil
+	}
+
+	// Chaves achatadas (json_parse com flatten) têm precedência sobre o aninhamento
+	if len(n.keys) > 1 {
+		if value, ok := entry.GetField(strings.Join(n.keys, ".")); ok {
+			return normalizeConditionValue(value)
+		}
+	}
+
+	value, ok := entry.GetField(n.keys[0])
+	if !ok {
+		return nil
+	}
+	for _, key := range n.keys[1:] {
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok = nested[key]; !ok {
+			return nil
+		}
+	}
+	return normalizeConditionValue(value)
+}
+
+type notNode struct {
+	operand conditionNode
+}
+
+func (n *notNode) eval(entry *types.LogEntry) interface{} {
+	return !conditionTruthy(n.operand.eval(entry))
+}
+
+type logicalNode struct {
+	and         bool
+	left, right conditionNode
+}
+
+func (n *logicalNode) eval(entry *types.LogEntry) interface{} {
+	left := conditionTruthy(n.left.eval(entry))
+	if n.and != left {
+		// and com falso ou or com verdadeiro: resultado já definido
+		return left
+	}
+	return conditionTruthy(n.right.eval(entry))
+}
+
+type compareNode struct {
+	op          string
+	left, right conditionNode
+}
+
+func (n *compareNode) eval(entry *types.LogEntry) interface{} {
+	return compareConditionValues(n.op, n.left.eval(entry), n.right.eval(entry))
+}
+
+type matchNode struct {
+	negate  bool
+	operand conditionNode
+	re      *regexp.Regexp
+}
+
+func (n *matchNode) eval(entry *types.LogEntry) interface{} {
+	value := n.operand.eval(entry)
+	matched := value != nil && n.re.MatchString(conditionString(value))
+	return matched != n.negate
+}
+
+type inNode struct {
+	negate      bool
+	left, right conditionNode
+}
+
+func (n *inNode) eval(entry *types.LogEntry) interface{} {
+	left := n.left.eval(entry)
+	found := false
+
+	switch right := n.right.eval(entry).(type) {
+	case []interface{}:
+		for _, item := range right {
+			if compareConditionValues("==", left, normalizeConditionValue(item)) {
+				found = true
+				break
+			}
+		}
+	case []string:
+		for _, item := range right {
+			if compareConditionValues("==", left, item) {
+				found = true
+				break
+			}
+		}
+	case string:
+		found = left != nil && strings.Contains(right, conditionString(left))
+	}
+
+	return found != n.negate
+}
+
+type callNode struct {
+	name string
+	args []conditionNode
+	fn   func(args []interface{}) interface{}
+}
+
+func (n *callNode) eval(entry *types.LogEntry) interface{} {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.eval(entry)
+	}
+	return n.fn(args)
+}
+
+// conditionFunc descreve uma função disponível nas expressões
+type conditionFunc struct {
+	arity int
+	fn    func(args []interface{}) interface{}
+}
+
+var conditionFuncs = map[string]conditionFunc{
+	"exists": {1, func(args []interface{}) interface{} {
+		return args[0] != nil
+	}},
+	"starts_with": {2, stringPredicate(strings.HasPrefix)},
+	"ends_with":   {2, stringPredicate(strings.HasSuffix)},
+	"contains":    {2, stringPredicate(strings.Contains)},
+	"lower": {1, func(args []interface{}) interface{} {
+		if args[0] == nil {
+			return nil
+		}
+		return strings.ToLower(conditionString(args[0]))
+	}},
+	"upper": {1, func(args []interface{}) interface{} {
+		if args[0] == nil {
+			return nil
+		}
+		return strings.ToUpper(conditionString(args[0]))
+	}},
+	"number": {1, func(args []interface{}) interface{} {
+		if number, ok := conditionNumber(args[0]); ok {
+			return number
+		}
+		return nil
+	}},
+	"string": {1, func(args []interface{}) interface{} {
+		if args[0] == nil {
+			return nil
+		}
+		return conditionString(args[0])
+	}},
+}
+
+// stringPredicate adapta funções do pacote strings (valores ausentes são falsos)
+func stringPredicate(predicate func(s, substr string) bool) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		if args[0] == nil || args[1] == nil {
+			return false
+		}
+		return predicate(conditionString(args[0]), conditionString(args[1]))
+	}
+}
+
+// Tipagem dos valores
+
+// normalizeConditionValue converte números dos fields para float64
+func normalizeConditionValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if number, err := v.Float64(); err == nil {
+			return number
+		}
+		return v.String()
+	}
+	return value
+}
+
+// conditionTruthy define o valor lógico: ausente, false, "" e 0 são falsos
+func conditionTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// conditionNumber converte números e strings numéricas
+func conditionNumber(value interface{}) (float64, bool) {
+	switch v := normalizeConditionValue(value).(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// conditionString converte o valor para comparação textual
+func conditionString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// compareConditionValues compara dois valores: numericamente quando um dos
+// lados é número e o outro converte, senão como texto
+func compareConditionValues(op string, left, right interface{}) bool {
+	if left == nil || right == nil {
+		both := left == nil && right == nil
+		switch op {
+		case "==":
+			return both
+		case "!=":
+			return !both
+		}
+		return false
+	}
+
+	var cmp int
+	_, leftIsNumber := left.(float64)
+	_, rightIsNumber := right.(float64)
+	x, xOK := conditionNumber(left)
+	y, yOK := conditionNumber(right)
+
+	if (leftIsNumber || rightIsNumber) && xOK && yOK {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(conditionString(left), conditionString(right))
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
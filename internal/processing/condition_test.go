@@ -0,0 +1,204 @@
+package processing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConditionEntry() *types.LogEntry {
+	return &types.LogEntry{
+		Message:    "GET /api 502",
+		Level:      "error",
+		SourceType: "docker",
+		SourceID:   "abc123",
+		Labels:     map[string]string{"container_name": "web-frontend", "env": "prod", "app.kubernetes.io/name": "shop"},
+		Fields: map[string]interface{}{
+			"status":  "502",
+			"latency": 1.5,
+			"retries": 3,
+			"tags":    []interface{}{"edge", "canary"},
+			"http":    map[string]interface{}{"method": "GET"},
+			"k8s.pod": "web-0",
+		},
+	}
+}
+
+func TestCondition_Match(t *testing.T) {
+	entry := newConditionEntry()
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`labels.container_name =~ "^web-" && fields.status >= 500`, true},
+		{`labels.container_name =~ "^web-" && fields.status < 500`, false},
+		{`level in ["error", "fatal"]`, true},
+		{`level not in ["error", "fatal"]`, false},
+		{`source_type == "docker" and not (labels.env == "dev")`, true},
+		{`labels.env != "prod" || fields.retries == 3`, true},
+		{`exists(fields.status) && !exists(labels.missing)`, true},
+		{`labels.missing == "x"`, false},
+		{`labels.missing != "x"`, true},
+		{`fields.latency > 1 and fields.latency <= 1.5`, true},
+		{`fields.status == 502`, true},
+		{`fields.status == "502"`, true},
+		{`fields.http.method == "GET"`, true},
+		{`fields.http.path == "/"`, false},
+		{`fields.k8s.pod == "web-0"`, true},
+		{`"canary" in fields.tags`, true},
+		{`"api" in message`, true},
+		{`labels["app.kubernetes.io/name"] == "shop"`, true},
+		{`starts_with(labels.container_name, "web-") && ends_with(message, "502")`, true},
+		{`lower(level) == "error" and upper(source_type) == 'DOCKER'`, true},
+		{`message !~ "\d{3}$"`, false},
+		{`fields.retries > -1`, true},
+		{`"10" < "9"`, true},
+		{`number("10") < number("9")`, false},
+		{`source_id`, true},
+		{`fields.missing`, false},
+	}
+
+	for _, c := range cases {
+		condition, err := CompileCondition(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		assert.Equal(t, c.want, condition.Match(entry), c.expr)
+	}
+}
+
+func TestCompileCondition_Errors(t *testing.T) {
+	for _, expr := range []string{
+		`labels.app ==`,
+		`unknown == "x"`,
+		`labels == "x"`,
+		`labels.a.b == "x"`,
+		`message =~ "("`,
+		`message =~ level`,
+		`exists(labels.a, labels.b)`,
+		`(level == "error"`,
+		`level == "unterminated`,
+		`level == "a" $`,
+	} {
+		_, err := CompileCondition(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCompileStepCondition_LegacyRegex(t *testing.T) {
+	entry := newConditionEntry()
+
+	condition, legacy, err := compileStepCondition(`GET\s+/api`)
+	require.NoError(t, err)
+	assert.True(t, legacy)
+	assert.True(t, condition.Match(entry))
+
+	condition, legacy, err = compileStepCondition(`timeout|refused`)
+	require.NoError(t, err)
+	assert.True(t, legacy)
+	assert.False(t, condition.Match(entry))
+
+	// Inválido como expressão e como regex
+	_, _, err = compileStepCondition(`labels.app == "x" && (`)
+	assert.Error(t, err)
+}
+
+func TestCompileStepCondition_LegacyCompatibility(t *testing.T) {
+	cases := []struct {
+		source  string
+		message string
+	}{
+		{"timeout or reset", "read timeout or reset by peer"},
+		{"failed in handler", "request failed in handler /api"},
+		{"not found", "user not found"},
+		{"message", "unexpected message received"},
+		{"level", "log level changed"},
+		{"error", "connection error"},
+		// Literais e caminhos sem operador não viram expressões sempre verdadeiras
+		{"500", "upstream returned 500"},
+		{"1.5", "client 1.5 connected"},
+		{`"timeout"`, `error="timeout"`},
+		{"fields.foo", "missing fields.foo"},
+		{"labels.app", "labels.app not set"},
+	}
+	other := &types.LogEntry{
+		Message: "other",
+		Level:   "info",
+		Labels:  map[string]string{"app": "api"},
+		Fields:  map[string]interface{}{"foo": "bar"},
+	}
+	for _, tc := range cases {
+		condition, legacy, err := compileStepCondition(tc.source)
+		require.NoError(t, err, tc.source)
+		assert.True(t, legacy, tc.source)
+		assert.True(t, condition.Match(&types.LogEntry{Message: tc.message, Level: "info"}), tc.source)
+		assert.False(t, condition.Match(other), tc.source)
+	}
+
+	// Expressões válidas continuam expressões
+	for _, source := range []string{
+		`level == "error" or message =~ "timeout"`,
+		`exists(labels.container_name)`,
+		`not (level == "debug")`,
+		`level in ["error", "warn"]`,
+	} {
+		condition, legacy, err := compileStepCondition(source)
+		require.NoError(t, err, source)
+		assert.False(t, legacy, source)
+		assert.True(t, condition.Match(newConditionEntry()), source)
+	}
+}
+
+func TestLogProcessor_ConditionExpressions(t *testing.T) {
+	pipelines := `
+pipelines:
+  - name: default
+    steps:
+      - name: mark
+        type: field_add
+        config:
+          fields:
+            pipeline: default
+  - name: web_errors
+    condition: 'labels.container_name =~ "^web-" && level == "error"'
+    steps:
+      - name: tag_5xx
+        type: field_add
+        condition: 'fields.status >= 500'
+        config:
+          fields:
+            alert: "true"
+      - name: tag_4xx
+        type: field_add
+        condition: 'fields.status >= 400 && fields.status < 500'
+        config:
+          fields:
+            client_error: "true"
+`
+	file := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(pipelines), 0644))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	processor, err := NewLogProcessor(types.PipelineConfig{Enabled: true, File: file}, logger)
+	require.NoError(t, err)
+
+	entry, err := processor.Process(context.Background(), newConditionEntry())
+	require.NoError(t, err)
+	assert.Equal(t, "true", entry.Labels["alert"])
+	assert.NotContains(t, entry.Labels, "client_error")
+	assert.NotContains(t, entry.Labels, "pipeline")
+
+	other := newConditionEntry()
+	other.Labels["container_name"] = "db"
+	entry, err = processor.Process(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, "default", entry.Labels["pipeline"])
+}
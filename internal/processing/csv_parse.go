@@ -0,0 +1,279 @@
+package processing
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// LabelCSVHeader marca linhas reconhecidas como cabeçalho pelo csv_parse
+const LabelCSVHeader = "csv_header"
+
+// Limite de fontes com cabeçalho memorizado (a menos usada é descartada)
+const maxCSVHeaderSources = 1024
+
+// CSVParseProcessor separa linhas delimitadas (CSV/TSV) em Fields. Com
+// header_row, a linha do offset 0 de cada arquivo (label file_start) que
+// pareça um cabeçalho define os nomes das colunas daquela fonte.
+type CSVParseProcessor struct {
+	Field        string   // Origem: message, labels.X, fields.X ou nome solto
+	Columns      []string // Nomes das colunas; excedentes viram columnN
+	Delimiter    rune     // Separador de colunas
+	Quote        rune     // Aspas (0 = sem aspas); aspas duplicadas escapam
+	HeaderRow    bool     // Detecta a linha de cabeçalho por arquivo
+	InferTypes   bool     // Converte valores em bool, int64 ou float64
+	TagOnFailure bool     // Marca a entrada quando a linha é inválida
+
+	source      stepSource
+	headers     map[string]*list.Element // Fonte -> elemento de headerOrder
+	headerOrder *list.List               // Cabeçalhos memorizados, do mais recente ao menos usado
+	mutex       sync.Mutex
+}
+
+// csvHeader cabeçalho memorizado de uma fonte (columns nil = fonte sem cabeçalho)
+type csvHeader struct {
+	key     string
+	columns []string
+}
+
+func NewCSVParseProcessor(config map[string]interface{}) (*CSVParseProcessor, error) {
+	processor := &CSVParseProcessor{
+		headers:     make(map[string]*list.Element),
+		headerOrder: list.New(),
+	}
+	var err error
+
+	if processor.Field, err = configString(config, "field", "message"); err != nil {
+		return nil, fmt.Errorf("csv_parse: %w", err)
+	}
+	if processor.Columns, err = configStringList(config, "columns"); err != nil {
+		return nil, fmt.Errorf("csv_parse: %w", err)
+	}
+
+	delimiter, err := configString(config, "delimiter", ",")
+	if err != nil {
+		return nil, fmt.Errorf("csv_parse: %w", err)
+	}
+	switch strings.ToLower(delimiter) {
+	case "tab", "tsv", `\t`:
+		delimiter = "\t"
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return nil, fmt.Errorf("csv_parse: delimiter must be a single character, got %q", delimiter)
+	}
+	processor.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+
+	quote, err := configString(config, "quote", `"`)
+	if err != nil {
+		return nil, fmt.Errorf("csv_parse: %w", err)
+	}
+	switch utf8.RuneCountInString(quote) {
+	case 0:
+	case 1:
+		processor.Quote, _ = utf8.DecodeRuneInString(quote)
+	default:
+		return nil, fmt.Errorf("csv_parse: quote must be a single character or empty, got %q", quote)
+	}
+	if processor.Quote == processor.Delimiter {
+		return nil, fmt.Errorf("csv_parse: quote and delimiter must differ")
+	}
+
+	flags := []struct {
+		key   string
+		dest  *bool
+		value bool
+	}{
+		{"header_row", &processor.HeaderRow, false},
+		{"infer_types", &processor.InferTypes, false},
+		{"tag_on_failure", &processor.TagOnFailure, true},
+	}
+	for _, flag := range flags {
+		if *flag.dest, err = configBool(config, flag.key, flag.value); err != nil {
+			return nil, fmt.Errorf("csv_parse: %w", err)
+		}
+	}
+
+	processor.source = parseStepSource(processor.Field)
+	return processor, nil
+}
+
+func (cp *CSVParseProcessor) Process(ctx context.Context, entry *types.LogEntry) (*types.LogEntry, error) {
+	raw, ok := cp.source.read(entry)
+	if !ok {
+		return entry, nil
+	}
+
+	values, err := cp.split(strings.TrimSuffix(raw, "\r"))
+	if err != nil {
+		if !cp.TagOnFailure {
+			return entry, nil
+		}
+		newEntry := entry.DeepCopy()
+		tagParseFailure(newEntry, cp.GetType(), err)
+		return newEntry, nil
+	}
+
+	columns := cp.Columns
+	if cp.HeaderRow {
+		header, isHeader := cp.header(entry, values)
+		if isHeader {
+			newEntry := entry.DeepCopy()
+			if newEntry.Labels == nil {
+				newEntry.Labels = make(map[string]string)
+			}
+			newEntry.Labels[LabelCSVHeader] = "true"
+			return newEntry, nil
+		}
+		if header != nil {
+			columns = header
+		}
+	}
+
+	newEntry := entry.DeepCopy()
+	if newEntry.Fields == nil {
+		newEntry.Fields = make(map[string]interface{})
+	}
+	for i, value := range values {
+		name := "column" + strconv.Itoa(i+1)
+		if i < len(columns) && columns[i] != "" {
+			name = columns[i]
+		}
+		if cp.InferTypes {
+			newEntry.Fields[name] = inferValue(value)
+		} else {
+			newEntry.Fields[name] = value
+		}
+	}
+	return newEntry, nil
+}
+
+func (cp *CSVParseProcessor) GetType() string {
+	return "csv_parse"
+}
+
+// header retorna o cabeçalho conhecido da fonte e se values é um cabeçalho.
+// Uma linha igual a columns é sempre cabeçalho; fora isso, só a linha do
+// offset 0 do arquivo (label file_start) pode definir um cabeçalho novo.
+func (cp *CSVParseProcessor) header(entry *types.LogEntry, values []string) ([]string, bool) {
+	key := csvHeaderKey(entry)
+	fileStart, _ := entry.GetLabel(types.LabelFileStart)
+
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	if fileStart != "true" {
+		element, seen := cp.headers[key]
+		if !seen {
+			return nil, equalStrings(values, cp.Columns)
+		}
+		cp.headerOrder.MoveToFront(element)
+		header := element.Value.(*csvHeader).columns
+		return header, equalStrings(values, cp.Columns) || equalStrings(values, header)
+	}
+
+	// Início do arquivo (novo ou após rotação): o cabeçalho é aprendido de novo
+	var columns []string
+	isHeader := equalStrings(values, cp.Columns) || looksLikeCSVHeader(values)
+	if isHeader {
+		columns = append([]string(nil), values...)
+	}
+	cp.rememberHeader(key, columns)
+	return nil, isHeader
+}
+
+// rememberHeader grava o cabeçalho da fonte, descartando o menos usado
+// quando o limite de fontes é atingido
+func (cp *CSVParseProcessor) rememberHeader(key string, columns []string) {
+	if element, seen := cp.headers[key]; seen {
+		element.Value.(*csvHeader).columns = columns
+		cp.headerOrder.MoveToFront(element)
+		return
+	}
+
+	if cp.headerOrder.Len() >= maxCSVHeaderSources {
+		oldest := cp.headerOrder.Back()
+		cp.headerOrder.Remove(oldest)
+		delete(cp.headers, oldest.Value.(*csvHeader).key)
+	}
+	cp.headers[key] = cp.headerOrder.PushFront(&csvHeader{key: key, columns: columns})
+}
+
+// csvHeaderKey identifica o arquivo de origem da linha
+func csvHeaderKey(entry *types.LogEntry) string {
+	if path, ok := entry.GetLabel("file_path"); ok {
+		return path
+	}
+	return entry.SourceType + ":" + entry.SourceID
+}
+
+// looksLikeCSVHeader aceita nomes não vazios, únicos e que não sejam números ou booleanos
+func looksLikeCSVHeader(values []string) bool {
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			return false
+		}
+		if _, isText := inferValue(value).(string); !isText {
+			return false
+		}
+		seen[value] = true
+	}
+	return len(values) > 0
+}
+
+// equalStrings compara duas listas de strings
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) || len(a) == 0 {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// split separa a linha respeitando aspas; aspas duplicadas dentro de um
+// campo entre aspas representam uma aspa literal
+func (cp *CSVParseProcessor) split(line string) ([]string, error) {
+	var values []string
+	var field strings.Builder
+	inQuotes, quoted := false, false
+
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+
+		switch {
+		case inQuotes:
+			if r != cp.Quote {
+				field.WriteRune(r)
+			} else if next, nextSize := utf8.DecodeRuneInString(line[i:]); i < len(line) && next == cp.Quote {
+				field.WriteRune(r)
+				i += nextSize
+			} else {
+				inQuotes = false
+			}
+		case cp.Quote != 0 && r == cp.Quote && field.Len() == 0 && !quoted:
+			inQuotes, quoted = true, true
+		case r == cp.Delimiter:
+			values = append(values, field.String())
+			field.Reset()
+			quoted = false
+		default:
+			field.WriteRune(r)
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quoted field")
+	}
+	return append(values, field.String()), nil
+}
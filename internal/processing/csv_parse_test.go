@@ -0,0 +1,151 @@
+package processing
+
+import (
+	"strconv"
+	"testing"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCSVLine(path, line string) *types.LogEntry {
+	return &types.LogEntry{Message: line, SourceType: "file", Labels: map[string]string{"file_path": path}}
+}
+
+// newCSVFirstLine linha lida do offset 0 do arquivo
+func newCSVFirstLine(path, line string) *types.LogEntry {
+	entry := newCSVLine(path, line)
+	entry.Labels[types.LabelFileStart] = "true"
+	return entry
+}
+
+func TestCSVParse_ColumnsAndQuotes(t *testing.T) {
+	processor, err := NewCSVParseProcessor(map[string]interface{}{
+		"columns":     []interface{}{"ts", "host", "message", "bytes"},
+		"infer_types": true,
+	})
+	require.NoError(t, err)
+
+	result := runStep(t, processor, newCSVLine("/var/log/fw.csv", `2024-05-01T10:00:00Z,fw-1,"denied, ""tcp"" 443",1500,extra`))
+	assert.Equal(t, map[string]interface{}{
+		"ts":      "2024-05-01T10:00:00Z",
+		"host":    "fw-1",
+		"message": `denied, "tcp" 443`,
+		"bytes":   int64(1500),
+		"column5": "extra",
+	}, result.Fields)
+
+	result = runStep(t, processor, newCSVLine("/var/log/fw.csv", `a,"unterminated`))
+	assert.Equal(t, "csv_parse", result.Labels[LabelParseFailure])
+	assert.Equal(t, "unterminated quoted field", result.Fields[FieldParseError])
+}
+
+func TestCSVParse_TSVWithoutQuotes(t *testing.T) {
+	processor, err := NewCSVParseProcessor(map[string]interface{}{
+		"columns":   []interface{}{"a", "b", "c"},
+		"delimiter": "tab",
+		"quote":     "",
+	})
+	require.NoError(t, err)
+
+	result := runStep(t, processor, newCSVLine("/x.tsv", "1\t\"two\"\t\r"))
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": `"two"`, "c": ""}, result.Fields)
+}
+
+func TestCSVParse_HeaderRow(t *testing.T) {
+	processor, err := NewCSVParseProcessor(map[string]interface{}{"header_row": true, "delimiter": ";"})
+	require.NoError(t, err)
+
+	header := runStep(t, processor, newCSVFirstLine("/data/a.csv", "id;user;amount"))
+	assert.Equal(t, "true", header.Labels[LabelCSVHeader])
+	assert.Empty(t, header.Fields)
+
+	row := runStep(t, processor, newCSVLine("/data/a.csv", "1;ana;9.5"))
+	assert.Equal(t, map[string]interface{}{"id": "1", "user": "ana", "amount": "9.5"}, row.Fields)
+
+	// Cabeçalho repetido continua sendo reconhecido
+	assert.Equal(t, "true", runStep(t, processor, newCSVLine("/data/a.csv", "id;user;amount")).Labels[LabelCSVHeader])
+
+	// Arquivo rotacionado com outro cabeçalho no offset 0
+	assert.Equal(t, "true", runStep(t, processor, newCSVFirstLine("/data/a.csv", "id;name;total")).Labels[LabelCSVHeader])
+	row = runStep(t, processor, newCSVLine("/data/a.csv", "2;bia;10"))
+	assert.Equal(t, map[string]interface{}{"id": "2", "name": "bia", "total": "10"}, row.Fields)
+
+	// Arquivo cuja primeira linha já é dado
+	row = runStep(t, processor, newCSVFirstLine("/data/b.csv", "2;bia;10"))
+	assert.NotContains(t, row.Labels, LabelCSVHeader)
+	assert.Equal(t, map[string]interface{}{"column1": "2", "column2": "bia", "column3": "10"}, row.Fields)
+	row = runStep(t, processor, newCSVLine("/data/b.csv", "name;user;total"))
+	assert.NotContains(t, row.Labels, LabelCSVHeader, "only the line at offset 0 can be a header")
+
+	// Leitura iniciada no meio do arquivo (start_at: end): linha textual não é cabeçalho
+	row = runStep(t, processor, newCSVLine("/data/c.csv", "error;timeout;retry"))
+	assert.NotContains(t, row.Labels, LabelCSVHeader)
+	assert.Equal(t, "error", row.Fields["column1"])
+}
+
+func TestCSVParse_HeaderRowMatchesColumns(t *testing.T) {
+	processor, err := NewCSVParseProcessor(map[string]interface{}{"header_row": true, "columns": []interface{}{"ts", "host", "action"}})
+	require.NoError(t, err)
+
+	// Sem o offset 0, só uma linha igual a columns é tratada como cabeçalho
+	header := runStep(t, processor, newCSVLine("/data/fw.csv", "ts,host,action"))
+	assert.Equal(t, "true", header.Labels[LabelCSVHeader])
+
+	row := runStep(t, processor, newCSVLine("/data/fw.csv", "t1,fw-1,deny"))
+	assert.Equal(t, map[string]interface{}{"ts": "t1", "host": "fw-1", "action": "deny"}, row.Fields)
+}
+
+func TestCSVParse_HeaderEvictionIsLRU(t *testing.T) {
+	processor, err := NewCSVParseProcessor(map[string]interface{}{"header_row": true})
+	require.NoError(t, err)
+
+	runStep(t, processor, newCSVFirstLine("/data/hot.csv", "id,user"))
+	for i := 0; i < maxCSVHeaderSources; i++ {
+		path := "/data/cold-" + strconv.Itoa(i) + ".csv"
+		runStep(t, processor, newCSVFirstLine(path, "a,b"))
+		// Fonte ativa continua entre as mais recentes
+		runStep(t, processor, newCSVLine("/data/hot.csv", "1,ana"))
+	}
+
+	assert.Len(t, processor.headers, maxCSVHeaderSources)
+	assert.NotContains(t, processor.headers, "/data/cold-0.csv", "least recently used source is evicted")
+	row := runStep(t, processor, newCSVLine("/data/hot.csv", "2,bia"))
+	assert.Equal(t, map[string]interface{}{"id": "2", "user": "bia"}, row.Fields)
+}
+
+func TestNewCSVParseProcessor_InvalidConfig(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{"delimiter": ";;"},
+		{"quote": "''"},
+		{"delimiter": ",", "quote": ","},
+		{"columns": 1},
+		{"header_row": "yes"},
+	} {
+		_, err := NewCSVParseProcessor(config)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestInferValue(t *testing.T) {
+	cases := map[string]interface{}{
+		"42":    int64(42),
+		"-7":    int64(-7),
+		"0":     int64(0),
+		"0.5":   0.5,
+		"1e3":   1000.0,
+		"TRUE":  true,
+		"false": false,
+		"007":   "007",
+		"0x1F":  "0x1F",
+		"NaN":   "NaN",
+		"":      "",
+		"1.2.3": "1.2.3",
+		"+":     "+",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, inferValue(input), input)
+	}
+}
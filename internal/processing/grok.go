@@ -0,0 +1,301 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// Destinos das capturas do grok
+const (
+	grokTargetFields = "fields"
+	grokTargetLabels = "labels"
+)
+
+// grokReference casa %{PADRAO}, %{PADRAO:campo} e %{PADRAO:campo:tipo}
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w@.\[\]-]+))?(?::(\w+))?\}`)
+
+// grokCapture associa um grupo da regex a um campo e ao tipo de conversão
+type grokCapture struct {
+	field string
+	kind  string // "", int, float, bool ou string
+}
+
+// grokExpression é um padrão grok expandido e compilado
+type grokExpression struct {
+	re       *regexp.Regexp
+	captures map[string]grokCapture // Nome do grupo -> captura
+}
+
+// GrokProcessor extrai campos com padrões grok (biblioteca do Logstash + customizados).
+// Os padrões são tentados em ordem; por padrão o primeiro que casar vence.
+type GrokProcessor struct {
+	Field             string // Origem: message, labels.X, fields.X ou nome solto
+	Target            string // fields (com conversão de tipo) ou labels
+	BreakOnMatch      bool   // Para no primeiro padrão que casar
+	NamedCapturesOnly bool   // %{PADRAO} sem nome não gera campo
+	KeepEmpty         bool   // Grava capturas vazias
+	TagOnFailure      bool   // Marca a entrada quando nenhum padrão casa
+
+	expressions []grokExpression
+	source      stepSource
+}
+
+// NewGrokProcessor cria o step grok. shared são os padrões definidos em
+// grok_patterns no topo do arquivo de pipelines.
+func NewGrokProcessor(config map[string]interface{}, shared map[string]string) (*GrokProcessor, error) {
+	processor := &GrokProcessor{}
+	var err error
+
+	if processor.Field, err = configString(config, "field", "message"); err != nil {
+		return nil, fmt.Errorf("grok: %w", err)
+	}
+	if processor.Target, err = configString(config, "target", grokTargetFields); err != nil {
+		return nil, fmt.Errorf("grok: %w", err)
+	}
+	if processor.Target != grokTargetFields && processor.Target != grokTargetLabels {
+		return nil, fmt.Errorf("grok: target must be %q or %q, got %q", grokTargetFields, grokTargetLabels, processor.Target)
+	}
+
+	flags := []struct {
+		key   string
+		dest  *bool
+		value bool
+	}{
+		{"break_on_match", &processor.BreakOnMatch, true},
+		{"named_captures_only", &processor.NamedCapturesOnly, true},
+		{"keep_empty_captures", &processor.KeepEmpty, false},
+		{"tag_on_failure", &processor.TagOnFailure, true},
+	}
+	for _, flag := range flags {
+		if *flag.dest, err = configBool(config, flag.key, flag.value); err != nil {
+			return nil, fmt.Errorf("grok: %w", err)
+		}
+	}
+
+	patterns, err := configStringList(config, "patterns")
+	if err != nil {
+		return nil, fmt.Errorf("grok: %w", err)
+	}
+	if len(patterns) == 0 {
+		if patterns, err = configStringList(config, "pattern"); err != nil {
+			return nil, fmt.Errorf("grok: %w", err)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("patterns is required for grok")
+	}
+
+	custom, err := configStringMap(config, "pattern_definitions")
+	if err != nil {
+		return nil, fmt.Errorf("grok: %w", err)
+	}
+	definitions := make(map[string]string, len(grokBuiltinPatterns)+len(shared)+len(custom))
+	for _, source := range []map[string]string{grokBuiltinPatterns, shared, custom} {
+		for name, pattern := range source {
+			definitions[name] = pattern
+		}
+	}
+
+	for _, pattern := range patterns {
+		expression, err := compileGrok(pattern, definitions, processor.NamedCapturesOnly)
+		if err != nil {
+			return nil, fmt.Errorf("grok: pattern %q: %w", pattern, err)
+		}
+		processor.expressions = append(processor.expressions, expression)
+	}
+
+	processor.source = parseStepSource(processor.Field)
+	return processor, nil
+}
+
+func (gp *GrokProcessor) Process(ctx context.Context, entry *types.LogEntry) (*types.LogEntry, error) {
+	raw, ok := gp.source.read(entry)
+	if !ok {
+		return entry, nil
+	}
+
+	values := make(map[string]interface{})
+	matched := false
+	for _, expression := range gp.expressions {
+		if expression.match(raw, values, gp.KeepEmpty, gp.Target == grokTargetFields) {
+			matched = true
+			if gp.BreakOnMatch {
+				break
+			}
+		}
+	}
+
+	if !matched {
+		if !gp.TagOnFailure {
+			return entry, nil
+		}
+		newEntry := entry.DeepCopy()
+		tagParseFailure(newEntry, gp.GetType(), fmt.Errorf("no grok pattern matched"))
+		return newEntry, nil
+	}
+
+	newEntry := entry.DeepCopy()
+	if gp.Target == grokTargetLabels {
+		if newEntry.Labels == nil {
+			newEntry.Labels = make(map[string]string)
+		}
+		for field, value := range values {
+			newEntry.Labels[field] = fmt.Sprint(value)
+		}
+		return newEntry, nil
+	}
+
+	if newEntry.Fields == nil {
+		newEntry.Fields = make(map[string]interface{})
+	}
+	for field, value := range values {
+		newEntry.Fields[field] = value
+	}
+	return newEntry, nil
+}
+
+func (gp *GrokProcessor) GetType() string {
+	return "grok"
+}
+
+// match aplica a expressão e grava as capturas em values. Um campo capturado
+// mais de uma vez fica com o último valor não vazio.
+func (e *grokExpression) match(raw string, values map[string]interface{}, keepEmpty, convert bool) bool {
+	indexes := e.re.FindStringSubmatchIndex(raw)
+	if indexes == nil {
+		return false
+	}
+
+	for i, name := range e.re.SubexpNames() {
+		capture, ok := e.captures[name]
+		if !ok || indexes[2*i] < 0 {
+			continue
+		}
+		value := raw[indexes[2*i]:indexes[2*i+1]]
+		if value == "" {
+			if _, exists := values[capture.field]; exists || !keepEmpty {
+				continue
+			}
+		}
+		if convert {
+			values[capture.field] = convertGrokValue(value, capture.kind)
+		} else {
+			values[capture.field] = value
+		}
+	}
+	return true
+}
+
+// compileGrok expande as referências %{...} e compila a regex resultante
+func compileGrok(pattern string, definitions map[string]string, namedOnly bool) (grokExpression, error) {
+	compiler := &grokCompiler{definitions: definitions, namedOnly: namedOnly, captures: make(map[string]grokCapture)}
+	expanded, err := compiler.expand(pattern, nil)
+	if err != nil {
+		return grokExpression{}, err
+	}
+
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return grokExpression{}, fmt.Errorf("invalid expanded regex: %w", err)
+	}
+
+	// Grupos nomeados escritos direto na regex, ex: (?P<user>\w+)
+	for _, name := range re.SubexpNames() {
+		if _, ok := compiler.captures[name]; name != "" && !ok {
+			compiler.captures[name] = grokCapture{field: name}
+		}
+	}
+	return grokExpression{re: re, captures: compiler.captures}, nil
+}
+
+// grokCompiler guarda o estado da expansão de um padrão
+type grokCompiler struct {
+	definitions map[string]string
+	namedOnly   bool
+	captures    map[string]grokCapture
+	groups      int
+}
+
+// expand substitui recursivamente as referências; stack detecta ciclos
+func (c *grokCompiler) expand(pattern string, stack []string) (string, error) {
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+		parts := grokReference.FindStringSubmatch(reference)
+		name, field, kind := parts[1], parts[2], parts[3]
+
+		definition, ok := c.definitions[name]
+		if !ok {
+			err = fmt.Errorf("unknown grok pattern %%{%s}", name)
+			return ""
+		}
+		for _, parent := range stack {
+			if parent == name {
+				err = fmt.Errorf("recursive grok pattern %%{%s}", name)
+				return ""
+			}
+		}
+		switch kind {
+		case "", "string", "int", "integer", "long", "float", "double", "bool", "boolean":
+		default:
+			err = fmt.Errorf("unknown type %q in %s", kind, reference)
+			return ""
+		}
+
+		inner, expandErr := c.expand(definition, append(stack, name))
+		if expandErr != nil {
+			err = expandErr
+			return ""
+		}
+
+		if field == "" && !c.namedOnly {
+			field = name
+		}
+		if field == "" {
+			return "(?:" + inner + ")"
+		}
+		group := fmt.Sprintf("grok%d", c.groups)
+		c.groups++
+		c.captures[group] = grokCapture{field: grokFieldName(field), kind: kind}
+		return "(?P<" + group + ">" + inner + ")"
+	})
+	return expanded, err
+}
+
+// grokFieldName converte a notação [a][b] do Logstash para a.b
+func grokFieldName(field string) string {
+	if !strings.HasPrefix(field, "[") {
+		return field
+	}
+	field = strings.TrimSuffix(strings.TrimPrefix(field, "["), "]")
+	return strings.ReplaceAll(field, "][", ".")
+}
+
+// convertGrokValue aplica o tipo da captura; valores inválidos ficam como string
+func convertGrokValue(value, kind string) interface{} {
+	switch kind {
+	case "int", "integer", "long":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return int64(f)
+		}
+	case "float", "double":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "bool", "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
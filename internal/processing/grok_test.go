@@ -0,0 +1,147 @@
+package processing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrokBuiltinPatternsCompile(t *testing.T) {
+	for name := range grokBuiltinPatterns {
+		_, err := compileGrok("%{"+name+"}", grokBuiltinPatterns, true)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestGrok_CombinedApacheLog(t *testing.T) {
+	entry := &types.LogEntry{Message: `10.1.2.3 - frank [10/Oct/2024:13:55:36 -0700] "GET /api/items?id=7 HTTP/1.1" 200 2326 "https://example.com/" "curl/8.4.0"`}
+
+	processor, err := NewGrokProcessor(map[string]interface{}{
+		"patterns": []interface{}{`%{IPORHOST:clientip} %{HTTPDUSER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "%{WORD:verb} %{NOTSPACE:request} HTTP/%{NUMBER:httpversion:float}" %{NUMBER:response:int} %{NUMBER:bytes:int} %{QS:referrer} %{QS:agent}`},
+	}, nil)
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+
+	assert.Equal(t, "10.1.2.3", result.Fields["clientip"])
+	assert.Equal(t, "frank", result.Fields["auth"])
+	assert.Equal(t, "10/Oct/2024:13:55:36 -0700", result.Fields["timestamp"])
+	assert.Equal(t, "/api/items?id=7", result.Fields["request"])
+	assert.Equal(t, 1.1, result.Fields["httpversion"])
+	assert.Equal(t, int64(200), result.Fields["response"])
+	assert.Equal(t, int64(2326), result.Fields["bytes"])
+	assert.Equal(t, `"curl/8.4.0"`, result.Fields["agent"])
+	assert.NotContains(t, result.Labels, LabelParseFailure)
+	assert.Empty(t, entry.Fields, "input entry is not modified")
+
+	// O padrão composto da biblioteca produz os mesmos campos nomeados
+	processor, err = NewGrokProcessor(map[string]interface{}{"patterns": "%{COMBINEDAPACHELOG}"}, nil)
+	require.NoError(t, err)
+	result = runStep(t, processor, entry)
+	assert.Equal(t, "GET", result.Fields["verb"])
+	assert.Equal(t, "2326", result.Fields["bytes"])
+}
+
+func TestGrok_MultiplePatternsAndCustomDefinitions(t *testing.T) {
+	config := map[string]interface{}{
+		"patterns": []interface{}{
+			`^%{TIMESTAMP_ISO8601:ts} %{LOGLEVEL:level} \[%{REQID:request_id}\] %{GREEDYDATA:msg}$`,
+			`^%{LOGLEVEL:level}: %{GREEDYDATA:msg}$`,
+		},
+		"pattern_definitions": map[interface{}]interface{}{"REQID": `req-%{UUID}`},
+		"target":              "labels",
+	}
+
+	processor, err := NewGrokProcessor(config, nil)
+	require.NoError(t, err)
+	result := runStep(t, processor, &types.LogEntry{Message: "2024-05-01T10:00:00.123Z ERROR [req-123e4567-e89b-12d3-a456-426614174000] boom"})
+	assert.Equal(t, "ERROR", result.Labels["level"])
+	assert.Equal(t, "req-123e4567-e89b-12d3-a456-426614174000", result.Labels["request_id"])
+	assert.Equal(t, "boom", result.Labels["msg"])
+	assert.Empty(t, result.Fields)
+
+	result = runStep(t, processor, &types.LogEntry{Message: "warning: disk almost full"})
+	assert.Equal(t, "warning", result.Labels["level"])
+	assert.Equal(t, "disk almost full", result.Labels["msg"])
+	assert.NotContains(t, result.Labels, "request_id")
+}
+
+func TestGrok_Options(t *testing.T) {
+	entry := &types.LogEntry{Message: "user=ana id=42", Labels: map[string]string{}}
+
+	// break_on_match: false acumula capturas de todos os padrões
+	processor, err := NewGrokProcessor(map[string]interface{}{
+		"patterns":       []interface{}{`user=%{USERNAME:user}`, `id=%{INT:[account][id]:int}`},
+		"break_on_match": false,
+	}, nil)
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, "ana", result.Fields["user"])
+	assert.Equal(t, int64(42), result.Fields["account.id"])
+
+	// named_captures_only: false grava %{PADRAO} pelo nome do padrão; (?P<x>) vira campo
+	processor, err = NewGrokProcessor(map[string]interface{}{
+		"patterns":            `user=%{USERNAME} id=(?P<uid>\d+)`,
+		"named_captures_only": false,
+	}, nil)
+	require.NoError(t, err)
+	result = runStep(t, processor, entry)
+	assert.Equal(t, "ana", result.Fields["USERNAME"])
+	assert.Equal(t, "42", result.Fields["uid"])
+
+	// Sem match: marca a entrada, a menos que tag_on_failure seja false
+	processor, err = NewGrokProcessor(map[string]interface{}{"patterns": `^%{IPV4:ip}`}, nil)
+	require.NoError(t, err)
+	result = runStep(t, processor, entry)
+	assert.Equal(t, "grok", result.Labels[LabelParseFailure])
+	assert.Equal(t, "no grok pattern matched", result.Fields[FieldParseError])
+
+	processor, err = NewGrokProcessor(map[string]interface{}{"patterns": `^%{IPV4:ip}`, "tag_on_failure": false}, nil)
+	require.NoError(t, err)
+	assert.Same(t, entry, runStep(t, processor, entry))
+}
+
+func TestNewGrokProcessor_Errors(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{},
+		{"patterns": "%{NOPE:x}"},
+		{"patterns": "%{A}", "pattern_definitions": map[string]interface{}{"A": "%{B}", "B": "%{A}"}},
+		{"patterns": "%{INT:x:decimal}"},
+		{"patterns": "%{INT:x}", "target": "metrics"},
+		{"patterns": "(unclosed"},
+	} {
+		_, err := NewGrokProcessor(config, nil)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestLogProcessor_GrokSharedPatterns(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+grok_patterns:
+  TENANT: "[a-z]+-[0-9]+"
+pipelines:
+  - name: default
+    steps:
+      - name: tenant
+        type: grok
+        config:
+          patterns: ['tenant=%{TENANT:tenant}']
+          target: labels
+`), 0644))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	processor, err := NewLogProcessor(types.PipelineConfig{Enabled: true, File: file}, logger)
+	require.NoError(t, err)
+
+	result, err := processor.Process(context.Background(), &types.LogEntry{Message: "login ok tenant=acme-7"})
+	require.NoError(t, err)
+	assert.Equal(t, "acme-7", result.Labels["tenant"])
+}
@@ -0,0 +1,352 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// JSONParseProcessor parseia JSON do campo de origem (ou após um prefixo) e
+// mescla as chaves em Fields, promovendo chaves para Labels, Level,
+// Timestamp e Message. Falhas de parse marcam a entrada em vez de falhar.
+type JSONParseProcessor struct {
+	Field           string            // Origem: message, labels.X, fields.X ou nome solto
+	Prefix          string            // O JSON começa após a primeira ocorrência do prefixo
+	Target          string            // Prefixo/campo de destino em Fields ("" = raiz)
+	Flatten         bool              // Achata objetos aninhados em chaves a.b.c
+	MaxDepth        int               // Objetos além dessa profundidade viram string JSON (0 = sem limite)
+	Labels          map[string]string // Caminho JSON -> nome do label
+	LevelKey        string            // Caminho JSON promovido para Level
+	TimestampKey    string            // Caminho JSON promovido para Timestamp
+	TimestampFormat string            // Layout Go do timestamp ("" = detecção automática)
+	MessageKey      string            // Caminho JSON promovido para Message
+	KeepOriginal    bool              // Mantém o texto de origem
+	OriginalField   string            // Field que guarda a mensagem original quando message é promovida
+
+	source stepSource
+}
+
+func NewJSONParseProcessor(config map[string]interface{}) (*JSONParseProcessor, error) {
+	processor := &JSONParseProcessor{}
+	var err error
+
+	options := []struct {
+		key   string
+		dest  *string
+		value string
+	}{
+		{"field", &processor.Field, "message"},
+		{"prefix", &processor.Prefix, ""},
+		{"target", &processor.Target, ""},
+		{"level", &processor.LevelKey, ""},
+		{"timestamp", &processor.TimestampKey, ""},
+		{"timestamp_format", &processor.TimestampFormat, ""},
+		{"message", &processor.MessageKey, ""},
+		{"original_field", &processor.OriginalField, "original_message"},
+	}
+	for _, option := range options {
+		if *option.dest, err = configString(config, option.key, option.value); err != nil {
+			return nil, fmt.Errorf("json_parse: %w", err)
+		}
+	}
+
+	if processor.Flatten, err = configBool(config, "flatten", false); err != nil {
+		return nil, fmt.Errorf("json_parse: %w", err)
+	}
+	if processor.KeepOriginal, err = configBool(config, "keep_original", true); err != nil {
+		return nil, fmt.Errorf("json_parse: %w", err)
+	}
+	if processor.MaxDepth, err = configInt(config, "max_depth", 0); err != nil {
+		return nil, fmt.Errorf("json_parse: %w", err)
+	}
+	if processor.MaxDepth < 0 {
+		return nil, fmt.Errorf("json_parse: max_depth must be >= 0")
+	}
+	if processor.Labels, err = configStringMap(config, "labels"); err != nil {
+		return nil, fmt.Errorf("json_parse: %w", err)
+	}
+
+	processor.source = parseStepSource(processor.Field)
+	return processor, nil
+}
+
+func (jpp *JSONParseProcessor) Process(ctx context.Context, entry *types.LogEntry) (*types.LogEntry, error) {
+	raw, ok := jpp.source.read(entry)
+	if !ok {
+		return entry, nil
+	}
+
+	before := ""
+	if jpp.Prefix != "" {
+		index := strings.Index(raw, jpp.Prefix)
+		if index < 0 {
+			return entry, nil // Sem prefixo: a linha não carrega JSON
+		}
+		before = strings.TrimSpace(raw[:index])
+		raw = raw[index+len(jpp.Prefix):]
+	}
+
+	newEntry := entry.DeepCopy()
+	object, err := decodeJSONObject(raw)
+	if err != nil {
+		tagParseFailure(newEntry, jpp.GetType(), err)
+		return newEntry, nil
+	}
+
+	if newEntry.Labels == nil {
+		newEntry.Labels = make(map[string]string)
+	}
+	if newEntry.Fields == nil {
+		newEntry.Fields = make(map[string]interface{})
+	}
+	original := newEntry.Message
+
+	// Promoções retiram as chaves do objeto antes da mescla em Fields
+	for path, label := range jpp.Labels {
+		if value, ok := takeJSONPath(object, path); ok {
+			newEntry.Labels[label] = jsonString(value)
+		}
+	}
+	if jpp.LevelKey != "" {
+		if value, ok := takeJSONPath(object, jpp.LevelKey); ok {
+			newEntry.Level = strings.ToLower(jsonString(value))
+		}
+	}
+	if jpp.TimestampKey != "" {
+		// Só sai do objeto se converter; senão fica no caminho original
+		if value, ok := peekJSONPath(object, jpp.TimestampKey); ok {
+			timestamp, err := parseTimestampValue(value, jpp.TimestampFormat)
+			if err != nil {
+				tagParseFailure(newEntry, jpp.GetType(), err)
+			} else {
+				takeJSONPath(object, jpp.TimestampKey)
+				newEntry.Timestamp = timestamp
+			}
+		}
+	}
+	messagePromoted := false
+	if jpp.MessageKey != "" {
+		if value, ok := takeJSONPath(object, jpp.MessageKey); ok {
+			newEntry.Message = jsonString(value)
+			messagePromoted = true
+		}
+	}
+
+	jpp.merge(newEntry.Fields, object)
+
+	switch {
+	case jpp.KeepOriginal && messagePromoted && jpp.source.isMessage():
+		newEntry.Fields[jpp.OriginalField] = original
+	case !jpp.KeepOriginal && jpp.source.isMessage():
+		if !messagePromoted {
+			newEntry.Message = before
+		}
+	case !jpp.KeepOriginal:
+		jpp.source.remove(newEntry)
+	}
+
+	return newEntry, nil
+}
+
+// merge grava o objeto em Fields respeitando target, flatten e max_depth
+func (jpp *JSONParseProcessor) merge(fields map[string]interface{}, object map[string]interface{}) {
+	if !jpp.Flatten {
+		limited := limitJSONDepth(object, jpp.MaxDepth, 1).(map[string]interface{})
+		if jpp.Target != "" {
+			fields[jpp.Target] = limited
+			return
+		}
+		for key, value := range limited {
+			fields[key] = value
+		}
+		return
+	}
+	flattenJSON(fields, jpp.Target, object, jpp.MaxDepth, 1)
+}
+
+func (jpp *JSONParseProcessor) GetType() string {
+	return "json_parse"
+}
+
+// decodeJSONObject decodifica o primeiro valor JSON do texto (texto depois dele é ignorado)
+func decodeJSONObject(raw string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(strings.TrimSpace(raw)))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON value is not an object")
+	}
+	return normalizeJSONValue(object).(map[string]interface{}), nil
+}
+
+// normalizeJSONValue converte json.Number em int64 (quando inteiro) ou float64
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONValue(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return value
+}
+
+// takeJSONPath remove e retorna o valor no caminho a.b.c (chave literal com pontos tem precedência)
+func takeJSONPath(object map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := object[path]; ok {
+		delete(object, path)
+		return value, true
+	}
+
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := object[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := takeJSONPath(nested, rest)
+	if ok && len(nested) == 0 {
+		delete(object, head)
+	}
+	return value, ok
+}
+
+// peekJSONPath lê o valor no caminho, como takeJSONPath, sem removê-lo
+func peekJSONPath(object map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := object[path]; ok {
+		return value, true
+	}
+
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := object[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return peekJSONPath(nested, rest)
+}
+
+// flattenJSON grava chaves achatadas (prefix.a.b) até max_depth
+func flattenJSON(fields map[string]interface{}, prefix string, object map[string]interface{}, maxDepth, depth int) {
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		nested, isObject := value.(map[string]interface{})
+		switch {
+		case !isObject:
+			fields[key] = value
+		case maxDepth > 0 && depth >= maxDepth:
+			fields[key] = jsonString(nested)
+		case len(nested) == 0:
+			fields[key] = nested
+		default:
+			flattenJSON(fields, key, nested, maxDepth, depth+1)
+		}
+	}
+}
+
+// limitJSONDepth serializa como string JSON os objetos além de max_depth
+func limitJSONDepth(value interface{}, maxDepth, depth int) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	if maxDepth > 0 && depth > maxDepth {
+		return jsonString(object)
+	}
+	for key, item := range object {
+		object[key] = limitJSONDepth(item, maxDepth, depth+1)
+	}
+	return object
+}
+
+// jsonString converte um valor JSON em texto (objetos e listas em JSON compacto)
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+	return fmt.Sprint(value)
+}
+
+// parseTimestampValue interpreta strings (layout configurado ou formatos comuns)
+// e números epoch em s, ms, µs ou ns conforme a magnitude
+func parseTimestampValue(value interface{}, format string) (time.Time, error) {
+	var epoch float64
+	switch v := value.(type) {
+	case int64:
+		epoch = float64(v)
+	case float64:
+		epoch = v
+	case string:
+		if format != "" {
+			parsed, err := time.Parse(format, v)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", v, err)
+			}
+			return parsed, nil
+		}
+		if number, err := strconv.ParseFloat(v, 64); err == nil {
+			epoch = number
+			break
+		}
+		for _, layout := range getCommonTimestampFormats() {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", value)
+	}
+
+	abs := math.Abs(epoch)
+	switch {
+	case abs < 1e11:
+		sec, frac := math.Modf(epoch)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	case abs < 1e14:
+		return time.UnixMicro(int64(epoch * 1e3)).UTC(), nil
+	case abs < 1e17:
+		return time.UnixMicro(int64(epoch)).UTC(), nil
+	}
+	return time.Unix(0, int64(epoch)).UTC(), nil
+}
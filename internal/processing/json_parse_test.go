@@ -0,0 +1,153 @@
+package processing
+
+import (
+	"testing"
+	"time"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParse_MergeAndPromote(t *testing.T) {
+	entry := &types.LogEntry{
+		Message: `{"ts":"2024-05-01T10:00:00Z","level":"WARN","msg":"disk almost full","service":"api","http":{"status":507,"latency":0.25},"id":9007199254740993}`,
+		Labels:  map[string]string{"container_name": "api-1"},
+	}
+
+	processor, err := NewJSONParseProcessor(map[string]interface{}{
+		"level":     "level",
+		"timestamp": "ts",
+		"message":   "msg",
+		"labels":    []interface{}{"service"},
+	})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+
+	assert.Equal(t, "disk almost full", result.Message)
+	assert.Equal(t, "warn", result.Level)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), result.Timestamp.UTC())
+	assert.Equal(t, "api", result.Labels["service"])
+	assert.Equal(t, map[string]interface{}{"status": int64(507), "latency": 0.25}, result.Fields["http"])
+	assert.Equal(t, int64(9007199254740993), result.Fields["id"], "integers keep full precision")
+	assert.NotContains(t, result.Fields, "service", "promoted keys are not duplicated")
+	assert.NotContains(t, result.Fields, "msg")
+	assert.Equal(t, entry.Message, result.Fields["original_message"])
+	assert.Empty(t, entry.Fields, "input entry is not modified")
+}
+
+func TestJSONParse_FlattenTargetDepth(t *testing.T) {
+	entry := &types.LogEntry{Message: `{"a":{"b":{"c":1,"d":{"e":true}}},"list":[1,2],"empty":{}}`}
+
+	processor, err := NewJSONParseProcessor(map[string]interface{}{"flatten": true, "target": "json", "max_depth": 3})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, int64(1), result.Fields["json.a.b.c"])
+	assert.Equal(t, `{"e":true}`, result.Fields["json.a.b.d"])
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, result.Fields["json.list"])
+	assert.Equal(t, map[string]interface{}{}, result.Fields["json.empty"])
+	assert.Equal(t, entry.Message, result.Message)
+
+	processor, err = NewJSONParseProcessor(map[string]interface{}{"target": "json", "max_depth": 1})
+	require.NoError(t, err)
+	result = runStep(t, processor, entry)
+	require.IsType(t, map[string]interface{}{}, result.Fields["json"])
+	assert.Equal(t, `{"b":{"c":1,"d":{"e":true}}}`, result.Fields["json"].(map[string]interface{})["a"])
+}
+
+func TestJSONParse_PrefixAndDropOriginal(t *testing.T) {
+	entry := &types.LogEntry{Message: `2024-05-01 INFO request done json={"user":"ana","nested":{"k":"v"}} trailing`}
+
+	processor, err := NewJSONParseProcessor(map[string]interface{}{
+		"prefix":        "json=",
+		"keep_original": false,
+		"flatten":       true,
+		"labels":        map[interface{}]interface{}{"nested.k": "kind"},
+	})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, "2024-05-01 INFO request done", result.Message)
+	assert.Equal(t, "ana", result.Fields["user"])
+	assert.Equal(t, "v", result.Labels["kind"])
+	assert.NotContains(t, result.Fields, "nested")
+
+	// Sem o prefixo a entrada não é alterada nem marcada
+	plain := &types.LogEntry{Message: "no json here"}
+	processor, err = NewJSONParseProcessor(map[string]interface{}{"prefix": "json="})
+	require.NoError(t, err)
+	assert.Same(t, plain, runStep(t, processor, plain))
+
+	// Origem em label é removida com keep_original: false
+	labeled := &types.LogEntry{Message: "x", Labels: map[string]string{"payload": `{"a":"b"}`}}
+	processor, err = NewJSONParseProcessor(map[string]interface{}{"field": "labels.payload", "keep_original": false})
+	require.NoError(t, err)
+	result = runStep(t, processor, labeled)
+	assert.Equal(t, "b", result.Fields["a"])
+	assert.NotContains(t, result.Labels, "payload")
+	assert.Equal(t, "x", result.Message)
+}
+
+func TestJSONParse_FailuresAreTagged(t *testing.T) {
+	cases := []struct {
+		name       string
+		config     map[string]interface{}
+		message    string
+		fields     map[string]interface{} // Fields esperados além de parse_error
+		parseError string                 // Trecho esperado em parse_error
+	}{
+		{"invalid JSON", map[string]interface{}{}, `{"broken":`, map[string]interface{}{}, "invalid JSON"},
+		{"not an object", map[string]interface{}{}, `[1,2]`, map[string]interface{}{}, "object"},
+		{
+			"unparsed timestamp", map[string]interface{}{"timestamp": "ts"}, `{"ts":"yesterday","a":1}`,
+			map[string]interface{}{"ts": "yesterday", "a": int64(1)}, "invalid timestamp",
+		},
+		// Caminho aninhado que não converte continua no lugar original
+		{
+			"unparsed nested timestamp", map[string]interface{}{"timestamp": "meta.ts"}, `{"meta":{"ts":"yesterday"}}`,
+			map[string]interface{}{"meta": map[string]interface{}{"ts": "yesterday"}}, "invalid timestamp",
+		},
+		{
+			"unparsed nested timestamp flattened", map[string]interface{}{"timestamp": "meta.ts", "flatten": true}, `{"meta":{"ts":"yesterday","host":"a"}}`,
+			map[string]interface{}{"meta.ts": "yesterday", "meta.host": "a"}, "invalid timestamp",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			processor, err := NewJSONParseProcessor(tc.config)
+			require.NoError(t, err)
+			result := runStep(t, processor, &types.LogEntry{Message: tc.message})
+
+			assert.Equal(t, "json_parse", result.Labels[LabelParseFailure])
+			assert.Equal(t, tc.message, result.Message)
+			assert.Contains(t, result.Fields[FieldParseError], tc.parseError)
+			delete(result.Fields, FieldParseError)
+			assert.Equal(t, tc.fields, result.Fields)
+		})
+	}
+}
+
+func TestParseTimestampValue_Epoch(t *testing.T) {
+	expected := time.Date(2024, 5, 1, 10, 0, 0, 500000000, time.UTC)
+	for _, value := range []interface{}{1714557600.5, int64(1714557600500), int64(1714557600500000), int64(1714557600500000000), "1714557600.5"} {
+		parsed, err := parseTimestampValue(value, "")
+		require.NoError(t, err, value)
+		assert.True(t, expected.Equal(parsed), "%v -> %v", value, parsed)
+	}
+
+	parsed, err := parseTimestampValue("01/05/2024 10:00", "02/01/2006 15:04")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), parsed)
+}
+
+func TestNewJSONParseProcessor_InvalidConfig(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{"flatten": "yes"},
+		{"max_depth": -1},
+		{"labels": 3},
+		{"prefix": 1},
+	} {
+		_, err := NewJSONParseProcessor(config)
+		assert.Error(t, err, config)
+	}
+}
@@ -0,0 +1,209 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// KVParseProcessor extrai pares chave=valor (logfmt por padrão) para Fields.
+// Tokens sem separador de valor são ignorados.
+type KVParseProcessor struct {
+	Field          string          // Origem: message, labels.X, fields.X ou nome solto
+	PairSeparator  string          // Separador entre pares ("" = espaços)
+	ValueSeparator string          // Separador entre chave e valor
+	QuoteChars     string          // Aspas aceitas em chaves e valores
+	Prefix         string          // Prefixo adicionado às chaves gravadas
+	IncludeKeys    map[string]bool // Se definido, só essas chaves são gravadas
+	ExcludeKeys    map[string]bool // Chaves ignoradas
+	InferTypes     bool            // Converte valores em bool, int64 ou float64
+	TagOnFailure   bool            // Marca a entrada quando nenhum par é encontrado
+
+	source stepSource
+}
+
+func NewKVParseProcessor(config map[string]interface{}) (*KVParseProcessor, error) {
+	processor := &KVParseProcessor{}
+	var err error
+
+	options := []struct {
+		key   string
+		dest  *string
+		value string
+	}{
+		{"field", &processor.Field, "message"},
+		{"pair_separator", &processor.PairSeparator, ""},
+		{"value_separator", &processor.ValueSeparator, "="},
+		{"quote_chars", &processor.QuoteChars, `"'`},
+		{"prefix", &processor.Prefix, ""},
+	}
+	for _, option := range options {
+		if *option.dest, err = configString(config, option.key, option.value); err != nil {
+			return nil, fmt.Errorf("kv_parse: %w", err)
+		}
+	}
+	if processor.ValueSeparator == "" {
+		return nil, fmt.Errorf("kv_parse: value_separator must not be empty")
+	}
+	if strings.TrimSpace(processor.PairSeparator) == "" {
+		processor.PairSeparator = ""
+	}
+
+	if processor.InferTypes, err = configBool(config, "infer_types", false); err != nil {
+		return nil, fmt.Errorf("kv_parse: %w", err)
+	}
+	if processor.TagOnFailure, err = configBool(config, "tag_on_failure", true); err != nil {
+		return nil, fmt.Errorf("kv_parse: %w", err)
+	}
+
+	for key, dest := range map[string]*map[string]bool{"include_keys": &processor.IncludeKeys, "exclude_keys": &processor.ExcludeKeys} {
+		keys, err := configStringList(config, key)
+		if err != nil {
+			return nil, fmt.Errorf("kv_parse: %w", err)
+		}
+		if len(keys) > 0 {
+			*dest = make(map[string]bool, len(keys))
+			for _, k := range keys {
+				(*dest)[k] = true
+			}
+		}
+	}
+
+	processor.source = parseStepSource(processor.Field)
+	return processor, nil
+}
+
+func (kvp *KVParseProcessor) Process(ctx context.Context, entry *types.LogEntry) (*types.LogEntry, error) {
+	raw, ok := kvp.source.read(entry)
+	if !ok {
+		return entry, nil
+	}
+
+	pairs := kvp.parse(raw)
+	if len(pairs) == 0 {
+		if !kvp.TagOnFailure {
+			return entry, nil
+		}
+		newEntry := entry.DeepCopy()
+		tagParseFailure(newEntry, kvp.GetType(), fmt.Errorf("no key/value pairs found"))
+		return newEntry, nil
+	}
+
+	newEntry := entry.DeepCopy()
+	if newEntry.Fields == nil {
+		newEntry.Fields = make(map[string]interface{})
+	}
+	for _, pair := range pairs {
+		if kvp.IncludeKeys != nil && !kvp.IncludeKeys[pair[0]] {
+			continue
+		}
+		if kvp.ExcludeKeys[pair[0]] {
+			continue
+		}
+		var value interface{} = pair[1]
+		if kvp.InferTypes {
+			value = inferValue(pair[1])
+		}
+		newEntry.Fields[kvp.Prefix+pair[0]] = value
+	}
+	return newEntry, nil
+}
+
+func (kvp *KVParseProcessor) GetType() string {
+	return "kv_parse"
+}
+
+// parse percorre o texto e retorna os pares [chave, valor] na ordem em que aparecem
+func (kvp *KVParseProcessor) parse(raw string) [][2]string {
+	var pairs [][2]string
+	for pos := 0; pos < len(raw); {
+		pos = kvp.skipPairSeparators(raw, pos)
+		if pos >= len(raw) {
+			break
+		}
+
+		key, next := kvp.readToken(raw, pos, true)
+		if !strings.HasPrefix(raw[next:], kvp.ValueSeparator) {
+			// Token solto (texto livre): avança até o próximo separador de par
+			if next == pos {
+				next++
+			}
+			pos = next
+			continue
+		}
+
+		value, end := kvp.readToken(raw, next+len(kvp.ValueSeparator), false)
+		if key = strings.TrimSpace(key); key != "" {
+			pairs = append(pairs, [2]string{key, value})
+		}
+		pos = end
+	}
+	return pairs
+}
+
+// skipPairSeparators pula separadores de par e espaços
+func (kvp *KVParseProcessor) skipPairSeparators(raw string, pos int) int {
+	for pos < len(raw) {
+		r, size := utf8.DecodeRuneInString(raw[pos:])
+		switch {
+		case kvp.PairSeparator != "" && strings.HasPrefix(raw[pos:], kvp.PairSeparator):
+			pos += len(kvp.PairSeparator)
+		case unicode.IsSpace(r):
+			pos += size
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// readToken lê uma chave (até o separador de valor) ou um valor (até o
+// separador de par), tratando aspas com escapes \x
+func (kvp *KVParseProcessor) readToken(raw string, pos int, isKey bool) (string, int) {
+	// Com separador explícito, espaços ao redor de chaves e valores não fazem parte deles
+	for kvp.PairSeparator != "" && pos < len(raw) && raw[pos] == ' ' {
+		pos++
+	}
+	if pos < len(raw) && strings.IndexByte(kvp.QuoteChars, raw[pos]) >= 0 {
+		quote := raw[pos]
+		var b strings.Builder
+		for i := pos + 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '\\':
+				if i+1 < len(raw) {
+					i++
+					b.WriteByte(raw[i])
+					continue
+				}
+			case quote:
+				return b.String(), i + 1
+			}
+			b.WriteByte(raw[i])
+		}
+		// Aspas sem fechamento: o restante do texto é o valor
+		return b.String(), len(raw)
+	}
+
+	end := pos
+	for end < len(raw) {
+		if kvp.atPairSeparator(raw, end) || (isKey && strings.HasPrefix(raw[end:], kvp.ValueSeparator)) {
+			break
+		}
+		end++
+	}
+	return strings.TrimSpace(raw[pos:end]), end
+}
+
+// atPairSeparator indica se há um separador de par na posição
+func (kvp *KVParseProcessor) atPairSeparator(raw string, pos int) bool {
+	if kvp.PairSeparator == "" {
+		// Decodificar a runa: bytes de continuação como 0x85 e 0xA0 (Å, à) não são espaço
+		r, _ := utf8.DecodeRuneInString(raw[pos:])
+		return unicode.IsSpace(r)
+	}
+	return strings.HasPrefix(raw[pos:], kvp.PairSeparator)
+}
@@ -0,0 +1,89 @@
+package processing
+
+import (
+	"testing"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVParse_Logfmt(t *testing.T) {
+	entry := &types.LogEntry{Message: `time=2024-05-01T10:00:00Z level=info msg="request done" path=/api status=200 took=0.25 cached=false zip=01310 quote="say \"hi\"" empty= dangling`}
+
+	processor, err := NewKVParseProcessor(map[string]interface{}{"infer_types": true})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, "2024-05-01T10:00:00Z", result.Fields["time"])
+	assert.Equal(t, "request done", result.Fields["msg"])
+	assert.Equal(t, "/api", result.Fields["path"])
+	assert.Equal(t, int64(200), result.Fields["status"])
+	assert.Equal(t, 0.25, result.Fields["took"])
+	assert.Equal(t, false, result.Fields["cached"])
+	assert.Equal(t, "01310", result.Fields["zip"], "leading zeros stay text")
+	assert.Equal(t, `say "hi"`, result.Fields["quote"])
+	assert.Equal(t, "", result.Fields["empty"])
+	assert.NotContains(t, result.Fields, "dangling")
+	assert.Empty(t, entry.Fields, "input entry is not modified")
+
+	processor, err = NewKVParseProcessor(map[string]interface{}{})
+	require.NoError(t, err)
+	result = runStep(t, processor, entry)
+	assert.Equal(t, "200", result.Fields["status"], "no inference by default")
+}
+
+func TestKVParse_NonASCIIValues(t *testing.T) {
+	// "à" (C3 A0) e "Å" (C3 85) têm bytes de continuação que, lidos soltos, seriam espaço
+	entry := &types.LogEntry{Message: "msg=àtoa user=Åsa cidade=São Paulo"}
+
+	processor, err := NewKVParseProcessor(map[string]interface{}{})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, map[string]interface{}{"msg": "àtoa", "user": "Åsa", "cidade": "São"}, result.Fields)
+}
+
+func TestKVParse_SeparatorsAndKeys(t *testing.T) {
+	entry := &types.LogEntry{Message: `user: 'ana maria'; id: 42 ; role:admin;token: secret`}
+
+	processor, err := NewKVParseProcessor(map[string]interface{}{
+		"pair_separator":  ";",
+		"value_separator": ":",
+		"prefix":          "kv.",
+		"exclude_keys":    []interface{}{"token"},
+	})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, map[string]interface{}{"kv.user": "ana maria", "kv.id": "42", "kv.role": "admin"}, result.Fields)
+
+	processor, err = NewKVParseProcessor(map[string]interface{}{
+		"pair_separator":  ";",
+		"value_separator": ":",
+		"include_keys":    "role",
+	})
+	require.NoError(t, err)
+	result = runStep(t, processor, entry)
+	assert.Equal(t, map[string]interface{}{"role": "admin"}, result.Fields)
+
+	query := &types.LogEntry{Message: "GET", Labels: map[string]string{"query": "a=1&b=two&c"}}
+	processor, err = NewKVParseProcessor(map[string]interface{}{"field": "labels.query", "pair_separator": "&", "infer_types": true})
+	require.NoError(t, err)
+	result = runStep(t, processor, query)
+	assert.Equal(t, map[string]interface{}{"a": int64(1), "b": "two"}, result.Fields)
+}
+
+func TestKVParse_NoPairs(t *testing.T) {
+	entry := &types.LogEntry{Message: "plain text line"}
+
+	processor, err := NewKVParseProcessor(map[string]interface{}{})
+	require.NoError(t, err)
+	result := runStep(t, processor, entry)
+	assert.Equal(t, "kv_parse", result.Labels[LabelParseFailure])
+
+	processor, err = NewKVParseProcessor(map[string]interface{}{"tag_on_failure": false})
+	require.NoError(t, err)
+	assert.Same(t, entry, runStep(t, processor, entry))
+
+	_, err = NewKVParseProcessor(map[string]interface{}{"value_separator": ""})
+	assert.Error(t, err)
+}
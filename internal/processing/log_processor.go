@@ -20,9 +20,12 @@ import (
 type LogProcessor struct {
 	config        types.PipelineConfig
 	pipelines     map[string]*Pipeline
+	pipelineOrder []string        // Ordem dos pipelines no arquivo
 	sourceMapping map[string][]string
+	rules         []*pipelineRule // Regras de seleção ordenadas por prioridade (first match wins)
+	grokPatterns  map[string]string // Padrões grok compartilhados entre os pipelines
 	logger        *logrus.Logger
-	mutex         sync.RWMutex // Protege pipelines e sourceMapping
+	mutex         sync.RWMutex // Protege pipelines, sourceMapping e rules
 }
 
 // Pipeline representa um pipeline de processamento
@@ -31,7 +34,11 @@ type Pipeline struct {
 	Description  string              `yaml:"description"`
 	Steps        []ProcessingStep    `yaml:"steps"`
 	SourceMap    map[string][]string `yaml:"source_mapping"`
+	Condition    string              `yaml:"condition,omitempty"` // Expressão que seleciona o pipeline
+	Priority     int                 `yaml:"priority,omitempty"`  // Regras de maior prioridade são avaliadas primeiro
+	Selectors    []PipelineSelector  `yaml:"selectors,omitempty"` // Seletores de entradas (OR entre seletores)
 	compiledSteps []CompiledStep
+	condition     *Condition
 }
 
 // ProcessingStep representa um passo de processamento
@@ -46,7 +53,7 @@ type ProcessingStep struct {
 type CompiledStep struct {
 	Step      ProcessingStep
 	Processor StepProcessor
-	Condition *regexp.Regexp
+	Condition *Condition
 }
 
 // StepProcessor interface para processadores de steps
@@ -59,6 +66,7 @@ type StepProcessor interface {
 type PipelineConfig struct {
 	Pipelines     []Pipeline            `yaml:"pipelines"`
 	SourceMapping map[string][]string   `yaml:"source_mapping"`
+	GrokPatterns  map[string]string     `yaml:"grok_patterns"` // Padrões grok customizados (NOME: regex)
 }
 
 // NewLogProcessor cria um novo processador de logs
@@ -91,6 +99,8 @@ func (lp *LogProcessor) loadPipelines() error {
 		return fmt.Errorf("failed to parse pipeline config: %w", err)
 	}
 
+	lp.grokPatterns = config.GrokPatterns
+
 	// Compilar pipelines
 	for _, pipeline := range config.Pipelines {
 		compiled, err := lp.compilePipeline(pipeline)
@@ -98,11 +108,23 @@ func (lp *LogProcessor) loadPipelines() error {
 			return fmt.Errorf("failed to compile pipeline %s: %w", pipeline.Name, err)
 		}
 		lp.pipelines[pipeline.Name] = compiled
+		lp.pipelineOrder = append(lp.pipelineOrder, pipeline.Name)
 	}
 
 	// Armazenar source mapping
 	lp.sourceMapping = config.SourceMapping
 
+	// Montar regras de seleção na ordem do arquivo
+	mappingOrder, err := sourceMappingOrder(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse source_mapping: %w", err)
+	}
+	rules, err := lp.buildPipelineRules(lp.pipelineOrder, lp.sourceMapping, mappingOrder)
+	if err != nil {
+		return fmt.Errorf("failed to build pipeline selectors: %w", err)
+	}
+	lp.rules = rules
+
 	lp.logger.WithField("pipelines", len(lp.pipelines)).Info("Pipelines loaded successfully")
 	return nil
 }
@@ -114,9 +136,21 @@ func (lp *LogProcessor) compilePipeline(pipeline Pipeline) (*Pipeline, error) {
 		Description:   pipeline.Description,
 		Steps:         pipeline.Steps,
 		SourceMap:     pipeline.SourceMap,
+		Condition:     pipeline.Condition,
+		Priority:      pipeline.Priority,
+		Selectors:     pipeline.Selectors,
 		compiledSteps: make([]CompiledStep, 0, len(pipeline.Steps)),
 	}
 
+	if pipeline.Condition != "" {
+		condition, legacy, err := compileStepCondition(pipeline.Condition)
+		if err != nil {
+			return nil, err
+		}
+		lp.warnLegacyCondition(pipeline.Name, "", condition, legacy)
+		compiled.condition = condition
+	}
+
 	for _, step := range pipeline.Steps {
 		compiledStep, err := lp.compileStep(step)
 		if err != nil {
@@ -141,6 +175,12 @@ func (lp *LogProcessor) compileStep(step ProcessingStep) (CompiledStep, error) {
 		processor, err = NewTimestampParseProcessor(step.Config)
 	case "json_parse":
 		processor, err = NewJSONParseProcessor(step.Config)
+	case "grok":
+		processor, err = NewGrokProcessor(step.Config, lp.grokPatterns)
+	case "kv_parse":
+		processor, err = NewKVParseProcessor(step.Config)
+	case "csv_parse":
+		processor, err = NewCSVParseProcessor(step.Config)
 	case "field_add":
 		processor, err = NewFieldAddProcessor(step.Config)
 	case "field_remove":
@@ -162,16 +202,29 @@ func (lp *LogProcessor) compileStep(step ProcessingStep) (CompiledStep, error) {
 
 	// Compilar condição se existir
 	if step.Condition != "" {
-		regex, err := regexp.Compile(step.Condition)
+		condition, legacy, err := compileStepCondition(step.Condition)
 		if err != nil {
-			return CompiledStep{}, fmt.Errorf("failed to compile condition regex: %w", err)
+			return CompiledStep{}, fmt.Errorf("failed to compile condition: %w", err)
 		}
-		compiledStep.Condition = regex
+		lp.warnLegacyCondition("", step.Name, condition, legacy)
+		compiledStep.Condition = condition
 	}
 
 	return compiledStep, nil
 }
 
+// warnLegacyCondition avisa que uma condição foi interpretada como regex sobre message
+func (lp *LogProcessor) warnLegacyCondition(pipeline, step string, condition *Condition, legacy bool) {
+	if !legacy {
+		return
+	}
+	lp.logger.WithFields(logrus.Fields{
+		"pipeline":  pipeline,
+		"step":      step,
+		"condition": condition.String(),
+	}).Warn("Condition is a plain regex matched against message; use message =~ \"...\" instead")
+}
+
 // Process processa uma entrada de log
 func (lp *LogProcessor) Process(ctx context.Context, entry *types.LogEntry) (*types.LogEntry, error) {
 	if !lp.config.Enabled {
@@ -198,17 +251,10 @@ func (lp *LogProcessor) findPipeline(entry *types.LogEntry) *Pipeline {
 	lp.mutex.RLock()
 	defer lp.mutex.RUnlock()
 
-	// Verificar mapeamento global de sources para pipelines
-	for pipelineName, sourcePatterns := range lp.sourceMapping {
-		pipeline, exists := lp.pipelines[pipelineName]
-		if !exists {
-			continue
-		}
-
-		for _, pattern := range sourcePatterns {
-			if lp.matchesSource(entry, pattern) {
-				return pipeline
-			}
+	// Regras ordenadas por prioridade; a primeira que casar vence
+	for _, rule := range lp.rules {
+		if matched, _ := rule.evaluate(entry); matched {
+			return rule.pipeline
 		}
 	}
 
@@ -220,29 +266,6 @@ func (lp *LogProcessor) findPipeline(entry *types.LogEntry) *Pipeline {
 	return nil
 }
 
-// matchesSource verifica se a entrada corresponde ao padrão de source
-func (lp *LogProcessor) matchesSource(entry *types.LogEntry, pattern string) bool {
-	// Implementar matching baseado em source_type, source_id, etc.
-	if strings.Contains(pattern, entry.SourceType) {
-		return true
-	}
-
-	// Thread-safe label access
-	if containerName, exists := entry.GetLabel("container_name"); exists {
-		if strings.Contains(pattern, containerName) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// matchesLabels verifica se a entrada corresponde aos labels do pipeline
-func (lp *LogProcessor) matchesLabels(entry *types.LogEntry, pipeline *Pipeline) bool {
-	// Implementar matching baseado em labels específicos
-	return false
-}
-
 // processThroughPipeline processa entrada através de um pipeline
 func (lp *LogProcessor) processThroughPipeline(ctx context.Context, entry *types.LogEntry, pipeline *Pipeline) (*types.LogEntry, error) {
 	startTime := time.Now()
@@ -258,7 +281,7 @@ func (lp *LogProcessor) processThroughPipeline(ctx context.Context, entry *types
 
 		// Verificar condição se existir
 		if compiledStep.Condition != nil {
-			if !compiledStep.Condition.MatchString(currentEntry.Message) {
+			if !compiledStep.Condition.Match(currentEntry) {
 				continue
 			}
 		}
@@ -536,31 +559,6 @@ func (tpp *TimestampParseProcessor) GetType() string {
 	return "timestamp_parse"
 }
 
-// JSONParseProcessor parseia JSON
-type JSONParseProcessor struct {
-	Field string
-}
-
-func NewJSONParseProcessor(config map[string]interface{}) (*JSONParseProcessor, error) {
-	field := "message"
-	if f, ok := config["field"].(string); ok {
-		field = f
-	}
-
-	return &JSONParseProcessor{
-		Field: field,
-	}, nil
-}
-
-func (jpp *JSONParseProcessor) Process(ctx context.Context, entry *types.LogEntry) (*types.LogEntry, error) {
-	// Implementação simplificada
-	return entry, nil
-}
-
-func (jpp *JSONParseProcessor) GetType() string {
-	return "json_parse"
-}
-
 // FieldAddProcessor adiciona campos
 type FieldAddProcessor struct {
 	Fields map[string]string
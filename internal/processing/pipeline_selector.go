@@ -0,0 +1,488 @@
+package processing
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ssw-logs-capture/pkg/types"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Origem de uma regra de seleção de pipeline
+const (
+	ruleOriginSelector      = "selector"
+	ruleOriginCondition     = "condition"
+	ruleOriginSourceMapping = "source_mapping"
+)
+
+// Motivo da escolha do pipeline em PipelineMatch
+const (
+	MatchReasonRule     = "rule"
+	MatchReasonDefault  = "default"
+	MatchReasonNone     = "none"
+	MatchReasonDisabled = "disabled"
+)
+
+// stringList aceita tanto um valor escalar quanto uma lista no YAML
+type stringList []string
+
+// UnmarshalYAML implementa yaml.Unmarshaler
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*l = stringList{single}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// PipelineSelector seleciona entradas para um pipeline. Critérios diferentes
+// são combinados com AND; valores de um mesmo critério, com OR.
+type PipelineSelector struct {
+	SourceType     stringList `yaml:"source_type,omitempty"`     // Globs sobre source_type
+	SourceID       stringList `yaml:"source_id,omitempty"`       // Globs sobre source_id
+	ContainerName  stringList `yaml:"container_name,omitempty"`  // Globs sobre o label container_name
+	ContainerImage stringList `yaml:"container_image,omitempty"` // Globs sobre o label image (com ou sem tag)
+	FilePath       stringList `yaml:"file_path,omitempty"`       // Globs com ** sobre o label file_path
+	Labels         stringList `yaml:"labels,omitempty"`          // key=value, key!=value, key=~regex, key!~regex
+}
+
+// selectorCriterion é um critério compilado de um seletor
+type selectorCriterion struct {
+	description string
+	match       func(entry *types.LogEntry) bool
+}
+
+// pipelineRule é uma regra ordenada que direciona entradas para um pipeline
+type pipelineRule struct {
+	pipeline    *Pipeline
+	priority    int
+	origin      string
+	description string
+	criteria    []selectorCriterion
+	condition   *Condition
+}
+
+// PipelineRuleResult descreve a avaliação de uma regra para uma entrada
+type PipelineRuleResult struct {
+	Pipeline string `json:"pipeline"`
+	Priority int    `json:"priority"`
+	Origin   string `json:"origin"`
+	Selector string `json:"selector"`
+	Matched  bool   `json:"matched"`
+	Failed   string `json:"failed,omitempty"` // Primeiro critério que não casou
+}
+
+// PipelineMatch explica qual pipeline uma entrada usaria e por quê
+type PipelineMatch struct {
+	Pipeline  string               `json:"pipeline,omitempty"`
+	Reason    string               `json:"reason"`
+	Rule      *PipelineRuleResult  `json:"rule,omitempty"`
+	Evaluated []PipelineRuleResult `json:"evaluated"`
+}
+
+// evaluate verifica a regra e retorna o primeiro critério que falhou
+func (r *pipelineRule) evaluate(entry *types.LogEntry) (bool, string) {
+	for _, criterion := range r.criteria {
+		if !criterion.match(entry) {
+			return false, criterion.description
+		}
+	}
+	if r.condition != nil && !r.condition.Match(entry) {
+		return false, "condition: " + r.condition.String()
+	}
+	return true, ""
+}
+
+// result converte a avaliação da regra para a resposta de explicação
+func (r *pipelineRule) result(matched bool, failed string) PipelineRuleResult {
+	return PipelineRuleResult{
+		Pipeline: r.pipeline.Name,
+		Priority: r.priority,
+		Origin:   r.origin,
+		Selector: r.description,
+		Matched:  matched,
+		Failed:   failed,
+	}
+}
+
+// newPipelineRule compila um seletor em regra, combinando a condition do pipeline
+func newPipelineRule(pipeline *Pipeline, selector *PipelineSelector, origin string) (*pipelineRule, error) {
+	rule := &pipelineRule{
+		pipeline:  pipeline,
+		priority:  pipeline.Priority,
+		origin:    origin,
+		condition: pipeline.condition,
+	}
+	if origin == ruleOriginSourceMapping {
+		rule.condition = nil
+	}
+
+	if selector != nil {
+		criteria, err := compileSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		rule.criteria = criteria
+	}
+	if len(rule.criteria) == 0 && rule.condition == nil {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	descriptions := make([]string, 0, len(rule.criteria)+1)
+	for _, criterion := range rule.criteria {
+		descriptions = append(descriptions, criterion.description)
+	}
+	if rule.condition != nil {
+		descriptions = append(descriptions, "condition: "+rule.condition.String())
+	}
+	rule.description = strings.Join(descriptions, " && ")
+	return rule, nil
+}
+
+// compileSelector compila os critérios de um seletor na ordem de avaliação
+func compileSelector(selector *PipelineSelector) ([]selectorCriterion, error) {
+	var criteria []selectorCriterion
+
+	globs := []struct {
+		name     string
+		patterns stringList
+		path     bool
+		value    func(entry *types.LogEntry) []string
+	}{
+		{"source_type", selector.SourceType, false, func(entry *types.LogEntry) []string { return []string{entry.SourceType} }},
+		{"source_id", selector.SourceID, false, func(entry *types.LogEntry) []string { return []string{entry.SourceID} }},
+		{"container_name", selector.ContainerName, false, containerNameValues},
+		{"container_image", selector.ContainerImage, false, containerImageValues},
+		{"file_path", selector.FilePath, true, labelValues("file_path")},
+	}
+	for _, g := range globs {
+		if len(g.patterns) == 0 {
+			continue
+		}
+		criterion, err := globCriterion(g.name, g.patterns, g.path, g.value)
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, criterion)
+	}
+
+	for _, expression := range selector.Labels {
+		criterion, err := parseLabelSelector(expression)
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, criterion)
+	}
+	return criteria, nil
+}
+
+// globCriterion cria um critério que casa quando qualquer glob casa com qualquer valor
+func globCriterion(name string, patterns []string, path bool, values func(entry *types.LogEntry) []string) (selectorCriterion, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compileGlob(pattern, path)
+		if err != nil {
+			return selectorCriterion{}, fmt.Errorf("invalid %s glob %q: %w", name, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	return selectorCriterion{
+		description: name + " in [" + strings.Join(patterns, ", ") + "]",
+		match: func(entry *types.LogEntry) bool {
+			for _, value := range values(entry) {
+				for _, re := range compiled {
+					if re.MatchString(value) {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+// labelValues retorna o valor do label, se presente
+func labelValues(key string) func(entry *types.LogEntry) []string {
+	return func(entry *types.LogEntry) []string {
+		if value, ok := entry.GetLabel(key); ok {
+			return []string{value}
+		}
+		return nil
+	}
+}
+
+// containerNameValues retorna o nome do container sem a barra inicial do Docker
+func containerNameValues(entry *types.LogEntry) []string {
+	if name, ok := entry.GetLabel("container_name"); ok {
+		return []string{strings.TrimPrefix(name, "/")}
+	}
+	return nil
+}
+
+// containerImageValues retorna a imagem com e sem tag/digest ("nginx:1.25" casa com "nginx")
+func containerImageValues(entry *types.LogEntry) []string {
+	image, ok := entry.GetLabel("image")
+	if !ok {
+		return nil
+	}
+	values := []string{image}
+	repository := image
+	if at := strings.Index(repository, "@"); at >= 0 {
+		repository = repository[:at]
+	}
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository = repository[:colon]
+	}
+	if repository != image {
+		values = append(values, repository)
+	}
+	return values
+}
+
+// compileGlob converte um glob em regex ancorada. Com path, * e ? não cruzam
+// "/" e ** casa qualquer quantidade de diretórios.
+func compileGlob(pattern string, path bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if !path {
+				b.WriteString(".*")
+			} else if strings.HasPrefix(pattern[i:], "**/") {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			if path {
+				b.WriteString("[^/]")
+			} else {
+				b.WriteString(".")
+			}
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// parseLabelSelector compila key=value, key!=value, key=~regex ou key!~regex.
+// Label ausente só casa com != e !~.
+func parseLabelSelector(expression string) (selectorCriterion, error) {
+	index := strings.IndexAny(expression, "=!")
+	if index <= 0 {
+		return selectorCriterion{}, fmt.Errorf("invalid label selector %q: expected key=value, key!=value or key=~regex", expression)
+	}
+	key := strings.TrimSpace(expression[:index])
+	rest := expression[index:]
+
+	var operator string
+	for _, op := range []string{"=~", "!~", "!=", "=="} {
+		if strings.HasPrefix(rest, op) {
+			operator = op
+			break
+		}
+	}
+	if operator == "" {
+		if rest[0] != '=' {
+			return selectorCriterion{}, fmt.Errorf("invalid label selector %q", expression)
+		}
+		operator = "="
+	}
+	value := strings.TrimSpace(rest[len(operator):])
+	if operator == "==" {
+		operator = "="
+	}
+
+	criterion := selectorCriterion{description: "labels." + key + " " + operator + " " + value}
+	switch operator {
+	case "=", "!=":
+		negate := operator == "!="
+		criterion.match = func(entry *types.LogEntry) bool {
+			actual, ok := entry.GetLabel(key)
+			return (ok && actual == value) != negate
+		}
+	default:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return selectorCriterion{}, fmt.Errorf("invalid regex in label selector %q: %w", expression, err)
+		}
+		negate := operator == "!~"
+		criterion.match = func(entry *types.LogEntry) bool {
+			actual, ok := entry.GetLabel(key)
+			return (ok && re.MatchString(actual)) != negate
+		}
+	}
+	return criterion, nil
+}
+
+// legacySelectors converte um padrão de source_mapping ("container_name:mysql",
+// "source_type=file", "env=prod" ou um nome solto) em seletores exatos
+func legacySelectors(pattern string) ([]PipelineSelector, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("empty source_mapping pattern")
+	}
+
+	key, value, found := strings.Cut(pattern, ":")
+	if !found || strings.ContainsAny(key, "=!~ ") {
+		key, value, found = strings.Cut(pattern, "=")
+		if !found || strings.ContainsAny(key, "!") || strings.HasPrefix(value, "~") {
+			if strings.ContainsAny(pattern, "=!") {
+				return []PipelineSelector{{Labels: stringList{pattern}}}, nil
+			}
+			// Nome solto: tipo da fonte ou nome do container
+			return []PipelineSelector{
+				{SourceType: stringList{pattern}},
+				{ContainerName: stringList{pattern}},
+			}, nil
+		}
+	}
+
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	switch key {
+	case "source_type":
+		return []PipelineSelector{{SourceType: stringList{value}}}, nil
+	case "source_id":
+		return []PipelineSelector{{SourceID: stringList{value}}}, nil
+	case "container_name":
+		return []PipelineSelector{{ContainerName: stringList{value}}}, nil
+	case "container_image", "image":
+		return []PipelineSelector{{ContainerImage: stringList{value}}}, nil
+	case "file_path":
+		return []PipelineSelector{{FilePath: stringList{value}}}, nil
+	default:
+		return []PipelineSelector{{Labels: stringList{key + "=" + value}}}, nil
+	}
+}
+
+// sourceMappingOrder lê o source_mapping preservando a ordem do arquivo
+func sourceMappingOrder(data []byte) ([]string, error) {
+	var ordered struct {
+		SourceMapping yaml.MapSlice `yaml:"source_mapping"`
+	}
+	if err := yaml.Unmarshal(data, &ordered); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ordered.SourceMapping))
+	for _, item := range ordered.SourceMapping {
+		names = append(names, fmt.Sprint(item.Key))
+	}
+	return names, nil
+}
+
+// buildPipelineRules monta as regras ordenadas: seletores e conditions dos
+// pipelines na ordem do arquivo, depois o source_mapping, ordenados de forma
+// estável por prioridade decrescente
+func (lp *LogProcessor) buildPipelineRules(order []string, sourceMapping map[string][]string, mappingOrder []string) ([]*pipelineRule, error) {
+	var rules []*pipelineRule
+
+	for _, name := range order {
+		pipeline := lp.pipelines[name]
+		if len(pipeline.Selectors) == 0 {
+			if pipeline.condition != nil {
+				rule, err := newPipelineRule(pipeline, nil, ruleOriginCondition)
+				if err != nil {
+					return nil, fmt.Errorf("pipeline %s: %w", name, err)
+				}
+				rules = append(rules, rule)
+			}
+			continue
+		}
+		for i := range pipeline.Selectors {
+			rule, err := newPipelineRule(pipeline, &pipeline.Selectors[i], ruleOriginSelector)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline %s selector %d: %w", name, i, err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	for _, name := range mappingOrder {
+		pipeline, exists := lp.pipelines[name]
+		if !exists {
+			lp.logger.WithField("pipeline", name).Warn("source_mapping references unknown pipeline")
+			continue
+		}
+		for _, pattern := range sourceMapping[name] {
+			selectors, err := legacySelectors(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("source_mapping %s: %w", name, err)
+			}
+			for i := range selectors {
+				rule, err := newPipelineRule(pipeline, &selectors[i], ruleOriginSourceMapping)
+				if err != nil {
+					return nil, fmt.Errorf("source_mapping %s pattern %q: %w", name, pattern, err)
+				}
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].priority > rules[j].priority
+	})
+	return rules, nil
+}
+
+// MatchPipeline explica qual pipeline seria usado para a entrada, avaliando todas as regras
+func (lp *LogProcessor) MatchPipeline(entry *types.LogEntry) PipelineMatch {
+	match := PipelineMatch{Evaluated: []PipelineRuleResult{}}
+	if !lp.config.Enabled {
+		match.Reason = MatchReasonDisabled
+		return match
+	}
+
+	lp.mutex.RLock()
+	defer lp.mutex.RUnlock()
+
+	for _, rule := range lp.rules {
+		matched, failed := rule.evaluate(entry)
+		result := rule.result(matched, failed)
+		match.Evaluated = append(match.Evaluated, result)
+		if matched && match.Rule == nil {
+			match.Rule = &result
+			match.Pipeline = rule.pipeline.Name
+			match.Reason = MatchReasonRule
+		}
+	}
+	if match.Rule != nil {
+		return match
+	}
+
+	if _, exists := lp.pipelines["default"]; exists {
+		match.Pipeline = "default"
+		match.Reason = MatchReasonDefault
+	} else {
+		match.Reason = MatchReasonNone
+	}
+	return match
+}
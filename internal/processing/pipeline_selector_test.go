@@ -0,0 +1,198 @@
+package processing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectorPipelines = `
+pipelines:
+  - name: default
+    steps: []
+  - name: nginx
+    selectors:
+      - container_image: ["nginx", "*/nginx"]
+      - container_name: "web-*"
+        labels: ["env!=dev"]
+    steps: []
+  - name: payments
+    priority: 10
+    selectors:
+      - source_type: docker
+        labels: ["app=~^pay(ments)?$"]
+    steps: []
+  - name: app_files
+    selectors:
+      - file_path: "/var/log/app/**/*.log"
+    steps: []
+  - name: errors
+    condition: 'level == "error"'
+    steps: []
+  - name: mysql
+    steps: []
+  - name: syslog
+    steps: []
+source_mapping:
+  syslog:
+    - "file_path:/var/log/syslog"
+  mysql:
+    - "container_name:mysql"
+    - "mysql"
+`
+
+func newSelectorProcessor(t *testing.T, content string) *LogProcessor {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	processor, err := NewLogProcessor(types.PipelineConfig{Enabled: true, File: file}, logger)
+	require.NoError(t, err)
+	return processor
+}
+
+func newSelectorEntry(sourceType string, labels map[string]string) *types.LogEntry {
+	return &types.LogEntry{Message: "hello", SourceType: sourceType, SourceID: "abc", Labels: labels}
+}
+
+func TestFindPipeline_Selectors(t *testing.T) {
+	processor := newSelectorProcessor(t, selectorPipelines)
+
+	cases := []struct {
+		name  string
+		entry *types.LogEntry
+		want  string
+	}{
+		{"image without tag", newSelectorEntry("docker", map[string]string{"image": "nginx:1.25"}), "nginx"},
+		{"image with registry", newSelectorEntry("docker", map[string]string{"image": "docker.io/nginx:latest"}), "nginx"},
+		{"name glob and label", newSelectorEntry("docker", map[string]string{"container_name": "/web-1", "env": "prod"}), "nginx"},
+		{"label excluded", newSelectorEntry("docker", map[string]string{"container_name": "web-1", "env": "dev"}), "default"},
+		{"priority wins", newSelectorEntry("docker", map[string]string{"image": "nginx", "app": "payments"}), "payments"},
+		{"regex label", newSelectorEntry("docker", map[string]string{"app": "pay"}), "payments"},
+		{"regex anchored", newSelectorEntry("docker", map[string]string{"app": "paypal"}), "default"},
+		{"file glob", newSelectorEntry("file", map[string]string{"file_path": "/var/log/app/api/v1/out.log"}), "app_files"},
+		{"file glob root", newSelectorEntry("file", map[string]string{"file_path": "/var/log/app/out.log"}), "app_files"},
+		{"file glob extension", newSelectorEntry("file", map[string]string{"file_path": "/var/log/app/out.txt"}), "default"},
+		{"legacy file", newSelectorEntry("file", map[string]string{"file_path": "/var/log/syslog"}), "syslog"},
+		{"legacy container", newSelectorEntry("docker", map[string]string{"container_name": "mysql"}), "mysql"},
+		{"legacy bare name is exact", newSelectorEntry("docker", map[string]string{"container_name": "mysql-exporter"}), "default"},
+		{"legacy bare source type", newSelectorEntry("mysql", nil), "mysql"},
+		// "docker" não é mais substring de nenhum padrão legado
+		{"no substring match", newSelectorEntry("docker", map[string]string{"container_name": "my"}), "default"},
+	}
+
+	for _, c := range cases {
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, c.want, processor.findPipeline(c.entry).Name, c.name)
+		}
+	}
+
+	errorEntry := newSelectorEntry("docker", map[string]string{"image": "nginx"})
+	errorEntry.Level = "error"
+	assert.Equal(t, "nginx", processor.findPipeline(errorEntry).Name, "explicit selector comes before later condition")
+	errorEntry.Labels = map[string]string{"container_name": "mysql"}
+	assert.Equal(t, "errors", processor.findPipeline(errorEntry).Name, "pipeline rules come before source_mapping")
+}
+
+func TestMatchPipeline_Explain(t *testing.T) {
+	processor := newSelectorProcessor(t, selectorPipelines)
+
+	match := processor.MatchPipeline(newSelectorEntry("docker", map[string]string{"container_name": "web-1", "env": "prod"}))
+	assert.Equal(t, "nginx", match.Pipeline)
+	assert.Equal(t, MatchReasonRule, match.Reason)
+	require.NotNil(t, match.Rule)
+	assert.Equal(t, ruleOriginSelector, match.Rule.Origin)
+	assert.Equal(t, "container_name in [web-*] && labels.env != dev", match.Rule.Selector)
+
+	require.NotEmpty(t, match.Evaluated)
+	first := match.Evaluated[0]
+	assert.Equal(t, "payments", first.Pipeline)
+	assert.Equal(t, 10, first.Priority)
+	assert.False(t, first.Matched)
+	assert.Equal(t, "labels.app =~ ^pay(ments)?$", first.Failed)
+
+	match = processor.MatchPipeline(newSelectorEntry("journald", nil))
+	assert.Equal(t, "default", match.Pipeline)
+	assert.Equal(t, MatchReasonDefault, match.Reason)
+	assert.Nil(t, match.Rule)
+	for _, result := range match.Evaluated {
+		assert.False(t, result.Matched)
+		assert.NotEmpty(t, result.Failed)
+	}
+}
+
+func TestLogProcessor_SelectorErrors(t *testing.T) {
+	for _, content := range []string{
+		"pipelines:\n  - name: a\n    selectors:\n      - labels: [\"app\"]\n",
+		"pipelines:\n  - name: a\n    selectors:\n      - labels: [\"app=~(\"]\n",
+		"pipelines:\n  - name: a\n    selectors:\n      - file_path: \"/var/[log\"\n",
+		"pipelines:\n  - name: a\n    selectors:\n      - {}\n",
+	} {
+		file := filepath.Join(t.TempDir(), "pipelines.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		_, err := NewLogProcessor(types.PipelineConfig{Enabled: true, File: file}, logrus.New())
+		assert.Error(t, err, content)
+	}
+}
+
+func TestLogProcessor_ProcessUsesSelectedPipeline(t *testing.T) {
+	processor := newSelectorProcessor(t, `
+pipelines:
+  - name: default
+    steps:
+      - name: mark
+        type: field_add
+        config:
+          fields:
+            pipeline: default
+  - name: web
+    selectors:
+      - container_name: "web-*"
+    steps:
+      - name: mark
+        type: field_add
+        config:
+          fields:
+            pipeline: web
+`)
+
+	entry, err := processor.Process(context.Background(), newSelectorEntry("docker", map[string]string{"container_name": "web-a"}))
+	require.NoError(t, err)
+	assert.Equal(t, "web", entry.Labels["pipeline"])
+
+	entry, err = processor.Process(context.Background(), newSelectorEntry("docker", map[string]string{"container_name": "api"}))
+	require.NoError(t, err)
+	assert.Equal(t, "default", entry.Labels["pipeline"])
+}
+
+func TestCompileGlob(t *testing.T) {
+	cases := []struct {
+		pattern, value string
+		path, want     bool
+	}{
+		{"web-*", "web-frontend", false, true},
+		{"*nginx*", "registry.local/team/nginx:1", false, true},
+		{"web-?", "web-10", false, false},
+		{"web-[0-9]", "web-7", false, true},
+		{"web-[!0-9]", "web-7", false, false},
+		{"/var/log/*.log", "/var/log/a/b.log", true, false},
+		{"/var/log/**", "/var/log/a/b.log", true, true},
+		{"**/nginx/*.log", "/srv/nginx/access.log", true, true},
+		{"/var/log/a.log", "/var/log/a.log", true, true},
+		{"/var/log/a.log", "/var/log/aXlog", true, false},
+	}
+	for _, c := range cases {
+		re, err := compileGlob(c.pattern, c.path)
+		require.NoError(t, err, c.pattern)
+		assert.Equal(t, c.want, re.MatchString(c.value), "%s vs %s", c.pattern, c.value)
+	}
+}
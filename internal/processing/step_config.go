@@ -0,0 +1,224 @@
+package processing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ssw-logs-capture/pkg/types"
+)
+
+// Marcação de entradas cujo parse falhou (o pipeline continua)
+const (
+	LabelParseFailure = "parse_failure" // Tipos de step que falharam, separados por vírgula
+	FieldParseError   = "parse_error"   // Mensagem do último erro de parse
+)
+
+// configString lê uma opção string do config do step
+func configString(config map[string]interface{}, key, defaultValue string) (string, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string, got %T", key, value)
+	}
+	return s, nil
+}
+
+// configBool lê uma opção booleana do config do step
+func configBool(config map[string]interface{}, key string, defaultValue bool) (bool, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be a boolean, got %T", key, value)
+	}
+	return b, nil
+}
+
+// configInt lê uma opção inteira do config do step
+func configInt(config map[string]interface{}, key string, defaultValue int) (int, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%s must be an integer, got %v", key, value)
+}
+
+// configStringList lê uma string ou lista de strings do config do step
+func configStringList(config map[string]interface{}, key string) ([]string, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings, got item %T", key, item)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("%s must be a string or list of strings, got %T", key, value)
+}
+
+// configStringMap lê um mapa string→string do config do step. Uma lista
+// mapeia cada item para ele mesmo.
+func configStringMap(config map[string]interface{}, key string) (map[string]string, error) {
+	value, ok := config[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	result := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			result[k] = fmt.Sprint(item)
+		}
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			result[fmt.Sprint(k)] = fmt.Sprint(item)
+		}
+	default:
+		list, err := configStringList(config, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a map or list of strings, got %T", key, value)
+		}
+		for _, item := range list {
+			result[item] = item
+		}
+	}
+	return result, nil
+}
+
+// stepSource identifica de onde um step lê o texto: message, labels.X,
+// fields.X ou um nome solto (field, se existir, senão label)
+type stepSource struct {
+	kind string // "message", "labels", "fields" ou "" (solto)
+	name string
+}
+
+// parseStepSource interpreta a opção field/source de um step
+func parseStepSource(field string) stepSource {
+	switch {
+	case field == "" || field == "message":
+		return stepSource{kind: "message"}
+	case strings.HasPrefix(field, "labels."):
+		return stepSource{kind: "labels", name: strings.TrimPrefix(field, "labels.")}
+	case strings.HasPrefix(field, "fields."):
+		return stepSource{kind: "fields", name: strings.TrimPrefix(field, "fields.")}
+	}
+	return stepSource{name: field}
+}
+
+// isMessage indica se a fonte é a mensagem da entrada
+func (s stepSource) isMessage() bool {
+	return s.kind == "message"
+}
+
+// read retorna o texto da fonte, se presente
+func (s stepSource) read(entry *types.LogEntry) (string, bool) {
+	switch s.kind {
+	case "message":
+		return entry.Message, true
+	case "labels":
+		return entry.GetLabel(s.name)
+	}
+
+	if value, ok := entry.GetField(s.name); ok && value != nil {
+		if str, ok := value.(string); ok {
+			return str, true
+		}
+		return fmt.Sprint(value), true
+	}
+	if s.kind == "" {
+		return entry.GetLabel(s.name)
+	}
+	return "", false
+}
+
+// remove apaga a fonte da entrada (a mensagem fica vazia)
+func (s stepSource) remove(entry *types.LogEntry) {
+	switch s.kind {
+	case "message":
+		entry.Message = ""
+	case "labels":
+		delete(entry.Labels, s.name)
+	case "fields":
+		delete(entry.Fields, s.name)
+	default:
+		if _, ok := entry.Fields[s.name]; ok {
+			delete(entry.Fields, s.name)
+		} else {
+			delete(entry.Labels, s.name)
+		}
+	}
+}
+
+// tagParseFailure marca a entrada com o step que falhou, sem interromper o pipeline
+func tagParseFailure(entry *types.LogEntry, stepType string, err error) {
+	if entry.Labels == nil {
+		entry.Labels = make(map[string]string)
+	}
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]interface{})
+	}
+
+	failed := entry.Labels[LabelParseFailure]
+	if failed == "" {
+		failed = stepType
+	} else if !strings.Contains(","+failed+",", ","+stepType+",") {
+		failed += "," + stepType
+	}
+	entry.Labels[LabelParseFailure] = failed
+	entry.Fields[FieldParseError] = err.Error()
+}
+
+// inferValue converte texto em bool, int64 ou float64 quando possível. Números
+// com zero à esquerda (ex: CEP "01310") continuam texto.
+func inferValue(value string) interface{} {
+	switch strings.ToLower(value) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return value
+	}
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return value
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
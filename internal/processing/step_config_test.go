@@ -0,0 +1,18 @@
+package processing
+
+import (
+	"context"
+	"testing"
+
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// runStep executa um step sobre a entrada, exigindo que não haja erro
+func runStep(t *testing.T, processor StepProcessor, entry *types.LogEntry) *types.LogEntry {
+	t.Helper()
+	result, err := processor.Process(context.Background(), entry)
+	require.NoError(t, err)
+	return result
+}
@@ -0,0 +1,101 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+var (
+	// ErrDecompressedTooLarge is returned when decompressed data exceeds the caller's limit
+	ErrDecompressedTooLarge = errors.New("decompressed payload exceeds size limit")
+	// ErrUnsupportedContentEncoding is returned for Content-Encoding values without a decoder
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+)
+
+// AlgorithmFromContentEncoding maps an HTTP Content-Encoding value to an Algorithm.
+// An empty value or "identity" maps to AlgorithmNone.
+func AlgorithmFromContentEncoding(contentEncoding string) (Algorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return AlgorithmNone, nil
+	case "gzip", "x-gzip":
+		return AlgorithmGzip, nil
+	case "deflate":
+		return AlgorithmZlib, nil
+	case "zstd":
+		return AlgorithmZstd, nil
+	case "lz4":
+		return AlgorithmLZ4, nil
+	case "snappy":
+		return AlgorithmSnappy, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnsupportedContentEncoding, contentEncoding)
+	}
+}
+
+// DecompressLimited decompresses data using the specified algorithm, refusing
+// results larger than limit bytes. Unlike HTTPCompressor.Decompress it streams
+// the output, so a small compressed payload cannot expand without bound.
+func DecompressLimited(data []byte, algorithm Algorithm, limit int64) ([]byte, error) {
+	var reader io.Reader
+	switch algorithm {
+	case AlgorithmNone:
+		if int64(len(data)) > limit {
+			return nil, ErrDecompressedTooLarge
+		}
+		return data, nil
+	case AlgorithmGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip payload: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case AlgorithmZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zlib payload: %w", err)
+		}
+		defer zr.Close()
+		reader = zr
+	case AlgorithmZstd:
+		decoder, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd payload: %w", err)
+		}
+		defer decoder.Close()
+		reader = decoder
+	case AlgorithmLZ4:
+		reader = lz4.NewReader(bytes.NewReader(data))
+	case AlgorithmSnappy:
+		// Block format carries the decoded length up front
+		decodedLen, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snappy payload: %w", err)
+		}
+		if int64(decodedLen) > limit {
+			return nil, ErrDecompressedTooLarge
+		}
+		return snappy.Decode(nil, data)
+	default:
+		return nil, fmt.Errorf("unsupported decompression algorithm: %s", algorithm)
+	}
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", algorithm, err)
+	}
+	if int64(len(decoded)) > limit {
+		return nil, ErrDecompressedTooLarge
+	}
+	return decoded, nil
+}
@@ -0,0 +1,676 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Caminhos do service account montado nos pods (configuração in-cluster)
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// kubernetesWatchTimeout tempo máximo de cada requisição de watch; ao expirar o
+	// watch é reaberto a partir do último resourceVersion
+	kubernetesWatchTimeout = 5 * time.Minute
+	kubernetesListTimeout  = 30 * time.Second
+	kubernetesMaxBackoff   = 30 * time.Second
+)
+
+// errWatchExpired indica que o resourceVersion expirou (410 Gone) e é preciso refazer o list
+var errWatchExpired = errors.New("watch resource version expired")
+
+// KubernetesPod metadados de um pod mantidos no cache
+type KubernetesPod struct {
+	UID          string            `json:"uid"`
+	Name         string            `json:"name"`
+	Namespace    string            `json:"namespace"`
+	NodeName     string            `json:"node_name"`
+	Phase        string            `json:"phase"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	WorkloadKind string            `json:"workload_kind"` // Deployment, StatefulSet, DaemonSet, Job...
+	Workload     string            `json:"workload"`
+}
+
+// KubernetesWatcherStats estatísticas do watcher
+type KubernetesWatcherStats struct {
+	Pods          int       `json:"pods"`
+	Namespaces    int       `json:"namespaces"`
+	Synced        bool      `json:"synced"`
+	Lists         int64     `json:"lists"`
+	WatchEvents   int64     `json:"watch_events"`
+	Relists       int64     `json:"relists"`
+	ErrorCount    int64     `json:"error_count"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastEventTime time.Time `json:"last_event_time"`
+}
+
+// KubernetesPodWatcher mantém um cache local dos pods (e, se necessário, dos
+// namespaces) usando o protocolo list+watch da API do Kubernetes sobre HTTP.
+// O cache é usado para enriquecer as labels dos logs de pods.
+type KubernetesPodWatcher struct {
+	config  KubernetesDiscoveryConfig
+	logger  *logrus.Logger
+	client  *http.Client
+	baseURL string
+
+	mutex      sync.RWMutex
+	pods       map[string]*KubernetesPod    // Por UID
+	podsByName map[string]*KubernetesPod    // Por "<namespace>/<nome>"
+	namespaces map[string]map[string]string // Labels por namespace
+	synced     bool
+	stats      KubernetesWatcherStats
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// kubernetesResource descreve um recurso acompanhado via list+watch
+type kubernetesResource struct {
+	name    string
+	path    string
+	query   url.Values
+	replace func(items []json.RawMessage) error
+	apply   func(eventType string, object json.RawMessage) error
+}
+
+// k8sObjectMeta subconjunto de metav1.ObjectMeta usado pelo watcher
+type k8sObjectMeta struct {
+	Name            string              `json:"name"`
+	Namespace       string              `json:"namespace"`
+	UID             string              `json:"uid"`
+	ResourceVersion string              `json:"resourceVersion"`
+	Labels          map[string]string   `json:"labels"`
+	Annotations     map[string]string   `json:"annotations"`
+	OwnerReferences []k8sOwnerReference `json:"ownerReferences"`
+}
+
+// k8sOwnerReference referência ao controlador do objeto
+type k8sOwnerReference struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Controller *bool  `json:"controller"`
+}
+
+// k8sPod subconjunto de corev1.Pod
+type k8sPod struct {
+	Metadata k8sObjectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// k8sNamespace subconjunto de corev1.Namespace
+type k8sNamespace struct {
+	Metadata k8sObjectMeta `json:"metadata"`
+}
+
+// k8sList resposta de uma chamada de list
+type k8sList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// k8sWatchEvent evento do stream de watch
+type k8sWatchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// k8sStatus objeto Status retornado em erros
+type k8sStatus struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// NewKubernetesPodWatcher cria o watcher. Sem api_server configurado, usa a
+// configuração in-cluster (KUBERNETES_SERVICE_HOST/PORT e o service account montado).
+func NewKubernetesPodWatcher(config KubernetesDiscoveryConfig, logger *logrus.Logger) (*KubernetesPodWatcher, error) {
+	if config.APIServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes api_server not configured and not running in a cluster")
+		}
+		config.APIServer = "https://" + net.JoinHostPort(host, port)
+		if config.TokenFile == "" {
+			config.TokenFile = inClusterTokenFile
+		}
+		if config.CAFile == "" {
+			config.CAFile = inClusterCAFile
+		}
+	}
+	if config.NodeName == "" {
+		config.NodeName = os.Getenv("NODE_NAME")
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.CAFile != "" {
+		caPEM, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in kubernetes CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &KubernetesPodWatcher{
+		config:     config,
+		logger:     logger,
+		client:     &http.Client{Transport: transport},
+		baseURL:    strings.TrimSuffix(config.APIServer, "/"),
+		pods:       make(map[string]*KubernetesPod),
+		podsByName: make(map[string]*KubernetesPod),
+		namespaces: make(map[string]map[string]string),
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+// Start faz o list inicial (para que os primeiros logs já sejam enriquecidos)
+// e mantém o cache atualizado via watch em background
+func (w *KubernetesPodWatcher) Start() error {
+	w.logger.WithFields(logrus.Fields{
+		"api_server": w.baseURL,
+		"namespace":  w.config.Namespace,
+		"node":       w.config.NodeName,
+	}).Info("Starting kubernetes pod watcher")
+
+	resources := []*kubernetesResource{w.podResource()}
+	if len(w.config.NamespaceLabels) > 0 {
+		resources = append(resources, w.namespaceResource())
+	}
+
+	for _, resource := range resources {
+		resourceVersion, err := w.list(resource)
+		if err != nil {
+			w.recordError(err)
+			w.logger.WithError(err).WithField("resource", resource.name).Warn("Initial kubernetes list failed, retrying in background")
+		}
+
+		w.wg.Add(1)
+		go w.run(resource, resourceVersion)
+	}
+
+	return nil
+}
+
+// Stop encerra os watches
+func (w *KubernetesPodWatcher) Stop() error {
+	w.cancel()
+	w.wg.Wait()
+	w.client.CloseIdleConnections()
+	w.logger.Info("Kubernetes pod watcher stopped")
+	return nil
+}
+
+// podResource pods do nó (spec.nodeName) ou do namespace configurado
+func (w *KubernetesPodWatcher) podResource() *kubernetesResource {
+	path := "/api/v1/pods"
+	if w.config.Namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(w.config.Namespace) + "/pods"
+	}
+
+	query := url.Values{}
+	if w.config.NodeName != "" {
+		query.Set("fieldSelector", "spec.nodeName="+w.config.NodeName)
+	}
+
+	return &kubernetesResource{
+		name:    "pods",
+		path:    path,
+		query:   query,
+		replace: w.replacePods,
+		apply:   w.applyPodEvent,
+	}
+}
+
+// namespaceResource namespaces, acompanhados apenas quando há namespace_labels na allowlist
+func (w *KubernetesPodWatcher) namespaceResource() *kubernetesResource {
+	return &kubernetesResource{
+		name:    "namespaces",
+		path:    "/api/v1/namespaces",
+		query:   url.Values{},
+		replace: w.replaceNamespaces,
+		apply:   w.applyNamespaceEvent,
+	}
+}
+
+// run mantém o watch de um recurso: reabre o stream quando ele termina e refaz
+// o list quando o resourceVersion expira ou após erros (com backoff)
+func (w *KubernetesPodWatcher) run(resource *kubernetesResource, resourceVersion string) {
+	defer w.wg.Done()
+
+	backoff := time.Second
+	for {
+		var err error
+		if resourceVersion == "" {
+			resourceVersion, err = w.list(resource)
+		}
+		if err == nil {
+			resourceVersion, err = w.watch(resource, resourceVersion)
+		}
+
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, errWatchExpired) {
+			w.logger.WithField("resource", resource.name).Debug("Kubernetes watch expired, relisting")
+			w.mutex.Lock()
+			w.stats.Relists++
+			w.mutex.Unlock()
+			resourceVersion = ""
+			continue
+		}
+
+		if err != nil {
+			w.recordError(err)
+			w.logger.WithError(err).WithField("resource", resource.name).Warn("Kubernetes watch failed")
+			resourceVersion = ""
+
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > kubernetesMaxBackoff {
+				backoff = kubernetesMaxBackoff
+			}
+			continue
+		}
+
+		backoff = time.Second
+	}
+}
+
+// list carrega o estado completo do recurso e retorna o resourceVersion da lista
+func (w *KubernetesPodWatcher) list(resource *kubernetesResource) (string, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, kubernetesListTimeout)
+	defer cancel()
+
+	resp, err := w.get(ctx, resource.path, resource.query)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", resource.name, err)
+	}
+	defer resp.Body.Close()
+
+	var list k8sList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", fmt.Errorf("failed to decode %s list: %w", resource.name, err)
+	}
+
+	if err := resource.replace(list.Items); err != nil {
+		return "", err
+	}
+
+	w.mutex.Lock()
+	w.stats.Lists++
+	w.mutex.Unlock()
+
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watch consome o stream de eventos até ele terminar. Retorna o último
+// resourceVersion visto, para o próximo watch continuar de onde parou.
+func (w *KubernetesPodWatcher) watch(resource *kubernetesResource, resourceVersion string) (string, error) {
+	query := url.Values{}
+	for key, values := range resource.query {
+		query[key] = values
+	}
+	query.Set("watch", "1")
+	query.Set("resourceVersion", resourceVersion)
+	query.Set("allowWatchBookmarks", "true")
+	query.Set("timeoutSeconds", fmt.Sprintf("%d", int(kubernetesWatchTimeout.Seconds())))
+
+	resp, err := w.get(w.ctx, resource.path, query)
+	if err != nil {
+		return resourceVersion, fmt.Errorf("failed to watch %s: %w", resource.name, err)
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event k8sWatchEvent
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF || w.ctx.Err() != nil {
+				return resourceVersion, nil
+			}
+			return resourceVersion, fmt.Errorf("failed to decode %s watch event: %w", resource.name, err)
+		}
+
+		w.mutex.Lock()
+		w.stats.WatchEvents++
+		w.stats.LastEventTime = time.Now()
+		w.mutex.Unlock()
+
+		switch event.Type {
+		case "ERROR":
+			var status k8sStatus
+			if err := json.Unmarshal(event.Object, &status); err == nil && status.Code == http.StatusGone {
+				return "", errWatchExpired
+			}
+			return "", fmt.Errorf("%s watch error: %s", resource.name, string(event.Object))
+		case "ADDED", "MODIFIED", "DELETED", "BOOKMARK":
+			var object struct {
+				Metadata k8sObjectMeta `json:"metadata"`
+			}
+			if err := json.Unmarshal(event.Object, &object); err != nil {
+				return resourceVersion, fmt.Errorf("invalid %s watch object: %w", resource.name, err)
+			}
+			if object.Metadata.ResourceVersion != "" {
+				resourceVersion = object.Metadata.ResourceVersion
+			}
+			if event.Type == "BOOKMARK" {
+				continue
+			}
+			if err := resource.apply(event.Type, event.Object); err != nil {
+				return resourceVersion, err
+			}
+		}
+	}
+}
+
+// get executa uma requisição autenticada contra a API
+func (w *KubernetesPodWatcher) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	endpoint := w.baseURL + path
+	if encoded := query.Encode(); encoded != "" {
+		endpoint += "?" + encoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// O token do service account é rotacionado pelo kubelet: ler a cada requisição
+	if w.config.TokenFile != "" {
+		token, err := os.ReadFile(w.config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusGone {
+		resp.Body.Close()
+		return nil, errWatchExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("kubernetes API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return resp, nil
+}
+
+// replacePods substitui o cache de pods pelo resultado de um list
+func (w *KubernetesPodWatcher) replacePods(items []json.RawMessage) error {
+	pods := make(map[string]*KubernetesPod, len(items))
+	for _, item := range items {
+		var raw k8sPod
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return fmt.Errorf("invalid pod in list: %w", err)
+		}
+		if pod := w.newPod(&raw); pod != nil {
+			pods[pod.UID] = pod
+		}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.pods = pods
+	w.podsByName = make(map[string]*KubernetesPod, len(pods))
+	for _, pod := range pods {
+		w.podsByName[pod.Namespace+"/"+pod.Name] = pod
+	}
+	w.synced = true
+
+	return nil
+}
+
+// applyPodEvent aplica um evento de watch ao cache de pods
+func (w *KubernetesPodWatcher) applyPodEvent(eventType string, object json.RawMessage) error {
+	var raw k8sPod
+	if err := json.Unmarshal(object, &raw); err != nil {
+		return fmt.Errorf("invalid pod in watch event: %w", err)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if existing := w.pods[raw.Metadata.UID]; existing != nil {
+		delete(w.pods, existing.UID)
+		delete(w.podsByName, existing.Namespace+"/"+existing.Name)
+	}
+
+	if eventType == "DELETED" {
+		return nil
+	}
+
+	// Pods que deixam de atender required_labels/annotations saem do cache
+	if pod := w.newPod(&raw); pod != nil {
+		w.pods[pod.UID] = pod
+		w.podsByName[pod.Namespace+"/"+pod.Name] = pod
+	}
+
+	return nil
+}
+
+// replaceNamespaces substitui o cache de labels de namespaces
+func (w *KubernetesPodWatcher) replaceNamespaces(items []json.RawMessage) error {
+	namespaces := make(map[string]map[string]string, len(items))
+	for _, item := range items {
+		var raw k8sNamespace
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return fmt.Errorf("invalid namespace in list: %w", err)
+		}
+		namespaces[raw.Metadata.Name] = raw.Metadata.Labels
+	}
+
+	w.mutex.Lock()
+	w.namespaces = namespaces
+	w.mutex.Unlock()
+
+	return nil
+}
+
+// applyNamespaceEvent aplica um evento de watch ao cache de namespaces
+func (w *KubernetesPodWatcher) applyNamespaceEvent(eventType string, object json.RawMessage) error {
+	var raw k8sNamespace
+	if err := json.Unmarshal(object, &raw); err != nil {
+		return fmt.Errorf("invalid namespace in watch event: %w", err)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if eventType == "DELETED" {
+		delete(w.namespaces, raw.Metadata.Name)
+	} else {
+		w.namespaces[raw.Metadata.Name] = raw.Metadata.Labels
+	}
+
+	return nil
+}
+
+// newPod converte o objeto da API, retornando nil se o pod não atende aos filtros
+func (w *KubernetesPodWatcher) newPod(raw *k8sPod) *KubernetesPod {
+	meta := &raw.Metadata
+	for key, expected := range w.config.RequiredLabels {
+		if value, exists := meta.Labels[key]; !exists || value != expected {
+			return nil
+		}
+	}
+	for key, expected := range w.config.RequiredAnnotations {
+		if value, exists := meta.Annotations[key]; !exists || value != expected {
+			return nil
+		}
+	}
+
+	pod := &KubernetesPod{
+		UID:         meta.UID,
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		NodeName:    raw.Spec.NodeName,
+		Phase:       raw.Status.Phase,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+	pod.WorkloadKind, pod.Workload = resolveWorkload(meta)
+
+	return pod
+}
+
+// resolveWorkload identifica o workload dono do pod a partir do controlador.
+// ReplicaSets criados por Deployments são resolvidos para o Deployment usando
+// o sufixo pod-template-hash do nome.
+func resolveWorkload(meta *k8sObjectMeta) (string, string) {
+	for _, owner := range meta.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+
+		if owner.Kind == "ReplicaSet" {
+			if hash := meta.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+		}
+		return owner.Kind, owner.Name
+	}
+	return "", ""
+}
+
+// EnrichLabels adiciona às labels de um log de pod (namespace/pod/pod_uid) o nó,
+// o workload e as labels, annotations e labels de namespace presentes nas
+// allowlists. Labels já existentes não são sobrescritas.
+func (w *KubernetesPodWatcher) EnrichLabels(labels map[string]string) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	pod := w.pods[labels["pod_uid"]]
+	if pod == nil {
+		pod = w.podsByName[labels["namespace"]+"/"+labels["pod"]]
+	}
+	if pod == nil {
+		return
+	}
+
+	setMissingLabel(labels, "node", pod.NodeName)
+	setMissingLabel(labels, "workload_kind", pod.WorkloadKind)
+	setMissingLabel(labels, "workload", pod.Workload)
+
+	for _, key := range w.config.PodLabels {
+		setMissingLabel(labels, "pod_label_"+sanitizeLabelName(key), pod.Labels[key])
+	}
+	for _, key := range w.config.PodAnnotations {
+		setMissingLabel(labels, "pod_annotation_"+sanitizeLabelName(key), pod.Annotations[key])
+	}
+	if namespaceLabels := w.namespaces[pod.Namespace]; namespaceLabels != nil {
+		for _, key := range w.config.NamespaceLabels {
+			setMissingLabel(labels, "namespace_label_"+sanitizeLabelName(key), namespaceLabels[key])
+		}
+	}
+}
+
+// GetPods retorna uma cópia dos pods em cache
+func (w *KubernetesPodWatcher) GetPods() []KubernetesPod {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	pods := make([]KubernetesPod, 0, len(w.pods))
+	for _, pod := range w.pods {
+		pods = append(pods, *pod)
+	}
+	return pods
+}
+
+// HasSynced indica se o list inicial de pods foi concluído
+func (w *KubernetesPodWatcher) HasSynced() bool {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.synced
+}
+
+// GetStats retorna as estatísticas do watcher
+func (w *KubernetesPodWatcher) GetStats() KubernetesWatcherStats {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	stats := w.stats
+	stats.Pods = len(w.pods)
+	stats.Namespaces = len(w.namespaces)
+	stats.Synced = w.synced
+	return stats
+}
+
+// recordError registra o último erro nas estatísticas
+func (w *KubernetesPodWatcher) recordError(err error) {
+	w.mutex.Lock()
+	w.stats.ErrorCount++
+	w.stats.LastError = err.Error()
+	w.mutex.Unlock()
+}
+
+// setMissingLabel define a label apenas se o valor não é vazio e a chave ainda não existe
+func setMissingLabel(labels map[string]string, key, value string) {
+	if value == "" {
+		return
+	}
+	if _, exists := labels[key]; !exists {
+		labels[key] = value
+	}
+}
+
+// sanitizeLabelName converte uma chave Kubernetes (app.kubernetes.io/name) em
+// um nome de label válido no Loki/Prometheus (app_kubernetes_io_name)
+func sanitizeLabelName(key string) string {
+	var b strings.Builder
+	b.Grow(len(key))
+	for _, r := range key {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package discovery
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIServer simula list+watch da API do Kubernetes
+type fakeAPIServer struct {
+	mutex      sync.Mutex
+	podLists   []string // Respostas de list, em ordem (a última se repete)
+	podWatches []string // Streams de watch, em ordem; depois deles o watch fica aberto
+	namespaces string
+	requests   []*http.Request
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	f.requests = append(f.requests, r)
+	var body string
+	streaming := r.URL.Query().Get("watch") == "1"
+	switch {
+	case r.URL.Path == "/api/v1/pods" && !streaming:
+		body = f.podLists[0]
+		if len(f.podLists) > 1 {
+			f.podLists = f.podLists[1:]
+		}
+	case r.URL.Path == "/api/v1/pods" && len(f.podWatches) > 0:
+		body = f.podWatches[0]
+		f.podWatches = f.podWatches[1:]
+	case r.URL.Path == "/api/v1/namespaces" && !streaming:
+		body = f.namespaces
+	case streaming:
+		f.mutex.Unlock()
+		// Watch sem eventos: mantém a conexão até o cliente cancelar
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		return
+	default:
+		f.mutex.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	f.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, body)
+}
+
+func (f *fakeAPIServer) Requests() []*http.Request {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]*http.Request(nil), f.requests...)
+}
+
+func podJSON(uid, name, namespace, resourceVersion, labels, owner string) string {
+	return fmt.Sprintf(`{"metadata":{"uid":%q,"name":%q,"namespace":%q,"resourceVersion":%q,"labels":%s,"annotations":{"team.example.com/owner":"payments"},"ownerReferences":%s},"spec":{"nodeName":"node-1"},"status":{"phase":"Running"}}`,
+		uid, name, namespace, resourceVersion, labels, owner)
+}
+
+func newTestKubernetesWatcher(t *testing.T, server *httptest.Server, config KubernetesDiscoveryConfig) *KubernetesPodWatcher {
+	t.Helper()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0600))
+
+	config.APIServer = server.URL
+	config.TokenFile = tokenFile
+	config.NodeName = "node-1"
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	watcher, err := NewKubernetesPodWatcher(config, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { watcher.Stop() })
+	return watcher
+}
+
+func TestKubernetesPodWatcher_ListWatchAndRelist(t *testing.T) {
+	deployment := `[{"kind":"ReplicaSet","name":"api-7d9f","controller":true}]`
+	fake := &fakeAPIServer{
+		podLists: []string{
+			`{"metadata":{"resourceVersion":"100"},"items":[` +
+				podJSON("uid-a", "api-7d9f-x1", "shop", "90", `{"app":"api","pod-template-hash":"7d9f"}`, deployment) + `,` +
+				podJSON("uid-c", "old-job-z", "shop", "95", `{}`, `[{"kind":"Job","name":"old-job","controller":true}]`) + `]}`,
+			// Relist após o 410: estado atual completo
+			`{"metadata":{"resourceVersion":"200"},"items":[` +
+				podJSON("uid-a", "api-7d9f-x1", "shop", "150", `{"app":"api-v2","pod-template-hash":"7d9f"}`, deployment) + `,` +
+				podJSON("uid-d", "db-0", "shop", "160", `{}`, `[{"kind":"StatefulSet","name":"db","controller":true}]`) + `]}`,
+		},
+		podWatches: []string{
+			`{"type":"ADDED","object":` + podJSON("uid-b", "worker", "shop", "101", `{}`, `[]`) + "}\n" +
+				`{"type":"DELETED","object":` + podJSON("uid-c", "old-job-z", "shop", "102", `{}`, `[]`) + "}\n" +
+				`{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"110"}}}` + "\n",
+			`{"type":"ERROR","object":{"kind":"Status","code":410,"reason":"Expired","message":"too old resource version"}}` + "\n",
+		},
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	watcher := newTestKubernetesWatcher(t, server, KubernetesDiscoveryConfig{})
+	require.NoError(t, watcher.Start())
+	assert.True(t, watcher.HasSynced(), "initial list happens before Start returns")
+
+	require.Eventually(t, func() bool {
+		return watcher.GetStats().Relists == 1 && watcher.GetStats().Pods == 2
+	}, 5*time.Second, 20*time.Millisecond)
+
+	pods := make(map[string]KubernetesPod)
+	for _, pod := range watcher.GetPods() {
+		pods[pod.UID] = pod
+	}
+	require.Contains(t, pods, "uid-a")
+	require.Contains(t, pods, "uid-d")
+	assert.Equal(t, "api-v2", pods["uid-a"].Labels["app"])
+	assert.Equal(t, "Deployment", pods["uid-a"].WorkloadKind)
+	assert.Equal(t, "api", pods["uid-a"].Workload)
+	assert.Equal(t, "StatefulSet", pods["uid-d"].WorkloadKind)
+
+	requests := fake.Requests()
+	require.GreaterOrEqual(t, len(requests), 4)
+	for _, req := range requests {
+		assert.Equal(t, "Bearer secret-token", req.Header.Get("Authorization"))
+		assert.Equal(t, "spec.nodeName=node-1", req.URL.Query().Get("fieldSelector"))
+	}
+	// O primeiro watch parte do resourceVersion do list, o segundo do bookmark
+	assert.Equal(t, "100", requests[1].URL.Query().Get("resourceVersion"))
+	assert.Equal(t, "110", requests[2].URL.Query().Get("resourceVersion"))
+}
+
+func TestKubernetesPodWatcher_EnrichLabels(t *testing.T) {
+	fake := &fakeAPIServer{
+		podLists: []string{
+			`{"metadata":{"resourceVersion":"1"},"items":[` +
+				podJSON("uid-a", "api-7d9f-x1", "shop", "1", `{"app.kubernetes.io/name":"api","pod-template-hash":"7d9f","version":"abc123"}`,
+					`[{"kind":"ReplicaSet","name":"api-7d9f","controller":true}]`) + `]}`,
+		},
+		namespaces: `{"metadata":{"resourceVersion":"1"},"items":[{"metadata":{"name":"shop","labels":{"team":"payments","cost-center":"42"}}}]}`,
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	watcher := newTestKubernetesWatcher(t, server, KubernetesDiscoveryConfig{
+		PodLabels:       []string{"app.kubernetes.io/name", "missing"},
+		PodAnnotations:  []string{"team.example.com/owner"},
+		NamespaceLabels: []string{"team"},
+	})
+	require.NoError(t, watcher.Start())
+
+	labels := map[string]string{"namespace": "shop", "pod": "api-7d9f-x1", "pod_uid": "uid-a", "container": "api", "node": "preset"}
+	watcher.EnrichLabels(labels)
+
+	assert.Equal(t, map[string]string{
+		"namespace":                             "shop",
+		"pod":                                   "api-7d9f-x1",
+		"pod_uid":                               "uid-a",
+		"container":                             "api",
+		"node":                                  "preset", // Labels existentes não são sobrescritas
+		"workload_kind":                         "Deployment",
+		"workload":                              "api",
+		"pod_label_app_kubernetes_io_name":      "api",
+		"pod_annotation_team_example_com_owner": "payments",
+		"namespace_label_team":                  "payments",
+	}, labels, "labels outside the allowlists (version, cost-center) are not added")
+
+	// Busca por namespace/nome quando o UID não é conhecido
+	labels = map[string]string{"namespace": "shop", "pod": "api-7d9f-x1"}
+	watcher.EnrichLabels(labels)
+	assert.Equal(t, "node-1", labels["node"])
+
+	labels = map[string]string{"namespace": "shop", "pod": "unknown", "pod_uid": "uid-z"}
+	watcher.EnrichLabels(labels)
+	assert.Len(t, labels, 3)
+}
+
+func TestKubernetesPodWatcher_RequiredLabels(t *testing.T) {
+	fake := &fakeAPIServer{
+		podLists: []string{
+			`{"metadata":{"resourceVersion":"1"},"items":[` +
+				podJSON("uid-a", "a", "shop", "1", `{"logs":"true"}`, `[]`) + `,` +
+				podJSON("uid-b", "b", "shop", "1", `{}`, `[]`) + `]}`,
+		},
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	watcher := newTestKubernetesWatcher(t, server, KubernetesDiscoveryConfig{
+		Namespace:      "shop",
+		RequiredLabels: map[string]string{"logs": "true"},
+	})
+
+	// Namespace configurado usa o endpoint namespaced
+	_, err := watcher.list(watcher.podResource())
+	assert.Error(t, err, "fake server only serves the cluster-wide pods path")
+
+	watcher.config.Namespace = ""
+	_, err = watcher.list(watcher.podResource())
+	require.NoError(t, err)
+
+	pods := watcher.GetPods()
+	require.Len(t, pods, 1)
+	assert.Equal(t, "uid-a", pods[0].UID)
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	assert.Equal(t, "app_kubernetes_io_name", sanitizeLabelName("app.kubernetes.io/name"))
+	assert.Equal(t, "cost_center", sanitizeLabelName("cost-center"))
+}
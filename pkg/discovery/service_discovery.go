@@ -17,6 +17,7 @@ type ServiceDiscovery struct {
 	config       Config
 	logger       *logrus.Logger
 	dockerClient *client.Client
+	kubernetes   *KubernetesPodWatcher
 
 	// Discovered services
 	services     map[string]*DiscoveredService
@@ -49,7 +50,7 @@ type Config struct {
 	// File discovery config
 	FileConfig FileDiscoveryConfig `yaml:"file"`
 
-	// Kubernetes discovery (future)
+	// Kubernetes discovery (list+watch de pods na API)
 	KubernetesEnabled bool                      `yaml:"kubernetes_enabled"`
 	KubernetesConfig  KubernetesDiscoveryConfig `yaml:"kubernetes"`
 }
@@ -79,6 +80,18 @@ type KubernetesDiscoveryConfig struct {
 	RequiredAnnotations map[string]string `yaml:"required_annotations"`
 	RequiredLabels      map[string]string `yaml:"required_labels"`
 	ServiceAccount      string            `yaml:"service_account"`
+
+	// Acesso à API (vazio = configuração in-cluster)
+	APIServer          string `yaml:"api_server"`
+	TokenFile          string `yaml:"token_file"`
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	NodeName           string `yaml:"node_name"` // Restringe aos pods do nó (padrão: $NODE_NAME)
+
+	// Allowlists de metadados adicionados aos logs de pods
+	PodLabels       []string `yaml:"pod_labels"`
+	PodAnnotations  []string `yaml:"pod_annotations"`
+	NamespaceLabels []string `yaml:"namespace_labels"`
 }
 
 // DiscoveredService representa um serviço descoberto
@@ -137,6 +150,15 @@ func NewServiceDiscovery(config Config, logger *logrus.Logger) (*ServiceDiscover
 		sd.dockerClient = dockerClient
 	}
 
+	// Initialize Kubernetes pod watcher if enabled
+	if config.KubernetesEnabled {
+		watcher, err := NewKubernetesPodWatcher(config.KubernetesConfig, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes pod watcher: %w", err)
+		}
+		sd.kubernetes = watcher
+	}
+
 	return sd, nil
 }
 
@@ -160,6 +182,12 @@ func (sd *ServiceDiscovery) Start() error {
 
 	sd.logger.Info("Starting service discovery")
 
+	if sd.kubernetes != nil {
+		if err := sd.kubernetes.Start(); err != nil {
+			return fmt.Errorf("failed to start kubernetes pod watcher: %w", err)
+		}
+	}
+
 	// Initial discovery
 	if err := sd.runDiscovery(); err != nil {
 		sd.logger.WithError(err).Error("Initial discovery failed")
@@ -184,6 +212,10 @@ func (sd *ServiceDiscovery) Stop() error {
 		sd.dockerClient.Close()
 	}
 
+	if sd.kubernetes != nil {
+		sd.kubernetes.Stop()
+	}
+
 	sd.logger.Info("Service discovery stopped")
 	return nil
 }
@@ -232,6 +264,11 @@ func (sd *ServiceDiscovery) runDiscovery() error {
 		}
 	}
 
+	// Kubernetes discovery (a partir do cache do watcher)
+	if sd.kubernetes != nil {
+		sd.discoverKubernetesServices()
+	}
+
 	// Update stats
 	sd.updateStats()
 
@@ -416,6 +453,92 @@ func (sd *ServiceDiscovery) createServiceFromContainer(container dockertypes.Con
 	}
 }
 
+// discoverKubernetesServices sincroniza os pods do cache do watcher como serviços
+func (sd *ServiceDiscovery) discoverKubernetesServices() {
+	if !sd.kubernetes.HasSynced() {
+		return
+	}
+
+	now := time.Now()
+	discoveredIDs := make(map[string]bool)
+
+	for _, pod := range sd.kubernetes.GetPods() {
+		status := "inactive"
+		if pod.Phase == "Running" {
+			status = "active"
+		}
+
+		service := &DiscoveredService{
+			ID:        pod.UID,
+			Name:      pod.Namespace + "/" + pod.Name,
+			Type:      "kubernetes",
+			Status:    status,
+			Source:    pod.NodeName,
+			Labels:    pod.Labels,
+			Pipeline:  "default",
+			Component: "application",
+			Tenant:    pod.Namespace,
+			Metadata: map[string]interface{}{
+				"namespace":     pod.Namespace,
+				"node":          pod.NodeName,
+				"phase":         pod.Phase,
+				"workload_kind": pod.WorkloadKind,
+				"workload":      pod.Workload,
+			},
+			LastSeen:    now,
+			FirstSeen:   now,
+			UpdateCount: 1,
+		}
+		discoveredIDs[service.ID] = true
+
+		sd.servicesMux.Lock()
+		existing, exists := sd.services[service.ID]
+		switch {
+		case !exists:
+			sd.services[service.ID] = service
+			sd.servicesMux.Unlock()
+			if sd.onServiceAdded != nil {
+				sd.onServiceAdded(service)
+			}
+		case sd.hasServiceChanged(existing, service):
+			old := *existing
+			service.FirstSeen = existing.FirstSeen
+			service.UpdateCount = existing.UpdateCount + 1
+			sd.services[service.ID] = service
+			sd.servicesMux.Unlock()
+			if sd.onServiceUpdated != nil {
+				sd.onServiceUpdated(&old, service)
+			}
+		default:
+			existing.LastSeen = now
+			existing.UpdateCount++
+			sd.servicesMux.Unlock()
+		}
+	}
+
+	// Remove pods that are no longer present
+	sd.servicesMux.Lock()
+	var removed []string
+	for serviceID, service := range sd.services {
+		if service.Type == "kubernetes" && !discoveredIDs[serviceID] {
+			delete(sd.services, serviceID)
+			removed = append(removed, serviceID)
+		}
+	}
+	sd.servicesMux.Unlock()
+
+	for _, serviceID := range removed {
+		if sd.onServiceRemoved != nil {
+			sd.onServiceRemoved(serviceID)
+		}
+	}
+}
+
+// KubernetesWatcher retorna o watcher de pods (nil se kubernetes_enabled está desligado)
+func (sd *ServiceDiscovery) KubernetesWatcher() *KubernetesPodWatcher {
+	return sd.kubernetes
+}
+
 // discoverFileServices descobre serviços baseados em arquivos
 func (sd *ServiceDiscovery) discoverFileServices() error {
 	// Implementation for file-based service discovery
@@ -612,5 +735,10 @@ func (sd *ServiceDiscovery) IsHealthy() bool {
 		}
 	}
 
+	// Check if the kubernetes cache was loaded
+	if sd.kubernetes != nil && !sd.kubernetes.HasSynced() {
+		return false
+	}
+
 	return true
 }
\ No newline at end of file
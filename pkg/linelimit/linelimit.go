@@ -0,0 +1,216 @@
+// Package linelimit bounds the size of individual log lines accepted by inputs.
+//
+// Readers use MaxBytes to stop buffering a line once it reaches the limit and
+// describe what happened with an Oversize value; Finish then tags the entry
+// (or diverts it to the dead letter queue) and records metrics. Inputs that
+// already hold complete messages, such as HTTP ingest, call Apply instead.
+package linelimit
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/dlq"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/google/uuid"
+)
+
+// Oversized line policies
+const (
+	PolicyTruncate = "truncate" // Keep the first max_line_bytes and label the entry truncated=true
+	PolicySplit    = "split"    // Emit max_line_bytes chunks linked by a shared group ID
+	PolicyDLQ      = "dlq"      // Send the entry (cut at max_line_bytes) to the dead letter queue
+)
+
+// Label and field names set on oversized entries
+const (
+	LabelTruncated     = "truncated"
+	FieldOriginalBytes = "original_bytes"
+	FieldSplitGroup    = "split_group"
+	FieldSplitPart     = "split_part"
+	FieldSplitFinal    = "split_final"
+)
+
+// DeadLetterQueue is the subset of *dlq.DeadLetterQueue used by the dlq policy.
+type DeadLetterQueue interface {
+	AddEntry(originalEntry types.LogEntry, errorMsg, errorType, failedSink string, retryCount int, context map[string]string) error
+}
+
+// Oversize describes how a line exceeded the limit while it was being read.
+// The zero value means the line fit within the limit.
+type Oversize struct {
+	OriginalBytes int64  // Size of the complete line when it was cut (truncate and dlq)
+	Group         string // Group shared by the parts of a split line
+	Part          int    // 1-based part number of a split line
+	Final         bool   // Last part of a split line
+}
+
+// IsZero reports whether the line fit within the limit.
+func (o Oversize) IsZero() bool {
+	return o.OriginalBytes == 0 && o.Group == ""
+}
+
+// Limiter applies max_line_bytes and the oversized line policy of one input.
+type Limiter struct {
+	maxBytes  int
+	policy    string
+	component string
+	dlq       DeadLetterQueue
+}
+
+// Validate checks max_line_bytes and the policy name.
+func Validate(config types.LineLimitConfig) error {
+	if config.MaxLineBytes < 0 {
+		return fmt.Errorf("max_line_bytes must not be negative: %d", config.MaxLineBytes)
+	}
+	switch config.Policy {
+	case "", PolicyTruncate, PolicySplit, PolicyDLQ:
+		return nil
+	default:
+		return fmt.Errorf("invalid oversized_line_policy %q (expected %s, %s or %s)", config.Policy, PolicyTruncate, PolicySplit, PolicyDLQ)
+	}
+}
+
+// New creates a limiter for component. It returns nil when max_line_bytes is
+// not set, meaning lines are unbounded. queue may be nil; the dlq policy then
+// drops oversized entries.
+func New(config types.LineLimitConfig, component string, queue DeadLetterQueue) (*Limiter, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+	if config.MaxLineBytes == 0 {
+		return nil, nil
+	}
+
+	policy := config.Policy
+	if policy == "" {
+		policy = PolicyTruncate
+	}
+	return &Limiter{maxBytes: config.MaxLineBytes, policy: policy, component: component, dlq: queue}, nil
+}
+
+// DeadLetterQueueOf returns the dead letter queue of a dispatcher that exposes
+// one through GetDLQ, or nil.
+func DeadLetterQueueOf(dispatcher interface{}) DeadLetterQueue {
+	provider, ok := dispatcher.(interface{ GetDLQ() *dlq.DeadLetterQueue })
+	if !ok {
+		return nil
+	}
+	if queue := provider.GetDLQ(); queue != nil {
+		return queue
+	}
+	return nil
+}
+
+// MaxBytes returns the maximum line size in bytes.
+func (l *Limiter) MaxBytes() int {
+	return l.maxBytes
+}
+
+// Policy returns the oversized line policy.
+func (l *Limiter) Policy() string {
+	return l.policy
+}
+
+// NewGroup returns an identifier shared by the parts of a split line.
+func NewGroup() string {
+	return uuid.New().String()
+}
+
+// CutIndex returns the largest index <= max at which s can be cut without
+// splitting a UTF-8 sequence. Invalid data is cut at max.
+func CutIndex(s string, max int) int {
+	if len(s) <= max {
+		return len(s)
+	}
+	for i := max; i > 0 && i > max-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			return i
+		}
+	}
+	return max
+}
+
+// Finish tags entry according to oversize and records metrics. It returns
+// false when the entry was diverted to the dead letter queue (or dropped) and
+// must not be dispatched.
+func (l *Limiter) Finish(entry *types.LogEntry, oversize Oversize) bool {
+	switch {
+	case oversize.Group != "":
+		entry.SetField(FieldSplitGroup, oversize.Group)
+		entry.SetField(FieldSplitPart, oversize.Part)
+		if oversize.Final {
+			entry.SetField(FieldSplitFinal, true)
+		}
+		if oversize.Part == 1 {
+			metrics.RecordOversizedLine(l.component, PolicySplit, 0)
+		}
+		return true
+
+	case oversize.OriginalBytes > 0:
+		metrics.RecordOversizedLine(l.component, l.policy, oversize.OriginalBytes-int64(len(entry.Message)))
+		entry.SetField(FieldOriginalBytes, oversize.OriginalBytes)
+		if l.policy != PolicyDLQ {
+			entry.SetLabel(LabelTruncated, "true")
+			return true
+		}
+
+		if l.dlq != nil {
+			context := map[string]string{
+				"component":      l.component,
+				"original_bytes": fmt.Sprintf("%d", oversize.OriginalBytes),
+				"max_line_bytes": fmt.Sprintf("%d", l.maxBytes),
+			}
+			if err := l.dlq.AddEntry(types.LogEntry{
+				TraceID:    entry.TraceID,
+				Timestamp:  entry.Timestamp,
+				Message:    entry.Message,
+				Level:      entry.Level,
+				SourceType: entry.SourceType,
+				SourceID:   entry.SourceID,
+				Labels:     entry.CopyLabels(),
+				Fields:     entry.CopyFields(),
+			}, "line exceeds max_line_bytes", "oversized_line", l.component, 0, context); err != nil {
+				metrics.RecordError(l.component, "oversized_line_dlq_error")
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Apply enforces the limit on an entry whose message is complete and returns
+// the entries to dispatch: the entry itself, its truncated form, its parts, or
+// none when it went to the dead letter queue.
+func (l *Limiter) Apply(entry *types.LogEntry) []*types.LogEntry {
+	if len(entry.Message) <= l.maxBytes {
+		return []*types.LogEntry{entry}
+	}
+
+	if l.policy != PolicySplit {
+		original := int64(len(entry.Message))
+		entry.Message = entry.Message[:CutIndex(entry.Message, l.maxBytes)]
+		if !l.Finish(entry, Oversize{OriginalBytes: original}) {
+			return nil
+		}
+		return []*types.LogEntry{entry}
+	}
+
+	group := NewGroup()
+	message := entry.Message
+	var parts []*types.LogEntry
+	for part := 1; message != ""; part++ {
+		cut := CutIndex(message, l.maxBytes)
+		if cut == 0 {
+			cut = l.maxBytes
+		}
+		piece := entry.DeepCopy()
+		piece.Message = message[:cut]
+		message = message[cut:]
+		l.Finish(piece, Oversize{Group: group, Part: part, Final: message == ""})
+		parts = append(parts, piece)
+	}
+	return parts
+}
@@ -0,0 +1,95 @@
+package linelimit
+
+import (
+	"strings"
+	"testing"
+
+	"ssw-logs-capture/internal/metrics"
+	"ssw-logs-capture/pkg/dlq"
+	"ssw-logs-capture/pkg/types"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntry(message string) *types.LogEntry {
+	return &types.LogEntry{Message: message, SourceType: "test", Labels: map[string]string{"app": "api"}}
+}
+
+func TestNew(t *testing.T) {
+	limiter, err := New(types.LineLimitConfig{}, "test", nil)
+	require.NoError(t, err)
+	assert.Nil(t, limiter, "max_line_bytes 0 means unbounded")
+
+	limiter, err = New(types.LineLimitConfig{MaxLineBytes: 10}, "test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, PolicyTruncate, limiter.Policy())
+	assert.Equal(t, 10, limiter.MaxBytes())
+
+	_, err = New(types.LineLimitConfig{MaxLineBytes: 10, Policy: "drop"}, "test", nil)
+	assert.Error(t, err)
+	assert.Error(t, Validate(types.LineLimitConfig{MaxLineBytes: -1}))
+}
+
+func TestCutIndex(t *testing.T) {
+	assert.Equal(t, 3, CutIndex("abc", 5))
+	assert.Equal(t, 5, CutIndex("abcdefgh", 5))
+	// "ã" occupies bytes 1-2: cutting at 2 would split it
+	assert.Equal(t, 1, CutIndex("são", 2))
+	assert.Equal(t, 3, CutIndex("são", 3))
+}
+
+func TestApply_Truncate(t *testing.T) {
+	limiter, err := New(types.LineLimitConfig{MaxLineBytes: 8}, "test_truncate", nil)
+	require.NoError(t, err)
+
+	entry := newEntry("short")
+	assert.Equal(t, []*types.LogEntry{entry}, limiter.Apply(entry))
+	assert.Empty(t, entry.Fields)
+
+	before := testutil.ToFloat64(metrics.OversizedLinesTotal.WithLabelValues("test_truncate", PolicyTruncate))
+	entries := limiter.Apply(newEntry(strings.Repeat("x", 20)))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "xxxxxxxx", entries[0].Message)
+	assert.Equal(t, "true", entries[0].Labels[LabelTruncated])
+	assert.Equal(t, int64(20), entries[0].Fields[FieldOriginalBytes])
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.OversizedLinesTotal.WithLabelValues("test_truncate", PolicyTruncate)))
+	assert.Equal(t, float64(12), testutil.ToFloat64(metrics.OversizedBytesDroppedTotal.WithLabelValues("test_truncate")))
+}
+
+func TestApply_Split(t *testing.T) {
+	limiter, err := New(types.LineLimitConfig{MaxLineBytes: 4, Policy: PolicySplit}, "test_split", nil)
+	require.NoError(t, err)
+
+	entries := limiter.Apply(newEntry("0123456789"))
+	require.Len(t, entries, 3)
+
+	var joined string
+	group := entries[0].Fields[FieldSplitGroup]
+	assert.NotEmpty(t, group)
+	for i, entry := range entries {
+		joined += entry.Message
+		assert.Equal(t, group, entry.Fields[FieldSplitGroup])
+		assert.Equal(t, i+1, entry.Fields[FieldSplitPart])
+		assert.Equal(t, "api", entry.Labels["app"])
+	}
+	assert.Equal(t, "0123456789", joined)
+	assert.Equal(t, true, entries[2].Fields[FieldSplitFinal])
+	assert.NotContains(t, entries[0].Fields, FieldSplitFinal)
+}
+
+func TestApply_DLQ(t *testing.T) {
+	queue := dlq.NewDeadLetterQueue(dlq.Config{Enabled: true, Directory: t.TempDir(), QueueSize: 10}, logrus.New())
+	limiter, err := New(types.LineLimitConfig{MaxLineBytes: 4, Policy: PolicyDLQ}, "test_dlq", queue)
+	require.NoError(t, err)
+
+	assert.Empty(t, limiter.Apply(newEntry("too long for the limit")))
+	assert.Equal(t, int64(1), queue.GetStats().TotalEntries)
+
+	// Without a dead letter queue the entry is dropped
+	limiter, err = New(types.LineLimitConfig{MaxLineBytes: 4, Policy: PolicyDLQ}, "test_dlq", nil)
+	require.NoError(t, err)
+	assert.Empty(t, limiter.Apply(newEntry("too long for the limit")))
+}
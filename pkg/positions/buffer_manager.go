@@ -298,6 +298,10 @@ func (pbm *PositionBufferManager) UpdateFilePosition(filePath string, offset int
 	pbm.stats.mu.Unlock()
 }
 
+func (pbm *PositionBufferManager) SetFileFingerprint(filePath, fingerprint string, fingerprintSize int64) {
+	pbm.fileManager.SetFingerprint(filePath, fingerprint, fingerprintSize)
+}
+
 func (pbm *PositionBufferManager) GetContainerPosition(containerID string) *ContainerPosition {
 	return pbm.containerManager.GetPosition(containerID)
 }
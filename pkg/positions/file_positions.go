@@ -22,6 +22,10 @@ type FilePosition struct {
 	LogCount     int64     `json:"log_count"`
 	BytesRead    int64     `json:"bytes_read"`
 	Status       string    `json:"status"`
+	// Fingerprint is a hash of the first FingerprintSize bytes of the file.
+	// Together with inode/device it identifies the file across restarts.
+	Fingerprint     string `json:"fingerprint,omitempty"`
+	FingerprintSize int64  `json:"fingerprint_size,omitempty"`
 }
 
 type FilePositionManager struct {
@@ -118,18 +122,8 @@ func (fpm *FilePositionManager) GetPosition(filePath string) *FilePosition {
 
 	if pos, exists := fpm.positions[filePath]; exists {
 		// Return a copy to avoid concurrent modification
-		return &FilePosition{
-			FilePath:     pos.FilePath,
-			Offset:       pos.Offset,
-			Size:         pos.Size,
-			LastModified: pos.LastModified,
-			LastRead:     pos.LastRead,
-			Inode:        pos.Inode,
-			Device:       pos.Device,
-			LogCount:     pos.LogCount,
-			BytesRead:    pos.BytesRead,
-			Status:       pos.Status,
-		}
+		copied := *pos
+		return &copied
 	}
 
 	return nil
@@ -192,6 +186,29 @@ func (fpm *FilePositionManager) UpdatePosition(filePath string, offset int64, si
 	})
 }
 
+// SetFingerprint records the content fingerprint of a file
+func (fpm *FilePositionManager) SetFingerprint(filePath, fingerprint string, fingerprintSize int64) {
+	fpm.mu.Lock()
+	defer fpm.mu.Unlock()
+
+	pos, exists := fpm.positions[filePath]
+	if !exists {
+		pos = &FilePosition{
+			FilePath: filePath,
+			Status:   "active",
+		}
+		fpm.positions[filePath] = pos
+	}
+
+	if pos.Fingerprint == fingerprint && pos.FingerprintSize == fingerprintSize {
+		return
+	}
+
+	pos.Fingerprint = fingerprint
+	pos.FingerprintSize = fingerprintSize
+	fpm.dirty = true
+}
+
 func (fpm *FilePositionManager) SetFileStatus(filePath, status string) {
 	fpm.mu.Lock()
 	defer fpm.mu.Unlock()
@@ -247,7 +264,7 @@ func (fpm *FilePositionManager) CleanupOldPositions(maxAge time.Duration) {
 	// Coletamos arquivos para remover primeiro para evitar concurrent map iteration/write
 	toDelete := make([]string, 0)
 	for filePath, pos := range fpm.positions {
-		if pos.LastRead.Before(cutoff) && (pos.Status == "removed" || pos.Status == "deleted") {
+		if pos.LastRead.Before(cutoff) && (pos.Status == "removed" || pos.Status == "deleted" || pos.Status == "rotated") {
 			toDelete = append(toDelete, filePath)
 		}
 	}
@@ -272,18 +289,8 @@ func (fpm *FilePositionManager) GetAllPositions() map[string]*FilePosition {
 
 	result := make(map[string]*FilePosition, len(fpm.positions))
 	for path, pos := range fpm.positions {
-		result[path] = &FilePosition{
-			FilePath:     pos.FilePath,
-			Offset:       pos.Offset,
-			Size:         pos.Size,
-			LastModified: pos.LastModified,
-			LastRead:     pos.LastRead,
-			Inode:        pos.Inode,
-			Device:       pos.Device,
-			LogCount:     pos.LogCount,
-			BytesRead:    pos.BytesRead,
-			Status:       pos.Status,
-		}
+		copied := *pos
+		result[path] = &copied
 	}
 
 	return result
@@ -0,0 +1,60 @@
+package positions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// FingerprintSize is the number of leading bytes hashed to identify a file
+const FingerprintSize int64 = 1024
+
+// FileIdentity returns the inode and device of a file (zero on non-Unix systems)
+func FileIdentity(info os.FileInfo) (inode uint64, device uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return stat.Ino, uint64(stat.Dev)
+	}
+	return 0, 0
+}
+
+// ComputeFingerprint hashes up to FingerprintSize leading bytes of the file.
+// It uses ReadAt so the current read offset of the handle is not affected.
+// Returns the fingerprint and the number of bytes it covers.
+func ComputeFingerprint(file io.ReaderAt, size int64) (string, int64, error) {
+	n := size
+	if n > FingerprintSize {
+		n = FingerprintSize
+	}
+	if n <= 0 {
+		return "", 0, nil
+	}
+
+	buf := make([]byte, n)
+	read, err := file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return "", 0, fmt.Errorf("failed to read file head: %w", err)
+	}
+
+	sum := sha256.Sum256(buf[:read])
+	return hex.EncodeToString(sum[:]), int64(read), nil
+}
+
+// MatchesFingerprint checks whether the file still starts with the content
+// described by the stored fingerprint.
+func MatchesFingerprint(file io.ReaderAt, size int64, fingerprint string, fingerprintSize int64) bool {
+	if fingerprint == "" || fingerprintSize <= 0 {
+		return true
+	}
+	if size < fingerprintSize {
+		return false
+	}
+
+	current, read, err := ComputeFingerprint(file, fingerprintSize)
+	if err != nil || read != fingerprintSize {
+		return false
+	}
+	return current == fingerprint
+}
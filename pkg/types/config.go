@@ -24,7 +24,14 @@ type Config struct {
 	FileMonitorService  FileMonitorServiceConfig  `yaml:"file_monitor_service"`
 	FileMonitor         FileMonitorServiceConfig  `yaml:"file_monitor"`
 	ContainerMonitor    ContainerMonitorConfig    `yaml:"container_monitor"`
+	SyslogMonitor       SyslogMonitorConfig       `yaml:"syslog_monitor"`
+	KafkaMonitor        KafkaMonitorConfig        `yaml:"kafka_monitor"`
+	FluentForward       FluentForwardConfig       `yaml:"fluent_forward"`
+	ExecMonitor         ExecMonitorConfig         `yaml:"exec_monitor"`
+	KmsgMonitor         KmsgMonitorConfig         `yaml:"kmsg_monitor"`
+	KubernetesPods      KubernetesPodsConfig      `yaml:"kubernetes_pods"`
 	FilesConfig         FilesConfig               `yaml:"files_config"`
+	Ingest              IngestConfig              `yaml:"ingest"`
 
 	// Output destination configurations
 	Sinks               SinksConfig               `yaml:"sinks"`
@@ -78,6 +85,42 @@ type ServerConfig struct {
 	TLSEnabled   bool   `yaml:"tls_enabled"`   // Enable TLS/HTTPS
 	TLSCertFile  string `yaml:"tls_cert_file"` // TLS certificate file path
 	TLSKeyFile   string `yaml:"tls_key_file"`  // TLS private key file path
+	MaxBodySize  int64  `yaml:"max_body_size"` // Max /api/v1/logs body in bytes, before and after decompression
+
+	LineLimit LineLimitConfig `yaml:",inline"` // max_line_bytes for messages received by HTTP ingest
+}
+
+// LineLimitConfig bounds the size of a single log line read by an input.
+type LineLimitConfig struct {
+	MaxLineBytes int    `yaml:"max_line_bytes"`        // Maximum bytes per line (0 = unlimited)
+	Policy       string `yaml:"oversized_line_policy"` // truncate (default), split or dlq
+}
+
+// IngestConfig contains the backend-compatible push endpoints exposed by the HTTP server.
+type IngestConfig struct {
+	Elasticsearch ElasticsearchIngestConfig `yaml:"elasticsearch"` // Elasticsearch _bulk API
+	SplunkHEC     SplunkHECIngestConfig     `yaml:"splunk_hec"`    // Splunk HTTP Event Collector
+	OTLP          OTLPIngestConfig          `yaml:"otlp"`          // OpenTelemetry logs (OTLP/HTTP and OTLP/gRPC)
+}
+
+// ElasticsearchIngestConfig contains the Elasticsearch-compatible _bulk endpoint settings.
+type ElasticsearchIngestConfig struct {
+	Enabled bool   `yaml:"enabled"` // Expose /_bulk, /{index}/_bulk and the cluster info endpoint
+	Version string `yaml:"version"` // Elasticsearch version reported to clients
+}
+
+// SplunkHECIngestConfig contains the Splunk HEC-compatible endpoint settings.
+type SplunkHECIngestConfig struct {
+	Enabled bool     `yaml:"enabled"` // Expose /services/collector/event and /services/collector/raw
+	Tokens  []string `yaml:"tokens"`  // Accepted HEC tokens
+}
+
+// OTLPIngestConfig contains the OpenTelemetry logs receiver settings.
+type OTLPIngestConfig struct {
+	Enabled           bool   `yaml:"enabled"`              // Expose POST /v1/logs on the HTTP server
+	GRPCEnabled       bool   `yaml:"grpc_enabled"`         // Also serve the LogsService over gRPC
+	GRPCAddress       string `yaml:"grpc_address"`         // gRPC listen address
+	MaxRecvMessageMiB int    `yaml:"max_recv_message_mib"` // Maximum gRPC request size in MiB
 }
 
 // MetricsConfig contains Prometheus metrics settings.
@@ -118,12 +161,16 @@ type FileMonitorServiceConfig struct {
 	Recursive         bool                   `yaml:"recursive"`           // Enable recursive directory monitoring
 	FollowSymlinks    bool                   `yaml:"follow_symlinks"`     // Follow symbolic links
 	PipelineConfig    map[string]interface{} `yaml:"-"`                   // Loaded pipeline configuration (not from yaml)
+	LineLimit         LineLimitConfig        `yaml:",inline"`             // max_line_bytes for file lines
 }
 
 // ContainerMonitorConfig contains Docker container monitoring settings.
 type ContainerMonitorConfig struct {
 	Enabled           bool              `yaml:"enabled"`             // Enable container monitoring
+	Source            string            `yaml:"source"`              // "api" (Docker socket) or "json-file" (read log files directly)
 	SocketPath        string            `yaml:"socket_path"`         // Docker socket path
+	ContainersPath    string            `yaml:"containers_path"`     // Docker containers directory (json-file source)
+	ScanInterval      string            `yaml:"scan_interval"`       // Container directory scan interval (json-file source)
 	MaxConcurrent     int               `yaml:"max_concurrent"`      // Maximum concurrent container connections
 	ReconnectInterval string            `yaml:"reconnect_interval"`  // Docker API reconnection interval
 	HealthCheckDelay  string            `yaml:"health_check_delay"`  // Container health check delay
@@ -134,6 +181,101 @@ type ContainerMonitorConfig struct {
 	IncludeStdout     bool              `yaml:"include_stdout"`      // Include stdout logs
 	IncludeStderr     bool              `yaml:"include_stderr"`      // Include stderr logs
 	Follow            bool              `yaml:"follow"`              // Follow log stream
+	Multiline         MultilineConfig          `yaml:"multiline"`       // Default multiline aggregation for containers
+	MultilineRules    []ContainerMultilineRule `yaml:"multiline_rules"` // Per-container multiline overrides (first match wins)
+	LineLimit         LineLimitConfig          `yaml:",inline"`         // max_line_bytes for container log lines
+}
+
+// SyslogMonitorConfig contains syslog receiver settings (RFC 5424 and RFC 3164).
+// Each listener is enabled by setting its address; an empty address disables it.
+type SyslogMonitorConfig struct {
+	Enabled        bool              `yaml:"enabled"`          // Enable the syslog receiver
+	UDPAddress     string            `yaml:"udp_address"`      // UDP listen address (e.g. ":5514")
+	TCPAddress     string            `yaml:"tcp_address"`      // Plain TCP listen address
+	TLSAddress     string            `yaml:"tls_address"`      // TLS listen address (requires tls.cert_file/key_file)
+	TLS            TLSConfig         `yaml:"tls"`              // Server certificate; ca_file + verify_certificate require client certificates
+	MaxMessageSize int               `yaml:"max_message_size"` // Maximum message size in bytes
+	MaxConnections int               `yaml:"max_connections"`  // Maximum concurrent TCP/TLS connections
+	ReadTimeout    string            `yaml:"read_timeout"`     // Idle timeout for TCP/TLS connections
+	Labels         map[string]string `yaml:"labels"`           // Static labels added to every message
+}
+
+// FluentForwardConfig contains the Fluent Forward protocol input settings
+// (Fluent Bit, Fluentd and the Docker fluentd log driver).
+type FluentForwardConfig struct {
+	Enabled        bool              `yaml:"enabled"`          // Enable the Forward receiver
+	Address        string            `yaml:"address"`          // TCP listen address (e.g. ":24224")
+	SocketPath     string            `yaml:"socket_path"`      // Unix socket path
+	SharedKey      string            `yaml:"shared_key"`       // Require the shared-key handshake when set
+	SelfHostname   string            `yaml:"self_hostname"`    // Hostname sent in the handshake (defaults to os.Hostname)
+	MaxMessageSize int               `yaml:"max_message_size"` // Maximum size of one Forward message in bytes
+	MaxConnections int               `yaml:"max_connections"`  // Maximum concurrent connections
+	ReadTimeout    string            `yaml:"read_timeout"`     // Idle timeout for connections
+	Labels         map[string]string `yaml:"labels"`           // Static labels added to every record
+}
+
+// ExecMonitorConfig contains the exec input settings: configured commands are
+// started and supervised, and their stdout/stderr captured line by line. The
+// stdin mode reads the capturer's own standard input (shell pipelines).
+type ExecMonitorConfig struct {
+	Enabled     bool                `yaml:"enabled"`       // Enable the exec/stdin input
+	Commands    []ExecCommandConfig `yaml:"commands"`      // Commands to run and capture
+	Stdin       bool                `yaml:"stdin"`         // Read log lines from standard input
+	MaxLineSize int                 `yaml:"max_line_size"` // Longer lines are truncated (bytes)
+	Labels      map[string]string   `yaml:"labels"`        // Static labels added to every line
+}
+
+// KmsgMonitorConfig contains the kernel ring buffer (/dev/kmsg) input settings.
+// The last sequence number is checkpointed in the positions directory.
+type KmsgMonitorConfig struct {
+	Enabled       bool              `yaml:"enabled"`        // Enable the kmsg input (Linux only)
+	DevicePath    string            `yaml:"device_path"`    // Device to read (defaults to /dev/kmsg; a regular file is tailed)
+	FlushInterval string            `yaml:"flush_interval"` // How often the sequence checkpoint is written
+	Labels        map[string]string `yaml:"labels"`         // Static labels added to every record
+}
+
+// ExecCommandConfig describes one supervised command.
+type ExecCommandConfig struct {
+	Name            string            `yaml:"name"`              // Value of the "command" label (defaults to the executable base name)
+	Command         string            `yaml:"command"`           // Executable path or name looked up in PATH
+	Args            []string          `yaml:"args"`              // Command arguments
+	Env             map[string]string `yaml:"env"`               // Extra environment variables (added to the capturer's environment)
+	WorkDir         string            `yaml:"workdir"`           // Working directory
+	RestartPolicy   string            `yaml:"restart_policy"`    // always, on-failure (default) or never
+	RestartDelay    string            `yaml:"restart_delay"`     // Initial delay before a restart, doubled on each consecutive restart
+	MaxRestartDelay string            `yaml:"max_restart_delay"` // Upper bound for the restart delay
+	MaxRestarts     int               `yaml:"max_restarts"`      // Give up after this many consecutive restarts (0 = unlimited)
+	Labels          map[string]string `yaml:"labels"`            // Static labels added to this command's lines
+}
+
+// KafkaMonitorConfig contains Kafka consumer group input settings.
+// Offsets are committed only after the dispatcher accepts each message.
+type KafkaMonitorConfig struct {
+	Enabled        bool              `yaml:"enabled"`         // Enable the Kafka consumer
+	Brokers        []string          `yaml:"brokers"`         // Bootstrap brokers
+	Topics         []string          `yaml:"topics"`          // Topics to consume
+	GroupID        string            `yaml:"group_id"`        // Consumer group ID
+	Version        string            `yaml:"version"`         // Kafka protocol version (e.g. "2.8.0")
+	InitialOffset  string            `yaml:"initial_offset"`  // Offset for groups without commits (newest, oldest)
+	Format         string            `yaml:"format"`          // Message decoding (json, raw)
+	CommitInterval string            `yaml:"commit_interval"` // Interval between offset commits
+	Auth           KafkaAuthConfig   `yaml:"auth"`            // SASL authentication
+	TLS            TLSConfig         `yaml:"tls"`             // TLS connection to the brokers
+	Labels         map[string]string `yaml:"labels"`          // Static labels added to every entry
+}
+
+// KubernetesPodsConfig contains node-level pod log collection settings.
+// Files under <logs_path>/<namespace>_<pod>_<uid>/<container>/N.log are read
+// in the CRI log format used by containerd and CRI-O.
+type KubernetesPodsConfig struct {
+	Enabled           bool              `yaml:"enabled"`            // Enable pod log collection
+	LogsPath          string            `yaml:"logs_path"`          // Kubelet pod logs directory
+	ScanInterval      string            `yaml:"scan_interval"`      // Interval between pod directory scans
+	IncludeNamespaces []string          `yaml:"include_namespaces"` // Namespaces to collect (empty = all)
+	ExcludeNamespaces []string          `yaml:"exclude_namespaces"` // Namespaces to skip
+	ExcludeContainers []string          `yaml:"exclude_containers"` // Container name patterns to skip
+	Multiline         MultilineConfig   `yaml:"multiline"`          // Multiline aggregation applied per stream
+	Labels            map[string]string `yaml:"labels"`             // Static labels added to every entry
 }
 
 // FilesConfig contains file selection and filtering settings.
@@ -339,9 +481,13 @@ type TimestampValidationConfig struct {
 
 // FilePipelineFileEntry represents a specific file entry in file pipeline configuration.
 type FilePipelineFileEntry struct {
-	Path    string            `yaml:"path"`    // File path to monitor
-	Labels  map[string]string `yaml:"labels"`  // Labels for this file
-	Enabled bool              `yaml:"enabled"` // Enable monitoring for this file
+	Path            string            `yaml:"path"`              // File path to monitor
+	Labels          map[string]string `yaml:"labels"`            // Labels for this file
+	Enabled         bool              `yaml:"enabled"`           // Enable monitoring for this file
+	Multiline       *MultilineConfig  `yaml:"multiline"`         // Multiline aggregation for this file
+	StartAt         string            `yaml:"start_at"`          // Start position without saved offset: "beginning" (default) or "end"
+	IgnoreOlderThan string            `yaml:"ignore_older_than"` // Skip the file when not modified within this duration
+	Encoding        string            `yaml:"encoding"`          // Source encoding (utf-16le, iso-8859-1, auto...); "" = UTF-8 as is
 }
 
 // FilePipelineDirEntry represents a directory entry in file pipeline configuration.
@@ -355,6 +501,34 @@ type FilePipelineDirEntry struct {
 	Enabled             bool              `yaml:"enabled"`              // Enable monitoring for this directory
 	FollowSymlinks      bool              `yaml:"follow_symlinks"`      // Follow symbolic links (kept for compatibility)
 	IncludePatterns     []string          `yaml:"include_patterns"`     // Include patterns (kept for compatibility)
+	Multiline           *MultilineConfig  `yaml:"multiline"`            // Multiline aggregation for discovered files
+	IncludeCompressed   bool              `yaml:"include_compressed"`   // Ingest rotated .gz/.zst archives once
+	Format              string            `yaml:"format"`               // Line format: "" (plain text) or "cri"
+	StartAt             string            `yaml:"start_at"`             // Start position for files without saved offset: "beginning" (default) or "end"
+	IgnoreOlderThan     string            `yaml:"ignore_older_than"`    // Skip files not modified within this duration
+	Encoding            string            `yaml:"encoding"`             // Source encoding (utf-16le, iso-8859-1, auto...); "" = UTF-8 as is
+}
+
+// MultilineConfig represents multiline log aggregation settings.
+//
+// A line matching StartPattern begins a new event; when ContinuationPattern is
+// set, lines matching it are appended to the current event. Negate inverts the
+// match of the primary pattern (ContinuationPattern if set, otherwise StartPattern).
+type MultilineConfig struct {
+	Enabled             bool   `yaml:"enabled"`              // Enable multiline aggregation
+	StartPattern        string `yaml:"start_pattern"`        // Regex matching the first line of an event
+	ContinuationPattern string `yaml:"continuation_pattern"` // Regex matching continuation lines
+	Negate              bool   `yaml:"negate"`               // Invert the primary pattern match
+	MaxLines            int    `yaml:"max_lines"`            // Maximum lines per event (default 500)
+	MaxBytes            int    `yaml:"max_bytes"`            // Maximum bytes per event (default 1MiB)
+	FlushTimeout        string `yaml:"flush_timeout"`        // Flush a pending event after this idle time (default 5s)
+}
+
+// ContainerMultilineRule applies a multiline configuration to matching containers.
+type ContainerMultilineRule struct {
+	Names     []string          `yaml:"names"`     // Container name substrings to match
+	Labels    map[string]string `yaml:"labels"`    // Container labels to match (empty value matches any)
+	Multiline MultilineConfig   `yaml:"multiline"` // Multiline configuration for matching containers
 }
 
 // FilePipelineMonitoringConfig represents monitoring configuration in file pipeline.
@@ -394,6 +568,7 @@ type FileConfig struct {
 	Recursive          bool          `yaml:"recursive"`
 	FollowSymlinks     bool          `yaml:"follow_symlinks"`
 	PipelineConfig     map[string]interface{} `yaml:"pipeline_config"` // Pipeline configuration
+	LineLimit          LineLimitConfig        `yaml:",inline"`
 }
 
 // LokiConfig represents legacy Loki configuration (alias for LokiSinkConfig).
@@ -473,6 +648,8 @@ type LocalFileConfig struct {
 type DockerConfig struct {
 	Enabled           bool              `yaml:"enabled"`
 	SocketPath        string            `yaml:"socket_path"`
+	ContainersPath    string            `yaml:"containers_path"`
+	ScanInterval      time.Duration     `yaml:"scan_interval"`
 	MaxConcurrent     int               `yaml:"max_concurrent"`
 	ReconnectInterval time.Duration     `yaml:"reconnect_interval"`
 	HealthCheckDelay  time.Duration     `yaml:"health_check_delay"`
@@ -480,6 +657,11 @@ type DockerConfig struct {
 	ExcludeLabels     map[string]string `yaml:"exclude_labels"`
 	IncludeNames      []string          `yaml:"include_names"`
 	ExcludeNames      []string          `yaml:"exclude_names"`
+	IncludeStdout     bool              `yaml:"include_stdout"`
+	IncludeStderr     bool              `yaml:"include_stderr"`
+	Multiline         MultilineConfig          `yaml:"multiline"`
+	MultilineRules    []ContainerMultilineRule `yaml:"multiline_rules"`
+	LineLimit         LineLimitConfig          `yaml:",inline"`
 }
 
 // PipelineConfig represents processing pipeline configuration.
@@ -496,7 +678,7 @@ type ServiceDiscoveryConfig struct {
 	FileEnabled     bool                      `yaml:"file_enabled"`     // Enable file discovery
 	Docker          DockerDiscoveryConfig     `yaml:"docker"`           // Docker discovery config
 	File            FileDiscoveryConfig       `yaml:"file"`             // File discovery config
-	KubernetesEnabled bool                    `yaml:"kubernetes_enabled"` // Enable Kubernetes discovery (future)
+	KubernetesEnabled bool                    `yaml:"kubernetes_enabled"` // Enable Kubernetes pod watch (metadata enrichment)
 	Kubernetes      KubernetesDiscoveryConfig `yaml:"kubernetes"`       // Kubernetes discovery config
 }
 
@@ -525,4 +707,12 @@ type KubernetesDiscoveryConfig struct {
 	RequiredAnnotations map[string]string `yaml:"required_annotations"`  // Required pod annotations
 	RequiredLabels      map[string]string `yaml:"required_labels"`       // Required pod labels
 	ServiceAccount      string            `yaml:"service_account"`       // Service account for K8s API access
+	APIServer           string            `yaml:"api_server"`            // API server URL (empty = in-cluster config)
+	TokenFile           string            `yaml:"token_file"`            // Bearer token file
+	CAFile              string            `yaml:"ca_file"`               // CA bundle for the API server
+	InsecureSkipVerify  bool              `yaml:"insecure_skip_verify"`  // Skip API server TLS verification
+	NodeName            string            `yaml:"node_name"`             // Only watch pods on this node (default: $NODE_NAME)
+	PodLabels           []string          `yaml:"pod_labels"`            // Allowlist of pod labels added to log labels
+	PodAnnotations      []string          `yaml:"pod_annotations"`       // Allowlist of pod annotations added to log labels
+	NamespaceLabels     []string          `yaml:"namespace_labels"`      // Allowlist of namespace labels added to log labels
 }
\ No newline at end of file
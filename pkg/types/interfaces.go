@@ -3,6 +3,8 @@ package types
 
 import (
 	"context"
+	"fmt"
+	"sort"
 )
 
 // Monitor defines the interface for log input sources that monitor and capture log entries.
@@ -40,8 +42,13 @@ type Sink interface {
 type Dispatcher interface {
 	// AddSink registers a new output destination
 	AddSink(sink Sink)
-	// Handle processes a single log entry through the pipeline
+	// Handle processes a single log line through the pipeline, stamped with the current time
 	Handle(ctx context.Context, sourceType, sourceID, message string, labels map[string]string) error
+	// HandleEntry processes a fully-formed log entry, preserving its timestamp,
+	// level, fields and tracing identifiers
+	HandleEntry(ctx context.Context, entry *LogEntry) error
+	// HandleBatch processes several entries; a *BatchError reports the rejected ones
+	HandleBatch(ctx context.Context, entries []*LogEntry) error
 	// Start begins dispatcher operations
 	Start(ctx context.Context) error
 	// Stop gracefully shuts down the dispatcher
@@ -50,6 +57,27 @@ type Dispatcher interface {
 	GetStats() DispatcherStats
 }
 
+// BatchError reports the entries of a batch rejected by Dispatcher.HandleBatch,
+// keyed by their index in the batch.
+type BatchError struct {
+	Total  int
+	Errors map[int]error
+}
+
+// Error summarizes the rejected entries using the first failure as example
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for index := range e.Errors {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	if len(indexes) == 0 {
+		return fmt.Sprintf("0 of %d entries rejected", e.Total)
+	}
+	return fmt.Sprintf("%d of %d entries rejected (entry %d: %v)", len(indexes), e.Total, indexes[0], e.Errors[indexes[0]])
+}
+
 // Processor defines the interface for log entry transformation and filtering.
 //
 // Processors apply configured pipelines to modify, enrich, or filter log entries
@@ -27,6 +27,10 @@ import (
 	"time"
 )
 
+// LabelFileStart marks the entry holding the line read at offset 0 of a file.
+// It is set by the file monitor so parsers can recognise header rows.
+const LabelFileStart = "file_start"
+
 // LogEntry represents a comprehensive log entry with full metadata, tracing, and compliance information.
 //
 // LogEntry is the central data structure that flows through the entire log processing